@@ -0,0 +1,256 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"simple-cli/internal/scl"
+)
+
+// BehaviorTypesDir is where the generated field types of TypeScript record
+// behaviors live, relative to the app's scripts/record-behaviors directory.
+// It is source for the type checker only and is never deployed.
+const BehaviorTypesDir = "types"
+
+// TableField is one field of a table as a record behavior sees it through
+// $form: its name, its SCL type without the leading colon, and whether a
+// record can be saved without it.
+type TableField struct {
+	Name     string
+	Type     string
+	Required bool
+	Values   []string // the allowed values of an :enum field
+}
+
+// ReadTableFields parses tablesPath and returns the fields of table, in
+// declaration order. It fails when the table is not declared there, since a
+// behavior typed against a table that does not exist checks nothing.
+func ReadTableFields(tablesPath, table string) ([]TableField, error) {
+	nodes, err := scl.ParseFile(tablesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range nodes {
+		if node.IsBlock() && node.Key == "table" && node.Name() == table {
+			return tableFields(node), nil
+		}
+	}
+
+	return nil, fmt.Errorf("table %s is not declared in %s", table, tablesPath)
+}
+
+// DeclaredTables returns the names of the tables tablesPath declares, in
+// declaration order. An app without a tables.scl declares none.
+func DeclaredTables(tablesPath string) ([]string, error) {
+	if _, err := os.Stat(tablesPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	nodes, err := scl.ParseFile(tablesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []string
+	for _, node := range nodes {
+		if node.IsBlock() && node.Key == "table" {
+			tables = append(tables, node.Name())
+		}
+	}
+	return tables, nil
+}
+
+// tableFields collects the fields a table block declares, including the ones
+// its `default` line adds on its behalf.
+func tableFields(table *scl.Node) []TableField {
+	var fields []TableField
+
+	for _, child := range table.Children {
+		switch child.Key {
+		case "default":
+			for _, d := range child.Names() {
+				switch d {
+				case "id":
+					fields = append(fields, TableField{Name: "id", Type: "string"})
+				case "timestamps":
+					fields = append(fields,
+						TableField{Name: "created_at", Type: "datetime"},
+						TableField{Name: "updated_at", Type: "datetime"})
+				case "userstamps":
+					fields = append(fields,
+						TableField{Name: "created_by", Type: "string"},
+						TableField{Name: "updated_by", Type: "string"})
+				}
+			}
+
+		case "required", "optional":
+			// `required email, :string` with or without a block of options.
+			parts := child.Names()
+			if len(parts) < 2 {
+				continue
+			}
+			field := TableField{Name: parts[0], Type: parts[1], Required: child.Key == "required"}
+			if field.Type == "enum" {
+				field.Values = childValues(child, "values")
+			}
+			fields = append(fields, field)
+
+		case "belongs":
+			// `belongs :to, department` stores the department's id in
+			// department_id, which is the field a behavior reads and sets.
+			parts := child.Names()
+			if len(parts) < 2 || parts[0] != "to" {
+				continue
+			}
+			required := false
+			if v := childValues(child, "required"); len(v) == 1 && v[0] == "true" {
+				required = true
+			}
+			fields = append(fields, TableField{Name: parts[1] + "_id", Type: "string", Required: required})
+		}
+	}
+
+	return fields
+}
+
+// childValues returns the values of the key-value named key directly inside
+// block, or nil when the block has no such statement.
+func childValues(block *scl.Node, key string) []string {
+	for _, c := range block.Children {
+		if c.Key == key && !c.IsBlock() {
+			return c.Names()
+		}
+	}
+	return nil
+}
+
+// RenderBehaviorTypes renders the TypeScript module that types a table's
+// record behavior: an interface of the table's fields and the behavior context
+// specialised to it. Required fields are the ones a saved record always has;
+// every other field, including the system ones, may be absent on a new form.
+func RenderBehaviorTypes(table string, fields []TableField) []byte {
+	typeName := BehaviorTypeName(table)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Generated by simple from tables.scl. Do not edit: this file is rewritten\n")
+	fmt.Fprintf(&buf, "// whenever the %s behavior is scaffolded or deployed.\n", table)
+	fmt.Fprintf(&buf, "import type { BehaviorContext } from './behavior'\n\n")
+	fmt.Fprintf(&buf, "export interface %sRecord {\n", typeName)
+	for _, f := range fields {
+		optional := "?"
+		if f.Required {
+			optional = ""
+		}
+		fmt.Fprintf(&buf, "  %s%s: %s\n", f.Name, optional, tsFieldType(f))
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "export type %sBehaviorContext = BehaviorContext<%sRecord>\n", typeName, typeName)
+
+	return buf.Bytes()
+}
+
+// tsFieldType maps an SCL field type onto the type of the value $form holds
+// for it. Dates and times travel as ISO strings; types whose value has no
+// fixed shape are unknown so a behavior has to narrow them before use.
+func tsFieldType(f TableField) string {
+	switch f.Type {
+	case "enum":
+		if len(f.Values) == 0 {
+			return "string"
+		}
+		quoted := make([]string, len(f.Values))
+		for i, v := range f.Values {
+			quoted[i] = "'" + strings.ReplaceAll(v, "'", "\\'") + "'"
+		}
+		return strings.Join(quoted, " | ")
+	case "integer", "decimal", "float", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "json", "document":
+		return "unknown"
+	default:
+		return "string"
+	}
+}
+
+// BehaviorTypeName is the prefix of the types generated for a table's
+// behavior: the table name in PascalCase, e.g. "OrderLine" for order_line.
+func BehaviorTypeName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+// WriteBehaviorTypes regenerates types/<table>.ts for the app at appPath from
+// its tables.scl, so a behavior is always checked against the fields the app
+// declares now rather than the ones it had when the behavior was scaffolded.
+// The file is left untouched when its content would not change.
+func WriteBehaviorTypes(appPath, table string) error {
+	fields, err := ReadTableFields(filepath.Join(appPath, "tables.scl"), table)
+	if err != nil {
+		return err
+	}
+
+	typesDir := filepath.Join(appPath, "scripts", "record-behaviors", BehaviorTypesDir)
+	if err := os.MkdirAll(typesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", typesDir, err)
+	}
+
+	dst := filepath.Join(typesDir, table+".ts")
+	content := RenderBehaviorTypes(table, fields)
+	if existing, err := os.ReadFile(dst); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	if err := os.WriteFile(dst, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return nil
+}
+
+// TypeCheckBehaviors runs the TypeScript compiler, without emitting, over the
+// record-behaviors project of the app at appPath. esbuild strips types without
+// checking them, so this is the step that refuses a behavior naming a field
+// its table does not have.
+func TypeCheckBehaviors(appPath string) error {
+	dir := filepath.Join(appPath, "scripts", "record-behaviors")
+
+	// -p typescript, because the package called "tsc" on npm is not the compiler.
+	cmd := exec.Command("npx", "--yes", "-p", "typescript", "tsc", "--noEmit", "-p", ".")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("record behaviors failed to type-check:\n%s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// BundleBehavior bundles scripts/record-behaviors/<table>.ts of the app at
+// appPath, with everything it imports, into the single ES module the platform
+// loads as <table>.js, and returns it.
+func BundleBehavior(appPath, table string) ([]byte, error) {
+	dir := filepath.Join(appPath, "scripts", "record-behaviors")
+
+	// --yes, as for tsc: without esbuild installed, npx would otherwise ask
+	// before fetching it, and fail where no one can answer.
+	cmd := exec.Command("npx", "--yes", "esbuild", table+".ts",
+		"--bundle",
+		"--format=esm",
+		"--platform=neutral",
+		"--log-level=warning",
+	)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("esbuild failed for %s.ts: %s: %w", table, strings.TrimSpace(stderr.String()), err)
+	}
+	return output, nil
+}
@@ -0,0 +1,69 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tablesSCL = `table order, orders {
+  default :id, :timestamps
+  required customer, :string { length 1..50 }
+  optional notes, :string
+  required status, :enum { values "Open", "Closed" }
+  belongs :to, department { required true }
+}
+`
+
+func TestReadTableFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tables.scl")
+	if err := os.WriteFile(path, []byte(tablesSCL), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err := ReadTableFields(path, "order")
+	if err != nil {
+		t.Fatalf("ReadTableFields() error = %v", err)
+	}
+
+	want := []TableField{
+		{Name: "id", Type: "string"},
+		{Name: "created_at", Type: "datetime"},
+		{Name: "updated_at", Type: "datetime"},
+		{Name: "customer", Type: "string", Required: true},
+		{Name: "notes", Type: "string"},
+		{Name: "status", Type: "enum", Required: true, Values: []string{"Open", "Closed"}},
+		{Name: "department_id", Type: "string", Required: true},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("ReadTableFields() =\n%+v\nwant\n%+v", fields, want)
+	}
+
+	if _, err := ReadTableFields(path, "invoice"); err == nil || !strings.Contains(err.Error(), "not declared") {
+		t.Errorf("expected an undeclared table to be an error, got %v", err)
+	}
+}
+
+func TestRenderBehaviorTypes(t *testing.T) {
+	got := string(RenderBehaviorTypes("order_line", []TableField{
+		{Name: "quantity", Type: "decimal", Required: true},
+		{Name: "shipped", Type: "boolean"},
+		{Name: "payload", Type: "json"},
+		{Name: "status", Type: "enum", Values: []string{"Open", "Closed"}},
+	}))
+
+	for _, want := range []string{
+		"export interface OrderLineRecord {",
+		"  quantity: number\n",
+		"  shipped?: boolean\n",
+		"  payload?: unknown\n",
+		"  status?: 'Open' | 'Closed'\n",
+		"export type OrderLineBehaviorContext = BehaviorContext<OrderLineRecord>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered types lack %q, got:\n%s", want, got)
+		}
+	}
+}
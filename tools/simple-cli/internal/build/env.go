@@ -1,33 +1,20 @@
 package build
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
 
 	"simple-cli/internal/fsx"
+	"simple-cli/internal/scl"
 )
 
-// SCL-parser outputs JSON AST in this format:
-// [{"key": "set", "name": [...], "children": [...], "type": "block"}]
-type sclBlock struct {
-	Type     string     `json:"type"`
-	Key      string     `json:"key"`
-	Children []sclChild `json:"children"`
-}
-
-type sclChild struct {
-	Type  string `json:"type"`
-	Key   string `json:"key"`
-	Value any    `json:"value"`
-}
-
-// ParseExecutionEnvironment uses scl-parser CLI to extract execution_environment
-func ParseExecutionEnvironment(sclParserPath, actionDir string) (string, error) {
+// ParseExecutionEnvironment reads the execution_environment of the action at
+// actionDir from its app's records/10_actions.scl. An action the file does not
+// declare, or declares without one, runs on the server.
+func ParseExecutionEnvironment(actionDir string) (string, error) {
 	// SCL file is at apps/<app>/records/10_actions.scl
 	// actionDir is apps/<app>/actions/<action>/
 	appDir := filepath.Dir(filepath.Dir(actionDir))
@@ -37,48 +24,34 @@ func ParseExecutionEnvironment(sclParserPath, actionDir string) (string, error)
 		return "server", nil // default
 	}
 
-	cmd := exec.Command(sclParserPath, sclPath)
-	output, err := cmd.Output()
+	nodes, err := scl.ParseFile(sclPath)
 	if err != nil {
 		return "server", nil // fallback on parse error
 	}
 
-	var blocks []sclBlock
-	if err := json.Unmarshal(output, &blocks); err != nil {
-		return "server", nil
-	}
-
-	// Find execution_environment in the correct set block
-	for _, block := range blocks {
-		if block.Key == "set" {
-			// Check if this block corresponds to the current action
-			// We must check the "name" property inside the block children
-			isTargetAction := false
-			for _, child := range block.Children {
-				if child.Key == "name" {
-					if val, ok := child.Value.(string); ok && val == actionName {
-						isTargetAction = true
-						break
-					}
-				}
-			}
-
-			if isTargetAction {
-				for _, child := range block.Children {
-					if child.Key == "execution_environment" {
-						if str, ok := child.Value.(string); ok {
-							return str, nil
-						}
-					}
-				}
-				// Default to server if name matches but no env specified
-				return "server", nil
-			}
+	// Find execution_environment in the correct set block. The block's own
+	// name is the record's id; the action is the one whose name child matches.
+	for _, node := range nodes {
+		if !node.IsBlock() || node.Key != "set" {
+			continue
+		}
+		name := node.Child("name")
+		if name == nil || name.Value().String() != actionName {
+			continue
+		}
+		if env := node.Child("execution_environment"); env != nil && env.Value().String() != "" {
+			return env.Value().String(), nil
 		}
+		// Default to server if name matches but no env specified
+		return "server", nil
 	}
 	return "server", nil
 }
 
+func NormalizeActionName(dirName string) string {
+	return strings.ReplaceAll(dirName, "-", "_")
+}
+
 // ActionLanguage is the language an action is written in. It is what the build
 // path switches on to pick a compiler, so there is one of these per toolchain
 // rather than per file extension.
@@ -1,7 +1,6 @@
 package build
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -93,7 +92,7 @@ func TestParseExecutionEnvironment_Fallback(t *testing.T) {
 	tmpDir := t.TempDir()
 	// No 10_actions.scl
 
-	env, err := ParseExecutionEnvironment("dummy-parser", tmpDir)
+	env, err := ParseExecutionEnvironment(tmpDir)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -102,60 +101,40 @@ func TestParseExecutionEnvironment_Fallback(t *testing.T) {
 	}
 }
 
-func TestParseExecutionEnvironment_MultiAction(t *testing.T) {
-	// Create a temporary mock scl-parser script
-	tmpDir := t.TempDir()
-	mockParserPath := filepath.Join(tmpDir, "mock-scl-parser")
-
-	// JSON output simulating two actions: one server, one client.
-	// We now test matching by the inner "name" property.
-	// The order matters: put "server" first so the buggy implementation picks it up.
-	jsonOutput := `[
-		{
-			"type": "block",
-			"key": "set",
-			"name": ["sys.logic", "action_server_id"],
-			"children": [
-				{ "type": "assignment", "key": "name", "value": "action_server" },
-				{ "type": "assignment", "key": "execution_environment", "value": "server" }
-			]
-		},
-		{
-			"type": "block",
-			"key": "set",
-			"name": ["sys.logic", "action_client_id"],
-			"children": [
-				{ "type": "assignment", "key": "name", "value": "action_client" },
-				{ "type": "assignment", "key": "execution_environment", "value": "client" }
-			]
-		}
-	]`
-
-	// Use a simple shell script to mock the parser
-	scriptContent := fmt.Sprintf("#!/bin/sh\necho '%s'", jsonOutput)
-	if err := os.WriteFile(mockParserPath, []byte(scriptContent), 0755); err != nil {
-		t.Fatal(err)
-	}
-
-	// Create dummy SCL file path logic
-	// actionDir is .../apps/my-app/actions/action_client
-	// so appDir is .../apps/my-app
-	// sclPath is .../apps/my-app/records/10_actions.scl
-
-	appDir := filepath.Join(tmpDir, "apps", "my-app")
+// writeActionsSCL writes records/10_actions.scl for an app under dir and
+// returns the app's directory.
+func writeActionsSCL(t *testing.T, dir, content string) string {
+	t.Helper()
+	appDir := filepath.Join(dir, "apps", "my-app")
 	recordsDir := filepath.Join(appDir, "records")
 	if err := os.MkdirAll(recordsDir, 0755); err != nil {
 		t.Fatal(err)
 	}
-	// Create dummy SCL file
-	if err := os.WriteFile(filepath.Join(recordsDir, "10_actions.scl"), []byte("dummy"), 0644); err != nil {
+	if err := os.WriteFile(filepath.Join(recordsDir, "10_actions.scl"), []byte(content), 0644); err != nil {
 		t.Fatal(err)
 	}
+	return appDir
+}
+
+func TestParseExecutionEnvironment_MultiAction(t *testing.T) {
+	// Two actions: one server, one client. Matching is by the inner "name"
+	// property, and the server one comes first so that picking the first set
+	// block would give the wrong answer for the client.
+	appDir := writeActionsSCL(t, t.TempDir(), `set sys.logic, action_server_id {
+  name action_server
+  execution_environment server
+}
+
+set sys.logic, action_client_id {
+  name action_client
+  execution_environment client
+}
+`)
 
 	// Test case 1: building action_client. Should return "client".
 	actionClientDir := filepath.Join(appDir, "actions", "action_client")
 
-	env, err := ParseExecutionEnvironment(mockParserPath, actionClientDir)
+	env, err := ParseExecutionEnvironment(actionClientDir)
 	if err != nil {
 		t.Fatalf("ParseExecutionEnvironment failed: %v", err)
 	}
@@ -166,7 +145,7 @@ func TestParseExecutionEnvironment_MultiAction(t *testing.T) {
 
 	// Test case 2: building action_server. Should return "server".
 	actionServerDir := filepath.Join(appDir, "actions", "action_server")
-	env, err = ParseExecutionEnvironment(mockParserPath, actionServerDir)
+	env, err = ParseExecutionEnvironment(actionServerDir)
 	if err != nil {
 		t.Fatalf("ParseExecutionEnvironment failed: %v", err)
 	}
@@ -179,64 +158,25 @@ func TestParseExecutionEnvironment_MultiAction(t *testing.T) {
 // - Single action: server, client, both
 // - Multiple actions: mixed combinations
 func TestParseExecutionEnvironment_Comprehensive(t *testing.T) {
-	tmpDir := t.TempDir()
-	mockParserPath := filepath.Join(tmpDir, "mock-scl-parser")
+	appDir := writeActionsSCL(t, t.TempDir(), `set sys.logic, action_server_id {
+  name action_server
+  execution_environment server
+}
 
-	// construct JSON for multiple actions
-	// Action 1: server
-	// Action 2: client
-	// Action 3: both
-	jsonOutput := `[
-		{
-			"type": "block",
-			"key": "set",
-			"name": ["sys.logic", "action_server_id"],
-			"children": [
-				{ "type": "assignment", "key": "name", "value": "action_server" },
-				{ "type": "assignment", "key": "execution_environment", "value": "server" }
-			]
-		},
-		{
-			"type": "block",
-			"key": "set",
-			"name": ["sys.logic", "action_client_id"],
-			"children": [
-				{ "type": "assignment", "key": "name", "value": "action_client" },
-				{ "type": "assignment", "key": "execution_environment", "value": "client" }
-			]
-		},
-		{
-			"type": "block",
-			"key": "set",
-			"name": ["sys.logic", "action_both_id"],
-			"children": [
-				{ "type": "assignment", "key": "name", "value": "action_both" },
-				{ "type": "assignment", "key": "execution_environment", "value": "both" }
-			]
-		},
-		{
-			"type": "block",
-			"key": "set",
-			"name": ["sys.logic", "action_default_id"],
-			"children": [
-				{ "type": "assignment", "key": "name", "value": "action_default" }
-			]
-		}
-	]`
+set sys.logic, action_client_id {
+  name action_client
+  execution_environment client
+}
 
-	scriptContent := fmt.Sprintf("#!/bin/sh\necho '%s'", jsonOutput)
-	if err := os.WriteFile(mockParserPath, []byte(scriptContent), 0755); err != nil {
-		t.Fatal(err)
-	}
+set sys.logic, action_both_id {
+  name "action_both"
+  execution_environment :both
+}
 
-	appDir := filepath.Join(tmpDir, "apps", "my-app")
-	recordsDir := filepath.Join(appDir, "records")
-	if err := os.MkdirAll(recordsDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.WriteFile(filepath.Join(recordsDir, "10_actions.scl"), []byte("dummy"), 0644); err != nil {
-		t.Fatal(err)
-	}
+set sys.logic, action_default_id {
+  name action_default
+}
+`)
 
 	tests := []struct {
 		actionName string
@@ -252,7 +192,7 @@ func TestParseExecutionEnvironment_Comprehensive(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.actionName, func(t *testing.T) {
 			actionDir := filepath.Join(appDir, "actions", tt.actionName)
-			gotEnv, err := ParseExecutionEnvironment(mockParserPath, actionDir)
+			gotEnv, err := ParseExecutionEnvironment(actionDir)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -262,3 +202,32 @@ func TestParseExecutionEnvironment_Comprehensive(t *testing.T) {
 		})
 	}
 }
+
+func TestParseExecutionEnvironment_SyntaxErrorFallsBack(t *testing.T) {
+	appDir := writeActionsSCL(t, t.TempDir(), "set sys.logic, broken {\n  name broken\n")
+
+	env, err := ParseExecutionEnvironment(filepath.Join(appDir, "actions", "broken"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env != "server" {
+		t.Errorf("got %s, want server (fallback)", env)
+	}
+}
+
+func TestNormalizeActionName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"my-action", "my_action"},
+		{"action_name", "action_name"},
+		{"mixed-separators_here", "mixed_separators_here"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeActionName(tt.input); got != tt.want {
+			t.Errorf("NormalizeActionName(%s) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
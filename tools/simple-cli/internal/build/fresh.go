@@ -0,0 +1,207 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SourcesStamp is the file a build leaves beside an action's or a space's
+// artifacts: the hash of the sources they were built from. The collector
+// never deploys it.
+const SourcesStamp = ".sources.sha256"
+
+// Target is an action or a space of an app: something built from sources
+// into the artifacts that deploy.
+type Target struct {
+	Dir   string // the action or space directory
+	Space bool
+}
+
+// Stale is a target whose artifacts cannot be deployed as they are, and why.
+type Stale struct {
+	Target
+	Reason string
+}
+
+// sourceSkips are the directories under a target that are not its source:
+// what builds write, and what package managers fetch.
+var sourceSkips = map[string]bool{
+	"build": true, "dist": true, "node_modules": true, "target": true, "coverage": true, ".git": true,
+}
+
+// CheckArtifacts reports the actions and spaces of the app at appDir whose
+// artifacts are missing, or were built from other sources than theirs.
+//
+// An action needs the artifacts its execution_environment names:
+// build/release.wasm to run on the server, build/release.async.wasm in the
+// browser. A space needs a dist/ with something in it. Artifacts are fresh
+// when the sources hash to what the build stamped beside them, which holds
+// however a checkout has shuffled their times; without a stamp that
+// matches, they are fresh only when no source is newer than any of them.
+//
+// A Go action is not checked: the platform compiles it, and nothing this CLI
+// builds for it is deployed.
+func CheckArtifacts(appDir string) ([]Stale, error) {
+	actions, err := FindActions(appDir)
+	if err != nil {
+		return nil, err
+	}
+	spaces, err := FindSpaces(appDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []Stale
+	for _, dir := range actions {
+		if reason := checkAction(dir); reason != "" {
+			stale = append(stale, Stale{Target{Dir: dir}, reason})
+		}
+	}
+	for _, dir := range spaces {
+		if reason := checkSpace(dir); reason != "" {
+			stale = append(stale, Stale{Target{Dir: dir, Space: true}, reason})
+		}
+	}
+	return stale, nil
+}
+
+func checkAction(dir string) string {
+	lang, err := DetectActionLanguage(dir)
+	if err != nil {
+		return err.Error()
+	}
+	if lang == LanguageGo {
+		return ""
+	}
+
+	execEnv, _ := ParseExecutionEnvironment(dir)
+	var artifacts []string
+	if execEnv == "server" || execEnv == "both" {
+		artifacts = append(artifacts, filepath.Join("build", "release.wasm"))
+	}
+	if execEnv == "client" || execEnv == "both" {
+		artifacts = append(artifacts, filepath.Join("build", "release.async.wasm"))
+	}
+	if len(artifacts) == 0 {
+		return fmt.Sprintf("execution_environment is %q, which names no artifact", execEnv)
+	}
+	return checkTarget(dir, "build", artifacts)
+}
+
+func checkSpace(dir string) string {
+	var artifacts []string
+	_ = filepath.WalkDir(filepath.Join(dir, "dist"), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && d.Name() != SourcesStamp {
+			rel, _ := filepath.Rel(dir, path)
+			artifacts = append(artifacts, rel)
+		}
+		return nil
+	})
+	if len(artifacts) == 0 {
+		return "dist/ is missing or empty"
+	}
+	return checkTarget(dir, "dist", artifacts)
+}
+
+// checkTarget compares the artifacts of the target at dir, relative to it,
+// with its sources, the stamp among them in outDir. It returns why they
+// are stale, or "" when they are not.
+func checkTarget(dir, outDir string, artifacts []string) string {
+	var oldest time.Time
+	oldestName := ""
+	for _, name := range artifacts {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			return filepath.ToSlash(name) + " is missing"
+		}
+		if oldestName == "" || info.ModTime().Before(oldest) {
+			oldest, oldestName = info.ModTime(), name
+		}
+	}
+
+	sources, err := targetSources(dir)
+	if err != nil {
+		return err.Error()
+	}
+	if stamp, err := os.ReadFile(filepath.Join(dir, outDir, SourcesStamp)); err == nil {
+		if sum, err := hashSources(dir, sources); err == nil && string(stamp) == sum {
+			return ""
+		}
+	}
+	for _, src := range sources {
+		info, err := os.Stat(filepath.Join(dir, src))
+		if err == nil && info.ModTime().After(oldest) {
+			return fmt.Sprintf("%s changed since %s was built", filepath.ToSlash(src), filepath.ToSlash(oldestName))
+		}
+	}
+	return ""
+}
+
+// StampSources records the hash of the sources of the target at dir in
+// outDir, for CheckArtifacts to find the artifacts built there fresh
+// however their times compare.
+func StampSources(dir, outDir string) error {
+	sources, err := targetSources(dir)
+	if err != nil {
+		return err
+	}
+	sum, err := hashSources(dir, sources)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, outDir, SourcesStamp), []byte(sum), 0644)
+}
+
+// targetSources lists the files of the target at dir that it is built
+// from, relative to dir and in order.
+func targetSources(dir string) ([]string, error) {
+	var sources []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && sourceSkips[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// action.json is written by the build, from the source.
+		if d.Name() == "action.json" {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		sources = append(sources, rel)
+		return nil
+	})
+	sort.Strings(sources)
+	return sources, err
+}
+
+// hashSources hashes the names, sizes and contents of sources together.
+func hashSources(dir string, sources []string) (string, error) {
+	h := sha256.New()
+	for _, src := range sources {
+		f, err := os.Open(filepath.Join(dir, src))
+		if err != nil {
+			return "", err
+		}
+		info, err := f.Stat()
+		if err == nil {
+			fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(src), info.Size())
+			_, err = io.Copy(h, f)
+		}
+		_ = f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
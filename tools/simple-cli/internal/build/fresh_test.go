@@ -0,0 +1,105 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// age sets the modification time of path to d ago.
+func age(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	at := time.Now().Add(-d)
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// reasons maps each stale target of appDir, by directory name, to why.
+func reasons(t *testing.T, appDir string) map[string]string {
+	t.Helper()
+	stale, err := CheckArtifacts(appDir)
+	if err != nil {
+		t.Fatalf("CheckArtifacts() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, s := range stale {
+		got[filepath.Base(s.Dir)] = s.Reason
+	}
+	return got
+}
+
+func TestCheckArtifacts(t *testing.T) {
+	app := t.TempDir()
+	writeFile(t, filepath.Join(app, "records", "10_actions.scl"), `set dev_simple_system.logic, browse {
+  name browse
+  execution_environment both
+}
+`)
+	for _, action := range []string{"send", "browse", "unbuilt"} {
+		writeFile(t, filepath.Join(app, "actions", action, "src", "index.ts"), "export default {}")
+	}
+	writeFile(t, filepath.Join(app, "actions", "send", "build", "release.wasm"), "wasm")
+	writeFile(t, filepath.Join(app, "actions", "browse", "build", "release.wasm"), "wasm")
+	writeFile(t, filepath.Join(app, "actions", "go_action", "main.go"), "package main")
+	writeFile(t, filepath.Join(app, "spaces", "board", "package.json"), "{}")
+	writeFile(t, filepath.Join(app, "spaces", "board", "index.html"), "<html>")
+
+	want := map[string]string{
+		"browse":  "build/release.async.wasm is missing",
+		"unbuilt": "build/release.wasm is missing",
+		"board":   "dist/ is missing or empty",
+	}
+	got := reasons(t, app)
+	if len(got) != len(want) {
+		t.Errorf("stale = %q, want %q", got, want)
+	}
+	for dir, reason := range want {
+		if got[dir] != reason {
+			t.Errorf("%s: reason = %q, want %q", dir, got[dir], reason)
+		}
+	}
+
+	// An edit after the build makes the artifact stale.
+	send := filepath.Join(app, "actions", "send")
+	age(t, filepath.Join(send, "build", "release.wasm"), time.Hour)
+	if got := reasons(t, app)["send"]; got != "src/index.ts changed since build/release.wasm was built" {
+		t.Errorf("send after an edit: reason = %q", got)
+	}
+
+	// A stamp of the same sources makes it fresh, however the times compare,
+	// until the sources change.
+	if err := StampSources(send, "build"); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reasons(t, app)["send"]; ok {
+		t.Errorf("send with a matching stamp: reason = %q", got)
+	}
+	writeFile(t, filepath.Join(send, "src", "index.ts"), "export default { changed: true }")
+	if got := reasons(t, app)["send"]; got != "src/index.ts changed since build/release.wasm was built" {
+		t.Errorf("send after editing its stamped sources: reason = %q", got)
+	}
+}
+
+func TestStampSources_IgnoresBuildOutput(t *testing.T) {
+	space := t.TempDir()
+	writeFile(t, filepath.Join(space, "package.json"), "{}")
+	writeFile(t, filepath.Join(space, "src", "main.ts"), "app()")
+	writeFile(t, filepath.Join(space, "dist", "index.html"), "<html>")
+	writeFile(t, filepath.Join(space, "node_modules", "dep", "index.js"), "dep")
+	if err := StampSources(space, "dist"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(filepath.Join(space, "dist", SourcesStamp))
+
+	writeFile(t, filepath.Join(space, "dist", "index.html"), "<html>rebuilt")
+	writeFile(t, filepath.Join(space, "node_modules", "dep", "index.js"), "dep v2")
+	if err := StampSources(space, "dist"); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.ReadFile(filepath.Join(space, "dist", SourcesStamp))
+	if string(before) != string(after) || len(before) != 64 {
+		t.Errorf("stamp changed with build output: %q, then %q", before, after)
+	}
+}
@@ -61,7 +61,7 @@ func withGoBuildHarness(t *testing.T, describeErr error) *goBuildHarness {
 
 		return describeErr
 	}
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	return h
 }
@@ -198,7 +198,7 @@ func TestBuildAction_Go_WritesActionJSON(t *testing.T) {
 
 	origParseEnv := ParseExecutionEnvironmentFunc
 	t.Cleanup(func() { ParseExecutionEnvironmentFunc = origParseEnv })
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	actionDir := writeGoAction(t, "sync-orders", `package main
 
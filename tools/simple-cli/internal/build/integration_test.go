@@ -15,17 +15,17 @@ func TestIntegration_DownloadTools(t *testing.T) {
 	t.Setenv("HOME", tmpDir)
 
 	// Since we mock ensure functions in manager tests, here we want to test that
-	// EnsureSCLParser etc. actually work with EnsureTool.
-	// But ensureSCLParser etc. are vars in manager.go, but defined as functions in respective files.
+	// EnsureJavy etc. actually work with EnsureTool.
+	// But ensureJavy etc. are vars in manager.go, but defined as functions in respective files.
 	// This test calls the REAL functions.
 
-	// Use EnsureSCLParser directly
-	path, err := EnsureSCLParser(nil)
+	// Use EnsureJavy directly
+	path, err := EnsureJavy(nil)
 	if err != nil {
-		t.Fatalf("EnsureSCLParser failed: %v", err)
+		t.Fatalf("EnsureJavy failed: %v", err)
 	}
 
 	if !fileExists(path) {
-		t.Error("SCL Parser binary not found after ensure")
+		t.Error("Javy binary not found after ensure")
 	}
 }
@@ -13,7 +13,6 @@ import (
 
 // Mockable dependencies
 var (
-	EnsureSCLParserFunc           = EnsureSCLParser
 	EnsureJavyFunc                = EnsureJavy
 	EnsureWasmOptFunc             = EnsureWasmOpt
 	EnsureDependenciesFunc        = EnsureDependencies
@@ -53,7 +52,6 @@ type BuildManager struct {
 }
 
 type ToolPaths struct {
-	SCLParser          string
 	Javy               string
 	WasmOpt            string
 	RuntimePluginSync  string
@@ -77,9 +75,9 @@ func (m *BuildManager) EnsureTools(onProgress ProgressReporter) error {
 	m.toolsOnce.Do(func() {
 		var wg sync.WaitGroup
 		var mu sync.Mutex
-		errors := make([]error, 3)
+		errors := make([]error, 2)
 
-		wg.Add(3)
+		wg.Add(2)
 
 		checkTool := func(index int, name string, ensureFn func(func(string)) (string, error)) {
 			defer wg.Done()
@@ -99,8 +97,6 @@ func (m *BuildManager) EnsureTools(onProgress ProgressReporter) error {
 			}
 			mu.Lock()
 			switch name {
-			case "scl-parser":
-				m.tools.SCLParser = path
 			case "javy":
 				m.tools.Javy = path
 			case "wasm-opt":
@@ -110,9 +106,8 @@ func (m *BuildManager) EnsureTools(onProgress ProgressReporter) error {
 			mu.Unlock()
 		}
 
-		go checkTool(0, "scl-parser", EnsureSCLParserFunc)
-		go checkTool(1, "javy", EnsureJavyFunc)
-		go checkTool(2, "wasm-opt", EnsureWasmOptFunc)
+		go checkTool(0, "javy", EnsureJavyFunc)
+		go checkTool(1, "wasm-opt", EnsureWasmOptFunc)
 
 		wg.Wait()
 
@@ -198,7 +193,7 @@ func (m *BuildManager) BuildAction(ctx context.Context, actionDir string, onProg
 	// the same decision for every language: `server` needs the sync artifact,
 	// `client` the async one, `both` needs two. Only how those artifacts are
 	// produced differs below.
-	execEnv, _ := ParseExecutionEnvironmentFunc(m.tools.SCLParser, actionDir)
+	execEnv, _ := ParseExecutionEnvironmentFunc(actionDir)
 	needsSync := execEnv == "server" || execEnv == "both"
 	needsAsync := execEnv == "client" || execEnv == "both"
 
@@ -225,9 +220,9 @@ func (m *BuildManager) BuildAction(ctx context.Context, actionDir string, onProg
 
 	switch lang {
 	case LanguageTypeScript:
-		return m.buildTypeScriptAction(actionDir, actionName, needsSync, needsAsync, report)
+		return stamped(actionDir, "build", m.buildTypeScriptAction(actionDir, actionName, needsSync, needsAsync, report))
 	case LanguageRust:
-		return m.buildRustAction(actionDir, actionName, needsSync, needsAsync, report)
+		return stamped(actionDir, "build", m.buildRustAction(actionDir, actionName, needsSync, needsAsync, report))
 	case LanguageGo:
 		return m.buildGoAction(actionDir, actionName, report)
 	default:
@@ -244,6 +239,17 @@ func (m *BuildManager) BuildAction(ctx context.Context, actionDir string, onProg
 	}
 }
 
+// stamped records the sources an action was built from beside its artifacts
+// once the build has succeeded, for deploy to tell them fresh. A stamp that
+// cannot be written is not a failed build: deploy falls back to comparing
+// the times of the sources and the artifacts.
+func stamped(actionDir, outDir string, res ActionBuildResult) ActionBuildResult {
+	if res.Error == nil {
+		_ = StampSources(actionDir, outDir)
+	}
+	return res
+}
+
 // buildGoAction describes a Go action, and says where its module comes from.
 //
 // DESCRIBING AN ACTION IS NOT COMPILING IT, AND ONLY ONE OF THE TWO BELONGS TO
@@ -15,17 +15,14 @@ func TestEnsureTools(t *testing.T) {
 	t.Setenv("HOME", t.TempDir())
 
 	// Save original functions and restore after test
-	origVerify := EnsureSCLParserFunc
 	origJavy := EnsureJavyFunc
 	origWasmOpt := EnsureWasmOptFunc
 	defer func() {
-		EnsureSCLParserFunc = origVerify
 		EnsureJavyFunc = origJavy
 		EnsureWasmOptFunc = origWasmOpt
 	}()
 
 	// Mock ensure functions
-	EnsureSCLParserFunc = func(func(string)) (string, error) { return "/path/to/scl", nil }
 	EnsureJavyFunc = func(func(string)) (string, error) { return "/path/to/javy", nil }
 	EnsureWasmOptFunc = func(func(string)) (string, error) { return "/path/to/wasm-opt", nil }
 
@@ -44,26 +41,23 @@ func TestEnsureTools(t *testing.T) {
 		t.Errorf("EnsureTools() error = %v", err)
 	}
 
-	if m.tools.SCLParser != "/path/to/scl" {
-		t.Errorf("SCLParser path mismatch: got %s", m.tools.SCLParser)
+	if m.tools.Javy != "/path/to/javy" {
+		t.Errorf("Javy path mismatch: got %s", m.tools.Javy)
 	}
 }
 
 func TestEnsureTools_Error(t *testing.T) {
 	t.Setenv("HOME", t.TempDir())
 	// Save original functions
-	origVerify := EnsureSCLParserFunc
 	origJavy := EnsureJavyFunc
 	origWasmOpt := EnsureWasmOptFunc
 	defer func() {
-		EnsureSCLParserFunc = origVerify
 		EnsureJavyFunc = origJavy
 		EnsureWasmOptFunc = origWasmOpt
 	}()
 
 	mockError := errors.New("mock error")
-	EnsureSCLParserFunc = func(func(string)) (string, error) { return "", mockError }
-	EnsureJavyFunc = func(func(string)) (string, error) { return "/path/to/javy", nil }
+	EnsureJavyFunc = func(func(string)) (string, error) { return "", mockError }
 	EnsureWasmOptFunc = func(func(string)) (string, error) { return "/path/to/wasm-opt", nil }
 
 	m := NewBuildManager(DefaultBuildOptions())
@@ -101,7 +95,7 @@ func TestBuildActions_Concurrency(t *testing.T) {
 	CompileToWasmFunc = func(javy, js, plugin, out string) error { return nil }
 	OptimizeWasmFunc = func(opt, in, out string, flags []string) error { return nil }
 	DetectActionLanguageFunc = func(dir string) (ActionLanguage, error) { return LanguageTypeScript, nil }
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	m := NewBuildManager(BuildOptions{Concurrency: 2})
 	m.tools.Javy = "javy"
@@ -174,7 +168,7 @@ func TestBuildAction_MetadataExtractionCalled(t *testing.T) {
 	CompileToWasmFunc = func(javy, js, plugin, out string) error { return nil }
 	OptimizeWasmFunc = func(opt, in, out string, flags []string) error { return nil }
 	DetectActionLanguageFunc = func(dir string) (ActionLanguage, error) { return LanguageTypeScript, nil }
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	m := NewBuildManager(DefaultBuildOptions())
 	m.tools.Javy = "javy"
@@ -270,7 +264,7 @@ func TestBuildAction_MetadataExtractionFailureStopsTheBuild(t *testing.T) {
 		return nil
 	}
 	DetectActionLanguageFunc = func(dir string) (ActionLanguage, error) { return LanguageTypeScript, nil }
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	m := NewBuildManager(DefaultBuildOptions())
 	m.tools.Javy = "javy"
@@ -357,7 +351,7 @@ func TestBuildAction_MetadataExtractionProgressReporting(t *testing.T) {
 	CompileToWasmFunc = func(javy, js, plugin, out string) error { return nil }
 	OptimizeWasmFunc = func(opt, in, out string, flags []string) error { return nil }
 	DetectActionLanguageFunc = func(dir string) (ActionLanguage, error) { return LanguageTypeScript, nil }
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	m := NewBuildManager(DefaultBuildOptions())
 	m.tools.Javy = "javy"
@@ -478,7 +472,7 @@ func TestBuildAction_MetadataExtractionIntegration(t *testing.T) {
 			CompileToWasmFunc = func(javy, js, plugin, out string) error { return nil }
 			OptimizeWasmFunc = func(opt, in, out string, flags []string) error { return nil }
 			DetectActionLanguageFunc = func(dir string) (ActionLanguage, error) { return LanguageTypeScript, nil }
-			ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+			ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 			m := NewBuildManager(DefaultBuildOptions())
 			m.tools.Javy = "javy"
@@ -569,7 +563,7 @@ func TestBuildAction_UnrecognisedExecutionEnvironment(t *testing.T) {
 					EnsureDependenciesFunc = origDeps
 					EnsureCargoFunc = origCargo
 				})
-				ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) {
+				ParseExecutionEnvironmentFunc = func(dir string) (string, error) {
 					return execEnv, nil
 				}
 				// Neither toolchain may be asked for anything: the refusal is
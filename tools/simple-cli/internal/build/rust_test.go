@@ -214,7 +214,7 @@ func TestBuildAction_Rust(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			h := withRustBuildHarness(t, nil)
-			ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return tt.execEnv, nil }
+			ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return tt.execEnv, nil }
 
 			actionDir := rustAction(t, t.TempDir())
 			m := NewBuildManager(DefaultBuildOptions())
@@ -256,7 +256,7 @@ func TestBuildAction_Rust(t *testing.T) {
 // and the server would run an action compiled against imports it does not bind.
 func TestBuildAction_Rust_ServerArtifactSurvivesTheBrowserBuild(t *testing.T) {
 	withRustBuildHarness(t, nil)
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "both", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "both", nil }
 
 	actionDir := rustAction(t, t.TempDir())
 	m := NewBuildManager(DefaultBuildOptions())
@@ -290,7 +290,7 @@ func TestBuildAction_Rust_ServerArtifactSurvivesTheBrowserBuild(t *testing.T) {
 // "memory.copy operations require bulk memory operations".
 func TestBuildAction_Rust_BrowserFlags(t *testing.T) {
 	h := withRustBuildHarness(t, nil)
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "client", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "client", nil }
 
 	actionDir := rustAction(t, t.TempDir())
 	m := NewBuildManager(DefaultBuildOptions())
@@ -358,7 +358,7 @@ func TestBuildAction_Rust_MissingToolchain(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			h := withRustBuildHarness(t, nil)
-			ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+			ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 			tt.arrange()
 
 			actionDir := rustAction(t, t.TempDir())
@@ -384,7 +384,7 @@ func TestBuildAction_Rust_MissingToolchain(t *testing.T) {
 // and find whichever Cargo.toml sits above it.
 func TestBuildAction_Rust_MissingManifest(t *testing.T) {
 	h := withRustBuildHarness(t, nil)
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	actionDir := rustAction(t, t.TempDir())
 	if err := os.Remove(filepath.Join(actionDir, "Cargo.toml")); err != nil {
@@ -433,7 +433,7 @@ func TestBuildAction_Rust_MissingManifest(t *testing.T) {
 // still done the work of a build that succeeded.
 func TestBuildAction_Rust_MetadataFailureStopsTheBuild(t *testing.T) {
 	h := withRustBuildHarness(t, nil)
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	refusal := &AnnotationRefusal{
 		Refusal: `greet-user: @tool is a modifier tag and takes no value, and this one carries "true"`,
@@ -471,7 +471,7 @@ func TestBuildAction_Rust_MetadataFailureStopsTheBuild(t *testing.T) {
 
 func TestBuildAction_Rust_CargoFailureReported(t *testing.T) {
 	withRustBuildHarness(t, errors.New("cargo build failed: error[E0308]: mismatched types"))
-	ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 
 	actionDir := rustAction(t, t.TempDir())
 	m := NewBuildManager(DefaultBuildOptions())
@@ -104,6 +104,10 @@ func (m *BuildManager) BuildSpace(ctx context.Context, spaceDir string, onProgre
 		}
 	}
 
+	// As for an action, a stamp that cannot be written only costs deploy
+	// its check by hash.
+	_ = StampSources(spaceDir, "dist")
+
 	report("Done")
 	return SpaceBuildResult{SpaceName: spaceName, Error: nil}
 }
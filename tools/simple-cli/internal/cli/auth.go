@@ -6,7 +6,6 @@ import (
 	"path/filepath"
 	"time"
 
-	"simple-cli/internal/build"
 	"simple-cli/internal/config"
 	"simple-cli/internal/deploy"
 	"simple-cli/internal/keystore"
@@ -164,12 +163,7 @@ func runAuthStatus(_ *cobra.Command, _ []string) error {
 }
 
 func runAuthLogout(_ *cobra.Command, _ []string) error {
-	parserPath, err := build.EnsureSCLParser(nil)
-	if err != nil {
-		return fmt.Errorf("failed to ensure scl-parser: %w", err)
-	}
-
-	cfg, err := config.NewLoader(parserPath).LoadSimpleSCL(".")
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
 	if err != nil {
 		return fmt.Errorf("failed to load simple.scl (are you in a Simple Platform workspace?): %w", err)
 	}
@@ -189,12 +183,7 @@ func runAuthLogout(_ *cobra.Command, _ []string) error {
 }
 
 func runAuthEnroll(cmd *cobra.Command, _ []string) error {
-	parserPath, err := build.EnsureSCLParser(nil)
-	if err != nil {
-		return fmt.Errorf("failed to ensure scl-parser: %w", err)
-	}
-
-	cfg, err := config.NewLoader(parserPath).LoadSimpleSCL(".")
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
 	if err != nil {
 		return fmt.Errorf("failed to load simple.scl (are you in a Simple Platform workspace?): %w", err)
 	}
@@ -204,7 +193,7 @@ func runAuthEnroll(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	rawKey := os.ExpandEnv(env.APIKey)
+	rawKey := env.APIKey
 	idSuffix, err := deploy.ParseIDSuffix(rawKey)
 	if err != nil {
 		return fmt.Errorf("invalid api key in simple.scl: %w", err)
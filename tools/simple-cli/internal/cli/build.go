@@ -64,9 +64,9 @@ func runBuild(fsys fsx.FileSystem, args []string) error {
 	manager := build.NewBuildManager(opts)
 
 	// Phase 1: Ensure Tools
-	// We need specific binaries (scl-parser, javy, wasm-opt, esbuild) to be present.
+	// We need specific binaries (javy, wasm-opt, esbuild) to be present.
 	// This step downloads them if missing.
-	toolKeys := []string{"scl-parser", "javy", "wasm-opt"}
+	toolKeys := []string{"javy", "wasm-opt"}
 	if !jsonOutput {
 		if err := runWithProgress(toolKeys, func(report build.ProgressReporter) {
 			_ = manager.EnsureTools(report)
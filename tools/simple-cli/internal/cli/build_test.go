@@ -35,16 +35,13 @@ func TestRunBuild(t *testing.T) {
 	build.CompileToWasmFunc = func(javy, js, plugin, out string) error { return nil }
 	build.OptimizeWasmFunc = func(opt, in, out string, flags []string) error { return nil }
 
-	// Mock tool-check functions to avoid needing actual binaries (scl-parser, javy, etc.) in the test environment
-	origSCL := build.EnsureSCLParserFunc
+	// Mock tool-check functions to avoid needing actual binaries (javy, wasm-opt) in the test environment
 	origJavy := build.EnsureJavyFunc
 	origWasm := build.EnsureWasmOptFunc
 	defer func() {
-		build.EnsureSCLParserFunc = origSCL
 		build.EnsureJavyFunc = origJavy
 		build.EnsureWasmOptFunc = origWasm
 	}()
-	build.EnsureSCLParserFunc = func(f func(string)) (string, error) { return "scl", nil }
 	build.EnsureJavyFunc = func(f func(string)) (string, error) { return "javy", nil }
 	build.EnsureWasmOptFunc = func(f func(string)) (string, error) { return "wasm-opt", nil }
 
@@ -63,7 +60,7 @@ func TestRunBuild(t *testing.T) {
 	build.DetectActionLanguageFunc = func(dir string) (build.ActionLanguage, error) {
 		return build.LanguageTypeScript, nil
 	}
-	build.ParseExecutionEnvironmentFunc = func(parser, dir string) (string, error) { return "server", nil }
+	build.ParseExecutionEnvironmentFunc = func(dir string) (string, error) { return "server", nil }
 	build.ExtractMetadataFunc = func(fs fsx.FileSystem, actionDir string) error {
 		if strings.HasSuffix(actionDir, "refused") {
 			return &build.AnnotationRefusal{
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"simple-cli/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd groups the commands that inspect the workspace's simple.scl.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the workspace configuration in simple.scl",
+}
+
+// configValidateCmd checks simple.scl against its schema.
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check simple.scl against its schema",
+	Long: `Check simple.scl in the current directory against the keys it may contain:
+a tenant, a pipeline ordering the environments, a timeouts block with a
+duration for any of connect, manifest, upload, deploy and install, and env
+blocks with an endpoint, an api_key and, for release environments, release
+true each.
+
+Every problem is reported with its file and line: unknown keys (with the key
+that was probably meant), missing keys, duplicates, statements of the wrong
+shape, timeouts that are not durations, and a pipeline that leaves out,
+repeats or invents an environment. The command exits non-zero when there is
+any.
+
+Examples:
+  simple config validate
+  simple config validate --json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+// configShowCmd prints an environment's configuration as commands see it.
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective configuration of an environment",
+	Long: `Show the configuration deploy, install and auth use for an environment,
+with its values resolved, and where each value came from.
+
+Variables referenced in simple.scl are looked up in the process environment
+first, then in these files, each overriding the ones before it:
+
+  .env          shared by every environment
+  .env.<env>    for the environment given by --env
+  .env.local    your own overrides, not committed
+
+The API key, and any secret read from a file or credential helper, is
+redacted.
+
+Examples:
+  simple config show --env dev
+  simple config show --env prod --json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+var configShowEnv string
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configShowCmd)
+
+	configShowCmd.Flags().StringVar(&configShowEnv, "env", "", "environment to show (required)")
+	_ = configShowCmd.MarkFlagRequired("env")
+}
+
+func runConfigValidate(_ *cobra.Command, _ []string) error {
+	const path = "simple.scl"
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		if jsonOutput {
+			type problem struct {
+				Line    int    `json:"line,omitempty"`
+				Column  int    `json:"column,omitempty"`
+				Message string `json:"message"`
+			}
+			problems := make([]problem, len(verr.Problems))
+			for i, p := range verr.Problems {
+				problems[i] = problem{Line: p.Pos.Line, Column: p.Pos.Col, Message: p.Msg}
+			}
+			if err := printJSON(map[string]interface{}{"status": "failure", "path": path, "problems": problems}); err != nil {
+				return err
+			}
+		} else {
+			fmt.Println(verr)
+		}
+		return fmt.Errorf("found %d problems in %s", len(verr.Problems), path)
+	}
+	if err != nil {
+		return err
+	}
+
+	envs := make([]string, 0, len(cfg.Environments))
+	for name := range cfg.Environments {
+		envs = append(envs, name)
+	}
+	sort.Strings(envs)
+
+	if jsonOutput {
+		releases := cfg.Releases()
+		if releases == nil {
+			releases = []string{}
+		}
+		return printJSON(map[string]interface{}{
+			"status":       "success",
+			"path":         path,
+			"tenant":       cfg.Tenant,
+			"environments": envs,
+			"pipeline":     cfg.Stages(),
+			"releases":     releases,
+		})
+	}
+	fmt.Printf("✅ %s is valid: tenant %s, pipeline %s\n", path, cfg.Tenant, describePipeline(cfg))
+	return nil
+}
+
+// describePipeline renders the pipeline of cfg as dev → qa → prod (release).
+func describePipeline(cfg *config.SimpleSCL) string {
+	stages := make([]string, len(cfg.Stages()))
+	for i, name := range cfg.Stages() {
+		stages[i] = name
+		if cfg.IsRelease(name) {
+			stages[i] += " (release)"
+		}
+	}
+	return strings.Join(stages, " → ")
+}
+
+func runConfigShow(_ *cobra.Command, _ []string) error {
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+	if err != nil {
+		return fmt.Errorf("failed to load simple.scl (are you in a Simple Platform workspace?): %w", err)
+	}
+	settings, err := cfg.Settings(configShowEnv)
+	if err != nil {
+		return err
+	}
+	dotenv, err := config.LoadDotenv(".", configShowEnv)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		type value struct {
+			Key    string `json:"key"`
+			Value  string `json:"value"`
+			Source string `json:"source"`
+			Secret bool   `json:"secret,omitempty"`
+		}
+		values := make([]value, len(settings))
+		for i, s := range settings {
+			values[i] = value{Key: s.Key, Value: s.Value, Source: s.Source, Secret: s.Secret}
+		}
+		files := dotenv.Files
+		if files == nil {
+			files = []string{}
+		}
+		return printJSON(map[string]interface{}{
+			"status":       "success",
+			"environment":  configShowEnv,
+			"values":       values,
+			"dotenv_files": files,
+		})
+	}
+
+	fmt.Printf("Environment %s\n\n", configShowEnv)
+	for _, s := range settings {
+		fmt.Printf("  %-10s %s\n  %-10s ↳ %s\n", s.Key, s.Value, "", s.Source)
+	}
+	fmt.Println()
+	if len(dotenv.Files) == 0 {
+		fmt.Println("No dotenv files read")
+	} else {
+		fmt.Printf("Dotenv files read: %s\n", strings.Join(dotenv.Files, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// configWorkspace makes a directory holding simple.scl the working directory
+// for the test.
+func configWorkspace(t *testing.T, simpleSCL string) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "simple.scl"), []byte(simpleSCL), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldWd, _ := os.Getwd()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+}
+
+func TestConfigValidateCmd_Valid(t *testing.T) {
+	configWorkspace(t, "tenant acme\npipeline dev, qa, prod\nenv prod {\n  endpoint acme.on.simple.dev\n  api_key $K\n}\nenv qa {\n  endpoint acme-qa.on.simple.dev\n  api_key $K\n}\nenv dev {\n  endpoint acme-dev.on.simple.dev\n  api_key $K\n}\n")
+
+	out, _, err := invokeCmd("config", "validate")
+	if err != nil {
+		t.Fatalf("config validate failed: %v", err)
+	}
+	if !strings.Contains(out, "simple.scl is valid: tenant acme, pipeline dev → qa → prod (release)") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestConfigValidateCmd_Problems(t *testing.T) {
+	configWorkspace(t, "tenant acme\nenv dev {\n  endpoint acme-dev.on.simple.dev\n  apikey $K\n  region eu\n}\n")
+
+	out, _, err := invokeCmd("config", "validate")
+	if err == nil || !strings.Contains(err.Error(), "found 2 problems in simple.scl") {
+		t.Fatalf("expected two problems, got %v", err)
+	}
+	for _, want := range []string{
+		`simple.scl:4:3: unknown key "apikey" in environment 'dev'; did you mean "api_key"?`,
+		`simple.scl:5:3: unknown key "region" in environment 'dev'; expected one of endpoint, api_key, release`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "missing api_key") {
+		t.Errorf("a misspelled key was also reported missing:\n%s", out)
+	}
+}
+
+func TestConfigShowCmd(t *testing.T) {
+	configWorkspace(t, "tenant acme\nenv dev {\n  endpoint \"${TENANT}-dev.on.simple.dev\"\n  api_key $SHOW_KEY\n}\n")
+	for name, src := range map[string]string{".env": "TENANT=acme\nSHOW_KEY=si_shared_key\n", ".env.dev": "SHOW_KEY=si_dev_show_key\n"} {
+		if err := os.WriteFile(name, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, _, err := invokeCmd("config", "show", "--env", "dev")
+	if err != nil {
+		t.Fatalf("config show failed: %v", err)
+	}
+	for _, want := range []string{
+		"acme-dev.on.simple.dev",
+		"↳ simple.scl with ${TENANT} from .env",
+		"↳ $SHOW_KEY from .env.dev",
+		"Dotenv files read: .env, .env.dev",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "si_dev_show_key") {
+		t.Errorf("output shows the API key:\n%s", out)
+	}
+	if _, ok := os.LookupEnv("SHOW_KEY"); ok {
+		t.Error("dotenv values leaked into the process environment")
+	}
+}
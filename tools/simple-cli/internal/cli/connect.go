@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/deploy"
+	"simple-cli/internal/ui"
+)
+
+// devopsEndpoint gives the DevOps endpoint of an environment; tests point it
+// at a mock server.
+var devopsEndpoint = (*config.Environment).DevOpsEndpoint
+
+// interruptible returns ctx cancelled by Ctrl-C or SIGTERM too, so that a
+// command talking to the server can leave its channel before it exits.
+func interruptible(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
+
+// clientTimeouts gives the phase timeouts of a deploy client: each set by a
+// flag, else by the timeouts block of simple.scl. The client gives the phases
+// left zero their defaults.
+func clientTimeouts(cfg *config.SimpleSCL, flags deploy.Timeouts) deploy.Timeouts {
+	pick := func(flag, scl time.Duration) time.Duration {
+		if flag > 0 {
+			return flag
+		}
+		return scl
+	}
+	return deploy.Timeouts{
+		Connect:  pick(flags.Connect, cfg.Timeouts.Connect),
+		Manifest: pick(flags.Manifest, cfg.Timeouts.Manifest),
+		Upload:   pick(flags.Upload, cfg.Timeouts.Upload),
+		Deploy:   pick(flags.Deploy, cfg.Timeouts.Deploy),
+		Install:  pick(flags.Install, cfg.Timeouts.Install),
+	}
+}
+
+// cancelled reports err as the command being interrupted when ctx has ended,
+// rather than as a failure of whatever call the interrupt cut short.
+func cancelled(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return errors.New("cancelled")
+	}
+	return err
+}
+
+// joinApp authenticates to an environment of cfg, connects to its DevOps
+// service and joins the deploy channel of appID.
+func joinApp(ctx context.Context, cfg *config.SimpleSCL, envName, appID string) (*deploy.Client, error) {
+	client, err := connectEnv(ctx, cfg, envName, deploy.Timeouts{})
+	if err != nil {
+		return nil, err
+	}
+	if err := client.JoinChannel(ctx, appID); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// connectEnv authenticates to an environment of cfg and connects to its
+// DevOps service, with the phase timeouts flags sets.
+func connectEnv(ctx context.Context, cfg *config.SimpleSCL, envName string, flags deploy.Timeouts) (*deploy.Client, error) {
+	session, err := authenticateEnv(ctx, cfg, envName)
+	if err != nil {
+		return nil, err
+	}
+	return session.connect(ctx, flags)
+}
+
+// envSession is an environment of cfg authenticated to, holding what it
+// takes to refresh the token when the server refuses it.
+type envSession struct {
+	cfg  *config.SimpleSCL
+	name string
+	env  *config.Environment
+	auth *deploy.Authenticator
+	key  string // the token cache key of the tenant and environment
+	jwt  string
+}
+
+// authenticateEnv resolves the environment envName of cfg and gets a token
+// for it, cached for the token's lifetime.
+func authenticateEnv(ctx context.Context, cfg *config.SimpleSCL, envName string) (*envSession, error) {
+	env, err := cfg.GetEnv(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &envSession{cfg: cfg, name: envName, env: env, auth: deploy.NewAuthenticator(), key: deploy.TenantEnvKey(cfg.Tenant, envName)}
+	s.jwt, err = s.auth.GetJWT(ctx, env.IdentityEndpoint(), env.APIKey, s.key)
+	if err != nil {
+		return nil, fmt.Errorf("authentication to %s failed: %w", envName, err)
+	}
+	return s, nil
+}
+
+// connect connects to the DevOps service of the environment, with the phase
+// timeouts flags sets. A token the server refuses (401/403) has expired: it
+// is dropped from the cache, a fresh one fetched and the connection retried
+// once.
+func (s *envSession) connect(ctx context.Context, flags deploy.Timeouts) (*deploy.Client, error) {
+	client := s.client(flags)
+	err := client.Connect(ctx)
+	var authErr *deploy.AuthFailedError
+	if !errors.As(err, &authErr) {
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+
+	if !jsonOutput {
+		fmt.Printf("🔄 Auth token for %s expired, refreshing...\n", s.name)
+	}
+	if err := s.auth.ClearCache(s.key); err != nil {
+		return nil, fmt.Errorf("failed to clear token cache: %w", err)
+	}
+	s.jwt, err = s.auth.GetJWT(ctx, s.env.IdentityEndpoint(), s.env.APIKey, s.key)
+	if err != nil {
+		return nil, fmt.Errorf("re-authentication to %s failed: %w", s.name, err)
+	}
+	client = s.client(flags)
+	if err := client.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connection to %s failed after token refresh: %w", s.name, err)
+	}
+	return client, nil
+}
+
+// client is a deploy client for the environment with the current token.
+// Installs routinely outlast the default timeouts on record-heavy apps, so
+// every client, the one after a refresh included, carries the same ones.
+func (s *envSession) client(flags deploy.Timeouts) *deploy.Client {
+	return deploy.NewClient(deploy.ClientConfig{
+		Endpoint:    devopsEndpoint(s.env),
+		JWT:         s.jwt,
+		Timeouts:    clientTimeouts(s.cfg, flags),
+		OnUpload:    reportUpload,
+		OnReconnect: reportReconnect,
+		OnInstall:   reportInstall(),
+	})
+}
+
+// appArg returns the app an argument names: an app directory, whose app.scl
+// gives the ID and the local version, or the ID itself, with no version.
+func appArg(arg string) (appID, version string, err error) {
+	if _, err := os.Stat(filepath.Join(arg, "app.scl")); err != nil {
+		return arg, "", nil
+	}
+	app, err := deploy.NewVersionManager().ParseAppSCL(arg)
+	if err != nil {
+		return "", "", err
+	}
+	return app.ID, app.Version, nil
+}
+
+// reportUpload prints the retries and resumes of an upload; the files
+// uploaded are summed up by the command afterwards.
+func reportUpload(p deploy.UploadProgress) {
+	if line := uploadLine(p); line != "" && !jsonOutput {
+		fmt.Println("   " + line)
+	}
+}
+
+func uploadLine(p deploy.UploadProgress) string {
+	switch p.Event {
+	case deploy.FileRetrying:
+		return fmt.Sprintf("↻ retrying %s (attempt %d failed: %v)", p.Path, p.Attempt, p.Err)
+	case deploy.UploadResumed:
+		return fmt.Sprintf("↻ connection restored; %d of %d files left to upload", p.Total-p.Done, p.Total)
+	}
+	return ""
+}
+
+// reportReconnect prints the attempts at restoring a dropped connection.
+func reportReconnect(r deploy.Reconnect) {
+	if !jsonOutput {
+		fmt.Println("   " + reconnectLine(r))
+	}
+}
+
+func reconnectLine(r deploy.Reconnect) string {
+	switch {
+	case r.Restored:
+		return "🔌 reconnected"
+	case r.Err != nil:
+		return fmt.Sprintf("🔌 reconnecting (attempt %d, previous attempt failed: %v)…", r.Attempt, r.Err)
+	default:
+		return fmt.Sprintf("🔌 connection lost; reconnecting (attempt %d)…", r.Attempt)
+	}
+}
+
+// reportInstall returns a reporter printing the progress the server reports
+// during an install, as the non-interactive deploy UI does: each step
+// reached and each line of the install's log.
+func reportInstall() func(deploy.InstallEvent) {
+	if jsonOutput {
+		return nil
+	}
+	progress := ui.NewSimpleProgress()
+	return func(e deploy.InstallEvent) {
+		if e.Step == "" {
+			progress.Log(ui.DeployLogMsg{Level: e.Level, Text: e.Message})
+			return
+		}
+		progress.Step(installStep(e))
+	}
+}
+
+// installStep describes the step of an install_progress event.
+func installStep(e deploy.InstallEvent) string {
+	step := e.Step
+	if e.Message != "" {
+		step += ": " + e.Message
+	}
+	if e.Total > 0 {
+		step += fmt.Sprintf(" (%d/%d records)", e.Current, e.Total)
+	}
+	return step
+}
@@ -5,10 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
-	"sync"
+	"strings"
 	"time"
 
-	"simple-cli/internal/build"
 	"simple-cli/internal/config"
 	"simple-cli/internal/deploy"
 	"simple-cli/internal/fsx"
@@ -20,51 +19,111 @@ var (
 	deployEnv       string
 	deployBump      string
 	deployDryRun    bool
+	deployPlan      bool
 	deployNoInstall bool
+	deployAll       bool
+	deployApps      string
+	deployBuild     bool
+	deployTimeouts  deploy.Timeouts
 )
 
 // deployCmd represents the 'deploy' command.
 // It handles the full deployment lifecycle: config loading, version bumping,
 // file hashing, manifest synchronization, artifact upload, and deployment triggering.
 var deployCmd = &cobra.Command{
-	Use:   "deploy <app-path>",
+	Use:   "deploy <app-path> | --all",
 	Short: "Deploy an app to Simple Platform",
 	Long: `Deploy an app to the specified environment.
 
-Version is automatically managed based on target environment.
-Use --bump for first deploy after a prod release.
+Version is automatically managed based on target environment: deploys to a
+release environment get versions like 1.2.0, deploys to the other
+environments of the pipeline in simple.scl prereleases like 1.2.0-dev.3.
+Use --bump for the first deploy after a release.
 By default, the deployed version is automatically installed.
 Use --no-install to skip installation (upload artifacts only).
 
+--dry-run lists the files that would be deployed without contacting the
+server, and those left out by a rule: built-in ones for tests, coverage
+reports and editor files, then the .simpleignore of the workspace root and
+of the app, in gitignore syntax. --plan sends the manifest and reports which
+files are new, changed, unchanged or removed relative to the installed
+version, then stops without uploading, deploying or touching app.scl.
+
+Before anything is deployed, every action and space of the app is checked:
+the artifacts its execution_environment needs exist, and were built from
+its current sources. Stale ones fail the deploy, each named with why;
+--build builds them first.
+
+Each phase of talking to the server has a timeout of its own, set by the
+--*-timeout flags or the timeouts block of simple.scl. Ctrl-C leaves the
+deploy channel before exiting.
+
+--all deploys every app under apps/, or with --apps only those named, by ID
+or directory. It authenticates and connects once, deploys the apps
+concurrently, each on a channel of its own, and installs an app only once
+the apps its app.scl names in depends_on are installed. A summary of every
+app ends it; an app whose dependency was not installed is deployed but not
+installed.
+
 Examples:
   simple deploy apps/com.example.crm --env dev --bump patch
   simple deploy apps/com.example.crm --env dev
   simple deploy apps/com.example.crm --env staging
-  simple deploy apps/com.example.crm --env prod`,
-	Args: cobra.ExactArgs(1),
+  simple deploy apps/com.example.crm --env prod
+  simple deploy apps/com.example.crm --env prod --plan
+  simple deploy apps/com.example.crm --env dev --build
+  simple deploy --all --env dev
+  simple deploy --all --apps com.example.core,com.example.crm --env dev`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if deployAll {
+			if len(args) > 0 {
+				return fmt.Errorf("cannot use --all with an app path")
+			}
+			return nil
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if deployAll {
+			return runDeployAll(cmd.Context())
+		}
+		if deployApps != "" {
+			return fmt.Errorf("--apps selects the apps of --all")
+		}
 		return runDeploy(cmd.Context(), fsx.OSFileSystem{}, args)
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(deployCmd)
-	deployCmd.Flags().StringVar(&deployEnv, "env", "", "target environment (required: dev, staging, or prod)")
-	deployCmd.Flags().StringVar(&deployBump, "bump", "", "version bump type: patch|minor|major (required for first deploy after prod)")
+	deployCmd.Flags().StringVar(&deployEnv, "env", "", "target environment from the pipeline in simple.scl (required)")
+	deployCmd.Flags().StringVar(&deployBump, "bump", "", "version bump type: patch|minor|major (required for first deploy after a release)")
 	deployCmd.Flags().BoolVar(&deployDryRun, "dry-run", false, "show what would be deployed without deploying")
+	deployCmd.Flags().BoolVar(&deployPlan, "plan", false, "show which files would change on the server, then stop before uploading")
 	deployCmd.Flags().BoolVar(&deployNoInstall, "no-install", false, "skip automatic installation after deploy")
+	deployCmd.Flags().BoolVar(&deployBuild, "build", false, "build stale actions and spaces before deploying")
+	deployCmd.Flags().BoolVar(&deployAll, "all", false, "deploy every app under apps/, installing each after the apps it depends on")
+	deployCmd.Flags().StringVar(&deployApps, "apps", "", "with --all, the apps to deploy (comma-separated IDs or directories)")
+	deployCmd.Flags().DurationVar(&deployTimeouts.Connect, "connect-timeout", 0, "how long connecting and joining may take (default 30s)")
+	deployCmd.Flags().DurationVar(&deployTimeouts.Manifest, "manifest-timeout", 0, "how long the manifest reply may take (default 2m)")
+	deployCmd.Flags().DurationVar(&deployTimeouts.Upload, "upload-timeout", 0, "how long uploading the files may take (default 30m)")
+	deployCmd.Flags().DurationVar(&deployTimeouts.Deploy, "deploy-timeout", 0, "how long the deploy reply may take (default 5m)")
+	deployCmd.Flags().DurationVar(&deployTimeouts.Install, "install-timeout", 0, "how long the install may take (default 15m)")
 	_ = deployCmd.MarkFlagRequired("env")
+	deployCmd.MarkFlagsMutuallyExclusive("dry-run", "plan")
+	deployCmd.MarkFlagsMutuallyExclusive("all", "dry-run")
+	deployCmd.MarkFlagsMutuallyExclusive("all", "plan")
 }
 
 // runDeploy executes the main deployment logic.
 // It orchestrates local preparation and remote communication with the DevOps service.
-func runDeploy(ctx context.Context, fsys fsx.FileSystem, args []string) error {
+func runDeploy(ctx context.Context, fsys fsx.FileSystem, args []string) (err error) {
 	appPath := args[0]
 	start := time.Now()
 
 	// Validate --env flag is provided
 	if deployEnv == "" {
-		return fmt.Errorf("--env flag is required (dev, staging, or prod)")
+		return envRequiredError()
 	}
 
 	// Validate app exists
@@ -72,66 +131,52 @@ func runDeploy(ctx context.Context, fsys fsx.FileSystem, args []string) error {
 		return fmt.Errorf("app path '%s' not found", appPath)
 	}
 
-	// Ensure scl-parser is available (downloads if needed) for config parsing
-	parserPath, err := build.EnsureSCLParser(nil)
-	if err != nil {
-		return fmt.Errorf("failed to ensure scl-parser: %w", err)
+	// Stale artifacts fail the deploy before app.scl is bumped.
+	if err := new(deployBuilder).ensureArtifacts(ctx, appPath); err != nil {
+		return err
 	}
 
 	// === PHASE 1: Config & Auth ===
 	// Load configuration to determine endpoints and credentials.
-	var cfg *config.SimpleSCL
-	var env *config.Environment
-	var cfgErr, authErr error
-	var jwt string
-
-	// Load simple.scl config
-	loader := config.NewLoader(parserPath)
-	cfg, cfgErr = loader.LoadSimpleSCL(".")
-	if cfgErr != nil {
-		return fmt.Errorf("failed to load simple.scl: %w", cfgErr)
-	}
-
-	env, cfgErr = cfg.GetEnv(deployEnv)
-	if cfgErr != nil {
-		return cfgErr
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+	if err != nil {
+		return fmt.Errorf("failed to load simple.scl: %w", err)
 	}
 
-	// Get JWT (cached for token lifetime)
-	tenantEnvKey := deploy.TenantEnvKey(cfg.Tenant, deployEnv)
-	auth := deploy.NewAuthenticator()
-	jwt, authErr = auth.GetJWT(ctx, env.IdentityEndpoint(), env.APIKey, tenantEnvKey)
-	if authErr != nil {
-		return fmt.Errorf("authentication failed: %w", authErr)
+	// Authenticate before anything is bumped, so that a refused key leaves
+	// app.scl as it was.
+	session, err := authenticateEnv(ctx, cfg, deployEnv)
+	if err != nil {
+		return err
 	}
 
-	// === PHASE 2: Version & Files (parallel) ===
-	// Calculate new version and hash all files concurrently for speed.
+	// === PHASE 2: Version & Files ===
+	// Bump the version before hashing the files: app.scl is one of them, and
+	// hashed while the bump rewrites it, its hash would not match what is
+	// uploaded. A plan leaves app.scl alone and hashes it as the bump would
+	// rewrite it.
+	vm := deploy.NewVersionManager()
 	var newVersion string
-	var files map[string]deploy.FileInfo
-	var versionErr, filesErr error
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
-		vm := deploy.NewVersionManager(parserPath)
-		newVersion, versionErr = vm.BumpVersion(appPath, deployEnv, deployBump)
-	}()
-
-	go func() {
-		defer wg.Done()
-		collector := deploy.NewFileCollector()
-		files, filesErr = collector.CollectFiles(appPath)
-	}()
-
-	wg.Wait()
-
+	var appSCL []byte
+	var versionErr error
+	if deployPlan {
+		newVersion, appSCL, versionErr = vm.NextVersion(appPath, deployEnv, deployBump, cfg)
+	} else {
+		newVersion, versionErr = vm.BumpVersion(appPath, deployEnv, deployBump, cfg)
+	}
 	if versionErr != nil {
 		return versionErr
 	}
-	if filesErr != nil {
-		return filesErr
+
+	collector := deploy.NewFileCollector()
+	var excluded []deploy.Exclusion
+	collector.OnExclude = func(e deploy.Exclusion) { excluded = append(excluded, e) }
+	files, err := collector.CollectFiles(appPath)
+	if err != nil {
+		return err
+	}
+	if appSCL != nil {
+		files["app.scl"] = deploy.MemFile("app.scl", appSCL)
 	}
 
 	if !jsonOutput {
@@ -140,67 +185,38 @@ func runDeploy(ctx context.Context, fsys fsx.FileSystem, args []string) error {
 	}
 
 	if deployDryRun {
-		return dryRunOutput(files, newVersion)
+		return dryRunOutput(files, excluded, newVersion)
 	}
 
 	// === PHASE 3: Connect & Deploy ===
-	// Establish connection to DevOps service.
-	client := deploy.NewClient(deploy.ClientConfig{
-		Endpoint: env.DevOpsEndpoint(),
-		JWT:      jwt,
-		Timeout:  15 * time.Minute,
-	})
-
-	if err := client.Connect(); err != nil {
-		// Handle potential auth failure (expired token)
-		// If 401/403, we try to refresh the token and reconnect once.
-		var authErr *deploy.AuthFailedError
-		if errors.As(err, &authErr) { // 401/403
-			if !jsonOutput {
-				fmt.Println("🔄 Auth token expired, refreshing...")
-			}
-
-			// 1. Clear token cache to force fresh prompt/login if needed
-			if err := auth.ClearCache(tenantEnvKey); err != nil {
-				return fmt.Errorf("failed to clear token cache: %w", err)
-			}
+	// Establish connection to DevOps service. From here on Ctrl-C cancels
+	// the call in flight, and the deferred Close leaves the channel.
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	defer func() { err = cancelled(ctx, err) }()
 
-			// 2. Get new JWT (force refresh)
-			var newJWTErr error
-			jwt, newJWTErr = auth.GetJWT(ctx, env.IdentityEndpoint(), env.APIKey, tenantEnvKey)
-			if newJWTErr != nil {
-				return fmt.Errorf("re-authentication failed: %w", newJWTErr)
-			}
-
-			// 3. Re-create client with new JWT
-			client = deploy.NewClient(deploy.ClientConfig{
-				Endpoint: env.DevOpsEndpoint(),
-				JWT:      jwt,
-				Timeout:  15 * time.Minute,
-			})
-
-			// 4. Retry connection
-			if err := client.Connect(); err != nil {
-				return fmt.Errorf("connection failed after refresh: %w", err)
-			}
-		} else {
-			return err
-		}
+	client, err := session.connect(ctx, deployTimeouts)
+	if err != nil {
+		return err
 	}
 	defer client.Close()
 
 	// Get app ID from app.scl to verify we are deploying the correct app
-	appID, err := deploy.ExtractAppID(parserPath, appPath)
+	appID, err := deploy.ExtractAppID(appPath)
 	if err != nil {
 		return err
 	}
 
-	if err := client.JoinChannel(appID); err != nil {
+	if err := client.JoinChannel(ctx, appID); err != nil {
 		return err
 	}
 
+	if deployPlan {
+		return planDeploy(ctx, client, appID, files, newVersion)
+	}
+
 	// Send manifest to server to check which files are missing (delta upload)
-	neededFiles, err := client.SendManifest(files, newVersion)
+	neededFiles, err := client.SendManifest(ctx, files, newVersion)
 	if err != nil {
 		return err
 	}
@@ -210,12 +226,12 @@ func runDeploy(ctx context.Context, fsys fsx.FileSystem, args []string) error {
 	}
 
 	// Upload needed files in parallel
-	if err := client.SendFiles(files, neededFiles); err != nil {
+	if err := client.SendFiles(ctx, files, neededFiles); err != nil {
 		return err
 	}
 
 	// Trigger deploy on server (finalize version)
-	result, err := client.Deploy()
+	result, err := client.Deploy(ctx)
 	if err != nil {
 		return err
 	}
@@ -227,7 +243,7 @@ func runDeploy(ctx context.Context, fsys fsx.FileSystem, args []string) error {
 		if !jsonOutput {
 			fmt.Printf("🚀 Installing %s@%s to %s...\n", result.AppID, result.Version, deployEnv)
 		}
-		installResult, err = installDeployedVersion(client, result.Version, jsonOutput)
+		installResult, err = installVersion(ctx, client, result.Version, false, jsonOutput)
 		if err != nil {
 			fmt.Printf("⚠️  Deploy successful but install failed: %v\n", err)
 			if jsonOutput {
@@ -267,8 +283,18 @@ func runDeploy(ctx context.Context, fsys fsx.FileSystem, args []string) error {
 	return nil
 }
 
-// dryRunOutput prints the files that would be deployed without actually deploying.
-func dryRunOutput(files map[string]deploy.FileInfo, version string) error {
+// envRequiredError reports a missing --env, listing the environments of the
+// workspace's pipeline when simple.scl can be read.
+func envRequiredError() error {
+	if cfg, err := config.NewLoader().LoadSimpleSCL("."); err == nil {
+		return fmt.Errorf("--env flag is required (one of %s)", strings.Join(cfg.Stages(), ", "))
+	}
+	return fmt.Errorf("--env flag is required")
+}
+
+// dryRunOutput prints the files that would be deployed without actually
+// deploying, and those that ignore rules excluded, each with its rule.
+func dryRunOutput(files map[string]deploy.FileInfo, excluded []deploy.Exclusion, version string) error {
 	if jsonOutput {
 		fileList := make([]map[string]interface{}, 0, len(files))
 		for path, fi := range files {
@@ -279,9 +305,10 @@ func dryRunOutput(files map[string]deploy.FileInfo, version string) error {
 			})
 		}
 		return printJSON(map[string]interface{}{
-			"dry_run": true,
-			"version": version,
-			"files":   fileList,
+			"dry_run":  true,
+			"version":  version,
+			"files":    fileList,
+			"excluded": append([]deploy.Exclusion{}, excluded...),
 		})
 	}
 
@@ -289,39 +316,105 @@ func dryRunOutput(files map[string]deploy.FileInfo, version string) error {
 	for path, fi := range files {
 		fmt.Printf("  %s (%d bytes, hash: %s...)\n", path, fi.Size, fi.Hash[:8])
 	}
+	if len(excluded) > 0 {
+		fmt.Println("\n🚫 Excluded:")
+		for _, e := range excluded {
+			fmt.Printf("  %s (%s, %s)\n", e.Path, e.Rule, e.Source)
+		}
+	}
 	fmt.Printf("\nTotal: %d files, version: %s\n", len(files), version)
 	return nil
 }
 
-// findSCLParser is deprecated - kept for test compatibility
-// Use build.EnsureSCLParser() instead which handles automatic download
+// planDeploy sends the manifest of files and reports how deploying it would
+// change the files of the installed version. Nothing is uploaded.
+func planDeploy(ctx context.Context, client *deploy.Client, appID string, files map[string]deploy.FileInfo, version string) error {
+	current := ""
+	var installedFiles map[string]deploy.FileInfo
+	installed, err := client.Installed(ctx)
+	var notInstalled *deploy.NotInstalledError
+	switch {
+	case err == nil:
+		current, installedFiles = installed.Version, installed.Files
+	case !errors.As(err, &notInstalled):
+		return err
+	}
+
+	neededFiles, err := client.SendManifest(ctx, files, version)
+	if err != nil {
+		return err
+	}
+	plan := deploy.DiffManifests(files, installedFiles)
+
+	if jsonOutput {
+		return printJSON(map[string]interface{}{
+			"status":    "success",
+			"plan":      true,
+			"app_id":    appID,
+			"env":       deployEnv,
+			"version":   version,
+			"installed": current,
+			"files":     plan,
+			"upload":    len(neededFiles),
+		})
+	}
+
+	fmt.Printf("\n📋 Plan for %s@%s in %s (installed: %s):\n", appID, version, deployEnv, orNone(current))
+	for _, group := range []struct {
+		mark  string
+		paths []string
+	}{{"+", plan.New}, {"~", plan.Changed}, {"-", plan.Removed}} {
+		for _, path := range group.paths {
+			fmt.Printf("  %s %s\n", group.mark, path)
+		}
+	}
+	fmt.Printf("\n%d new, %d changed, %d unchanged, %d removed; %d files to upload\n",
+		len(plan.New), len(plan.Changed), len(plan.Unchanged), len(plan.Removed), len(neededFiles))
+	return nil
+}
 
 // alreadyInstalledRe extracts the version named in the server's
 // "Version `X` of application `Y` is already installed" reply.
 var alreadyInstalledRe = regexp.MustCompile("Version `([^`]+)` of application `[^`]+` is already installed")
 
-// installDeployedVersion installs the version that was just deployed, absorbing
-// two server behaviours that are not real failures for a deploy:
+// installer installs deployed versions of an app; *deploy.Client is one.
+type installer interface {
+	Install(ctx context.Context) (*deploy.InstallResult, error)
+	InstallVersion(ctx context.Context, version string) (*deploy.InstallResult, error)
+}
+
+// installBackoff is how long installVersion waits before each retry.
+var installBackoff = []time.Duration{2 * time.Second, 5 * time.Second, 10 * time.Second}
+
+// installVersion installs version of an app and absorbs two server behaviours
+// that are not real failures:
 //
-//   - The server reports the version we just deployed as already installed.
-//     Installing is idempotent, so that outcome is a success, not an error.
+//   - The server reports version as already installed. Installing is
+//     idempotent, so that outcome is a success, not an error. A rollback to
+//     the version that is installed ends here too.
 //
-//   - The server reports a DIFFERENT (older) version as already installed. The
-//     install request carries no version, so the server resolves one itself and
-//     can briefly resolve the previously installed version instead of the one
-//     just deployed. Retrying resolves it once the newly deployed manifest is
-//     visible, which is why a manual `simple install` immediately afterwards has
-//     always succeeded.
+//   - The server reports a DIFFERENT version as already installed. After a
+//     deploy, when the install request carries no version (pin false), the
+//     server resolves one itself and can briefly resolve the previously
+//     installed version instead of the one just deployed. Retrying resolves
+//     it once the newly deployed manifest is visible, which is why a manual
+//     `simple install` immediately afterwards has always succeeded. A pinned
+//     install, which names version explicitly, is retried the same way.
 //
-// Any other error is returned unchanged on the first attempt.
-func installDeployedVersion(client *deploy.Client, deployedVersion string, quiet bool) (*deploy.InstallResult, error) {
-	const attempts = 4
-
-	backoff := []time.Duration{2 * time.Second, 5 * time.Second, 10 * time.Second}
+// Any other error is returned unchanged on the first attempt, as is ctx
+// ending while it waits to retry.
+func installVersion(ctx context.Context, client installer, version string, pin, quiet bool) (*deploy.InstallResult, error) {
+	attempts := len(installBackoff) + 1
 
 	var lastErr error
 	for attempt := range attempts {
-		result, err := client.Install()
+		var result *deploy.InstallResult
+		var err error
+		if pin {
+			result, err = client.InstallVersion(ctx, version)
+		} else {
+			result, err = client.Install(ctx)
+		}
 		if err == nil {
 			return result, nil
 		}
@@ -332,20 +425,24 @@ func installDeployedVersion(client *deploy.Client, deployedVersion string, quiet
 			return nil, err
 		}
 
-		// The version we deployed is installed — nothing left to do.
-		if match[1] == deployedVersion {
-			return &deploy.InstallResult{Version: deployedVersion, Success: true}, nil
+		// The version we asked for is installed — nothing left to do.
+		if match[1] == version {
+			return &deploy.InstallResult{Version: version, Success: true}, nil
 		}
 
-		// Stale resolution: the server answered about an older version. Wait for
-		// the new manifest to become visible and ask again.
+		// Stale resolution: the server answered about another version. Wait
+		// for the new manifest to become visible and ask again.
 		if attempt == attempts-1 {
 			break
 		}
 		if !quiet {
-			fmt.Printf("   ↻ server resolved %s; retrying install of %s…\n", match[1], deployedVersion)
+			fmt.Printf("   ↻ server resolved %s; retrying install of %s…\n", match[1], version)
+		}
+		select {
+		case <-time.After(installBackoff[attempt]):
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
-		time.Sleep(backoff[attempt])
 	}
 
 	return nil, lastErr
@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/deploy"
+)
+
+// appDeploy is one app of a deploy --all: what it is, and, once deployed,
+// how it went.
+type appDeploy struct {
+	Path      string   `json:"path"`
+	AppID     string   `json:"app_id"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Version   string   `json:"version,omitempty"`
+	// Status is installed, deployed (with --no-install), failed, or skipped
+	// when an app it depends on was not installed.
+	Status string         `json:"status"`
+	Phase  string         `json:"phase,omitempty"` // the phase that failed
+	Error  string         `json:"error,omitempty"`
+	Files  map[string]int `json:"files,omitempty"`
+
+	// done is closed once the app is installed or has failed, for the apps
+	// depending on it to install after it.
+	done chan struct{}
+}
+
+func (a *appDeploy) fail(phase string, err error) {
+	a.Status, a.Phase, a.Error = "failed", phase, err.Error()
+}
+
+// runDeployAll deploys the apps under apps/, or those --apps names, to
+// --env: it authenticates and connects once, deploys the apps concurrently
+// over that connection, each on a channel of its own, and installs each
+// only once the apps it depends on are installed. A summary of every app
+// ends it, and it fails when any app was not deployed and installed.
+func runDeployAll(ctx context.Context) (err error) {
+	start := time.Now()
+	if deployEnv == "" {
+		return envRequiredError()
+	}
+
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+	if err != nil {
+		return fmt.Errorf("failed to load simple.scl: %w", err)
+	}
+	env, err := cfg.GetEnv(deployEnv)
+	if err != nil {
+		return err
+	}
+	apps, err := workspaceApps(splitList(deployApps))
+	if err != nil {
+		return err
+	}
+	if err := checkDependencies(apps); err != nil {
+		return err
+	}
+
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	defer func() { err = cancelled(ctx, err) }()
+
+	conn, err := connectEnv(ctx, cfg, deployEnv, deployTimeouts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if !jsonOutput {
+		fmt.Printf("🚢 Deploying %d apps to %s\n", len(apps), deployEnv)
+	}
+	byID := map[string]*appDeploy{}
+	for _, app := range apps {
+		byID[app.AppID] = app
+	}
+	builder := new(deployBuilder)
+	var wg sync.WaitGroup
+	for _, app := range apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(app.done)
+			deployApp(ctx, conn, builder, cfg, env, app, byID)
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	failed := 0
+	for _, app := range apps {
+		if app.Status == "failed" || app.Status == "skipped" {
+			failed++
+		}
+	}
+	duration := time.Since(start)
+
+	if jsonOutput {
+		status := "success"
+		if failed > 0 {
+			status = "error"
+		}
+		if err := printJSON(map[string]interface{}{
+			"status":      status,
+			"env":         deployEnv,
+			"apps":        apps,
+			"duration_ms": duration.Milliseconds(),
+		}); err != nil {
+			return err
+		}
+	} else {
+		printDeploySummary(apps, duration)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d apps were not deployed to %s", failed, len(apps), deployEnv)
+	}
+	return nil
+}
+
+// deployApp deploys one app of a deploy --all on a channel of conn's
+// socket, recording the outcome in app.
+func deployApp(ctx context.Context, conn *deploy.Client, builder *deployBuilder, cfg *config.SimpleSCL, env *config.Environment, app *appDeploy, apps map[string]*appDeploy) {
+	if err := builder.ensureArtifacts(ctx, app.Path); err != nil {
+		app.fail("build", err)
+		return
+	}
+
+	// As in a deploy of one app, app.scl is bumped before it is hashed.
+	version, err := deploy.NewVersionManager().BumpVersion(app.Path, deployEnv, deployBump, cfg)
+	if err != nil {
+		app.fail("version", err)
+		return
+	}
+	app.Version = version
+	files, err := deploy.NewFileCollector().CollectFiles(app.Path)
+	if err != nil {
+		app.fail("files", err)
+		return
+	}
+
+	client := conn.Share(deploy.ClientConfig{
+		Timeouts:    clientTimeouts(cfg, deployTimeouts),
+		OnUpload:    appReporter(app.AppID, uploadLine),
+		OnReconnect: appReporter(app.AppID, reconnectLine),
+		OnInstall:   appReporter(app.AppID, installLine),
+	})
+	defer client.Close()
+	if err := client.JoinChannel(ctx, app.AppID); err != nil {
+		app.fail("connect", err)
+		return
+	}
+
+	needed, err := client.SendManifest(ctx, files, version)
+	if err != nil {
+		app.fail("manifest", err)
+		return
+	}
+	app.Files = map[string]int{"total": len(files), "new": len(needed), "cached": len(files) - len(needed)}
+	if !jsonOutput {
+		fmt.Printf("⬆️  %s@%s: uploading %d files (%d cached)\n", app.AppID, version, len(needed), len(files)-len(needed))
+	}
+	if err := client.SendFiles(ctx, files, needed); err != nil {
+		app.fail("upload", err)
+		return
+	}
+	if _, err := client.Deploy(ctx); err != nil {
+		app.fail("deploy", err)
+		return
+	}
+	app.Status = "deployed"
+	if deployNoInstall {
+		return
+	}
+
+	for _, dep := range app.DependsOn {
+		other, ok := apps[dep]
+		if !ok {
+			continue // not deployed now, so installed already or not at all
+		}
+		select {
+		case <-other.done:
+		case <-ctx.Done():
+			app.fail("install", ctx.Err())
+			return
+		}
+		if other.Status != "installed" {
+			app.Status, app.Phase, app.Error = "skipped", "install", fmt.Sprintf("%s was not installed", dep)
+			return
+		}
+	}
+
+	if !jsonOutput {
+		fmt.Printf("🚀 %s: installing %s\n", app.AppID, version)
+	}
+	if _, err := installVersion(ctx, client, version, false, jsonOutput); err != nil {
+		app.fail("install", err)
+		return
+	}
+	app.Status = "installed"
+}
+
+// workspaceApps reads the apps under apps/, in name order, or only those
+// named, each by its ID or its directory.
+func workspaceApps(names []string) ([]*appDeploy, error) {
+	entries, err := os.ReadDir("apps")
+	if err != nil {
+		return nil, fmt.Errorf("apps directory not found. Are you in a Simple Platform monorepo root?")
+	}
+
+	vm := deploy.NewVersionManager()
+	var apps []*appDeploy
+	found := map[string]bool{}
+	for _, entry := range entries {
+		dir := filepath.Join("apps", entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, "app.scl")); !entry.IsDir() || err != nil {
+			continue
+		}
+		app, err := vm.ParseAppSCL(dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		if len(names) > 0 && !slices.Contains(names, app.ID) && !slices.Contains(names, entry.Name()) {
+			continue
+		}
+		found[app.ID], found[entry.Name()] = true, true
+		apps = append(apps, &appDeploy{
+			Path:      dir,
+			AppID:     app.ID,
+			DependsOn: app.DependsOn,
+			done:      make(chan struct{}),
+		})
+	}
+
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("no app %s under apps/", name)
+		}
+	}
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("no apps found under apps/")
+	}
+	return apps, nil
+}
+
+// checkDependencies reports apps that depend on each other in a cycle,
+// which no order of installs satisfies. Dependencies on apps not being
+// deployed are left to the server.
+func checkDependencies(apps []*appDeploy) error {
+	byID := map[string]*appDeploy{}
+	for _, app := range apps {
+		byID[app.AppID] = app
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			cycle := append(path[slices.Index(path, id):], id)
+			return fmt.Errorf("apps depend on each other in a cycle: %s", strings.Join(cycle, " → "))
+		case visited:
+			return nil
+		}
+		state[id] = visiting
+		path = append(path, id)
+		for _, dep := range byID[id].DependsOn {
+			if _, ok := byID[dep]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = visited
+		return nil
+	}
+	for _, app := range apps {
+		if err := visit(app.AppID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// appReporter returns a reporter printing the events of one app of a
+// deploy --all, each line naming the app, as line describes them.
+func appReporter[E any](appID string, line func(E) string) func(E) {
+	if jsonOutput {
+		return nil
+	}
+	return func(e E) {
+		if l := line(e); l != "" {
+			fmt.Printf("   [%s] %s\n", appID, l)
+		}
+	}
+}
+
+// installLine describes an install_progress or install_log event.
+func installLine(e deploy.InstallEvent) string {
+	switch {
+	case e.Step != "":
+		return "▸ " + installStep(e)
+	case e.Level == "warning":
+		return "⚠️  " + e.Message
+	case e.Level == "error":
+		return "❌ " + e.Message
+	}
+	return "│ " + e.Message
+}
+
+func printDeploySummary(apps []*appDeploy, duration time.Duration) {
+	fmt.Printf("\nDeployed to %s in %s:\n", deployEnv, duration.Round(time.Millisecond))
+	fmt.Printf("  %-28s %-20s %s\n", "APP", "VERSION", "RESULT")
+	for _, app := range apps {
+		var result string
+		switch app.Status {
+		case "installed":
+			result = "✅ installed"
+		case "deployed":
+			result = "✅ deployed"
+		case "skipped":
+			result = "⏭️  not installed: " + app.Error
+		default:
+			result = fmt.Sprintf("❌ %s failed: %s", app.Phase, app.Error)
+		}
+		version := app.Version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Printf("  %-28s %-20s %s\n", app.AppID, version, result)
+	}
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// resetDeployAll clears the flags of deploy --all, whose group with --plan
+// and --dry-run cobra checks by the flags changed.
+func resetDeployAll() {
+	deployEnv, deployBump, deployAll, deployApps = "", "", false, ""
+	deployCmd.Flags().Lookup("all").Changed = false
+	deployCmd.Flags().Lookup("apps").Changed = false
+}
+
+// writeApp writes an app under apps/ with the app.scl given.
+func writeApp(t *testing.T, dir, appSCL string) {
+	t.Helper()
+	appDir := filepath.Join("apps", dir)
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(appDir, "app.scl"), []byte(appSCL), 0644)
+	_ = os.WriteFile(filepath.Join(appDir, "tables.scl"), []byte("table users {}"), 0644)
+}
+
+func TestDeployAll_InstallsDependenciesFirst(t *testing.T) {
+	srv := devServerWorkspace(t)
+	t.Cleanup(resetDeployAll)
+
+	// The dependent app sorts first, and would install first if it did not
+	// wait for core.
+	srv.InstallDelay = 50 * time.Millisecond
+	var mu sync.Mutex
+	var installs []string
+	srv.Logf = func(format string, args ...any) {
+		if line := fmt.Sprintf(format, args...); strings.Contains(line, "install") {
+			mu.Lock()
+			installs = append(installs, line)
+			mu.Unlock()
+		}
+	}
+	writeApp(t, "com.example.crm", "id com.example.crm\nversion 1.0.0\ndepends_on com.example.core\n")
+	writeApp(t, "com.example.core", "id com.example.core\nversion 1.0.0\n")
+	writeApp(t, "com.example.wiki", "id com.example.wiki\nversion 1.0.0\n")
+
+	out, _, err := invokeCmd("deploy", "--all", "--apps", "com.example.crm,com.example.core", "--env", "local", "--bump", "minor")
+	if err != nil {
+		t.Fatalf("deploy --all failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"🚢 Deploying 2 apps to local",
+		"[com.example.crm] ▸ verify: checking 2 files",
+		"com.example.core             1.1.0-local.1        ✅ installed",
+		"com.example.crm              1.1.0-local.1        ✅ installed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "com.example.wiki") {
+		t.Errorf("deployed an app --apps left out:\n%s", out)
+	}
+	want := []string{
+		"com.example.core: installing 1.1.0-local.1",
+		"com.example.core: installed 1.1.0-local.1",
+		"com.example.crm: installing 1.1.0-local.1",
+		"com.example.crm: installed 1.1.0-local.1",
+	}
+	if !slices.Equal(installs, want) {
+		t.Errorf("installs = %q, want %q", installs, want)
+	}
+}
+
+func TestDeployAll_SkipsDependentsOfFailedApps(t *testing.T) {
+	devServerWorkspace(t)
+	t.Cleanup(resetDeployAll)
+
+	// Without --bump, a release version cannot be deployed to local, so
+	// core fails while the others carry on from their prereleases.
+	writeApp(t, "core", "id com.example.core\nversion 1.0.0\n")
+	writeApp(t, "crm", "id com.example.crm\nversion 1.0.0-local.1\ndepends_on com.example.core\n")
+	writeApp(t, "wiki", "id com.example.wiki\nversion 1.0.0-local.1\n")
+
+	out, _, err := invokeCmd("deploy", "--all", "--env", "local", "--json")
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 apps were not deployed to local") {
+		t.Fatalf("deploy --all error = %v, want 2 of 3 apps failing\n%s", err, out)
+	}
+	var resp struct {
+		Status string `json:"status"`
+		Apps   []struct {
+			AppID  string `json:"app_id"`
+			Status string `json:"status"`
+			Phase  string `json:"phase"`
+			Error  string `json:"error"`
+		} `json:"apps"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	got := map[string]string{}
+	for _, app := range resp.Apps {
+		got[app.AppID] = app.Status + " " + app.Phase + ": " + app.Error
+	}
+	if resp.Status != "error" || got["com.example.wiki"] != "installed : " ||
+		got["com.example.crm"] != "skipped install: com.example.core was not installed" ||
+		!strings.HasPrefix(got["com.example.core"], "failed version: --bump required") {
+		t.Errorf("status %s, apps %q", resp.Status, got)
+	}
+}
+
+func TestCheckDependencies(t *testing.T) {
+	apps := []*appDeploy{
+		{AppID: "a", DependsOn: []string{"b", "elsewhere"}},
+		{AppID: "b", DependsOn: []string{"c"}},
+		{AppID: "c"},
+	}
+	if err := checkDependencies(apps); err != nil {
+		t.Errorf("checkDependencies() error = %v", err)
+	}
+
+	apps[2].DependsOn = []string{"a"}
+	err := checkDependencies(apps)
+	if err == nil || err.Error() != "apps depend on each other in a cycle: a → b → c → a" {
+		t.Errorf("checkDependencies() error = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"simple-cli/internal/build"
+)
+
+// deployBuilder builds the stale actions and spaces of the apps a deploy
+// ships, with one build manager, whose tools are set up once, for them all.
+type deployBuilder struct {
+	once    sync.Once
+	manager *build.BuildManager
+}
+
+// ensureArtifacts checks that the actions and spaces of the app at appPath
+// were built from their current sources before any of it is deployed. Stale
+// ones fail the deploy, naming each and why; with --build, they are built
+// first and checked again.
+func (b *deployBuilder) ensureArtifacts(ctx context.Context, appPath string) error {
+	stale, err := build.CheckArtifacts(appPath)
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	if !deployBuild {
+		return staleError(appPath, stale, "build them with simple build, or deploy with --build")
+	}
+
+	if !jsonOutput {
+		fmt.Printf("🔨 Building %d stale targets of %s\n", len(stale), appPath)
+	}
+	b.once.Do(func() {
+		b.manager = build.NewBuildManager(build.BuildOptions{JSONOutput: jsonOutput})
+	})
+	if err := b.manager.EnsureTools(nil); err != nil {
+		return fmt.Errorf("failed to ensure tools: %w", err)
+	}
+
+	var actions []string
+	var failures []string
+	for _, s := range stale {
+		// Tools like esbuild need the absolute path, as in simple build.
+		dir, err := filepath.Abs(s.Dir)
+		if err != nil {
+			return err
+		}
+		if !s.Space {
+			actions = append(actions, dir)
+			continue
+		}
+		if res := b.manager.BuildSpace(ctx, dir, nil); res.Error != nil {
+			failures = append(failures, fmt.Sprintf("  %s: %v", targetName(appPath, s.Dir), res.Error))
+		}
+	}
+	for i, res := range b.manager.BuildActions(ctx, actions, nil) {
+		if res.Error != nil {
+			failures = append(failures, fmt.Sprintf("  %s: %v", targetName(appPath, actions[i]), res.Error))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("building the stale targets of %s failed:\n%s", appPath, strings.Join(failures, "\n"))
+	}
+
+	if stale, err = build.CheckArtifacts(appPath); err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		return staleError(appPath, stale, "they are still stale after building them")
+	}
+	return nil
+}
+
+// staleError lists the stale targets of the app at appPath, with advice.
+func staleError(appPath string, stale []build.Stale, advice string) error {
+	lines := make([]string, len(stale))
+	for i, s := range stale {
+		lines[i] = fmt.Sprintf("  %s: %s", targetName(appPath, s.Dir), s.Reason)
+	}
+	return fmt.Errorf("%s has stale build targets; %s:\n%s", appPath, advice, strings.Join(lines, "\n"))
+}
+
+// targetName is a target's directory relative to its app, like
+// actions/send_email.
+func targetName(appPath, dir string) string {
+	absApp, err1 := filepath.Abs(appPath)
+	absDir, err2 := filepath.Abs(dir)
+	if err1 != nil || err2 != nil {
+		return dir
+	}
+	if rel, err := filepath.Rel(absApp, absDir); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return dir
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"simple-cli/internal/build"
+)
+
+// writeFile writes content to path, creating its directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeploy_RefusesStaleArtifacts(t *testing.T) {
+	devServerWorkspace(t)
+	t.Cleanup(func() { deployEnv, deployBump = "", "" })
+
+	writeApp(t, "myapp", "id myapp\nversion 1.0.0\n")
+	writeFile(t, filepath.Join("apps", "myapp", "actions", "send", "src", "index.ts"), "export default {}")
+
+	out, _, err := invokeCmd("deploy", "apps/myapp", "--env", "local", "--bump", "minor")
+	if err == nil || !strings.Contains(err.Error(), "apps/myapp has stale build targets; build them with simple build, or deploy with --build:\n  actions/send: build/release.wasm is missing") {
+		t.Fatalf("deploy error = %v\n%s", err, out)
+	}
+	if data, _ := os.ReadFile(filepath.Join("apps", "myapp", "app.scl")); !strings.Contains(string(data), "version 1.0.0") {
+		t.Errorf("app.scl bumped by a refused deploy:\n%s", data)
+	}
+}
+
+func TestDeploy_BuildsStaleSpaces(t *testing.T) {
+	devServerWorkspace(t)
+	t.Cleanup(func() { deployEnv, deployBump, deployBuild = "", "", false })
+
+	origJavy, origWasmOpt, origDeps, origExec := build.EnsureJavyFunc, build.EnsureWasmOptFunc, build.EnsureDependenciesFunc, build.ExecCommandFunc
+	t.Cleanup(func() {
+		build.EnsureJavyFunc, build.EnsureWasmOptFunc, build.EnsureDependenciesFunc, build.ExecCommandFunc = origJavy, origWasmOpt, origDeps, origExec
+	})
+	build.EnsureJavyFunc = func(func(string)) (string, error) { return "javy", nil }
+	build.EnsureWasmOptFunc = func(func(string)) (string, error) { return "wasm-opt", nil }
+	build.EnsureDependenciesFunc = func(string) error { return nil }
+	build.ExecCommandFunc = func(string, ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", "mkdir -p dist && echo '<html>' > dist/index.html")
+	}
+
+	writeApp(t, "myapp", "id myapp\nversion 1.0.0\n")
+	writeFile(t, filepath.Join("apps", "myapp", "spaces", "board", "package.json"), "{}")
+	writeFile(t, filepath.Join("apps", "myapp", "spaces", "board", "index.html"), "<html>")
+
+	out, _, err := invokeCmd("deploy", "apps/myapp", "--env", "local", "--bump", "minor", "--build", "--json")
+	if err != nil {
+		t.Fatalf("deploy --build failed: %v\n%s", err, out)
+	}
+	var resp struct {
+		Status string         `json:"status"`
+		Files  map[string]int `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	// app.scl, tables.scl and the space's index.html; not the stamp.
+	if resp.Status != "success" || resp.Files["total"] != 3 {
+		t.Errorf("deploy --build = %+v", resp)
+	}
+	if _, err := os.Stat(filepath.Join("apps", "myapp", "spaces", "board", "dist", build.SourcesStamp)); err != nil {
+		t.Errorf("space built without a stamp: %v", err)
+	}
+}
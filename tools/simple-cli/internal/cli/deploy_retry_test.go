@@ -1,6 +1,16 @@
 package cli
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/deploy"
+)
 
 func TestAlreadyInstalledRe(t *testing.T) {
 	msg := "Version `1.3.0-dev.21` of application `com.bnv.employee_hub` is already installed"
@@ -12,3 +22,101 @@ func TestAlreadyInstalledRe(t *testing.T) {
 		t.Fatal("matched an unrelated error")
 	}
 }
+
+// fakeInstaller replies to installs with the errors in replies, in order,
+// then succeeds.
+type fakeInstaller struct {
+	replies []error
+	pinned  []string
+	calls   int
+}
+
+func (f *fakeInstaller) reply(version string) (*deploy.InstallResult, error) {
+	f.calls++
+	if len(f.replies) > 0 {
+		err := f.replies[0]
+		f.replies = f.replies[1:]
+		return nil, err
+	}
+	return &deploy.InstallResult{Version: version, Success: true}, nil
+}
+
+func (f *fakeInstaller) Install(context.Context) (*deploy.InstallResult, error) {
+	return f.reply("")
+}
+
+func (f *fakeInstaller) InstallVersion(_ context.Context, version string) (*deploy.InstallResult, error) {
+	f.pinned = append(f.pinned, version)
+	return f.reply(version)
+}
+
+func TestInstallVersion(t *testing.T) {
+	old := installBackoff
+	installBackoff = []time.Duration{0, 0, 0}
+	t.Cleanup(func() { installBackoff = old })
+
+	installed := func(v string) error {
+		return fmt.Errorf("Version `%s` of application `com.example.crm` is already installed", v)
+	}
+
+	tests := []struct {
+		name      string
+		pin       bool
+		replies   []error
+		wantCalls int
+		wantErr   string
+	}{
+		{name: "installs", pin: true, wantCalls: 1},
+		{name: "pinned version already installed", pin: true, replies: []error{installed("1.4.2")}, wantCalls: 1},
+		{name: "deployed version already installed", replies: []error{installed("1.4.2")}, wantCalls: 1},
+		{name: "stale resolution is retried", replies: []error{installed("1.4.1"), installed("1.4.1")}, wantCalls: 3},
+		{name: "stale resolution gives up", pin: true, replies: []error{installed("1.4.1"), installed("1.4.1"), installed("1.4.1"), installed("1.4.1")}, wantCalls: 4, wantErr: "Version `1.4.1`"},
+		{name: "other errors are not retried", pin: true, replies: []error{errors.New("install blocked")}, wantCalls: 1, wantErr: "install blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeInstaller{replies: tt.replies}
+			result, err := installVersion(t.Context(), f, "1.4.2", tt.pin, true)
+			if f.calls != tt.wantCalls {
+				t.Errorf("installVersion() made %d calls, want %d", f.calls, tt.wantCalls)
+			}
+			if tt.pin != (len(f.pinned) > 0) {
+				t.Errorf("installVersion() pinned versions %v with pin %v", f.pinned, tt.pin)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("installVersion() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !result.Success {
+				t.Errorf("installVersion() = %+v, %v", result, err)
+			}
+		})
+	}
+}
+
+func TestInstallVersion_CancelledWhileWaiting(t *testing.T) {
+	old := installBackoff
+	installBackoff = []time.Duration{time.Hour}
+	t.Cleanup(func() { installBackoff = old })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	f := &fakeInstaller{replies: []error{fmt.Errorf("Version `1.4.1` of application `com.example.crm` is already installed")}}
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := installVersion(ctx, f, "1.4.2", false, true); !errors.Is(err, context.Canceled) {
+		t.Errorf("installVersion() error = %v, want it cancelled", err)
+	}
+	if f.calls != 1 {
+		t.Errorf("installVersion() made %d calls, want 1", f.calls)
+	}
+}
+
+func TestClientTimeouts(t *testing.T) {
+	cfg := &config.SimpleSCL{Timeouts: config.Timeouts{Manifest: time.Minute, Install: time.Hour}}
+	got := clientTimeouts(cfg, deploy.Timeouts{Connect: 5 * time.Second, Install: 20 * time.Minute})
+	want := deploy.Timeouts{Connect: 5 * time.Second, Manifest: time.Minute, Install: 20 * time.Minute}
+	if got != want {
+		t.Errorf("clientTimeouts() = %+v, want %+v", got, want)
+	}
+}
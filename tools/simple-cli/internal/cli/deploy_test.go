@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -64,7 +66,7 @@ func TestRunDeploy(t *testing.T) {
 				_ = os.WriteFile(filepath.Join(appDir, "app.scl"), []byte("id test\nversion 1.0.0"), 0644)
 			},
 			wantErr:     true,
-			errContains: "simple.scl", // Now fails at simple.scl loading since scl-parser is available
+			errContains: "simple.scl", // Fails at simple.scl loading
 		},
 	}
 
@@ -217,3 +219,114 @@ func TestRunDeploy_AuthRetry(t *testing.T) {
 		t.Errorf("expected at least 2 auth attempts (retry on 401), got %d", authAttempts)
 	}
 }
+
+func TestDeployCmd_Plan(t *testing.T) {
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	// The installed app.scl is the one on disk: only the bump the deploy
+	// would make changes it.
+	const appSCL = "id myapp\nversion 1.0.0-dev.1\n"
+	remoteWorkspace(t, map[string]mockReplies{
+		"dev": {
+			"installed": {"version": "1.0.0-dev.1", "files": []map[string]any{
+				{"path": "app.scl", "hash": sha(appSCL), "size": len(appSCL)},
+				{"path": "tables.scl", "hash": sha("table users {}"), "size": 14},
+				{"path": "scripts/old.js", "hash": sha("old"), "size": 3},
+			}},
+			"manifest": {"need_files": []string{"app.scl", "records/seed.json"}},
+		},
+	})
+	t.Cleanup(func() { deployEnv, deployPlan = "", false })
+
+	appDir := filepath.Join("apps", "myapp")
+	_ = os.MkdirAll(filepath.Join(appDir, "records"), 0755)
+	_ = os.WriteFile(filepath.Join(appDir, "app.scl"), []byte(appSCL), 0644)
+	_ = os.WriteFile(filepath.Join(appDir, "tables.scl"), []byte("table users {}"), 0644)
+	_ = os.WriteFile(filepath.Join(appDir, "records", "seed.json"), []byte("[]"), 0644)
+
+	out, _, err := invokeCmd("deploy", "apps/myapp", "--env", "dev", "--plan")
+	if err != nil {
+		t.Fatalf("deploy --plan failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"Plan for myapp@1.0.0-dev.2 in dev (installed: 1.0.0-dev.1)",
+		"  + records/seed.json\n",
+		"  ~ app.scl\n",
+		"  - scripts/old.js\n",
+		"1 new, 1 changed, 1 unchanged, 1 removed; 2 files to upload",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(appDir, "app.scl")); string(data) != appSCL {
+		t.Errorf("deploy --plan changed app.scl to %q", data)
+	}
+
+	out, _, err = invokeCmd("deploy", "apps/myapp", "--env", "dev", "--plan", "--json")
+	if err != nil {
+		t.Fatalf("deploy --plan --json failed: %v\n%s", err, out)
+	}
+	var resp struct {
+		Version   string      `json:"version"`
+		Installed string      `json:"installed"`
+		Files     deploy.Plan `json:"files"`
+		Upload    int         `json:"upload"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if resp.Version != "1.0.0-dev.2" || resp.Installed != "1.0.0-dev.1" || resp.Upload != 2 || len(resp.Files.Unchanged) != 1 || resp.Files.Removed[0] != "scripts/old.js" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestDeploy_DryRunReportsExclusions(t *testing.T) {
+	devServerWorkspace(t)
+	resetDryRun := func() {
+		deployEnv, deployBump, deployDryRun, deployPlan = "", "", false, false
+		deployCmd.Flags().Lookup("dry-run").Changed = false
+		deployCmd.Flags().Lookup("plan").Changed = false
+	}
+	resetDryRun()
+	t.Cleanup(resetDryRun)
+
+	writeApp(t, "myapp", "id myapp\nversion 1.0.0\n")
+	writeFile(t, deploy.IgnoreFile, "*.psd\n")
+	writeFile(t, filepath.Join("apps", "myapp", deploy.IgnoreFile), "fixtures/\n")
+	writeFile(t, filepath.Join("apps", "myapp", "scripts", "main.js"), "main()")
+	writeFile(t, filepath.Join("apps", "myapp", "scripts", "fixtures", "users.json"), "[]")
+	writeFile(t, filepath.Join("apps", "myapp", "assets", "logo.psd"), "psd")
+
+	out, _, err := invokeCmd("deploy", "apps/myapp", "--env", "local", "--bump", "minor", "--dry-run")
+	if err != nil {
+		t.Fatalf("deploy --dry-run failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"Excluded:", "scripts/fixtures/ (fixtures/, ", "assets/logo.psd (*.psd, "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	out, _, err = invokeCmd("deploy", "apps/myapp", "--env", "local", "--bump", "minor", "--dry-run", "--json")
+	if err != nil {
+		t.Fatalf("deploy --dry-run --json failed: %v\n%s", err, out)
+	}
+	var resp struct {
+		Files    []map[string]any   `json:"files"`
+		Excluded []deploy.Exclusion `json:"excluded"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(resp.Files) != 3 || len(resp.Excluded) != 2 {
+		t.Fatalf("files = %v, excluded = %+v", resp.Files, resp.Excluded)
+	}
+	for _, e := range resp.Excluded {
+		if e.Path == "assets/logo.psd" && e.Source != deploy.IgnoreFile+":1" {
+			t.Errorf("assets/logo.psd excluded by %s, want the workspace %s", e.Source, deploy.IgnoreFile)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"simple-cli/internal/devserver"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	devServerAddr         string
+	devServerData         string
+	devServerInstallDelay time.Duration
+)
+
+// devServerCmd runs a local stand-in for a tenant's services.
+var devServerCmd = &cobra.Command{
+	Use:   "dev-server",
+	Short: "Run a local stand-in for a tenant's Identity and DevOps services",
+	Long: `Serve the protocol deploy, install and the other remote commands speak,
+on one local address, so that deploy scripts can be tested without a tenant:
+API key enrollment, proof-of-possession login and the deploy channel with its
+manifest, file uploads, deploy and install.
+
+Point an environment of simple.scl at it with an endpoint that has a scheme:
+
+  env local {
+    endpoint http://127.0.0.1:4080
+    api_key  <the key the server prints>
+  }
+
+Any API key in the format of a real one is accepted. Uploaded files, deployed
+versions and the installed version are kept under --data and survive a
+restart. --install-delay makes each install take that long, to exercise
+timeouts and progress output. Stop the server with Ctrl-C.
+
+Examples:
+  simple dev-server
+  simple dev-server --addr 127.0.0.1:0 --data /tmp/simple-dev --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runDevServer(cmd.Context())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(devServerCmd)
+	devServerCmd.Flags().StringVar(&devServerAddr, "addr", "127.0.0.1:4080", "address to listen on (port 0 picks a free one)")
+	devServerCmd.Flags().StringVar(&devServerData, "data", ".simple/dev-server", "directory the server keeps uploads, versions and keys in")
+	devServerCmd.Flags().DurationVar(&devServerInstallDelay, "install-delay", 0, "how long each install takes")
+}
+
+// runDevServer serves until ctx ends or the process is interrupted. With
+// --json, the endpoint is printed as JSON once the server listens,
+// so that a script starting it can read where it is.
+func runDevServer(ctx context.Context) error {
+	srv, err := devserver.New(devServerData)
+	if err != nil {
+		return err
+	}
+	srv.InstallDelay = devServerInstallDelay
+	if !jsonOutput {
+		srv.Logf = func(format string, args ...any) {
+			fmt.Printf("   %s  %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+		}
+	}
+
+	ln, err := net.Listen("tcp", devServerAddr)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", devServerAddr, err)
+	}
+	endpoint := "http://" + ln.Addr().String()
+
+	if jsonOutput {
+		if err := printJSON(map[string]any{
+			"status":   "listening",
+			"endpoint": endpoint,
+			"api_key":  devserver.APIKey,
+			"data":     devServerData,
+		}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("🧪 Dev server listening on %s (data in %s)\n", endpoint, devServerData)
+		fmt.Printf("   Point an environment of simple.scl at it:\n\n")
+		fmt.Printf("     env local {\n       endpoint %s\n       api_key  %s\n     }\n\n", endpoint, devserver.APIKey)
+	}
+
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	hs := &http.Server{Handler: srv}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = hs.Shutdown(shutdownCtx)
+	}()
+
+	if err := hs.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	if !jsonOutput {
+		fmt.Println("👋 Dev server stopped")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"simple-cli/internal/devserver"
+)
+
+// devServerWorkspace is a workspace whose local environment is a dev server,
+// which it returns.
+func devServerWorkspace(t *testing.T) *devserver.Server {
+	t.Helper()
+	srv, err := devserver.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		srv.Close()
+		hs.Close()
+	})
+	configWorkspace(t, fmt.Sprintf("tenant acme\nenv local {\n  endpoint %s\n  api_key %s\n}\n", hs.URL, devserver.APIKey))
+	t.Setenv("HOME", t.TempDir())
+	return srv
+}
+
+func TestDevServer_DeployAndRollback(t *testing.T) {
+	devServerWorkspace(t)
+	t.Cleanup(func() { deployEnv, deployBump, rollbackEnv = "", "", "" })
+
+	appDir := filepath.Join("apps", "myapp")
+	_ = os.MkdirAll(appDir, 0755)
+	_ = os.WriteFile(filepath.Join(appDir, "app.scl"), []byte("id myapp\nversion 1.0.0\n"), 0644)
+	_ = os.WriteFile(filepath.Join(appDir, "tables.scl"), []byte("table users {}"), 0644)
+
+	out, _, err := invokeCmd("deploy", "apps/myapp", "--env", "local", "--bump", "minor")
+	if err != nil {
+		t.Fatalf("deploy failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"Uploading 2 files (0 cached)", "▸ verify: checking 2 files", "✅ Deployed myapp@1.1.0-local.1 (Installed)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("first deploy output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Only app.scl, bumped again, is uploaded the second time.
+	deployBump = ""
+	out, _, err = invokeCmd("deploy", "apps/myapp", "--env", "local")
+	if err != nil {
+		t.Fatalf("second deploy failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Uploading 1 files (1 cached)") || !strings.Contains(out, "myapp@1.1.0-local.2 (Installed)") {
+		t.Errorf("second deploy output:\n%s", out)
+	}
+
+	out, _, err = invokeCmd("rollback", "myapp", "--env", "local")
+	if err != nil {
+		t.Fatalf("rollback failed: %v\n%s", err, out)
+	}
+	out, _, err = invokeCmd("versions", "myapp", "--env", "local", "--json")
+	if err != nil {
+		t.Fatalf("versions failed: %v\n%s", err, out)
+	}
+	var resp struct {
+		Versions []struct {
+			Version   string `json:"version"`
+			Installed bool   `json:"installed"`
+		} `json:"versions"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(resp.Versions) != 2 || resp.Versions[0].Version != "1.1.0-local.2" || !resp.Versions[1].Installed {
+		t.Errorf("versions after rollback = %+v", resp.Versions)
+	}
+}
@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"simple-cli/internal/fsx"
+	"simple-cli/internal/lint"
+	"simple-cli/internal/scaffold"
+	"simple-cli/internal/scl"
+
+	"github.com/spf13/cobra"
+)
+
+// fmtCmd rewrites SCL files in canonical layout.
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [app-id | file.scl ...]",
+	Short: "Format SCL files",
+	Long: `Rewrite SCL files in canonical layout: two-space indentation, the values of
+consecutive key-values lined up, at most one blank line in a row and one around
+every top-level block. Comments and triple-backtick strings are kept as written.
+
+With no arguments it formats simple.scl and, for every app, app.scl, tables.scl
+and each file in records/. An app id, an app directory or an .scl file limits
+it to those.
+
+--check writes nothing and fails when a file is not formatted, for CI.
+--diff writes nothing and prints what formatting would change.
+
+Examples:
+  simple fmt                         # Format the whole workspace
+  simple fmt com.mycompany.crm       # Format one app
+  simple fmt --check                 # Fail if anything is unformatted
+  simple fmt --diff                  # Show what would change`,
+	RunE: runFmt,
+}
+
+func init() {
+	RootCmd.AddCommand(fmtCmd)
+	fmtCmd.Flags().Bool("check", false, "Report unformatted files and fail instead of rewriting them")
+	fmtCmd.Flags().Bool("diff", false, "Print the changes formatting would make instead of rewriting files")
+}
+
+func runFmt(cmd *cobra.Command, args []string) error {
+	check, _ := cmd.Flags().GetBool("check")
+	diff, _ := cmd.Flags().GetBool("diff")
+
+	fsys := fsx.OSFileSystem{}
+	files, err := fmtTargets(fsys, args)
+	if err != nil {
+		return err
+	}
+
+	var changed, failed []string
+	for _, path := range files {
+		src, err := fsys.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		out, err := scl.Format(src)
+		if err != nil {
+			if serr, ok := err.(*scl.Error); ok {
+				serr.Path = path
+			}
+			if !jsonOutput {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			failed = append(failed, err.Error())
+			continue
+		}
+		if bytes.Equal(src, out) {
+			continue
+		}
+		changed = append(changed, path)
+
+		switch {
+		case diff:
+			if !jsonOutput {
+				fmt.Print(unifiedDiff(path, src, out))
+			}
+		case check:
+			if !jsonOutput {
+				fmt.Println(path)
+			}
+		default:
+			if err := fsys.WriteFile(path, out, fsx.FilePerm); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			if !jsonOutput {
+				fmt.Printf("Formatted %s\n", path)
+			}
+		}
+	}
+
+	if jsonOutput {
+		key := "formatted"
+		if check || diff {
+			key = "unformatted"
+		}
+		if err := printJSON(map[string]interface{}{
+			"status":  fmtStatus(changed, failed, check),
+			"checked": len(files),
+			key:       nonNil(changed),
+			"errors":  nonNil(failed),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d SCL files could not be parsed", len(failed), len(files))
+	}
+	if check && len(changed) > 0 {
+		return fmt.Errorf("%d of %d SCL files are not formatted; run 'simple fmt' to fix them", len(changed), len(files))
+	}
+	if !jsonOutput && len(changed) == 0 {
+		fmt.Printf("✅ %d SCL files already formatted\n", len(files))
+	}
+	return nil
+}
+
+func fmtStatus(changed, failed []string, check bool) string {
+	if len(failed) > 0 || (check && len(changed) > 0) {
+		return "failure"
+	}
+	return "success"
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// fmtTargets resolves the command's arguments to the SCL files to format.
+// No arguments means the whole workspace.
+func fmtTargets(fsys fsx.FileSystem, args []string) ([]string, error) {
+	if len(args) == 0 {
+		if !scaffold.PathExists(fsys, "apps") && !scaffold.PathExists(fsys, "simple.scl") {
+			return nil, fmt.Errorf("apps directory not found. Are you in a Simple Platform monorepo root?")
+		}
+		return workspaceSCLFiles(fsys, ".")
+	}
+
+	var files []string
+	for _, arg := range args {
+		path := arg
+		if !scaffold.PathExists(fsys, path) {
+			path = filepath.Join("apps", arg)
+		}
+		info, err := fsys.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an SCL file, an app directory or an app id", arg)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		files = append(files, lint.AppFiles(fsys, path)...)
+	}
+	return files, nil
+}
+
+// workspaceSCLFiles lists simple.scl and the SCL files of every app under
+// root/apps.
+func workspaceSCLFiles(fsys fsx.FileSystem, root string) ([]string, error) {
+	var files []string
+	if p := filepath.Join(root, "simple.scl"); scaffold.PathExists(fsys, p) {
+		files = append(files, p)
+	}
+
+	appsDir := filepath.Join(root, "apps")
+	if !scaffold.PathExists(fsys, appsDir) {
+		return files, nil
+	}
+	entries, err := fsys.ReadDir(appsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read apps directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			files = append(files, lint.AppFiles(fsys, filepath.Join(appsDir, entry.Name()))...)
+		}
+	}
+	return files, nil
+}
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// unifiedDiff renders the change from a to b as a unified diff of path.
+func unifiedDiff(path string, a, b []byte) string {
+	x, y := diffLines(a), diffLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:]. SCL files are small enough for the quadratic table.
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		kind byte // ' ', '-' or '+'
+		text string
+		i, j int // lines of x and y before this one
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, op{' ', x[i], i, j})
+			i, j = i+1, j+1
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', x[i], i, j})
+			i++
+		default:
+			ops = append(ops, op{'+', y[j], i, j})
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", filepath.ToSlash(path), filepath.ToSlash(path))
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+
+		// A hunk runs from a few lines before this change to a few lines
+		// after the last change that is not further than that from the next.
+		start := max(0, k-diffContext)
+		end := k
+		for n := k; n < len(ops); n++ {
+			if ops[n].kind != ' ' {
+				end = n
+			} else if n-end > 2*diffContext {
+				break
+			}
+		}
+		end = min(len(ops), end+diffContext+1)
+
+		var body strings.Builder
+		oldLen, newLen := 0, 0
+		for _, o := range ops[start:end] {
+			body.WriteByte(o.kind)
+			body.WriteString(o.text)
+			body.WriteByte('\n')
+			if o.kind != '+' {
+				oldLen++
+			}
+			if o.kind != '-' {
+				newLen++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n%s", hunkRange(ops[start].i, oldLen), hunkRange(ops[start].j, newLen), body.String())
+		k = end
+	}
+	return sb.String()
+}
+
+// hunkRange renders one side of a hunk header. An empty side is numbered by
+// the line before it, as diff(1) does.
+func hunkRange(before, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, n)
+}
+
+func diffLines(b []byte) []string {
+	s := strings.TrimSuffix(string(b), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
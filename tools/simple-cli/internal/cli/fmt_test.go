@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	messyAppSCL     = "id com.acme.crm\nversion 0.0.1\ndisplay_name \"CRM\"\n"
+	formattedAppSCL = "id           com.acme.crm\nversion      0.0.1\ndisplay_name \"CRM\"\n"
+
+	messyRecords     = "set dev_simple_system.logic, send_email {\nname \"send_email\"\n}\n\n\n\nset dev_simple_system.logic, notify {\n  name \"notify\"\n}\n"
+	formattedRecords = "set dev_simple_system.logic, send_email {\n  name \"send_email\"\n}\n\nset dev_simple_system.logic, notify {\n  name \"notify\"\n}\n"
+
+	formattedSimpleSCL = "tenant acme\n\nenv dev {\n  api_key \"k\"\n}\n"
+)
+
+// fmtWorkspace lays out a workspace with one app whose app.scl and records
+// need formatting and a simple.scl that does not, and makes it the working
+// directory for the test.
+func fmtWorkspace(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"simple.scl":                         formattedSimpleSCL,
+		"apps/crm/app.scl":                   messyAppSCL,
+		"apps/crm/records/10_actions.scl":    messyRecords,
+		"apps/crm/actions/send-email/x.scl":  "not   formatted\n",
+		"apps/crm/records/notes.txt":         "not scl",
+		"apps/crm/tables.scl":                "",
+		"apps/crm/scripts/record-behaviors/": "",
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if strings.HasSuffix(rel, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldWd, _ := os.Getwd()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(oldWd)
+		_ = fmtCmd.Flags().Set("check", "false")
+		_ = fmtCmd.Flags().Set("diff", "false")
+	})
+	return root
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFmtCmd_FormatsWorkspace(t *testing.T) {
+	root := fmtWorkspace(t)
+
+	out, _, err := invokeCmd("fmt")
+	if err != nil {
+		t.Fatalf("fmt failed: %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(root, "apps", "crm", "app.scl")); got != formattedAppSCL {
+		t.Errorf("app.scl =\n%s\nwant\n%s", got, formattedAppSCL)
+	}
+	if got := readFile(t, filepath.Join(root, "apps", "crm", "records", "10_actions.scl")); got != formattedRecords {
+		t.Errorf("10_actions.scl =\n%s\nwant\n%s", got, formattedRecords)
+	}
+	if got := readFile(t, filepath.Join(root, "apps", "crm", "actions", "send-email", "x.scl")); got != "not   formatted\n" {
+		t.Errorf("a file outside the app's SCL files was rewritten: %q", got)
+	}
+	if strings.Contains(out, "simple.scl") {
+		t.Errorf("an already formatted file was reported: %s", out)
+	}
+	if !strings.Contains(out, filepath.Join("apps", "crm", "app.scl")) {
+		t.Errorf("expected the rewritten files to be reported, got: %s", out)
+	}
+
+	out, _, err = invokeCmd("fmt")
+	if err != nil {
+		t.Fatalf("second fmt failed: %v", err)
+	}
+	if !strings.Contains(out, "already formatted") {
+		t.Errorf("expected a second run to change nothing, got: %s", out)
+	}
+}
+
+func TestFmtCmd_Check(t *testing.T) {
+	root := fmtWorkspace(t)
+
+	out, _, err := invokeCmd("fmt", "--check")
+	if err == nil || !strings.Contains(err.Error(), "2 of 4 SCL files are not formatted") {
+		t.Fatalf("expected --check to fail naming the count, got %v", err)
+	}
+	for _, want := range []string{filepath.Join("apps", "crm", "app.scl"), filepath.Join("apps", "crm", "records", "10_actions.scl")} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected --check to list %s, got: %s", want, out)
+		}
+	}
+	if got := readFile(t, filepath.Join(root, "apps", "crm", "app.scl")); got != messyAppSCL {
+		t.Errorf("--check rewrote app.scl: %q", got)
+	}
+}
+
+func TestFmtCmd_Diff(t *testing.T) {
+	root := fmtWorkspace(t)
+
+	out, _, err := invokeCmd("fmt", "crm", "--diff")
+	if err != nil {
+		t.Fatalf("fmt --diff failed: %v", err)
+	}
+	for _, want := range []string{
+		"--- apps/crm/app.scl",
+		"-version 0.0.1",
+		"+version      0.0.1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("diff is missing %q:\n%s", want, out)
+		}
+	}
+	if got := readFile(t, filepath.Join(root, "apps", "crm", "app.scl")); got != messyAppSCL {
+		t.Errorf("--diff rewrote app.scl: %q", got)
+	}
+}
+
+func TestFmtCmd_SyntaxError(t *testing.T) {
+	root := fmtWorkspace(t)
+	if err := os.WriteFile(filepath.Join(root, "apps", "crm", "tables.scl"), []byte("table user {\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := invokeCmd("fmt")
+	if err == nil || !strings.Contains(err.Error(), "could not be parsed") {
+		t.Fatalf("expected a parse failure, got %v", err)
+	}
+	if !strings.Contains(stderr, filepath.Join("apps", "crm", "tables.scl")+":1:1: unterminated block") {
+		t.Errorf("expected the error to name the file and position, got: %s", stderr)
+	}
+	// The files that did parse are still formatted.
+	if got := readFile(t, filepath.Join(root, "apps", "crm", "app.scl")); got != formattedAppSCL {
+		t.Errorf("app.scl was not formatted alongside the broken file: %q", got)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n")
+	b := []byte("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n")
+
+	want := "--- x.scl\n+++ x.scl\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -11,3 +11,4 @@\n k\n l\n m\n+n\n"
+	if got := unifiedDiff("x.scl", a, b); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -2,11 +2,9 @@ package cli
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
-	"simple-cli/internal/build"
 	"simple-cli/internal/config"
 	"simple-cli/internal/deploy"
 
@@ -14,7 +12,8 @@ import (
 )
 
 var (
-	installEnv string
+	installEnv      string
+	installTimeouts deploy.Timeouts
 )
 
 // installCmd represents the command to install a deployed app.
@@ -27,7 +26,9 @@ var installCmd = &cobra.Command{
 
 This command triggers the installation process (database migrations, 
 service configuration, cache warming) for the latest deployed version 
-of the application in the target environment.
+of the application in the target environment. --install-timeout bounds
+how long it may take (15m unless simple.scl says otherwise); Ctrl-C leaves
+the deploy channel before exiting, while the install carries on server-side.
 
 Examples:
   simple install com.example.crm --env dev
@@ -41,104 +42,48 @@ Examples:
 
 func init() {
 	RootCmd.AddCommand(installCmd)
-	installCmd.Flags().StringVar(&installEnv, "env", "", "target environment (required: dev, staging, or prod)")
+	installCmd.Flags().StringVar(&installEnv, "env", "", "target environment from the pipeline in simple.scl (required)")
+	installCmd.Flags().DurationVar(&installTimeouts.Connect, "connect-timeout", 0, "how long connecting and joining may take (default 30s)")
+	installCmd.Flags().DurationVar(&installTimeouts.Install, "install-timeout", 0, "how long the install may take (default 15m)")
 	_ = installCmd.MarkFlagRequired("env")
 }
 
 // runInstall executes the installation logic.
 // It connects to the DevOps server and requests an install for the given app ID.
-func runInstall(ctx context.Context, appID string) error {
+func runInstall(ctx context.Context, appID string) (err error) {
 	start := time.Now()
 
 	// Validate --env flag is provided
 	if installEnv == "" {
-		return fmt.Errorf("--env flag is required (dev, staging, or prod)")
-	}
-
-	// Ensure scl-parser is available (for config loading)
-	// We need this to parse simple.scl to find the environment endpoints.
-	parserPath, err := build.EnsureSCLParser(nil)
-	if err != nil {
-		return fmt.Errorf("failed to ensure scl-parser: %w", err)
+		return envRequiredError()
 	}
 
 	// === PHASE 1: Config & Auth ===
-	// Load configuration to determine where to connect (DevOps endpoint) and how to authenticate.
-	var cfg *config.SimpleSCL
-	var env *config.Environment
-	var cfgErr, authErr error
-	var jwt string
-
-	// Load simple.scl config from current directory
-	// Note: We need simple.scl for endpoints and API keys
-	loader := config.NewLoader(parserPath)
-	cfg, cfgErr = loader.LoadSimpleSCL(".")
-	if cfgErr != nil {
-		return fmt.Errorf("failed to load simple.scl: %w", cfgErr)
-	}
-
-	env, cfgErr = cfg.GetEnv(installEnv)
-	if cfgErr != nil {
-		return cfgErr
+	// Load configuration to determine where to connect (DevOps endpoint) and
+	// how to authenticate.
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+	if err != nil {
+		return fmt.Errorf("failed to load simple.scl: %w", err)
 	}
-
-	// Get JWT (cached for token lifetime)
-	// Authentication is required to allow the CLI into the DevOps channel.
-	tenantEnvKey := deploy.TenantEnvKey(cfg.Tenant, installEnv)
-	auth := deploy.NewAuthenticator()
-	jwt, authErr = auth.GetJWT(ctx, env.IdentityEndpoint(), env.APIKey, tenantEnvKey)
-	if authErr != nil {
-		return fmt.Errorf("authentication failed: %w", authErr)
+	session, err := authenticateEnv(ctx, cfg, installEnv)
+	if err != nil {
+		return err
 	}
 
 	// === PHASE 2: Connect & Install ===
-	// Establish WebSocket connection to DevOps service.
-	client := deploy.NewClient(deploy.ClientConfig{
-		Endpoint: env.DevOpsEndpoint(),
-		JWT:      jwt,
-		Timeout:  15 * time.Minute,
-	})
-
-	if err := client.Connect(); err != nil {
-		var authErr *deploy.AuthFailedError
-		if errors.As(err, &authErr) { // 401/403
-			if !jsonOutput {
-				fmt.Println("🔄 Auth token expired, refreshing...")
-			}
-
-			// 1. Clear token cache to force fresh prompt/login if needed
-			if err := auth.ClearCache(tenantEnvKey); err != nil {
-				return fmt.Errorf("failed to clear token cache: %w", err)
-			}
-
-			// 2. Get new JWT (force refresh)
-			var newJWTErr error
-			jwt, newJWTErr = auth.GetJWT(ctx, env.IdentityEndpoint(), env.APIKey, tenantEnvKey)
-			if newJWTErr != nil {
-				return fmt.Errorf("re-authentication failed: %w", newJWTErr)
-			}
-
-			// 3. Re-create client with new JWT.
-			// Installs are synchronous server-side and routinely exceed 30s on
-			// record-heavy apps, so the reconnect path must carry the same
-			// timeout as the initial client above.
-			client = deploy.NewClient(deploy.ClientConfig{
-				Endpoint: env.DevOpsEndpoint(),
-				JWT:      jwt,
-				Timeout:  15 * time.Minute,
-			})
-
-			// 4. Retry connection once
-			if err := client.Connect(); err != nil {
-				return fmt.Errorf("connection failed after token refresh: %w", err)
-			}
-		} else {
-			return err
-		}
+	// Establish WebSocket connection to DevOps service. From here on Ctrl-C
+	// cancels the call in flight, and the deferred Close leaves the channel.
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	defer func() { err = cancelled(ctx, err) }()
+
+	client, err := session.connect(ctx, installTimeouts)
+	if err != nil {
+		return err
 	}
 	defer client.Close()
 
-	if err := client.JoinChannel(appID); err != nil {
+	if err := client.JoinChannel(ctx, appID); err != nil {
 		return err
 	}
 
@@ -147,7 +92,7 @@ func runInstall(ctx context.Context, appID string) error {
 	}
 
 	// Trigger remote install process via WebSocket
-	result, err := client.Install()
+	result, err := client.Install(ctx)
 	if err != nil {
 		return err
 	}
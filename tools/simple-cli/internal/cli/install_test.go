@@ -118,3 +118,19 @@ func TestRunInstall_AuthRetry(t *testing.T) {
 		t.Errorf("expected at least 2 WebSocket auth attempts (retry on 401), got %d", authAttempts)
 	}
 }
+
+func TestInstallStep(t *testing.T) {
+	tests := []struct {
+		event deploy.InstallEvent
+		want  string
+	}{
+		{deploy.InstallEvent{Step: "activate"}, "activate"},
+		{deploy.InstallEvent{Step: "activate", Message: "starting behaviors"}, "activate: starting behaviors"},
+		{deploy.InstallEvent{Step: "migrate orders", Current: 1200, Total: 5000}, "migrate orders (1200/5000 records)"},
+	}
+	for _, tt := range tests {
+		if got := installStep(tt.event); got != tt.want {
+			t.Errorf("installStep(%+v) = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
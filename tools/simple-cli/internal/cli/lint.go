@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"simple-cli/internal/fsx"
+	"simple-cli/internal/lint"
+	"simple-cli/internal/scaffold"
+
+	"github.com/spf13/cobra"
+)
+
+// lintCmd checks that each app's SCL is consistent before it reaches the server.
+var lintCmd = &cobra.Command{
+	Use:   "lint [app-id]",
+	Short: "Check an app's SCL for broken references",
+	Long: `Load an app's app.scl, tables.scl and records/*.scl and report, with file and
+line, what install would otherwise fail on:
+
+  - trigger links in 30_trigger_actions.scl that point at missing actions
+  - action records with no action directory, and action directories with no record
+  - database triggers on tables tables.scl does not declare
+  - $var(...) references with no var block before them in the same file
+  - the same set <type>, <key> defined twice
+  - execution_environment values other than server, client and both
+
+With no argument every app in the workspace is linted.
+
+Examples:
+  simple lint                        # Lint every app
+  simple lint com.mycompany.crm      # Lint one app`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLint,
+}
+
+func init() {
+	RootCmd.AddCommand(lintCmd)
+}
+
+func runLint(_ *cobra.Command, args []string) error {
+	fsys := fsx.OSFileSystem{}
+	if !scaffold.PathExists(fsys, "apps") {
+		return fmt.Errorf("apps directory not found. Are you in a Simple Platform monorepo root?")
+	}
+
+	var appDirs []string
+	if len(args) == 1 {
+		appDir := filepath.Join("apps", args[0])
+		if !scaffold.PathExists(fsys, appDir) {
+			return fmt.Errorf("app %s not found in apps/", args[0])
+		}
+		appDirs = append(appDirs, appDir)
+	} else {
+		entries, err := fsys.ReadDir("apps")
+		if err != nil {
+			return fmt.Errorf("failed to read apps directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				appDirs = append(appDirs, filepath.Join("apps", entry.Name()))
+			}
+		}
+	}
+
+	var diags []lint.Diagnostic
+	for _, appDir := range appDirs {
+		found, err := lint.App(fsys, appDir)
+		if err != nil {
+			return err
+		}
+		diags = append(diags, found...)
+	}
+
+	if jsonOutput {
+		type problem struct {
+			Path    string `json:"path"`
+			Line    int    `json:"line,omitempty"`
+			Column  int    `json:"column,omitempty"`
+			Message string `json:"message"`
+		}
+		problems := make([]problem, len(diags))
+		for i, d := range diags {
+			problems[i] = problem{Path: filepath.ToSlash(d.Path), Line: d.Pos.Line, Column: d.Pos.Col, Message: d.Msg}
+		}
+		status := "success"
+		if len(diags) > 0 {
+			status = "failure"
+		}
+		if err := printJSON(map[string]interface{}{"status": status, "apps": len(appDirs), "problems": problems}); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+	}
+
+	if len(diags) > 0 {
+		return fmt.Errorf("found %d problems in %d apps", len(diags), len(appDirs))
+	}
+	if !jsonOutput {
+		fmt.Printf("✅ No problems found in %d apps\n", len(appDirs))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func lintWorkspace(t *testing.T, actions string) {
+	t.Helper()
+	root := t.TempDir()
+	appDir := filepath.Join(root, "apps", "crm")
+	if err := os.MkdirAll(filepath.Join(appDir, "records"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(appDir, "actions", "send-email"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(appDir, "actions", "send-email", "action.scl"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(appDir, "records", "10_actions.scl"), []byte(actions), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldWd, _ := os.Getwd()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+}
+
+func TestLintCmd_Clean(t *testing.T) {
+	lintWorkspace(t, "set dev_simple_system.logic, send_email {\n  name \"send-email\"\n  execution_environment server\n}\n")
+
+	out, _, err := invokeCmd("lint")
+	if err != nil {
+		t.Fatalf("lint failed: %v", err)
+	}
+	if !strings.Contains(out, "No problems found in 1 apps") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestLintCmd_ReportsProblems(t *testing.T) {
+	lintWorkspace(t, "set dev_simple_system.logic, send_email {\n  name \"send-email\"\n  execution_environment edge\n}\n")
+
+	out, _, err := invokeCmd("lint", "crm")
+	if err == nil || !strings.Contains(err.Error(), "found 1 problems") {
+		t.Fatalf("expected lint to fail with one problem, got %v", err)
+	}
+	want := filepath.Join("apps", "crm", "records", "10_actions.scl") + `:3:25: invalid execution_environment "edge"`
+	if !strings.Contains(out, want) {
+		t.Errorf("expected %q in output, got: %s", want, out)
+	}
+}
+
+func TestLintCmd_UnknownApp(t *testing.T) {
+	lintWorkspace(t, "")
+
+	if _, _, err := invokeCmd("lint", "nope"); err == nil || !strings.Contains(err.Error(), "app nope not found") {
+		t.Errorf("expected an unknown app to be an error, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"os"
+
+	"simple-cli/internal/fsx"
+	"simple-cli/internal/lsp"
+
+	"github.com/spf13/cobra"
+)
+
+// lspCmd serves the SCL language server to an editor.
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run the SCL language server over stdio",
+	Long: `Speak the Language Server Protocol over stdin and stdout for .scl files.
+Editors start it themselves; point your editor's LSP client at "simple lsp" for
+the scl file type.
+
+It provides:
+  - diagnostics from the SCL parser and the checks of 'simple lint'
+  - go-to-definition for $var('x'), action names and table names
+  - completion of the keys of set dev_simple_system.* blocks and their values
+  - hover docs for record types, keys and $var references
+  - document symbols for the outline`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		s := lsp.NewServer(fsx.OSFileSystem{})
+		s.Version = Version
+		return s.Run(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(lspCmd)
+}
@@ -16,12 +16,30 @@ var newBehaviorCmd = &cobra.Command{
 	Short: "Create a new record behavior",
 	Long: `Scaffold a new record behavior and register it in SCL.
 
+A TypeScript behavior is typed against the table's fields in tables.scl, so the
+table must be declared there first. It is type-checked and bundled into the
+<table-name>.js the platform runs when the app is deployed.
+
 Arguments:
   <app-id>:     Target App ID (e.g., com.mycompany.crm)
   <table-name>: Table name to attach behavior to (e.g., order)`,
-	Example: `  simple new behavior com.mycompany.crm order`,
-	Args:    cobra.ExactArgs(2),
-	RunE:    runNewBehavior,
+	Example: `  simple new behavior com.mycompany.crm order
+  simple new behavior com.mycompany.crm order --lang ts`,
+	Args: cobra.ExactArgs(2),
+	RunE: runNewBehavior,
+}
+
+// behaviorLanguages maps the --lang shorthand onto the behavior languages the
+// scaffold package knows, as actionLanguages does for actions.
+var behaviorLanguages = map[string]string{
+	"js": scaffold.LanguageJavaScript,
+	"ts": scaffold.LanguageTypeScript,
+}
+
+// behaviorExtensions maps a behavior language onto its source file extension.
+var behaviorExtensions = map[string]string{
+	scaffold.LanguageJavaScript: ".js",
+	scaffold.LanguageTypeScript: ".ts",
 }
 
 // runNewBehavior executes the logic to scaffold a new behavior.
@@ -29,6 +47,12 @@ func runNewBehavior(cmd *cobra.Command, args []string) error {
 	appID := args[0]
 	tableName := args[1]
 
+	lang, _ := cmd.Flags().GetString("lang")
+	language, known := behaviorLanguages[lang]
+	if !known {
+		return fmt.Errorf("unsupported language: %s. Supported: 'js' (JavaScript), 'ts' (TypeScript)", lang)
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get current working directory: %w", err)
@@ -43,6 +67,7 @@ func runNewBehavior(cmd *cobra.Command, args []string) error {
 	cfg := scaffold.BehaviorConfig{
 		AppID:     appID,
 		TableName: tableName,
+		Language:  language,
 	}
 
 	if err := scaffold.CreateBehaviorStructure(fsys, scaffold.TemplatesFS, cwd, cfg); err != nil {
@@ -54,7 +79,8 @@ func runNewBehavior(cmd *cobra.Command, args []string) error {
 			"status":     "success",
 			"app_id":     appID,
 			"table_name": tableName,
-			"path":       "apps/" + appID + "/scripts/record-behaviors/" + tableName + ".js",
+			"language":   language,
+			"path":       "apps/" + appID + "/scripts/record-behaviors/" + tableName + behaviorExtensions[language],
 		})
 	}
 
@@ -64,4 +90,5 @@ func runNewBehavior(cmd *cobra.Command, args []string) error {
 
 func init() {
 	newCmd.AddCommand(newBehaviorCmd)
+	newBehaviorCmd.Flags().StringP("lang", "l", "js", "Behavior language: js or ts")
 }
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	promoteFrom      string
+	promoteTo        string
+	promoteBump      string
+	promoteNoInstall bool
+)
+
+// promoteCmd ships the version installed in one environment to the next.
+var promoteCmd = &cobra.Command{
+	Use:   "promote <app>",
+	Short: "Ship the version installed in one environment to the next",
+	Long: `Promote the version of an app installed in one environment to another,
+the next in the pipeline of simple.scl unless --to says otherwise.
+
+Nothing is rebuilt or read from disk: the manifest of the installed version is
+deployed as it is, so the target gets exactly the files the source was tested
+with. Files the target does not have yet are downloaded from the source and
+uploaded. The new version follows from the installed one, 1.4.0-staging.3
+becoming 1.4.0 in a release environment, and app.scl is left alone.
+
+Promotion refuses when nothing is installed in the source environment, and
+when the target comes before the source in the pipeline.
+
+<app> is an app ID or an app directory.
+
+Examples:
+  simple promote com.example.crm --from staging
+  simple promote apps/com.example.crm --from staging --to prod
+  simple promote com.example.crm --from prod --to eu --bump patch`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPromote(cmd.Context(), args[0])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(promoteCmd)
+	promoteCmd.Flags().StringVar(&promoteFrom, "from", "", "environment whose installed version to promote (required)")
+	promoteCmd.Flags().StringVar(&promoteTo, "to", "", "environment to promote to (default: the next in the pipeline)")
+	promoteCmd.Flags().StringVar(&promoteBump, "bump", "", "version bump type: patch|minor|major (required when promoting a release to another environment)")
+	promoteCmd.Flags().BoolVar(&promoteNoInstall, "no-install", false, "skip automatic installation after promoting")
+	_ = promoteCmd.MarkFlagRequired("from")
+}
+
+// runPromote reads the installed version and its manifest from the source
+// environment and deploys that manifest to the target under the version the
+// state machine gives it.
+func runPromote(ctx context.Context, app string) error {
+	start := time.Now()
+
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+	if err != nil {
+		return fmt.Errorf("failed to load simple.scl: %w", err)
+	}
+	from, to, err := promotionEnvs(cfg, promoteFrom, promoteTo)
+	if err != nil {
+		return err
+	}
+	appID, _, err := appArg(app)
+	if err != nil {
+		return err
+	}
+
+	// === PHASE 1: Source ===
+	// Read the installed version and the manifest it was deployed with.
+	source, err := joinApp(ctx, cfg, from, appID)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	installed, err := source.Installed(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot promote %s from %s: %w", appID, from, err)
+	}
+
+	newVersion, err := deploy.ComputeNewVersion(installed.Version, to, promoteBump, cfg)
+	if err != nil {
+		return err
+	}
+
+	if !jsonOutput {
+		fmt.Printf("📦 Promoting %s@%s from %s to %s as %s\n", appID, installed.Version, from, to, newVersion)
+		fmt.Printf("📁 Files: %d\n", len(installed.Files))
+	}
+
+	// === PHASE 2: Target ===
+	// Deploy the same manifest; only files the target lacks are transferred.
+	target, err := joinApp(ctx, cfg, to, appID)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	neededFiles, err := target.SendManifest(ctx, installed.Files, newVersion)
+	if err != nil {
+		return err
+	}
+
+	if !jsonOutput {
+		fmt.Printf("⬆️  Copying %d files from %s (%d cached)\n", len(neededFiles), from, len(installed.Files)-len(neededFiles))
+	}
+
+	if err := source.FetchFiles(ctx, installed.Files, neededFiles); err != nil {
+		return err
+	}
+	if err := target.SendFiles(ctx, installed.Files, neededFiles); err != nil {
+		return err
+	}
+
+	result, err := target.Deploy(ctx)
+	if err != nil {
+		return err
+	}
+
+	// === PHASE 3: Auto-Install ===
+	var installResult *deploy.InstallResult
+	if !promoteNoInstall {
+		if !jsonOutput {
+			fmt.Printf("🚀 Installing %s@%s to %s...\n", result.AppID, result.Version, to)
+		}
+		installResult, err = installVersion(ctx, target, result.Version, false, jsonOutput)
+		if err != nil {
+			return fmt.Errorf("promoted %s@%s but install failed: %w", result.AppID, result.Version, err)
+		}
+	}
+
+	duration := time.Since(start)
+
+	if jsonOutput {
+		resp := map[string]interface{}{
+			"status":       "success",
+			"app_id":       result.AppID,
+			"from":         from,
+			"to":           to,
+			"from_version": installed.Version,
+			"version":      result.Version,
+			"files":        map[string]int{"total": len(installed.Files), "new": len(neededFiles), "cached": len(installed.Files) - len(neededFiles)},
+			"duration_ms":  duration.Milliseconds(),
+		}
+		if installResult != nil {
+			resp["installed"] = true
+			resp["install_success"] = installResult.Success
+		}
+		return printJSON(resp)
+	}
+
+	msg := fmt.Sprintf("✅ Promoted %s@%s from %s to %s as %s", result.AppID, installed.Version, from, to, result.Version)
+	if installResult != nil && installResult.Success {
+		msg += " (Installed)"
+	}
+	fmt.Printf("%s in %s\n", msg, duration.Round(time.Millisecond))
+	return nil
+}
+
+// promotionEnvs checks the --from and --to of a promotion against the
+// pipeline, defaulting --to to the environment after --from.
+func promotionEnvs(cfg *config.SimpleSCL, from, to string) (string, string, error) {
+	if to == "" {
+		if _, ok := cfg.Environments[from]; ok {
+			to = cfg.Next(from)
+			if to == "" {
+				return "", "", fmt.Errorf("%s is the last environment in the pipeline; pass --to to promote elsewhere", from)
+			}
+		}
+	}
+	if err := cfg.CheckPromotion(from, to); err != nil {
+		return "", "", err
+	}
+	return from, to, nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"simple-cli/internal/config"
+)
+
+func TestPromotionEnvs(t *testing.T) {
+	cfg := &config.SimpleSCL{
+		Tenant:   "acme",
+		Pipeline: []string{"dev", "staging", "prod"},
+		Environments: map[string]*config.Environment{
+			"dev":     {Name: "dev"},
+			"staging": {Name: "staging"},
+			"prod":    {Name: "prod"},
+		},
+	}
+
+	tests := []struct {
+		from, to string
+		wantTo   string
+		wantErr  string
+	}{
+		{from: "staging", wantTo: "prod"},
+		{from: "dev", wantTo: "staging"},
+		{from: "dev", to: "prod", wantTo: "prod"},
+		{from: "prod", wantErr: "prod is the last environment in the pipeline; pass --to to promote elsewhere"},
+		{from: "prod", to: "dev", wantErr: "dev comes before prod in the pipeline"},
+		{from: "qa", wantErr: "environment 'qa' not defined in simple.scl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			_, to, err := promotionEnvs(cfg, tt.from, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("promotionEnvs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || to != tt.wantTo {
+				t.Errorf("promotionEnvs() = %q, %v; want %q", to, err, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestPromoteCmd_RefusesBackwards(t *testing.T) {
+	configWorkspace(t, "tenant acme\nenv dev {\n  endpoint acme-dev.on.simple.dev\n  api_key $K\n}\nenv prod {\n  endpoint acme.on.simple.dev\n  api_key $K\n}\n")
+	t.Cleanup(func() { promoteFrom, promoteTo = "", "" })
+
+	_, _, err := invokeCmd("promote", "com.example.crm", "--from", "prod", "--to", "dev")
+	if err == nil || !strings.Contains(err.Error(), "cannot promote from prod to dev") {
+		t.Errorf("promote error = %v", err)
+	}
+}
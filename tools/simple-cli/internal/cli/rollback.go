@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackEnv string
+	rollbackTo  string
+)
+
+// rollbackListed is how many of the most recent versions rollback lists.
+const rollbackListed = 10
+
+// rollbackCmd installs an earlier deployed version of an app.
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <app>",
+	Short: "Install a previously deployed version of an app",
+	Long: `Roll an app in an environment back to a version deployed there before.
+
+The most recent versions deployed to the environment are listed, and the one
+chosen is installed by name: the version given by --to, or else the one
+deployed before the version installed now. Nothing is deployed or rebuilt.
+
+<app> is an app ID or an app directory.
+
+Examples:
+  simple rollback com.example.crm --env prod
+  simple rollback com.example.crm --env prod --to 1.4.2`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRollback(cmd.Context(), args[0])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(rollbackCmd)
+	rollbackCmd.Flags().StringVar(&rollbackEnv, "env", "", "target environment from the pipeline in simple.scl (required)")
+	rollbackCmd.Flags().StringVar(&rollbackTo, "to", "", "version to roll back to (default: the one deployed before the installed version)")
+	_ = rollbackCmd.MarkFlagRequired("env")
+}
+
+func runRollback(ctx context.Context, app string) error {
+	start := time.Now()
+
+	if rollbackEnv == "" {
+		return envRequiredError()
+	}
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+	if err != nil {
+		return fmt.Errorf("failed to load simple.scl: %w", err)
+	}
+	appID, _, err := appArg(app)
+	if err != nil {
+		return err
+	}
+
+	client, err := joinApp(ctx, cfg, rollbackEnv, appID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	versions, err := client.Versions(ctx)
+	if err != nil {
+		return err
+	}
+	current, target, err := rollbackTarget(versions, rollbackTo)
+	if err != nil {
+		return fmt.Errorf("cannot roll back %s in %s: %w", appID, rollbackEnv, err)
+	}
+
+	if !jsonOutput {
+		fmt.Println("Recent versions:")
+		printVersions(versions, rollbackListed, target.Version)
+	}
+
+	if target.Version == current {
+		if jsonOutput {
+			return printJSON(map[string]interface{}{"status": "success", "app_id": appID, "env": rollbackEnv, "version": current, "changed": false})
+		}
+		fmt.Printf("✅ %s@%s is already installed in %s\n", appID, current, rollbackEnv)
+		return nil
+	}
+
+	if !jsonOutput {
+		fmt.Printf("⏪ Rolling %s in %s back to %s...\n", appID, rollbackEnv, target.Version)
+	}
+	result, err := installVersion(ctx, client, target.Version, true, jsonOutput)
+	if err != nil {
+		return err
+	}
+
+	duration := time.Since(start)
+
+	if jsonOutput {
+		return printJSON(map[string]interface{}{
+			"status":       "success",
+			"app_id":       appID,
+			"env":          rollbackEnv,
+			"from_version": current,
+			"version":      result.Version,
+			"changed":      true,
+			"duration_ms":  duration.Milliseconds(),
+		})
+	}
+	fmt.Printf("✅ Rolled %s in %s back from %s to %s in %s\n", appID, rollbackEnv, orNone(current), result.Version, duration.Round(time.Millisecond))
+	return nil
+}
+
+// rollbackTarget picks the version to roll back to from versions, newest
+// first: the one named to, or else the one deployed before the installed
+// version. It also returns the installed version, "" when none is.
+func rollbackTarget(versions []deploy.DeployedVersion, to string) (string, deploy.DeployedVersion, error) {
+	current, installedAt := "", -1
+	for i, v := range versions {
+		if v.Installed {
+			current, installedAt = v.Version, i
+			break
+		}
+	}
+
+	if to != "" {
+		for _, v := range versions {
+			if v.Version == to {
+				return current, v, nil
+			}
+		}
+		return current, deploy.DeployedVersion{}, fmt.Errorf("version %s was never deployed there; recent versions: %s", to, recentVersions(versions))
+	}
+
+	switch {
+	case len(versions) == 0:
+		return current, deploy.DeployedVersion{}, fmt.Errorf("no versions have been deployed")
+	case installedAt == -1:
+		return current, deploy.DeployedVersion{}, fmt.Errorf("no version is installed; pass --to to install one of %s", recentVersions(versions))
+	case installedAt == len(versions)-1:
+		return current, deploy.DeployedVersion{}, fmt.Errorf("%s is the earliest deployed version; there is nothing to roll back to", current)
+	}
+	return current, versions[installedAt+1], nil
+}
+
+// recentVersions names the most recent versions for messages.
+func recentVersions(versions []deploy.DeployedVersion) string {
+	names := make([]string, 0, rollbackListed)
+	for i, v := range versions {
+		if i == rollbackListed {
+			break
+		}
+		names = append(names, v.Version)
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func orNone(version string) string {
+	if version == "" {
+		return "none"
+	}
+	return version
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"testing"
+
+	"simple-cli/internal/deploy"
+)
+
+func TestRollbackTarget(t *testing.T) {
+	versions := []deploy.DeployedVersion{
+		{Version: "1.5.0"},
+		{Version: "1.4.3", Installed: true},
+		{Version: "1.4.2"},
+		{Version: "1.4.1"},
+	}
+
+	tests := []struct {
+		name        string
+		versions    []deploy.DeployedVersion
+		to          string
+		wantCurrent string
+		wantTarget  string
+		wantErr     string
+	}{
+		{name: "the version before the installed one", versions: versions, wantCurrent: "1.4.3", wantTarget: "1.4.2"},
+		{name: "an explicit version", versions: versions, to: "1.4.1", wantCurrent: "1.4.3", wantTarget: "1.4.1"},
+		{name: "forward to a newer version", versions: versions, to: "1.5.0", wantCurrent: "1.4.3", wantTarget: "1.5.0"},
+		{name: "the installed version", versions: versions, to: "1.4.3", wantCurrent: "1.4.3", wantTarget: "1.4.3"},
+		{name: "never deployed", versions: versions, to: "1.3.0", wantErr: "version 1.3.0 was never deployed there; recent versions: 1.5.0, 1.4.3, 1.4.2, 1.4.1"},
+		{name: "earliest installed", versions: versions[:2], wantErr: "1.4.3 is the earliest deployed version; there is nothing to roll back to"},
+		{name: "nothing installed", versions: []deploy.DeployedVersion{{Version: "1.0.0"}}, wantErr: "no version is installed; pass --to to install one of 1.0.0"},
+		{name: "nothing deployed", wantErr: "no versions have been deployed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, target, err := rollbackTarget(tt.versions, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("rollbackTarget() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || current != tt.wantCurrent || target.Version != tt.wantTarget {
+				t.Errorf("rollbackTarget() = %q, %q, %v; want %q, %q", current, target.Version, err, tt.wantCurrent, tt.wantTarget)
+			}
+		})
+	}
+}
@@ -4,10 +4,14 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
+	"simple-cli/internal/config"
+
 	"github.com/spf13/cobra"
 )
 
@@ -43,7 +47,7 @@ func Execute() int {
 		if jsonOutput {
 			printErrorJSON(err)
 		} else {
-			fmt.Fprintln(os.Stderr, "Error:", err)
+			fmt.Fprintln(os.Stderr, "Error:", config.Redact(err.Error()))
 		}
 		return 1
 	}
@@ -57,14 +61,17 @@ func init() {
 
 // printJSON encodes data to stdout in JSON format.
 // This is used when the --json flag is provided.
+// Secrets resolved from simple.scl are redacted.
 func printJSON(data interface{}) error {
-	encoder := json.NewEncoder(os.Stdout)
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(data); err != nil {
 		printErrorJSON(fmt.Errorf("failed to encode JSON output: %w", err))
 		return err
 	}
-	return nil
+	_, err := io.WriteString(os.Stdout, config.Redact(buf.String()))
+	return err
 }
 
 // printErrorJSON encodes an error to stderr in JSON format.
@@ -75,5 +82,5 @@ func printErrorJSON(err error) {
 	}
 	encoder := json.NewEncoder(os.Stderr)
 	encoder.SetIndent("", "  ")
-	_ = encoder.Encode(ErrorOutput{Error: err.Error()})
+	_ = encoder.Encode(ErrorOutput{Error: config.Redact(err.Error())})
 }
@@ -3,10 +3,13 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"strings"
 	"testing"
+
+	"simple-cli/internal/config"
 )
 
 // invokeCmd is a test helper that executes the root command with specific arguments
@@ -165,6 +168,33 @@ func TestPrintJSON_Error(t *testing.T) {
 	}
 }
 
+// TestPrintJSON_RedactsSecrets ensures a resolved api key never reaches JSON
+// output or a JSON error.
+func TestPrintJSON_RedactsSecrets(t *testing.T) {
+	config.RegisterSecret("si_test_redaction_secret")
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+
+	if err := printJSON(map[string]string{"api_key": "si_test_redaction_secret"}); err != nil {
+		t.Fatal(err)
+	}
+	printErrorJSON(fmt.Errorf("key si_test_redaction_secret was rejected"))
+	_ = outW.Close()
+	_ = errW.Close()
+	stdout, _ := io.ReadAll(outR)
+	stderr, _ := io.ReadAll(errR)
+
+	for _, out := range []string{string(stdout), string(stderr)} {
+		if strings.Contains(out, "si_test_redaction_secret") || !strings.Contains(out, "[redacted]") {
+			t.Errorf("secret not redacted: %s", out)
+		}
+	}
+}
+
 // TestExecute_Scenarios table-drives tests for top-level command execution issues.
 // It covers missing arguments, unknown commands, and bad flag combinations.
 func TestExecute_Scenarios(t *testing.T) {
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+// statusCmd shows what is installed in each environment of the pipeline.
+var statusCmd = &cobra.Command{
+	Use:   "status <app>",
+	Short: "Show the version of an app installed in each environment",
+	Long: `Show, for each environment in the pipeline of simple.scl, the version of an
+app installed there and the latest version deployed there. Nothing is changed.
+
+When <app> is an app directory, the version in its app.scl is compared with
+each environment's installed version, saying whether the local app is ahead
+of it, behind it or level with it. <app> may also be an app ID.
+
+Environments are queried in parallel; one that cannot be reached is reported
+in its row, and the command then exits with an error.
+
+Examples:
+  simple status apps/com.example.crm
+  simple status com.example.crm --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStatus(cmd.Context(), args[0])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+}
+
+// envStatus is what status reports for one environment.
+type envStatus struct {
+	Env       string `json:"env"`
+	Installed string `json:"installed,omitempty"`
+	Latest    string `json:"latest,omitempty"`
+	Deployed  int    `json:"deployed"`
+	Local     string `json:"local,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func runStatus(ctx context.Context, app string) error {
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+	if err != nil {
+		return fmt.Errorf("failed to load simple.scl: %w", err)
+	}
+	appID, localVersion, err := appArg(app)
+	if err != nil {
+		return err
+	}
+
+	stages := cfg.Stages()
+	rank := func(env string) int { return slices.Index(stages, env) }
+
+	statuses := make([]envStatus, len(stages))
+	var wg sync.WaitGroup
+	for i, envName := range stages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = queryStatus(ctx, cfg, envName, appID)
+			if localVersion != "" && statuses[i].Error == "" {
+				statuses[i].Local = compareLocal(localVersion, statuses[i].Installed, rank)
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, s := range statuses {
+		if s.Error != "" {
+			failed++
+		}
+	}
+
+	if jsonOutput {
+		status := "success"
+		if failed > 0 {
+			status = "error"
+		}
+		resp := map[string]interface{}{
+			"status":       status,
+			"app_id":       appID,
+			"environments": statuses,
+		}
+		if localVersion != "" {
+			resp["local_version"] = localVersion
+		}
+		if err := printJSON(resp); err != nil {
+			return err
+		}
+	} else {
+		printStatus(appID, localVersion, statuses)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not read the status of %s in %d of %d environments", appID, failed, len(statuses))
+	}
+	return nil
+}
+
+// queryStatus reads the deployed versions of appID in one environment.
+func queryStatus(ctx context.Context, cfg *config.SimpleSCL, envName, appID string) envStatus {
+	s := envStatus{Env: envName}
+	client, err := joinApp(ctx, cfg, envName, appID)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	defer client.Close()
+
+	versions, err := client.Versions(ctx)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Deployed = len(versions)
+	if len(versions) > 0 {
+		s.Latest = versions[0].Version
+	}
+	for _, v := range versions {
+		if v.Installed {
+			s.Installed = v.Version
+			break
+		}
+	}
+	return s
+}
+
+// compareLocal says how the local version stands against the one installed
+// in an environment.
+func compareLocal(local, installed string, rank func(string) int) string {
+	if installed == "" {
+		return "not installed"
+	}
+	switch deploy.CompareVersions(local, installed, rank) {
+	case 1:
+		return "ahead"
+	case -1:
+		return "behind"
+	}
+	return "up to date"
+}
+
+func printStatus(appID, localVersion string, statuses []envStatus) {
+	if localVersion != "" {
+		fmt.Printf("%s (local %s)\n", appID, localVersion)
+	} else {
+		fmt.Println(appID)
+	}
+	fmt.Printf("  %-12s %-20s %-20s %s\n", "ENV", "INSTALLED", "LATEST", "LOCAL")
+	for _, s := range statuses {
+		if s.Error != "" {
+			fmt.Printf("  %-12s ❌ %s\n", s.Env, s.Error)
+			continue
+		}
+		local := s.Local
+		if local == "" {
+			local = "-"
+		}
+		fmt.Printf("  %-12s %-20s %-20s %s\n", s.Env, orNone(s.Installed), orNone(s.Latest), local)
+	}
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatusCmd(t *testing.T) {
+	remoteWorkspace(t, map[string]mockReplies{
+		"dev": versionsReply(
+			map[string]any{"version": "1.5.0-dev.3", "deployed_at": deployedAt(3), "file_count": 45, "installed": true},
+			map[string]any{"version": "1.5.0-dev.4", "deployed_at": deployedAt(4), "file_count": 46},
+		),
+		"qa": versionsReply(
+			map[string]any{"version": "1.5.0-qa.1", "deployed_at": deployedAt(2), "file_count": 45, "installed": true},
+		),
+		"prod": versionsReply(),
+	})
+	if err := os.MkdirAll(filepath.Join("apps", "com.example.crm"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("apps", "com.example.crm", "app.scl"), []byte("id com.example.crm\nversion 1.5.0-dev.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := invokeCmd("status", "apps/com.example.crm")
+	if err != nil {
+		t.Fatalf("status failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"com.example.crm (local 1.5.0-dev.3)",
+		"dev          1.5.0-dev.3          1.5.0-dev.4          up to date",
+		"qa           1.5.0-qa.1           1.5.0-qa.1           behind",
+		"prod         none                 none                 not installed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "dev ") > strings.Index(out, "qa ") || strings.Index(out, "qa ") > strings.Index(out, "prod ") {
+		t.Errorf("environments not in pipeline order:\n%s", out)
+	}
+
+	out, _, err = invokeCmd("status", "com.example.crm", "--json")
+	if err != nil {
+		t.Fatalf("status --json failed: %v\n%s", err, out)
+	}
+	var resp struct {
+		Status       string      `json:"status"`
+		LocalVersion string      `json:"local_version"`
+		Environments []envStatus `json:"environments"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	want := envStatus{Env: "dev", Installed: "1.5.0-dev.3", Latest: "1.5.0-dev.4", Deployed: 2}
+	if resp.Status != "success" || resp.LocalVersion != "" || len(resp.Environments) != 3 || resp.Environments[0] != want {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestStatusCmd_UnreachableEnv(t *testing.T) {
+	remoteWorkspace(t, map[string]mockReplies{
+		"dev": versionsReply(map[string]any{"version": "1.5.0-dev.3", "deployed_at": deployedAt(3), "installed": true}),
+		"qa":  versionsReply(),
+	})
+
+	out, _, err := invokeCmd("status", "com.example.crm")
+	if err == nil || err.Error() != "could not read the status of com.example.crm in 1 of 3 environments" {
+		t.Errorf("status error = %v", err)
+	}
+	if !strings.Contains(out, "dev          1.5.0-dev.3") || !strings.Contains(out, "prod         ❌ ") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestCompareLocal(t *testing.T) {
+	rank := func(env string) int { return map[string]int{"dev": 0, "qa": 1, "prod": 2}[env] }
+	tests := []struct {
+		local, installed, want string
+	}{
+		{"1.5.0-dev.3", "", "not installed"},
+		{"1.5.0-dev.3", "1.5.0-dev.3", "up to date"},
+		{"1.5.0-dev.4", "1.5.0-dev.3", "ahead"},
+		{"1.5.0-dev.3", "1.5.0-qa.1", "behind"},
+		{"1.5.0-dev.3", "1.4.2", "ahead"},
+		{"1.5.0-dev.3", "1.5.0", "behind"},
+	}
+	for _, tt := range tests {
+		if got := compareLocal(tt.local, tt.installed, rank); got != tt.want {
+			t.Errorf("compareLocal(%q, %q) = %q, want %q", tt.local, tt.installed, got, tt.want)
+		}
+	}
+}
@@ -2,13 +2,16 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"simple-cli/internal/build"
@@ -18,6 +21,17 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	// defaultSuiteTimeout bounds a single suite when --timeout is not given. It
+	// is generous on purpose: the first 'cargo test' of a Rust action compiles
+	// its whole dependency tree, and that is slow rather than hung.
+	defaultSuiteTimeout = 10 * time.Minute
+
+	// suiteKillGrace is how long a suite interrupted by Ctrl-C is given to
+	// shut down on its own before its process group is killed outright.
+	suiteKillGrace = 5 * time.Second
+)
+
 // testCmd represents the command to run tests.
 // It runs each target's own test runner: vitest for TypeScript and JavaScript,
 // cargo for Rust actions.
@@ -29,12 +43,23 @@ var testCmd = &cobra.Command{
 TypeScript and JavaScript targets run under Vitest; Rust actions run under
 'cargo test', on this machine, with no wasm build and no emulator.
 
+Each suite runs under a deadline (--timeout). A suite that overruns it is
+stopped together with every process it started, and counts as failed.
+--retries re-runs only the suites that failed; one that passes on a later
+attempt is reported as flaky.
+
+--watch stays resident and re-runs a suite whenever a file in its action,
+space or record-behaviors directory changes. Press 'a' to re-run every
+suite, 'f' to re-run the failed ones, and 'q' to quit.
+
 Examples:
   simple test                        # Run all tests
   simple test com.mycompany.crm      # Run tests for a specific app
   simple test com.mycompany.crm -a send-email    # Run tests for specific action
   simple test com.mycompany.crm -b order         # Run tests for specific behavior
   simple test com.mycompany.crm -s analytics     # Run tests for specific space
+  simple test --timeout 2m --retries 2           # Bound and retry every suite
+  simple test com.mycompany.crm --watch          # Re-run suites as their files change
 `,
 	// Limit to at most 1 argument (the app-id)
 	Args: cobra.MaximumNArgs(1),
@@ -47,6 +72,10 @@ func init() {
 	testCmd.Flags().StringP("space", "s", "", "Run tests for a specific space")
 	testCmd.Flags().Bool("coverage", false, "Enable test coverage reporting")
 	testCmd.Flags().Bool("json", false, "Output results in JSON format")
+	testCmd.Flags().Duration("timeout", defaultSuiteTimeout, "Maximum run time per test suite (0 disables the limit)")
+	testCmd.Flags().Int("retries", 0, "Number of times to re-run a failed test suite")
+	testCmd.Flags().Int("concurrency", 0, "Number of test suites to run in parallel (default: number of CPU cores)")
+	testCmd.Flags().BoolP("watch", "w", false, "Stay resident and re-run suites whose files change")
 
 	RootCmd.AddCommand(testCmd)
 }
@@ -60,6 +89,23 @@ func runTest(cmd *cobra.Command, args []string) error {
 	spaceName, _ := cmd.Flags().GetString("space")
 	coverage, _ := cmd.Flags().GetBool("coverage")
 	jsonMode, _ := cmd.Flags().GetBool("json")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	retries, _ := cmd.Flags().GetInt("retries")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	watch, _ := cmd.Flags().GetBool("watch")
+
+	if timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+	if retries < 0 {
+		return fmt.Errorf("--retries must not be negative")
+	}
+	if watch && jsonMode {
+		return fmt.Errorf("--watch cannot be combined with --json")
+	}
+	if watch && retries > 0 {
+		return fmt.Errorf("--watch cannot be combined with --retries; a failed suite runs again on the next change, or on 'f'")
+	}
 
 	// Verify we are in a valid monorepo root by checking for "apps" directory.
 	fsys := fsx.OSFileSystem{}
@@ -67,125 +113,253 @@ func runTest(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("apps directory not found. Are you in a Simple Platform monorepo root?")
 	}
 
-	var targetPath string
-	if len(args) > 0 {
-		appID := args[0]
-		targetPath = filepath.Join("apps", appID)
+	targetPath, err := resolveTestTarget(fsys, args, actionName, behaviorName, spaceName)
+	if err != nil {
+		return err
+	}
 
-		// Validate app exists to provide a friendly error early
-		if !scaffold.PathExists(fsys, targetPath) {
-			return fmt.Errorf("app not found: %s", appID)
-		}
+	// Phase 1: Discover all testable directories within the target path
+	testDirs, err := discoverTestDirs(fsys, targetPath)
+	if err != nil {
+		return err
+	}
 
-		// Narrow down to specific action, behavior, or space if flags are set
-		if actionName != "" {
-			targetPath = filepath.Join(targetPath, "actions", actionName)
-			if !scaffold.PathExists(fsys, targetPath) {
-				return fmt.Errorf("action not found: %s in app %s", actionName, appID)
-			}
-		} else if behaviorName != "" {
-			// Behaviors are scripts specifically in scripts/record-behaviors
-			targetPath = filepath.Join(targetPath, "scripts", "record-behaviors")
-			if !scaffold.PathExists(fsys, targetPath) {
-				return fmt.Errorf("behavior tests not found in app %s", appID)
-			}
-			// Validate the specific behavior test file exists
-			testFile := filepath.Join(targetPath, behaviorName+".test.js")
-			if !scaffold.PathExists(fsys, testFile) {
-				return fmt.Errorf("behavior test not found: %s in app %s", behaviorName, appID)
-			}
-		} else if spaceName != "" {
-			targetPath = filepath.Join(targetPath, "spaces", spaceName)
-			if !scaffold.PathExists(fsys, targetPath) {
-				return fmt.Errorf("space not found: %s in app %s", spaceName, appID)
-			}
+	// Watching an app with no suites yet is still useful: the first action
+	// added to it is picked up as soon as it appears.
+	if len(testDirs) == 0 && !watch {
+		if jsonMode {
+			fmt.Println(`{"status":"success","message":"No tests found"}`)
+		} else {
+			fmt.Println("No tests found to run.")
 		}
-	} else {
+		return nil
+	}
+
+	runner, err := newSuiteRunner(fsys, testDirs, suiteOptions{
+		JSONMode:     jsonMode,
+		Coverage:     coverage,
+		BehaviorName: behaviorName,
+		Timeout:      timeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl-C reaches this process, but not the runners: each suite is started
+	// in a process group of its own so that a timeout can stop everything it
+	// spawned, and that same isolation keeps the terminal's interrupt away from
+	// it. The interrupt is therefore caught here and handed on to every suite
+	// still running, rather than leaving them orphaned behind a CLI that has
+	// already exited.
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if watch {
+		return runTestWatch(ctx, fsys, runner, targetPath, concurrency)
+	}
+
+	results := runner.runAll(ctx, testDirs, concurrency, retries)
+
+	return reportSuiteResults(ctx, results, jsonMode)
+}
+
+// resolveTestTarget turns the command's argument and flags into the path to
+// test, refusing early and by name when the app, action, behavior or space
+// asked for does not exist.
+func resolveTestTarget(fsys fsx.FileSystem, args []string, actionName, behaviorName, spaceName string) (string, error) {
+	if len(args) == 0 {
 		// Default to running tests for all apps
-		targetPath = "apps"
+		return "apps", nil
+	}
+
+	appID := args[0]
+	targetPath := filepath.Join("apps", appID)
+
+	// Validate app exists to provide a friendly error early
+	if !scaffold.PathExists(fsys, targetPath) {
+		return "", fmt.Errorf("app not found: %s", appID)
+	}
+
+	// Narrow down to specific action, behavior, or space if flags are set
+	if actionName != "" {
+		targetPath = filepath.Join(targetPath, "actions", actionName)
+		if !scaffold.PathExists(fsys, targetPath) {
+			return "", fmt.Errorf("action not found: %s in app %s", actionName, appID)
+		}
+	} else if behaviorName != "" {
+		// Behaviors are scripts specifically in scripts/record-behaviors
+		targetPath = filepath.Join(targetPath, "scripts", "record-behaviors")
+		if !scaffold.PathExists(fsys, targetPath) {
+			return "", fmt.Errorf("behavior tests not found in app %s", appID)
+		}
+		// Validate the specific behavior test file exists, in either language
+		if len(behaviorTestFiles(fsys, targetPath, behaviorName)) == 0 {
+			return "", fmt.Errorf("behavior test not found: %s in app %s", behaviorName, appID)
+		}
+	} else if spaceName != "" {
+		targetPath = filepath.Join(targetPath, "spaces", spaceName)
+		if !scaffold.PathExists(fsys, targetPath) {
+			return "", fmt.Errorf("space not found: %s in app %s", spaceName, appID)
+		}
 	}
 
+	return targetPath, nil
+}
+
+// discoverTestDirs lists every directory under targetPath that holds a suite
+// of its own: each action, each space, and an app's record-behaviors scripts
+// when there are tests among them.
+func discoverTestDirs(fsys fsx.FileSystem, targetPath string) ([]string, error) {
 	// Resolve absolute target path to ensure we can verify it exists
 	absTarget, err := filepath.Abs(targetPath)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	// Phase 1: Discover all testable directories within the target path
-	var testDirs []string
-
 	// If the target is exactly an action, space, or scripts dir, test it directly
 	if filepath.Base(filepath.Dir(absTarget)) == "actions" || filepath.Base(filepath.Dir(absTarget)) == "spaces" || filepath.Base(absTarget) == "record-behaviors" {
-		testDirs = append(testDirs, targetPath) // Store the relative path
-	} else {
-		// Traverse targetPath to find all action, space, and behavior script directories
-		// e.g. target is "apps" or "apps/com.example.app"
-		appsToScan := []string{targetPath}
-
-		// If target is "apps", collect all individual apps
-		if filepath.Base(targetPath) == "apps" {
-			appsToScan = []string{}
-			entries, err := fsys.ReadDir(targetPath)
-			if err == nil {
-				for _, entry := range entries {
-					if entry.IsDir() {
-						appsToScan = append(appsToScan, filepath.Join(targetPath, entry.Name()))
-					}
+		return []string{targetPath}, nil // Store the relative path
+	}
+
+	var testDirs []string
+
+	// Traverse targetPath to find all action, space, and behavior script directories
+	// e.g. target is "apps" or "apps/com.example.app"
+	appsToScan := []string{targetPath}
+
+	// If target is "apps", collect all individual apps
+	if filepath.Base(targetPath) == "apps" {
+		appsToScan = []string{}
+		entries, err := fsys.ReadDir(targetPath)
+		if err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() {
+					appsToScan = append(appsToScan, filepath.Join(targetPath, entry.Name()))
 				}
 			}
 		}
+	}
 
-		// Gather testable subdirectories for each app
-		for _, appDir := range appsToScan {
-			// Actions
-			actionsDir := filepath.Join(appDir, "actions")
-			if entries, err := fsys.ReadDir(actionsDir); err == nil {
-				for _, entry := range entries {
-					if entry.IsDir() {
-						testDirs = append(testDirs, filepath.Join(actionsDir, entry.Name()))
-					}
+	// Gather testable subdirectories for each app
+	for _, appDir := range appsToScan {
+		// Actions
+		actionsDir := filepath.Join(appDir, "actions")
+		if entries, err := fsys.ReadDir(actionsDir); err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() {
+					testDirs = append(testDirs, filepath.Join(actionsDir, entry.Name()))
 				}
 			}
-			// Spaces
-			spacesDir := filepath.Join(appDir, "spaces")
-			if entries, err := fsys.ReadDir(spacesDir); err == nil {
-				for _, entry := range entries {
-					if entry.IsDir() {
-						testDirs = append(testDirs, filepath.Join(spacesDir, entry.Name()))
-					}
+		}
+		// Spaces
+		spacesDir := filepath.Join(appDir, "spaces")
+		if entries, err := fsys.ReadDir(spacesDir); err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() {
+					testDirs = append(testDirs, filepath.Join(spacesDir, entry.Name()))
 				}
 			}
-			// Record Behaviors (one test suite per app realistically)
-			behaviorsDir := filepath.Join(appDir, "scripts", "record-behaviors")
-			if scaffold.PathExists(fsys, behaviorsDir) {
-				// Only add if there are actual test files, otherwise vitest exits 1
-				hasTests := false
-				if entries, err := fsys.ReadDir(behaviorsDir); err == nil {
-					for _, e := range entries {
-						if strings.HasSuffix(e.Name(), ".test.js") || strings.HasSuffix(e.Name(), ".test.ts") {
-							hasTests = true
-							break
-						}
+		}
+		// Record Behaviors (one test suite per app realistically)
+		behaviorsDir := filepath.Join(appDir, "scripts", "record-behaviors")
+		if scaffold.PathExists(fsys, behaviorsDir) {
+			// Only add if there are actual test files, otherwise vitest exits 1
+			hasTests := false
+			if entries, err := fsys.ReadDir(behaviorsDir); err == nil {
+				for _, e := range entries {
+					if strings.HasSuffix(e.Name(), ".test.js") || strings.HasSuffix(e.Name(), ".test.ts") {
+						hasTests = true
+						break
 					}
 				}
-				if hasTests {
-					testDirs = append(testDirs, behaviorsDir)
-				}
+			}
+			if hasTests {
+				testDirs = append(testDirs, behaviorsDir)
 			}
 		}
 	}
 
-	if len(testDirs) == 0 {
-		if jsonMode {
-			fmt.Println(`{"status":"success","message":"No tests found"}`)
-		} else {
-			fmt.Println("No tests found to run.")
+	return testDirs, nil
+}
+
+// behaviorTestFiles returns the test files of a record behavior that exist in
+// dir, by file name. A behavior is tested by <name>.test.js or <name>.test.ts
+// depending on the language it was written in.
+func behaviorTestFiles(fsys fsx.FileSystem, dir, behaviorName string) []string {
+	var files []string
+	for _, ext := range []string{".test.js", ".test.ts"} {
+		if scaffold.PathExists(fsys, filepath.Join(dir, behaviorName+ext)) {
+			files = append(files, behaviorName+ext)
 		}
-		return nil
 	}
+	return files
+}
 
-	// Phase 2: decide which runner each directory gets.
-	//
+// suiteOptions carries the command's flags down to each suite.
+type suiteOptions struct {
+	JSONMode     bool
+	Coverage     bool
+	BehaviorName string
+
+	// Timeout bounds a single attempt at a single suite. Zero means none.
+	Timeout time.Duration
+}
+
+// suiteResult is what became of one suite once every attempt at it is over.
+type suiteResult struct {
+	Dir        string `json:"path"`
+	Passed     bool   `json:"passed"`
+	Attempts   int    `json:"attempts"`
+	Flaky      bool   `json:"flaky"`
+	TimedOut   bool   `json:"timed_out"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// suiteRunner knows which runner each suite needs and how to run it once.
+type suiteRunner struct {
+	fsys fsx.FileSystem
+	opts suiteOptions
+
+	// rustDirs is read by every suite as it starts and, in watch mode,
+	// extended as directories appear, so it is guarded by langMu.
+	rustDirs map[string]bool
+	langMu   sync.RWMutex
+
+	// mu serialises what suites print, so the output of two suites finishing
+	// together is never interleaved.
+	mu sync.Mutex
+}
+
+// newSuiteRunner decides which runner each directory gets, and refuses up
+// front when a runner one of them needs is not installed.
+func newSuiteRunner(fsys fsx.FileSystem, testDirs []string, opts suiteOptions) (*suiteRunner, error) {
+	r := &suiteRunner{fsys: fsys, opts: opts, rustDirs: make(map[string]bool, len(testDirs))}
+
+	rustFound, err := r.classify(testDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	// --coverage has no counterpart in cargo: coverage for Rust is a separate
+	// subcommand (cargo-llvm-cov) rather than a flag on the test runner, and
+	// installing one on a developer's behalf is not this command's business.
+	// Say so once, and run the tests without it, so a mixed app still reports
+	// coverage for the targets that can produce it.
+	if rustFound && opts.Coverage && !opts.JSONMode {
+		fmt.Println("Note: --coverage does not apply to Rust actions; their tests run without it.")
+	}
+
+	return r, nil
+}
+
+// classify records which of testDirs are Rust actions, and reports whether
+// any were. It is asked again for directories that appear after the run has
+// started, which is why it is not folded into newSuiteRunner.
+func (r *suiteRunner) classify(testDirs []string) (bool, error) {
 	// A Rust action's tests are 'cargo test' and they run on the host: the test
 	// seam stands in for the platform, so nothing here needs a wasm build or an
 	// emulator, which is what makes them fast enough to run on every save.
@@ -202,12 +376,16 @@ func runTest(cmd *cobra.Command, args []string) error {
 	// something — an action with no source, or with two — 'simple build' is
 	// what says so, by name; saying it twice in two different sentences would
 	// leave a developer looking for two problems.
-	rustDirs := make(map[string]bool, len(testDirs))
+	r.langMu.Lock()
+	defer r.langMu.Unlock()
+
 	rustFound := false
 	for _, tDir := range testDirs {
 		if lang, err := build.DetectActionLanguage(tDir); err == nil && lang == build.LanguageRust {
-			rustDirs[tDir] = true
+			r.rustDirs[tDir] = true
 			rustFound = true
+		} else {
+			delete(r.rustDirs, tDir)
 		}
 	}
 
@@ -215,164 +393,339 @@ func runTest(cmd *cobra.Command, args []string) error {
 	// not found": one clear sentence beats one cryptic line per action.
 	if rustFound {
 		if _, err := exec.LookPath("cargo"); err != nil {
-			return fmt.Errorf("cargo not found on PATH, and this run includes Rust actions. Install a Rust toolchain (https://rustup.rs) to run their tests")
+			return true, fmt.Errorf("cargo not found on PATH, and this run includes Rust actions. Install a Rust toolchain (https://rustup.rs) to run their tests")
 		}
 	}
 
-	// --coverage has no counterpart in cargo: coverage for Rust is a separate
-	// subcommand (cargo-llvm-cov) rather than a flag on the test runner, and
-	// installing one on a developer's behalf is not this command's business.
-	// Say so once, and run the tests without it, so a mixed app still reports
-	// coverage for the targets that can produce it.
-	if rustFound && coverage && !jsonMode {
-		fmt.Println("Note: --coverage does not apply to Rust actions; their tests run without it.")
+	return rustFound, nil
+}
+
+// runAll runs every suite, at most concurrency at a time, then re-runs the
+// ones that failed up to retries more times. Only failures are retried: a
+// suite that passed has nothing left to say, and running it again would only
+// slow the answer down.
+//
+// A suite stopped because the run itself was interrupted is not a failure to
+// retry; nothing further is started once ctx is done.
+func (r *suiteRunner) runAll(ctx context.Context, testDirs []string, concurrency, retries int) []suiteResult {
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]suiteResult, len(testDirs))
+	for i, tDir := range testDirs {
+		results[i] = suiteResult{Dir: tDir}
+	}
+
+	pending := make([]int, len(testDirs))
+	for i := range testDirs {
+		pending[i] = i
+	}
+
+	for attempt := 1; attempt <= retries+1 && len(pending) > 0; attempt++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, concurrency)
+
+		for _, idx := range pending {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				defer func() { <-sem }()
+
+				if ctx.Err() != nil {
+					return
+				}
+
+				res := &results[idx]
+				passed, timedOut, duration := r.runOnce(ctx, res.Dir, attempt, retries+1)
+
+				res.Attempts = attempt
+				res.Passed = passed
+				res.TimedOut = timedOut
+				res.Flaky = passed && attempt > 1
+				res.DurationMS += duration.Milliseconds()
+			}(idx)
+		}
+
+		wg.Wait()
+
+		var failed []int
+		for _, idx := range pending {
+			if !results[idx].Passed {
+				failed = append(failed, idx)
+			}
+		}
+		pending = failed
 	}
 
+	return results
+}
+
+// command builds the argument list that runs the suite in tDir. Its error
+// means the suite could not be prepared, and counts as the suite failing.
+func (r *suiteRunner) command(tDir string) ([]string, error) {
+	var fullArgs []string
+
+	hasPackageJSON := scaffold.PathExists(r.fsys, filepath.Join(tDir, "package.json"))
+
 	// Construct Vitest command arguments base
 	reporterFlag := "--reporter=verbose"
-	if jsonMode {
+	if r.opts.JSONMode {
 		reporterFlag = "--reporter=json"
 	}
 
-	var passed, failed int
-	var mu sync.Mutex
-	var wg sync.WaitGroup
+	// Rust first, and before the package.json question rather than after it: a
+	// Rust action carries no package.json, so without this branch it would fall
+	// through to the vitest fallback and be handed to a runner that has nothing
+	// to run.
+	//
+	// cargo resolves and fetches the crate's dependencies itself, so there is
+	// no install step to run beforehand the way there is for npm.
+	r.langMu.RLock()
+	isRust := r.rustDirs[tDir]
+	r.langMu.RUnlock()
+
+	if isRust {
+		fullArgs = []string{"cargo", "test"}
+		// FORCE_COLOR is a Node convention; cargo takes a flag. In JSON mode
+		// the output is not printed at all, so it is left alone.
+		if !r.opts.JSONMode {
+			fullArgs = append(fullArgs, "--color", "always")
+		}
+	} else if hasPackageJSON && r.opts.BehaviorName == "" {
+		// Use `npm run test` for directories containing a package.json (Actions and Spaces).
+		// This ensures package managers (npm/pnpm/yarn) naturally map their own
+		// workspace resolution graphs for hoisted dependencies like @simpleplatform/sdk.
+		//
+		// Install only when the packages are genuinely unreachable, which is
+		// the same question the comment above answers for the runner: a
+		// workspace member does not carry its own node_modules, and asking only
+		// for one is how a hoisted action gets a full install it does not need
+		// on every run.
+		_, resolvable := fsx.ResolveUpward(r.fsys, tDir, "node_modules")
+		if !resolvable {
+			if err := build.EnsureDependenciesFunc(tDir); err != nil {
+				return nil, fmt.Errorf("installing dependencies: %w", err)
+			}
+		}
 
-	// Limit concurrency to NumCPU
-	limit := runtime.NumCPU()
-	if limit < 1 {
-		limit = 1
+		fullArgs = []string{"npm", "run", "test", "--", reporterFlag}
+		if r.opts.Coverage {
+			fullArgs = append(fullArgs, "--coverage")
+		}
+	} else {
+		// Fallback for record-behaviors or targets without a package.json test script
+		// The runner is looked for the way an import is resolved, rather than
+		// in the target's own directory and then in the one this process
+		// happens to have been started from. A workspace installs it at the
+		// root, which is usually neither: the walk finds it, two fixed guesses
+		// did not, and missing it fell through to `npx`, which resolves and may
+		// fetch on every run.
+		vitestBin, found := fsx.ResolveUpward(r.fsys, tDir, "node_modules", ".bin", "vitest")
+
+		if found {
+			// The command runs from tDir, where a path found relative to
+			// this process's directory would no longer lead anywhere.
+			if abs, err := filepath.Abs(vitestBin); err == nil {
+				vitestBin = abs
+			}
+			fullArgs = []string{vitestBin, "run", reporterFlag}
+		} else {
+			fullArgs = []string{"npx", "vitest", "run", reporterFlag}
+		}
+
+		if r.opts.Coverage {
+			fullArgs = append(fullArgs, "--coverage")
+		}
+
+		if r.opts.BehaviorName != "" && filepath.Base(tDir) == "record-behaviors" {
+			fullArgs = append(fullArgs, behaviorTestFiles(r.fsys, tDir, r.opts.BehaviorName)...)
+		}
 	}
-	sem := make(chan struct{}, limit)
 
-	for _, tDir := range testDirs {
-		wg.Add(1)
-		go func(tDir string) {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			var fullArgs []string
-
-			hasPackageJSON := scaffold.PathExists(fsys, filepath.Join(tDir, "package.json"))
-
-			// Rust first, and before the package.json question rather than
-			// after it: a Rust action carries no package.json, so without this
-			// branch it would fall through to the vitest fallback and be handed
-			// to a runner that has nothing to run.
-			//
-			// cargo resolves and fetches the crate's dependencies itself, so
-			// there is no install step to run beforehand the way there is for
-			// npm.
-			if rustDirs[tDir] {
-				fullArgs = []string{"cargo", "test"}
-				// FORCE_COLOR is a Node convention; cargo takes a flag. In JSON
-				// mode the output is not printed at all, so it is left alone.
-				if !jsonMode {
-					fullArgs = append(fullArgs, "--color", "always")
-				}
-			} else if hasPackageJSON && behaviorName == "" {
-				// Use `npm run test` for directories containing a package.json (Actions and Spaces).
-				// This ensures package managers (npm/pnpm/yarn) naturally map their own
-				// workspace resolution graphs for hoisted dependencies like @simpleplatform/sdk.
-				//
-				// Install only when the packages are genuinely unreachable, which
-				// is the same question the comment above answers for the runner:
-				// a workspace member does not carry its own node_modules, and
-				// asking only for one is how a hoisted action gets a full
-				// install it does not need on every run.
-				_, resolvable := fsx.ResolveUpward(fsys, tDir, "node_modules")
-				if !resolvable {
-					if err := build.EnsureDependenciesFunc(tDir); err != nil {
-						mu.Lock()
-						if !jsonMode {
-							fmt.Printf("Error installing dependencies for %s: %v\n", filepath.Base(tDir), err)
-						}
-						failed++
-						mu.Unlock()
-						return
-					}
-				}
+	return fullArgs, nil
+}
 
-				fullArgs = []string{"npm", "run", "test", "--"}
-				if jsonMode {
-					fullArgs = append(fullArgs, "--reporter=json")
-				} else {
-					fullArgs = append(fullArgs, "--reporter=verbose")
-				}
-				if coverage {
-					fullArgs = append(fullArgs, "--coverage")
-				}
-			} else {
-				// Fallback for record-behaviors or targets without a package.json test script
-				// The runner is looked for the way an import is resolved, rather
-				// than in the target's own directory and then in the one this
-				// process happens to have been started from. A workspace
-				// installs it at the root, which is usually neither: the walk
-				// finds it, two fixed guesses did not, and missing it fell
-				// through to `npx`, which resolves and may fetch on every run.
-				vitestBin, found := fsx.ResolveUpward(fsys, tDir, "node_modules", ".bin", "vitest")
-
-				if found {
-					fullArgs = []string{vitestBin, "run", reporterFlag}
-				} else {
-					fullArgs = []string{"npx", "vitest", "run", reporterFlag}
-				}
+// suiteAttempt is one run of one suite: what it printed and how it ended.
+type suiteAttempt struct {
+	Dir      string
+	Err      error
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+
+	// Started is false when the suite could not be prepared, so no runner
+	// was ever launched and there is no output to show.
+	Started bool
+
+	// TimedOut reports that the attempt was stopped for running past the
+	// per-suite timeout, rather than failing on its own.
+	TimedOut bool
+}
 
-				if coverage {
-					fullArgs = append(fullArgs, "--coverage")
-				}
+// Passed reports whether the suite's runner exited cleanly.
+func (a suiteAttempt) Passed() bool { return a.Err == nil }
 
-				if behaviorName != "" && filepath.Base(tDir) == "record-behaviors" {
-					fullArgs = append(fullArgs, behaviorName+".test.js")
-				}
-			}
+// runOnce makes one attempt at the suite in tDir and prints what it said.
+func (r *suiteRunner) runOnce(ctx context.Context, tDir string, attempt, maxAttempts int) (passed, timedOut bool, duration time.Duration) {
+	res := r.execute(ctx, tDir)
 
-			// Execute FROM the target directory
-			execCmd := exec.Command(fullArgs[0], fullArgs[1:]...)
-			execCmd.Dir = tDir
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-			// Vitest strips colors if not directly attached to a TTY.
-			// Force colors so the captured combined output retains syntax highlighting.
-			execCmd.Env = append(os.Environ(), "FORCE_COLOR=1")
+	if !r.opts.JSONMode {
+		r.printAttempt(res, attempt, maxAttempts)
+	}
 
-			var stdoutBuf bytes.Buffer
-			var stderrBuf bytes.Buffer
-			execCmd.Stdout = &stdoutBuf
-			execCmd.Stderr = &stderrBuf
+	return res.Passed(), res.TimedOut, res.Duration
+}
 
-			startTime := time.Now()
-			err := execCmd.Run()
-			duration := time.Since(startTime)
+// execute runs the suite in tDir once and captures its output without
+// printing any of it, so that the caller decides how much of it is shown.
+func (r *suiteRunner) execute(ctx context.Context, tDir string) suiteAttempt {
+	res := suiteAttempt{Dir: tDir}
 
-			mu.Lock()
-			defer mu.Unlock()
+	fullArgs, err := r.command(tDir)
+	if err != nil {
+		res.Err = fmt.Errorf("preparing %s: %w", filepath.Base(tDir), err)
+		return res
+	}
 
-			if !jsonMode {
-				fmt.Printf("\n==> Testing %s (took %v)\n", filepath.Base(tDir), duration.Round(time.Millisecond))
+	suiteCtx := ctx
+	if r.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		suiteCtx, cancel = context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+	}
 
-				// Always print standard output which contains the pretty Vitest reporting
-				if stdoutBuf.Len() > 0 {
-					fmt.Print(stdoutBuf.String())
-				}
+	// Execute FROM the target directory
+	execCmd := exec.CommandContext(suiteCtx, fullArgs[0], fullArgs[1:]...)
+	execCmd.Dir = tDir
+
+	// Vitest strips colors if not directly attached to a TTY.
+	// Force colors so the captured combined output retains syntax highlighting.
+	execCmd.Env = append(os.Environ(), "FORCE_COLOR=1")
+
+	// vitest forks workers and cargo runs a test binary of its own, so ending
+	// only the process started here would leave those behind still holding
+	// the output pipes open. Each suite leads a process group instead, and is
+	// stopped as a group.
+	startInOwnProcessGroup(execCmd)
+	execCmd.Cancel = func() error {
+		if ctx.Err() != nil {
+			// Interrupted: pass the interrupt on and let the runner shut its
+			// workers down, then make sure of it.
+			_ = interruptProcessGroup(execCmd)
+			time.AfterFunc(suiteKillGrace, func() { _ = killProcessGroup(execCmd) })
+			return nil
+		}
+		return killProcessGroup(execCmd)
+	}
+	execCmd.WaitDelay = suiteKillGrace + time.Second
+
+	var stdoutBuf bytes.Buffer
+	var stderrBuf bytes.Buffer
+	execCmd.Stdout = &stdoutBuf
+	execCmd.Stderr = &stderrBuf
+
+	startTime := time.Now()
+	res.Started = true
+	res.Err = execCmd.Run()
+	res.Duration = time.Since(startTime)
+	res.Stdout = stdoutBuf.String()
+	res.Stderr = stderrBuf.String()
+	res.TimedOut = ctx.Err() == nil && suiteCtx.Err() == context.DeadlineExceeded
+
+	return res
+}
 
-				// Only print stderr if the test actually errored (or if we need to see warnings?)
-				// Often Vitest sends warnings to stderr even during successful runs,
-				// but let's dump it if things failed to assist debugging.
-				if err != nil && stderrBuf.Len() > 0 {
-					fmt.Print(stderrBuf.String())
-				}
+// printAttempt writes an attempt's output the way a one-shot run shows it.
+func (r *suiteRunner) printAttempt(res suiteAttempt, attempt, maxAttempts int) {
+	name := filepath.Base(res.Dir)
+
+	if !res.Started {
+		fmt.Printf("Error %v\n", res.Err)
+		return
+	}
+
+	if attempt > 1 {
+		fmt.Printf("\n==> Retrying %s, attempt %d/%d (took %v)\n", name, attempt, maxAttempts, res.Duration.Round(time.Millisecond))
+	} else {
+		fmt.Printf("\n==> Testing %s (took %v)\n", name, res.Duration.Round(time.Millisecond))
+	}
+
+	// Always print standard output which contains the pretty Vitest reporting
+	if res.Stdout != "" {
+		fmt.Print(res.Stdout)
+	}
+
+	// Only print stderr if the test actually errored (or if we need to see warnings?)
+	// Often Vitest sends warnings to stderr even during successful runs,
+	// but let's dump it if things failed to assist debugging.
+	if res.Err != nil && res.Stderr != "" {
+		fmt.Print(res.Stderr)
+	}
+
+	if res.TimedOut {
+		fmt.Printf("⏱️  %s timed out after %v and was stopped\n", name, r.opts.Timeout)
+	}
+}
+
+// reportSuiteResults prints the run's summary and turns it into the command's
+// outcome: an error when any suite failed or the run was interrupted.
+func reportSuiteResults(ctx context.Context, results []suiteResult, jsonMode bool) error {
+	var passed, failed, flaky int
+	for _, res := range results {
+		switch {
+		case res.Passed:
+			passed++
+			if res.Flaky {
+				flaky++
 			}
+		case res.Attempts > 0:
+			failed++
+		}
+	}
+	interrupted := ctx.Err() != nil
 
-			if err != nil {
-				failed++
-			} else {
-				passed++
+	if jsonMode {
+		status := "success"
+		if failed > 0 || interrupted {
+			status = "failure"
+		}
+		_ = printJSON(map[string]interface{}{
+			"status":      status,
+			"passed":      passed,
+			"failed":      failed,
+			"flaky":       flaky,
+			"interrupted": interrupted,
+			"suites":      results,
+		})
+	} else if flaky > 0 {
+		fmt.Printf("\n⚠️  %d flaky test suite(s) passed only on retry:\n", flaky)
+		for _, res := range results {
+			if res.Flaky {
+				fmt.Printf("  %s (attempt %d)\n", res.Dir, res.Attempts)
 			}
-		}(tDir)
+		}
 	}
 
-	wg.Wait()
+	if interrupted {
+		return fmt.Errorf("test run interrupted: %d passed, %d failed, %d not run", passed, failed, len(results)-passed-failed)
+	}
 
 	if failed > 0 {
 		return fmt.Errorf("%d/%d test suites failed", failed, passed+failed)
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startInOwnProcessGroup makes cmd the leader of a new process group, so that
+// everything it goes on to start can be signalled together.
+func startInOwnProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptProcessGroup delivers SIGINT to every process in cmd's group, the
+// way the terminal would have had the group been in the foreground.
+func interruptProcessGroup(cmd *exec.Cmd) error {
+	return signalProcessGroup(cmd, syscall.SIGINT)
+}
+
+// killProcessGroup kills every process in cmd's group.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return signalProcessGroup(cmd, syscall.SIGKILL)
+}
+
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	// A negative pid addresses the whole group the process leads.
+	if err := syscall.Kill(-cmd.Process.Pid, sig); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package cli
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// startInOwnProcessGroup makes cmd the root of a new process group, so that a
+// console interrupt meant for this CLI is not also delivered to it directly.
+func startInOwnProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// interruptProcessGroup stops cmd and everything it started. Windows has no
+// interrupt that can be addressed to another process group, so this is the
+// same as killProcessGroup.
+func interruptProcessGroup(cmd *exec.Cmd) error {
+	return killProcessGroup(cmd)
+}
+
+// killProcessGroup kills cmd's whole process tree.
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
@@ -16,6 +16,10 @@ func invokeTestCmd(args ...string) (string, string, error) {
 	_ = testCmd.Flags().Set("space", "")
 	_ = testCmd.Flags().Set("coverage", "false")
 	_ = testCmd.Flags().Set("json", "false")
+	_ = testCmd.Flags().Set("timeout", defaultSuiteTimeout.String())
+	_ = testCmd.Flags().Set("retries", "0")
+	_ = testCmd.Flags().Set("concurrency", "0")
+	_ = testCmd.Flags().Set("watch", "false")
 	return invokeCmd(args...)
 }
 
@@ -84,6 +88,29 @@ func TestTestCmd_BehaviorNotFound(t *testing.T) {
 	}
 }
 
+// A behavior written in TypeScript is tested by its .test.ts, and -b hands
+// that file, not a .test.js that does not exist, to the runner.
+func TestTestCmd_BehaviorWithTypeScriptTest(t *testing.T) {
+	root := fakeVitestApp(t, "")
+
+	behaviors := filepath.Join(root, "apps", "demo.app", "scripts", "record-behaviors")
+	_ = os.Remove(filepath.Join(behaviors, "order.test.js"))
+	_ = os.WriteFile(filepath.Join(behaviors, "order.test.ts"), []byte("// test\n"), 0644)
+
+	argsFile := filepath.Join(root, "runner-args")
+	script := "echo \"$@\" > " + argsFile + "\n"
+	_ = os.WriteFile(filepath.Join(root, "node_modules", ".bin", "vitest"), []byte("#!/bin/sh\n"+script), 0755)
+
+	if _, _, err := invokeTestCmd("test", "demo.app", "--behavior", "order"); err != nil {
+		t.Fatalf("expected the TypeScript behavior test to run, got: %v", err)
+	}
+
+	got, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(got), "order.test.ts") {
+		t.Errorf("expected the runner to be given order.test.ts, got: %q", got)
+	}
+}
+
 func TestTestCmd_SpaceNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 
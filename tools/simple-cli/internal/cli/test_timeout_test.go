@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeVitestApp lays out an app whose record-behavior suite is run by the
+// given shell script standing in for vitest, and moves into the monorepo root.
+//
+// The fallback runner is found by walking up for node_modules/.bin/vitest, so
+// a script placed there is what the command runs — no Node needed.
+func fakeVitestApp(t *testing.T, script string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in runner is a shell script")
+	}
+
+	root := t.TempDir()
+	behaviors := filepath.Join(root, "apps", "demo.app", "scripts", "record-behaviors")
+	if err := os.MkdirAll(behaviors, 0o755); err != nil {
+		t.Fatalf("could not build the behaviors directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(behaviors, "order.test.js"), []byte("// test\n"), 0o644); err != nil {
+		t.Fatalf("could not write the test file: %v", err)
+	}
+
+	bin := filepath.Join(root, "node_modules", ".bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatalf("could not build the bin directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "vitest"), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("could not write the stand-in runner: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	_ = os.Chdir(root)
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+
+	return root
+}
+
+// A hung suite used to hold the whole command forever. Under --timeout it is
+// stopped — together with what it started, which is what the grandchild
+// sleeping in the background stands for — and reported as failed.
+func TestTest_TimeoutStopsAHungSuite(t *testing.T) {
+	fakeVitestApp(t, "sleep 30 &\nsleep 30\n")
+
+	start := time.Now()
+	stdout, _, err := invokeTestCmd("test", "demo.app", "--timeout", "300ms")
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected a suite that overran its timeout to fail the run")
+	}
+	if !strings.Contains(err.Error(), "1/1 test suites failed") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "timed out") {
+		t.Errorf("expected the timeout to be reported, got:\n%s", stdout)
+	}
+	if elapsed > 10*time.Second {
+		t.Errorf("the run took %v; the hung suite was not stopped at its deadline", elapsed)
+	}
+}
+
+// Only the failed suite is run again, and a pass on the second attempt is a
+// pass that is called flaky rather than one that looks like any other.
+func TestTest_RetriesMarkASuiteThatPassesLaterAsFlaky(t *testing.T) {
+	root := fakeVitestApp(t, "")
+
+	// Fail the first time, pass every time after: the marker file is the
+	// runner's memory of having been called before.
+	marker := filepath.Join(root, "ran-once")
+	script := "if [ -f " + marker + " ]; then exit 0; fi\ntouch " + marker + "\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(root, "node_modules", ".bin", "vitest"), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("could not write the stand-in runner: %v", err)
+	}
+
+	stdout, _, err := invokeTestCmd("test", "demo.app", "--retries", "2")
+	if err != nil {
+		t.Fatalf("expected the retried suite to pass, got: %v\n%s", err, stdout)
+	}
+	if !strings.Contains(stdout, "attempt 2/3") {
+		t.Errorf("expected the second attempt to be announced, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "flaky") {
+		t.Errorf("expected the suite to be reported as flaky, got:\n%s", stdout)
+	}
+}
+
+// Retries are a second chance, not a way to hide a suite that always fails.
+func TestTest_RetriesStillFailASuiteThatNeverPasses(t *testing.T) {
+	fakeVitestApp(t, "exit 1\n")
+
+	stdout, _, err := invokeTestCmd("test", "demo.app", "--retries", "1")
+	if err == nil {
+		t.Fatal("expected a suite that fails every attempt to fail the run")
+	}
+	if strings.Count(stdout, "==> ") != 2 {
+		t.Errorf("expected exactly two attempts, got:\n%s", stdout)
+	}
+}
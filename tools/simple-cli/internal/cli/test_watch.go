@@ -0,0 +1,452 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"simple-cli/internal/fsx"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// watchPollInterval is how often the watched suites are looked at again. The
+// tree is polled rather than subscribed to: a poll sees a directory that did
+// not exist when the run began exactly the way it sees an edited file, and a
+// new action appearing mid-session is one of the changes that matters.
+const watchPollInterval = 500 * time.Millisecond
+
+// watchIgnoredDirs are directories whose contents are produced by the
+// runners and builds themselves. Watching them would have every run report a
+// change, and schedule the run after it, forever.
+var watchIgnoredDirs = map[string]bool{
+	"node_modules": true,
+	"target":       true,
+	"build":        true,
+	"dist":         true,
+	"coverage":     true,
+	".git":         true,
+}
+
+// runTestWatch keeps the command resident, re-running a suite whenever
+// anything in its directory changes.
+//
+// It drives the same discovery and the same runner selection as a one-shot
+// run rather than handing off to vitest's own watch mode, which would watch
+// TypeScript suites only and leave Rust ones behind.
+func runTestWatch(ctx context.Context, fsys fsx.FileSystem, runner *suiteRunner, targetPath string, concurrency int) error {
+	snapshot, err := watchSnapshot(fsys, targetPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	model := newTestWatchModel(ctx, runner, concurrency, sortedKeys(snapshot))
+	p := tea.NewProgram(model, tea.WithContext(ctx))
+
+	go func() {
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			next, err := watchSnapshot(fsys, targetPath)
+			if err != nil {
+				continue
+			}
+
+			changed, removed := diffWatchSnapshots(snapshot, next)
+			snapshot = next
+			if len(changed) > 0 || len(removed) > 0 {
+				p.Send(watchChangedMsg{Changed: changed, Removed: removed})
+			}
+		}
+	}()
+
+	_, err = p.Run()
+	// Leaving stops every suite still running, through the same context an
+	// interrupt would have cancelled.
+	cancel()
+
+	if err != nil && err != tea.ErrProgramKilled && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// watchSnapshot fingerprints every suite discovery finds under targetPath.
+func watchSnapshot(fsys fsx.FileSystem, targetPath string) (map[string]string, error) {
+	dirs, err := discoverTestDirs(fsys, targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		snapshot[dir] = suiteFingerprint(dir)
+	}
+	return snapshot, nil
+}
+
+// suiteFingerprint summarises every source file in a suite's directory by
+// name, size and modification time. Two fingerprints differ exactly when a
+// file was added, removed or written to; the contents are never read, so a
+// poll costs one stat per file.
+func suiteFingerprint(dir string) string {
+	h := sha256.New()
+
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && watchIgnoredDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00%d\n", rel, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// diffWatchSnapshots names the suites that are new or changed in next, and
+// the ones that have gone, each in a stable order.
+func diffWatchSnapshots(prev, next map[string]string) (changed, removed []string) {
+	for dir, fp := range next {
+		if prev[dir] != fp {
+			changed = append(changed, dir)
+		}
+	}
+	for dir := range prev {
+		if _, ok := next[dir]; !ok {
+			removed = append(removed, dir)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// watchState is where a watched suite stands.
+type watchState int
+
+const (
+	watchRunning watchState = iota
+	watchPassed
+	watchFailed
+)
+
+// watchSuite is what the view knows about one suite.
+type watchSuite struct {
+	state    watchState
+	duration time.Duration
+	timedOut bool
+
+	// dirty records a change seen while the suite was already running: the
+	// run in flight began before the change, so it is run again when done.
+	dirty bool
+}
+
+// watchChangedMsg carries the suites the poller found changed or gone.
+type watchChangedMsg struct {
+	Changed []string
+	Removed []string
+}
+
+// watchDoneMsg carries a finished run of one suite.
+type watchDoneMsg suiteAttempt
+
+// testWatchModel is the Bubble Tea model for 'simple test --watch'.
+type testWatchModel struct {
+	ctx     context.Context
+	runner  *suiteRunner
+	sem     chan struct{}
+	spinner spinner.Model
+
+	suites map[string]*watchSuite
+	order  []string
+	width  int
+}
+
+// newTestWatchModel creates the model with every suite in dirs scheduled for
+// its first run.
+func newTestWatchModel(ctx context.Context, runner *suiteRunner, concurrency int, dirs []string) testWatchModel {
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	m := testWatchModel{
+		ctx:     ctx,
+		runner:  runner,
+		sem:     make(chan struct{}, concurrency),
+		spinner: sp,
+		suites:  make(map[string]*watchSuite, len(dirs)),
+		width:   80,
+	}
+	for _, dir := range dirs {
+		m.suites[dir] = &watchSuite{state: watchRunning}
+	}
+	m.order = append(m.order, dirs...)
+	return m
+}
+
+// Init starts the spinner and the first run of every suite.
+func (m testWatchModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{m.spinner.Tick}
+	for _, dir := range m.order {
+		cmds = append(cmds, m.runSuite(dir))
+	}
+	return tea.Batch(cmds...)
+}
+
+// runSuite runs one suite in the background, at most as many at once as the
+// concurrency allows, and reports back with a watchDoneMsg.
+func (m testWatchModel) runSuite(dir string) tea.Cmd {
+	ctx, runner, sem := m.ctx, m.runner, m.sem
+	return func() tea.Msg {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+		defer func() { <-sem }()
+
+		return watchDoneMsg(runner.execute(ctx, dir))
+	}
+}
+
+// schedule marks dir to run, or to run again once its current run is over.
+func (m testWatchModel) schedule(dir string) tea.Cmd {
+	suite, ok := m.suites[dir]
+	if !ok {
+		suite = &watchSuite{}
+		m.suites[dir] = suite
+	} else if suite.state == watchRunning {
+		suite.dirty = true
+		return nil
+	}
+
+	suite.state = watchRunning
+	suite.dirty = false
+	return m.runSuite(dir)
+}
+
+// Update handles key presses, changes seen by the poller and finished runs.
+func (m testWatchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "a":
+			var cmds []tea.Cmd
+			for _, dir := range m.order {
+				cmds = append(cmds, m.schedule(dir))
+			}
+			return m, tea.Batch(cmds...)
+		case "f":
+			var cmds []tea.Cmd
+			for _, dir := range m.order {
+				if m.suites[dir].state == watchFailed {
+					cmds = append(cmds, m.schedule(dir))
+				}
+			}
+			return m, tea.Batch(cmds...)
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case watchChangedMsg:
+		for _, dir := range msg.Removed {
+			delete(m.suites, dir)
+		}
+
+		var added []string
+		for _, dir := range msg.Changed {
+			if _, ok := m.suites[dir]; !ok {
+				added = append(added, dir)
+			}
+		}
+
+		var cmds []tea.Cmd
+		if len(added) > 0 {
+			// A directory that did not exist when the session began has not
+			// been asked what language it holds yet.
+			if _, err := m.runner.classify(added); err != nil {
+				cmds = append(cmds, tea.Println(fmt.Sprintf("⚠️  %v", err)))
+			}
+		}
+		for _, dir := range msg.Changed {
+			cmds = append(cmds, m.schedule(dir))
+		}
+
+		m.order = m.order[:0]
+		for dir := range m.suites {
+			m.order = append(m.order, dir)
+		}
+		sort.Strings(m.order)
+		return m, tea.Batch(cmds...)
+
+	case watchDoneMsg:
+		suite, ok := m.suites[msg.Dir]
+		if !ok {
+			// The suite's directory went away while it ran.
+			return m, nil
+		}
+
+		res := suiteAttempt(msg)
+		suite.duration = res.Duration
+		suite.timedOut = res.TimedOut
+		suite.state = watchPassed
+		if !res.Passed() {
+			suite.state = watchFailed
+		}
+
+		var cmds []tea.Cmd
+		if !res.Passed() {
+			cmds = append(cmds, tea.Println(watchFailureOutput(res)))
+		}
+		if suite.dirty {
+			suite.state = watchRunning
+			suite.dirty = false
+			cmds = append(cmds, m.runSuite(msg.Dir))
+		}
+		return m, tea.Batch(cmds...)
+	}
+
+	return m, nil
+}
+
+// watchFailureOutput is what a failed run leaves above the status lines: the
+// runner's own report, so the failure can be read without leaving the watch.
+func watchFailureOutput(res suiteAttempt) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "\n==> %s failed", watchSuiteLabel(res.Dir))
+	if res.TimedOut {
+		s.WriteString(" (timed out)")
+	}
+	s.WriteString("\n")
+
+	if !res.Started {
+		fmt.Fprintf(&s, "%v\n", res.Err)
+		return s.String()
+	}
+
+	s.WriteString(strings.TrimRight(res.Stdout, "\n"))
+	if res.Stderr != "" {
+		s.WriteString("\n")
+		s.WriteString(strings.TrimRight(res.Stderr, "\n"))
+	}
+	return s.String()
+}
+
+// watchSuiteLabel names a suite by app and target, which is what tells two
+// actions of the same name in different apps apart.
+func watchSuiteLabel(dir string) string {
+	label := filepath.ToSlash(dir)
+	return strings.TrimPrefix(label, "apps/")
+}
+
+// View renders one compact line per suite.
+func (m testWatchModel) View() string {
+	var s strings.Builder
+
+	var passed, failed, running int
+	for _, dir := range m.order {
+		switch m.suites[dir].state {
+		case watchPassed:
+			passed++
+		case watchFailed:
+			failed++
+		case watchRunning:
+			running++
+		}
+	}
+
+	header := fmt.Sprintf("\n  Watching %d suites: %d passed, %d failed, %d running\n\n", len(m.order), passed, failed, running)
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render(header))
+
+	for _, dir := range m.order {
+		suite := m.suites[dir]
+
+		var line string
+		var style lipgloss.Style
+		switch suite.state {
+		case watchRunning:
+			line = fmt.Sprintf("  %s %s", m.spinner.View(), watchSuiteLabel(dir))
+			style = lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
+		case watchPassed:
+			line = fmt.Sprintf("  ✅ %s (%v)", watchSuiteLabel(dir), suite.duration.Round(time.Millisecond))
+			style = lipgloss.NewStyle().Foreground(lipgloss.Color("82"))
+		case watchFailed:
+			line = fmt.Sprintf("  ❌ %s (%v)", watchSuiteLabel(dir), suite.duration.Round(time.Millisecond))
+			if suite.timedOut {
+				line += " timed out"
+			}
+			style = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		}
+		s.WriteString(style.Render(truncateLine(line, m.width)))
+		s.WriteString("\n")
+	}
+
+	s.WriteString(lipgloss.NewStyle().Faint(true).Render("\n  a: run all · f: run failed · q: quit\n"))
+	return s.String()
+}
+
+// truncateLine clips a line to w display cells so a long suite name cannot
+// wrap and throw off the redraw.
+func truncateLine(line string, w int) string {
+	runes := []rune(line)
+	if w <= 1 || len(runes) <= w {
+		return line
+	}
+	return string(runes[:w-1]) + "…"
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"simple-cli/internal/fsx"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A suite's fingerprint moves when one of its sources is written to, and
+// stays put when only what its runners produce does: a watch that saw its own
+// node_modules or build output change would re-run every suite forever.
+func TestSuiteFingerprint_SeesSourcesAndIgnoresOutputs(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "index.ts")
+	if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("export {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	before := suiteFingerprint(dir)
+
+	for _, out := range []string{"node_modules", "build", "coverage", "target"} {
+		if err := os.MkdirAll(filepath.Join(dir, out), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, out, "x"), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := suiteFingerprint(dir); got != before {
+		t.Error("fingerprint changed when only runner and build output did")
+	}
+
+	if err := os.WriteFile(src, []byte("export const x = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := suiteFingerprint(dir); got == before {
+		t.Error("fingerprint did not change when a source file was written")
+	}
+}
+
+func TestDiffWatchSnapshots(t *testing.T) {
+	prev := map[string]string{"a": "1", "b": "2", "c": "3"}
+	next := map[string]string{"a": "1", "b": "changed", "d": "4"}
+
+	changed, removed := diffWatchSnapshots(prev, next)
+
+	if want := []string{"b", "d"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	if want := []string{"c"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+}
+
+func newWatchModelForTest(dirs ...string) testWatchModel {
+	runner := &suiteRunner{fsys: fsx.OSFileSystem{}, rustDirs: map[string]bool{}}
+	return newTestWatchModel(context.Background(), runner, 1, dirs)
+}
+
+// A change that lands while its suite is already running is not dropped: the
+// run in flight started before the change, so the suite goes again after it.
+func TestTestWatchModel_ChangeDuringRunSchedulesAnotherRun(t *testing.T) {
+	m := newWatchModelForTest("apps/x/actions/a")
+
+	next, cmd := m.Update(watchChangedMsg{Changed: []string{"apps/x/actions/a"}})
+	m = next.(testWatchModel)
+	if cmd != nil {
+		t.Fatal("a change to a running suite started a second concurrent run")
+	}
+	if !m.suites["apps/x/actions/a"].dirty {
+		t.Fatal("the change was not remembered for after the current run")
+	}
+
+	next, cmd = m.Update(watchDoneMsg{Dir: "apps/x/actions/a", Started: true})
+	m = next.(testWatchModel)
+	if cmd == nil {
+		t.Fatal("the suite was not run again after the change it missed")
+	}
+	if m.suites["apps/x/actions/a"].state != watchRunning {
+		t.Errorf("state = %v, want running", m.suites["apps/x/actions/a"].state)
+	}
+}
+
+// 'f' re-runs the failed suites and leaves the passing ones alone.
+func TestTestWatchModel_RerunFailedOnly(t *testing.T) {
+	m := newWatchModelForTest("apps/x/actions/good", "apps/x/actions/bad")
+
+	next, _ := m.Update(watchDoneMsg{Dir: "apps/x/actions/good", Started: true, Duration: time.Second})
+	m = next.(testWatchModel)
+	next, _ = m.Update(watchDoneMsg{Dir: "apps/x/actions/bad", Started: true, Err: errors.New("exit status 1")})
+	m = next.(testWatchModel)
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	m = next.(testWatchModel)
+
+	if got := m.suites["apps/x/actions/bad"].state; got != watchRunning {
+		t.Errorf("failed suite state = %v, want running", got)
+	}
+	if got := m.suites["apps/x/actions/good"].state; got != watchPassed {
+		t.Errorf("passing suite state = %v, want it left as passed", got)
+	}
+}
+
+// A suite that appears mid-session joins the list and is run; one whose
+// directory goes away leaves it.
+func TestTestWatchModel_SuitesComeAndGo(t *testing.T) {
+	m := newWatchModelForTest("apps/x/actions/old")
+
+	next, _ := m.Update(watchChangedMsg{
+		Changed: []string{"apps/x/actions/new"},
+		Removed: []string{"apps/x/actions/old"},
+	})
+	m = next.(testWatchModel)
+
+	if want := []string{"apps/x/actions/new"}; !reflect.DeepEqual(m.order, want) {
+		t.Errorf("order = %v, want %v", m.order, want)
+	}
+	if m.suites["apps/x/actions/new"].state != watchRunning {
+		t.Error("the new suite was not scheduled")
+	}
+}
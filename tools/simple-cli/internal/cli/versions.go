@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	versionsEnv   string
+	versionsLimit int
+)
+
+// versionsCmd lists the versions of an app deployed to an environment.
+var versionsCmd = &cobra.Command{
+	Use:   "versions <app>",
+	Short: "List the versions of an app deployed to an environment",
+	Long: `List the versions of an app deployed to an environment, newest first, with
+when each was deployed and how many files it has. The installed version is
+marked with ●.
+
+<app> is an app ID or an app directory.
+
+Examples:
+  simple versions com.example.crm --env prod
+  simple versions apps/com.example.crm --env dev --limit 50 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runVersions(cmd.Context(), args[0])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(versionsCmd)
+	versionsCmd.Flags().StringVar(&versionsEnv, "env", "", "environment from the pipeline in simple.scl (required)")
+	versionsCmd.Flags().IntVar(&versionsLimit, "limit", 20, "show at most this many versions (0 for all)")
+	_ = versionsCmd.MarkFlagRequired("env")
+}
+
+func runVersions(ctx context.Context, app string) error {
+	if versionsEnv == "" {
+		return envRequiredError()
+	}
+	cfg, err := config.NewLoader().LoadSimpleSCL(".")
+	if err != nil {
+		return fmt.Errorf("failed to load simple.scl: %w", err)
+	}
+	appID, _, err := appArg(app)
+	if err != nil {
+		return err
+	}
+
+	client, err := joinApp(ctx, cfg, versionsEnv, appID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	versions, err := client.Versions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		shown := versions
+		if versionsLimit > 0 && len(shown) > versionsLimit {
+			shown = shown[:versionsLimit]
+		}
+		return printJSON(map[string]interface{}{
+			"status":   "success",
+			"app_id":   appID,
+			"env":      versionsEnv,
+			"total":    len(versions),
+			"versions": shown,
+		})
+	}
+
+	if len(versions) == 0 {
+		fmt.Printf("No versions of %s have been deployed to %s\n", appID, versionsEnv)
+		return nil
+	}
+	fmt.Printf("Versions of %s in %s:\n", appID, versionsEnv)
+	printVersions(versions, versionsLimit, "")
+	return nil
+}
+
+// printVersions lists versions, newest first and at most limit of them (0 for
+// all), marking the installed one with ● and target, if any, with →.
+func printVersions(versions []deploy.DeployedVersion, limit int, target string) {
+	for i, v := range versions {
+		if limit > 0 && i == limit {
+			fmt.Printf("  … %d older\n", len(versions)-limit)
+			break
+		}
+		mark := " "
+		switch {
+		case v.Installed:
+			mark = "●"
+		case v.Version == target:
+			mark = "→"
+		}
+		fmt.Printf("  %s %-20s  %-16s  %d files\n", mark, v.Version, formatDeployedAt(v.DeployedAt), v.FileCount)
+	}
+}
+
+// formatDeployedAt renders when a version was deployed, or "-" when the
+// server did not say.
+func formatDeployedAt(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/deploy"
+
+	"github.com/gorilla/websocket"
+)
+
+// mockReplies maps the events a mock environment answers to the response of
+// its reply.
+type mockReplies map[string]map[string]any
+
+// versionsReply answers the "versions" event with list.
+func versionsReply(list ...map[string]any) mockReplies {
+	return mockReplies{"versions": {"versions": list}}
+}
+
+// remoteWorkspace sets up a workspace with a dev, qa and prod environment
+// whose DevOps services are one mock Phoenix server. Each environment answers
+// the events in its replies; one missing from envs refuses the connection.
+// Tokens are cached so no identity service is needed.
+func remoteWorkspace(t *testing.T, envs map[string]mockReplies) {
+	t.Helper()
+	configWorkspace(t, "tenant acme\npipeline dev, qa, prod\nenv dev {\n  endpoint dev.acme.test\n  api_key si_test\n}\nenv qa {\n  endpoint qa.acme.test\n  api_key si_test\n}\nenv prod {\n  endpoint prod.acme.test\n  api_key si_test\n}\n")
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tokens := map[string]map[string]deploy.CachedToken{"tokens": {}}
+	for _, env := range []string{"dev", "qa", "prod"} {
+		tokens["tokens"][deploy.TenantEnvKey("acme", env)] = deploy.CachedToken{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}
+	}
+	data, _ := json.Marshal(tokens)
+	if err := os.MkdirAll(filepath.Join(home, ".simple"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".simple", "tokens.json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		replies, ok := envs[env]
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg []any
+			if err := json.Unmarshal(data, &msg); err != nil || len(msg) != 5 {
+				continue
+			}
+			response, ok := replies[msg[3].(string)]
+			if !ok && msg[3] != "phx_join" {
+				continue
+			}
+			reply, _ := json.Marshal([]any{msg[0], msg[1], msg[2], "phx_reply", map[string]any{"status": "ok", "response": response}})
+			_ = conn.WriteMessage(websocket.TextMessage, reply)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	old := devopsEndpoint
+	devopsEndpoint = func(e *config.Environment) string {
+		return "ws" + strings.TrimPrefix(server.URL, "http") + "/" + e.Name
+	}
+	t.Cleanup(func() { devopsEndpoint = old })
+}
+
+func deployedAt(days int) string {
+	return time.Date(2026, 3, 1+days, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)
+}
+
+func TestVersionsCmd(t *testing.T) {
+	remoteWorkspace(t, map[string]mockReplies{
+		"prod": versionsReply(
+			map[string]any{"version": "1.4.1", "deployed_at": deployedAt(1), "file_count": 40},
+			map[string]any{"version": "1.4.2", "deployed_at": deployedAt(2), "file_count": 42, "installed": true},
+			map[string]any{"version": "1.5.0", "deployed_at": deployedAt(3), "file_count": 45},
+		),
+	})
+	t.Cleanup(func() { versionsEnv, versionsLimit = "", 20 })
+
+	out, _, err := invokeCmd("versions", "com.example.crm", "--env", "prod", "--limit", "2")
+	if err != nil {
+		t.Fatalf("versions failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"Versions of com.example.crm in prod:", "  1.5.0 ", "● 1.4.2 ", "42 files", "… 1 older"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "1.4.1") {
+		t.Errorf("output lists more than --limit versions:\n%s", out)
+	}
+
+	versionsLimit = 20
+	out, _, err = invokeCmd("versions", "com.example.crm", "--env", "prod", "--json")
+	if err != nil {
+		t.Fatalf("versions --json failed: %v\n%s", err, out)
+	}
+	var resp struct {
+		Status   string `json:"status"`
+		Total    int    `json:"total"`
+		Versions []struct {
+			Version   string `json:"version"`
+			FileCount int    `json:"file_count"`
+			Installed bool   `json:"installed"`
+		} `json:"versions"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if resp.Status != "success" || resp.Total != 3 || len(resp.Versions) != 3 || resp.Versions[0].Version != "1.5.0" || !resp.Versions[1].Installed || resp.Versions[1].FileCount != 42 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestVersionsCmd_UnknownEnv(t *testing.T) {
+	remoteWorkspace(t, nil)
+	t.Cleanup(func() { versionsEnv = "" })
+
+	_, _, err := invokeCmd("versions", "com.example.crm", "--env", "staging")
+	if err == nil || !strings.Contains(err.Error(), "environment 'staging' not defined in simple.scl") {
+		t.Errorf("versions error = %v", err)
+	}
+}
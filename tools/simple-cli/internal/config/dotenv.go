@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+// Dotenv is the variables a workspace's dotenv files give one environment.
+// The files are layered, each overriding the ones before it:
+//
+//  1. .env, shared by every environment
+//  2. .env.<env>, for the environment being targeted
+//  3. .env.local, the developer's own overrides, never committed
+//
+// and the process environment overrides them all. The variables are kept
+// here rather than written into the process environment, so one command can
+// look at several environments without them leaking into each other.
+type Dotenv struct {
+	// Files lists the dotenv files that exist, lowest precedence first.
+	Files []string
+
+	vars    map[string]string
+	sources map[string]string
+}
+
+// DotenvFiles returns the dotenv files read for env, lowest precedence
+// first, whether or not they exist.
+func DotenvFiles(dir, env string) []string {
+	return []string{
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, ".env."+env),
+		filepath.Join(dir, ".env.local"),
+	}
+}
+
+// LoadDotenv reads the dotenv files of dir for env. A file that is missing is
+// skipped; one that cannot be parsed is an error.
+func LoadDotenv(dir, env string) (*Dotenv, error) {
+	d := &Dotenv{vars: map[string]string{}, sources: map[string]string{}}
+	for _, path := range DotenvFiles(dir, env) {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		vars, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		d.Files = append(d.Files, path)
+		for k, v := range vars {
+			d.vars[k] = v
+			d.sources[k] = filepath.Base(path)
+		}
+	}
+	return d, nil
+}
+
+// processEnvSource names the process environment as a source.
+const processEnvSource = "the process environment"
+
+// Lookup returns the value of a variable and the file it came from, or the
+// process environment.
+func (d *Dotenv) Lookup(name string) (value, source string, ok bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, processEnvSource, true
+	}
+	if v, ok := d.vars[name]; ok {
+		return v, d.sources[name], true
+	}
+	return "", "", false
+}
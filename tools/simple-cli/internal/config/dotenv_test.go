@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDotenv(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadDotenv_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	writeDotenv(t, dir, map[string]string{
+		".env":       "SHARED=base\nTENANT=acme\nREGION=eu\nFROM_PROCESS=file\n",
+		".env.dev":   "TENANT=acme-dev\nREGION=us\n",
+		".env.prod":  "TENANT=acme-prod\n",
+		".env.local": "REGION=local\n",
+	})
+	t.Setenv("FROM_PROCESS", "process")
+
+	d, err := LoadDotenv(dir, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Files) != 3 || filepath.Base(d.Files[1]) != ".env.dev" {
+		t.Errorf("Files = %v", d.Files)
+	}
+
+	for name, want := range map[string][2]string{
+		"SHARED":       {"base", ".env"},
+		"TENANT":       {"acme-dev", ".env.dev"},
+		"REGION":       {"local", ".env.local"},
+		"FROM_PROCESS": {"process", processEnvSource},
+	} {
+		v, source, ok := d.Lookup(name)
+		if !ok || v != want[0] || source != want[1] {
+			t.Errorf("Lookup(%q) = %q, %q, %v; want %q from %q", name, v, source, ok, want[0], want[1])
+		}
+	}
+	if _, _, ok := d.Lookup("UNSET_DOTENV_VAR"); ok {
+		t.Error("Lookup() found a variable no file sets")
+	}
+	if _, ok := os.LookupEnv("SHARED"); ok {
+		t.Error("LoadDotenv() wrote into the process environment")
+	}
+}
+
+func TestLoadDotenv_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if d, err := LoadDotenv(dir, "dev"); err != nil || len(d.Files) != 0 {
+		t.Errorf("LoadDotenv() with no files = %v, %v", d, err)
+	}
+
+	writeDotenv(t, dir, map[string]string{".env.dev": "KEY='unterminated\n"})
+	_, err := LoadDotenv(dir, "dev")
+	if err == nil || !strings.Contains(err.Error(), "failed to load "+filepath.Join(dir, ".env.dev")) {
+		t.Errorf("LoadDotenv() error = %v", err)
+	}
+}
+
+func TestSimpleSCL_Settings(t *testing.T) {
+	resetSecrets(t)
+	dir := t.TempDir()
+	writeDotenv(t, dir, map[string]string{
+		".env":     "TENANT=acme\n",
+		".env.dev": "DEV_KEY=si_dev_from_dotenv\n",
+	})
+	cfg := &SimpleSCL{
+		Tenant: "acme",
+		Dir:    dir,
+		Environments: map[string]*Environment{
+			"dev":  {Name: "dev", Endpoint: "${TENANT}-dev.on.${DOMAIN:-simple.dev}", APIKey: "$DEV_KEY"},
+			"prod": {Name: "prod", Endpoint: "acme.on.simple.dev", APIKey: "$DEV_KEY"},
+		},
+	}
+
+	got, err := cfg.Settings("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Setting{
+		{Key: "tenant", Value: "acme", Source: "simple.scl"},
+		{Key: "endpoint", Value: "acme-dev.on.simple.dev", Source: "simple.scl with ${TENANT} from .env, ${DOMAIN} default"},
+		{Key: "api_key", Value: "[redacted]", Source: "$DEV_KEY from .env.dev", Secret: true},
+	}
+	for i := range want {
+		if i >= len(got) || got[i] != want[i] {
+			t.Errorf("Settings()[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// prod does not read .env.dev
+	if _, err := cfg.GetEnv("prod"); err == nil || err.Error() != "environment variable DEV_KEY not set" {
+		t.Errorf("GetEnv(prod) error = %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"simple-cli/internal/scl"
+)
+
+// Field declares one statement a configuration file may contain: its key,
+// whether it is written as a block, and, for a block, the statements inside
+// it.
+type Field struct {
+	Key string
+
+	// Noun is how messages name the statement, "environment" for env.
+	Noun string
+
+	// Block is set for a statement written as a block, and Named for a block
+	// that takes a name, like env dev. Named blocks may repeat under
+	// different names; everything else may appear once.
+	Block bool
+	Named bool
+
+	// Bool is set for a key-value whose value is true or false, and
+	// Duration for one whose value is a duration, like 30s or 15m.
+	Bool     bool
+	Duration bool
+
+	Required bool
+	Doc      string
+	Fields   []Field
+}
+
+// SimpleSCLSchema declares simple.scl.
+var SimpleSCLSchema = []Field{
+	{
+		Key:      "tenant",
+		Noun:     "tenant",
+		Required: true,
+		Doc:      "The tenant the workspace deploys to, e.g. acme.",
+	},
+	{
+		Key:  "pipeline",
+		Noun: "pipeline",
+		Doc:  "The order versions are promoted through the environments, e.g. pipeline dev, qa, prod. Defaults to the order the environments are declared in.",
+	},
+	{
+		Key:   "timeouts",
+		Noun:  "timeouts",
+		Block: true,
+		Doc:   "How long each phase of a deploy may take, e.g. timeouts { manifest 1m }. Flags of deploy and install override them.",
+		Fields: []Field{
+			{Key: "connect", Noun: "connect timeout", Duration: true, Doc: "Connecting to the DevOps service and joining the deploy channel. Defaults to 30s."},
+			{Key: "manifest", Noun: "manifest timeout", Duration: true, Doc: "The reply to the manifest and to lookups such as the versions deployed. Defaults to 2m."},
+			{Key: "upload", Noun: "upload timeout", Duration: true, Doc: "Uploading all of a deploy's files. Defaults to 30m."},
+			{Key: "deploy", Noun: "deploy timeout", Duration: true, Doc: "The reply to deploy, once the files are uploaded. Defaults to 5m."},
+			{Key: "install", Noun: "install timeout", Duration: true, Doc: "An install, which runs migrations and can take long on record-heavy apps. Defaults to 15m."},
+		},
+	},
+	{
+		Key:      "env",
+		Noun:     "environment",
+		Block:    true,
+		Named:    true,
+		Required: true,
+		Doc:      "A deployment target, e.g. env dev { ... }.",
+		Fields: []Field{
+			{Key: "endpoint", Noun: "endpoint", Required: true, Doc: "Base endpoint, e.g. acme-dev.on.simple.dev. May be a $ENV_VAR reference."},
+			{Key: "api_key", Noun: "api_key", Required: true, Doc: "API key for the environment, usually a $ENV_VAR reference."},
+			{Key: "release", Noun: "release", Bool: true, Doc: "Whether deploys to the environment are releases, with versions like 1.2.0 rather than 1.2.0-dev.3. When no environment sets it, prod is the release environment."},
+		},
+	},
+}
+
+// Problem is one way a configuration file departs from its schema. A problem
+// with the file as a whole, like a missing tenant, has a zero Pos.
+type Problem struct {
+	Pos scl.Pos
+	Msg string
+}
+
+// ValidationError reports every problem Validate found in a file.
+type ValidationError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		switch {
+		case p.Pos.Line == 0:
+			lines[i] = p.Msg
+		case e.Path == "":
+			lines[i] = fmt.Sprintf("%d:%d: %s", p.Pos.Line, p.Pos.Col, p.Msg)
+		default:
+			lines[i] = fmt.Sprintf("%s:%d:%d: %s", e.Path, p.Pos.Line, p.Pos.Col, p.Msg)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks nodes against schema and returns its problems in the order
+// they appear in the file, problems with the file as a whole last. A key the
+// schema does not have is reported with the declared key it is closest to,
+// and that key is then not also reported missing.
+func Validate(schema []Field, nodes []*scl.Node) []Problem {
+	v := &validator{}
+	v.list(schema, nodes, "simple.scl", nil)
+	return v.sorted()
+}
+
+// ValidateSimpleSCL checks simple.scl against SimpleSCLSchema, and then that
+// its environments can be promoted through: each name can be part of a
+// version, and a declared pipeline names every environment once.
+func ValidateSimpleSCL(nodes []*scl.Node) []Problem {
+	v := &validator{}
+	v.list(SimpleSCLSchema, nodes, "simple.scl", nil)
+
+	envs := map[string]*scl.Node{}
+	var order []string
+	for _, n := range nodes {
+		if n.Key != "env" || !n.IsBlock() || n.Name() == "" {
+			continue
+		}
+		if name := n.Name(); !envNameRe.MatchString(name) {
+			v.report(n.Values[0].Pos, "environment name '%s' may only contain letters, digits and -, as it becomes part of versions like 1.0.0-%s.1", name, name)
+		}
+		if _, dup := envs[n.Name()]; !dup {
+			envs[n.Name()] = n
+			order = append(order, n.Name())
+		}
+	}
+
+	pipeline := firstWithKey(nodes, "pipeline")
+	if pipeline == nil || pipeline.IsBlock() {
+		return v.sorted()
+	}
+	listed := map[string]bool{}
+	for _, val := range pipeline.Values {
+		name := val.String()
+		switch {
+		case listed[name]:
+			v.report(val.Pos, "environment '%s' appears twice in the pipeline", name)
+		case envs[name] == nil:
+			v.report(val.Pos, "pipeline names environment '%s', which is not defined", name)
+		}
+		listed[name] = true
+	}
+	for _, name := range order {
+		if !listed[name] {
+			v.report(envs[name].KeyPos, "environment '%s' is not in the pipeline on line %d", name, pipeline.KeyPos.Line)
+		}
+	}
+	return v.sorted()
+}
+
+// envNameRe matches the environment names that are valid in a semver
+// prerelease.
+var envNameRe = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+type validator struct {
+	problems []Problem
+}
+
+// sorted returns the problems in the order they appear in the file, problems
+// with the file as a whole last.
+func (v *validator) sorted() []Problem {
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		a, b := v.problems[i].Pos, v.problems[j].Pos
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Col < b.Col
+	})
+	return v.problems
+}
+
+func (v *validator) report(pos scl.Pos, format string, args ...any) {
+	v.problems = append(v.problems, Problem{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// list validates the statements of a file or block. where names it in
+// messages, and parent is the block, nil for the file.
+func (v *validator) list(fields []Field, nodes []*scl.Node, where string, parent *scl.Node) {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.Key
+	}
+
+	seen := map[string]*scl.Node{}
+	suggested := map[string]bool{}
+	for _, n := range nodes {
+		f, ok := field(fields, n.Key)
+		if !ok {
+			if s := suggest(n.Key, keys); s != "" {
+				v.report(n.KeyPos, "unknown key %q in %s; did you mean %q?", n.Key, where, s)
+				suggested[s] = true
+			} else {
+				v.report(n.KeyPos, "unknown key %q in %s; expected one of %s", n.Key, where, strings.Join(keys, ", "))
+			}
+			continue
+		}
+
+		switch {
+		case f.Block && !n.IsBlock():
+			v.report(n.KeyPos, "%s must be a block, like %s", f.Key, example(f))
+			continue
+		case !f.Block && n.IsBlock():
+			v.report(n.KeyPos, "%s must be a key-value, not a block", f.Key)
+			continue
+		case f.Named && n.Name() == "":
+			v.report(n.KeyPos, "%s block needs a name, like %s", f.Key, example(f))
+			continue
+		case f.Bool && n.Value().Kind != scl.Bool:
+			v.report(n.Value().Pos, "%s must be true or false", f.Key)
+			continue
+		case f.Duration && !isDuration(n.Value().String()):
+			v.report(n.Value().Pos, "%s must be a duration, like 30s or 15m", f.Key)
+			continue
+		}
+
+		id := n.Key
+		if f.Named {
+			id += " " + n.Name()
+		}
+		if prev, dup := seen[id]; dup {
+			v.report(n.KeyPos, "duplicate %s; first defined on line %d", describe(f, n), prev.KeyPos.Line)
+			continue
+		}
+		seen[id] = n
+
+		if f.Block {
+			v.list(f.Fields, n.Children, describe(f, n), n)
+		}
+	}
+
+	for _, f := range fields {
+		if !f.Required || suggested[f.Key] {
+			continue
+		}
+		if n := firstWithKey(nodes, f.Key); n != nil && (f.Block || n.Value().String() != "") {
+			continue
+		}
+		switch {
+		case parent != nil:
+			v.report(parent.KeyPos, "%s missing %s", where, f.Key)
+		case f.Block:
+			v.report(scl.Pos{}, "no %ss defined in %s", f.Noun, where)
+		default:
+			v.report(scl.Pos{}, "%s not defined in %s", f.Noun, where)
+		}
+	}
+}
+
+// isDuration reports whether s is a positive duration, like 30s or 1h30m.
+func isDuration(s string) bool {
+	d, err := time.ParseDuration(s)
+	return err == nil && d > 0
+}
+
+// schemaKeys lists every key of schema, at any depth.
+func schemaKeys(schema []Field) []string {
+	var keys []string
+	for _, f := range schema {
+		keys = append(keys, f.Key)
+		keys = append(keys, schemaKeys(f.Fields)...)
+	}
+	return keys
+}
+
+func field(fields []Field, key string) (Field, bool) {
+	for _, f := range fields {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+func firstWithKey(nodes []*scl.Node, key string) *scl.Node {
+	for _, n := range nodes {
+		if n.Key == key {
+			return n
+		}
+	}
+	return nil
+}
+
+// describe names a statement in messages: environment 'dev', tenant.
+func describe(f Field, n *scl.Node) string {
+	if f.Named {
+		return fmt.Sprintf("%s '%s'", f.Noun, n.Name())
+	}
+	return f.Noun
+}
+
+func example(f Field) string {
+	if f.Named {
+		return f.Key + " <name> { ... }"
+	}
+	return f.Key + " { ... }"
+}
+
+// suggest returns the candidate key closest to key, or "" when none is close
+// enough to be a likely typo. Case and the choice of - or _ do not count.
+func suggest(key string, candidates []string) string {
+	norm := func(s string) string { return strings.ReplaceAll(strings.ToLower(s), "-", "_") }
+	best, bestDist := "", 0
+	for _, c := range candidates {
+		d := editDistance(norm(key), norm(c))
+		if d > max(1, len(c)/3) {
+			continue
+		}
+		if best == "" || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "valid",
+			src:  "tenant acme\nenv dev {\n  endpoint acme-dev.on.simple.dev\n  api_key $KEY\n}\n",
+		},
+		{
+			name: "misspelled key suggests the declared one and is not also missing",
+			src:  "tenant acme\nenv dev {\n  endpoint acme-dev.on.simple.dev\n  apikey $KEY\n}\n",
+			want: []string{`4:3: unknown key "apikey" in environment 'dev'; did you mean "api_key"?`},
+		},
+		{
+			name: "misspelled top-level key",
+			src:  "tennant acme\nenv dev {\n  endpoint e\n  api_key k\n}\n",
+			want: []string{`1:1: unknown key "tennant" in simple.scl; did you mean "tenant"?`},
+		},
+		{
+			name: "unrelated key lists the allowed ones",
+			src:  "tenant acme\nenv dev {\n  endpoint e\n  api_key k\n  region eu\n}\n",
+			want: []string{`5:3: unknown key "region" in environment 'dev'; expected one of endpoint, api_key, release`},
+		},
+		{
+			name: "duplicates",
+			src:  "tenant acme\ntenant other\nenv dev {\n  endpoint e\n  api_key k\n}\nenv dev {\n  endpoint e\n  api_key k\n}\n",
+			want: []string{
+				"2:1: duplicate tenant; first defined on line 1",
+				"7:1: duplicate environment 'dev'; first defined on line 3",
+			},
+		},
+		{
+			name: "wrong shapes",
+			src:  "tenant {\n}\nenv dev\nenv {\n}\n",
+			want: []string{
+				"1:1: tenant must be a key-value, not a block",
+				"3:1: env must be a block, like env <name> { ... }",
+				"4:1: env block needs a name, like env <name> { ... }",
+				"tenant not defined in simple.scl",
+			},
+		},
+		{
+			name: "missing values",
+			src:  "env dev {\n  endpoint \"\"\n}\n",
+			want: []string{
+				"1:1: environment 'dev' missing endpoint",
+				"1:1: environment 'dev' missing api_key",
+				"tenant not defined in simple.scl",
+			},
+		},
+		{
+			name: "timeouts must be durations",
+			src:  "tenant acme\ntimeouts {\n  install soon\n  retries 3\n}\nenv dev {\n  endpoint e\n  api_key k\n}\n",
+			want: []string{
+				"3:11: install must be a duration, like 30s or 15m",
+				`4:3: unknown key "retries" in timeouts; expected one of connect, manifest, upload, deploy, install`,
+			},
+		},
+		{
+			name: "empty file",
+			src:  "",
+			want: []string{"tenant not defined in simple.scl", "no environments defined in simple.scl"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range Validate(SimpleSCLSchema, parseSCL(tt.src)) {
+				got = append(got, (&ValidationError{Problems: []Problem{p}}).Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{Path: "simple.scl", Problems: Validate(SimpleSCLSchema, parseSCL("tenant acme\nenv dev {\n  endpont e\n  api_key k\n}\n"))}
+	want := `simple.scl:3:3: unknown key "endpont" in environment 'dev'; did you mean "endpoint"?`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestLoader_LoadSimpleSCL_DashedKey(t *testing.T) {
+	dir := t.TempDir()
+	src := "tenant acme\nenv dev {\n  endpoint acme-dev.on.simple.dev\n  api-key $KEY\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "simple.scl"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewLoader().LoadSimpleSCL(dir)
+	if err == nil || !strings.HasSuffix(err.Error(), `simple.scl:4:3: unexpected unquoted string "api-key"; expected block statement name; did you mean "api_key"?`) {
+		t.Errorf("LoadSimpleSCL() error = %v", err)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	keys := []string{"endpoint", "api_key"}
+	for key, want := range map[string]string{
+		"API_KEY":   "api_key",
+		"apikey":    "api_key",
+		"endpoints": "endpoint",
+		"url":       "",
+		"key":       "",
+	} {
+		if got := suggest(key, keys); got != want {
+			t.Errorf("suggest(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestValidateSimpleSCL_Pipeline(t *testing.T) {
+	envs := "env dev {\n  endpoint e\n  api_key k\n}\nenv qa {\n  endpoint e\n  api_key k\n}\nenv prod {\n  endpoint e\n  api_key k\n  release true\n}\n"
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{name: "declared order", src: "tenant acme\n" + envs},
+		{name: "pipeline", src: "tenant acme\npipeline dev, qa, prod\n" + envs},
+		{
+			name: "pipeline names an undefined environment",
+			src:  "tenant acme\npipeline dev, qa, uat, prod\n" + envs,
+			want: []string{"2:19: pipeline names environment 'uat', which is not defined"},
+		},
+		{
+			name: "environment left out of the pipeline",
+			src:  "tenant acme\npipeline dev, prod, dev\n" + envs,
+			want: []string{
+				"2:21: environment 'dev' appears twice in the pipeline",
+				"7:1: environment 'qa' is not in the pipeline on line 2",
+			},
+		},
+		{
+			name: "release is a boolean",
+			src:  "tenant acme\nenv prod {\n  endpoint e\n  api_key k\n  release yes\n}\n",
+			want: []string{"5:11: release must be true or false"},
+		},
+		{
+			name: "environment name that cannot be a prerelease",
+			src:  "tenant acme\nenv \"eu.prod\" {\n  endpoint e\n  api_key k\n}\n",
+			want: []string{"2:5: environment name 'eu.prod' may only contain letters, digits and -, as it becomes part of versions like 1.0.0-eu.prod.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := (&ValidationError{Problems: ValidateSimpleSCL(parseSCL(tt.src))}).Error()
+			if got != strings.Join(tt.want, "\n") {
+				t.Errorf("ValidateSimpleSCL() =\n%s\nwant\n%s", got, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
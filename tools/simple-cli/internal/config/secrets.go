@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Resolver turns the values simple.scl writes for an environment into the
+// values they stand for. A value may be:
+//
+//   - file:/path, the contents of a file, such as a mounted secret
+//   - cmd:command, the output of a credential helper run through the shell,
+//     such as a password manager CLI
+//   - $NAME, an environment variable
+//   - text with ${NAME} or ${NAME:-default} in it, interpolated
+//
+// Values are resolved when first asked for and cached, so a credential
+// helper runs at most once per invocation whatever asks for its value.
+type Resolver struct {
+	// Lookup returns an environment variable and where its value came from.
+	Lookup   func(name string) (value, source string, ok bool)
+	ReadFile func(path string) ([]byte, error)
+	Run      func(command string) ([]byte, error)
+
+	mu    sync.Mutex
+	cache map[string]resolved
+}
+
+type resolved struct {
+	value  string
+	source string
+}
+
+// NewResolver returns a Resolver that looks variables up in vars, reads the
+// filesystem and runs commands through the system shell.
+func NewResolver(vars *Dotenv) *Resolver {
+	return &Resolver{
+		Lookup:   vars.Lookup,
+		ReadFile: os.ReadFile,
+		Run:      runShell,
+	}
+}
+
+// runShell runs command through the shell and returns its standard output.
+// Standard error becomes part of the error.
+func runShell(command string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// interpRe matches ${NAME} and ${NAME:-default}. As in the shell, a variable
+// set to the empty string gives ${NAME} the empty string, and ${NAME:-default}
+// the default.
+var interpRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Resolve returns what raw stands for. A secret from a file or a credential
+// helper is registered for redaction before it is returned.
+func (r *Resolver) Resolve(raw string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if res, ok := r.cache[raw]; ok {
+		return res.value, nil
+	}
+
+	res, err := r.resolve(raw)
+	if err != nil {
+		return "", err
+	}
+	if r.cache == nil {
+		r.cache = map[string]resolved{}
+	}
+	r.cache[raw] = res
+	return res.value, nil
+}
+
+// Source describes where the value Resolve returned for raw came from, or ""
+// when raw has not been resolved.
+func (r *Resolver) Source(raw string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cache[raw].source
+}
+
+func (r *Resolver) resolve(raw string) (resolved, error) {
+	switch {
+	case strings.HasPrefix(raw, "file:"):
+		path := strings.TrimPrefix(raw, "file:")
+		data, err := r.ReadFile(path)
+		if err != nil {
+			return resolved{}, fmt.Errorf("failed to read secret file %s: %w", path, err)
+		}
+		v := strings.TrimRight(string(data), "\r\n")
+		RegisterSecret(v)
+		return resolved{v, "file " + path}, nil
+
+	case strings.HasPrefix(raw, "cmd:"):
+		command := strings.TrimSpace(strings.TrimPrefix(raw, "cmd:"))
+		out, err := r.Run(command)
+		if err != nil {
+			return resolved{}, fmt.Errorf("credential helper %q failed: %s", command, Redact(err.Error()))
+		}
+		v := strings.TrimRight(string(out), "\r\n")
+		RegisterSecret(v)
+		return resolved{v, fmt.Sprintf("credential helper %q", command)}, nil
+
+	case strings.HasPrefix(raw, "$") && !strings.HasPrefix(raw, "${"):
+		name := strings.TrimPrefix(raw, "$")
+		v, source, ok := r.Lookup(name)
+		if !ok {
+			return resolved{"", raw + " (not set)"}, nil
+		}
+		return resolved{v, raw + " from " + source}, nil
+	}
+
+	var missing, sources []string
+	v := interpRe.ReplaceAllStringFunc(raw, func(ref string) string {
+		m := interpRe.FindStringSubmatch(ref)
+		if v, source, ok := r.Lookup(m[1]); ok && (v != "" || m[2] == "") {
+			sources = append(sources, fmt.Sprintf("${%s} from %s", m[1], source))
+			return v
+		}
+		if m[2] != "" {
+			sources = append(sources, fmt.Sprintf("${%s} default", m[1]))
+			return m[3]
+		}
+		missing = append(missing, m[1])
+		return ""
+	})
+	if len(missing) > 0 {
+		return resolved{}, fmt.Errorf("environment variable %s not set", strings.Join(missing, ", "))
+	}
+	if len(sources) == 0 {
+		return resolved{v, "simple.scl"}, nil
+	}
+	return resolved{v, "simple.scl with " + strings.Join(sources, ", ")}, nil
+}
+
+// variable returns the name of the environment variable raw is, as $NAME or
+// ${NAME}, and whether it is set; name is "" when raw is anything else.
+func (r *Resolver) variable(raw string) (name string, set bool) {
+	if m := interpRe.FindStringSubmatch(raw); m != nil && m[0] == raw && m[2] == "" {
+		name = m[1]
+	} else if strings.HasPrefix(raw, "$") && !strings.HasPrefix(raw, "${") {
+		name = strings.TrimPrefix(raw, "$")
+	} else {
+		return "", false
+	}
+	_, _, set = r.Lookup(name)
+	return name, set
+}
+
+// redactions holds the secrets resolved during this invocation.
+var redactions struct {
+	mu     sync.Mutex
+	values []string
+}
+
+// redactedText replaces a secret wherever it would be shown.
+const redactedText = "[redacted]"
+
+// minSecretLen keeps values too short to be credentials, which would match
+// all over unrelated text, out of redaction.
+const minSecretLen = 4
+
+// RegisterSecret marks v as a secret, so Redact removes it from anything
+// shown to the user.
+func RegisterSecret(v string) {
+	if len(v) < minSecretLen {
+		return
+	}
+	redactions.mu.Lock()
+	defer redactions.mu.Unlock()
+	for _, s := range redactions.values {
+		if s == v {
+			return
+		}
+	}
+	redactions.values = append(redactions.values, v)
+	// Longest first, so a secret that contains another is replaced whole.
+	sort.Slice(redactions.values, func(i, j int) bool { return len(redactions.values[i]) > len(redactions.values[j]) })
+}
+
+// Redact replaces every registered secret in s, as written and as JSON
+// escapes it, with a placeholder.
+func Redact(s string) string {
+	redactions.mu.Lock()
+	defer redactions.mu.Unlock()
+	for _, v := range redactions.values {
+		s = strings.ReplaceAll(s, v, redactedText)
+		if quoted, err := json.Marshal(v); err == nil {
+			if escaped := string(quoted[1 : len(quoted)-1]); escaped != v {
+				s = strings.ReplaceAll(s, escaped, redactedText)
+			}
+		}
+	}
+	return s
+}
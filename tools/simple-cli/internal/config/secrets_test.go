@@ -0,0 +1,149 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testResolver resolves against a fixed environment, files and commands, and
+// counts the commands it runs.
+func testResolver(env, files, commands map[string]string, runs *int) *Resolver {
+	return &Resolver{
+		Lookup: func(name string) (string, string, bool) {
+			v, ok := env[name]
+			return v, "test", ok
+		},
+		ReadFile: func(path string) ([]byte, error) {
+			v, ok := files[path]
+			if !ok {
+				return nil, errors.New("no such file")
+			}
+			return []byte(v), nil
+		},
+		Run: func(command string) ([]byte, error) {
+			*runs++
+			v, ok := commands[command]
+			if !ok {
+				return nil, errors.New("exit status 1: not signed in")
+			}
+			return []byte(v), nil
+		},
+	}
+}
+
+// resetSecrets forgets the secrets registered by earlier tests.
+func resetSecrets(t *testing.T) {
+	t.Helper()
+	redactions.mu.Lock()
+	redactions.values = nil
+	redactions.mu.Unlock()
+	t.Cleanup(func() {
+		redactions.mu.Lock()
+		redactions.values = nil
+		redactions.mu.Unlock()
+	})
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	env := map[string]string{"MY_VAR": "resolved-value", "TENANT": "acme", "EMPTY": ""}
+	files := map[string]string{"/run/secrets/key": "si_file_secret\n"}
+	commands := map[string]string{"op read op://dev/simple": "si_cmd_secret\n"}
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr string
+	}{
+		{value: "$MY_VAR", want: "resolved-value"},
+		{value: "literal", want: "literal"},
+		{value: "$UNSET", want: ""},
+		{value: "prefix$suffix", want: "prefix$suffix"},
+		{value: "${TENANT}-dev.on.simple.dev", want: "acme-dev.on.simple.dev"},
+		{value: "${REGION:-eu}.${TENANT}.simple.dev", want: "eu.acme.simple.dev"},
+		{value: "${EMPTY:-fallback}", want: "fallback"},
+		{value: "${EMPTY}acme.simple.dev", want: "acme.simple.dev"},
+		{value: "${UNSET}.simple.dev", wantErr: "environment variable UNSET not set"},
+		{value: "file:/run/secrets/key", want: "si_file_secret"},
+		{value: "file:/missing", wantErr: "failed to read secret file /missing"},
+		{value: "cmd:op read op://dev/simple", want: "si_cmd_secret"},
+		{value: "cmd:op read op://prod/simple", wantErr: `credential helper "op read op://prod/simple" failed: exit status 1: not signed in`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			runs := 0
+			got, err := testResolver(env, files, commands, &runs).Resolve(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Resolve(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) error = %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_CachesAndRedacts(t *testing.T) {
+	resetSecrets(t)
+	runs := 0
+	r := testResolver(nil, nil, map[string]string{"helper": "si_from_helper"}, &runs)
+
+	for i := 0; i < 3; i++ {
+		if v, err := r.Resolve("cmd:helper"); err != nil || v != "si_from_helper" {
+			t.Fatalf("Resolve() = %q, %v", v, err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("credential helper ran %d times, want 1", runs)
+	}
+
+	if got := Redact("key si_from_helper rejected"); got != "key [redacted] rejected" {
+		t.Errorf("Redact() = %q", got)
+	}
+}
+
+func TestSimpleSCL_GetEnv_ResolvesLazilyAndRedacts(t *testing.T) {
+	resetSecrets(t)
+	runs := 0
+	cfg := &SimpleSCL{
+		Environments: map[string]*Environment{
+			"dev":  {Name: "dev", Endpoint: "${TENANT:-acme}-dev.on.simple.dev", APIKey: "cmd:dev-key"},
+			"prod": {Name: "prod", Endpoint: "acme.on.simple.dev", APIKey: "cmd:prod-key"},
+		},
+	}
+	cfg.Resolver = testResolver(nil, nil, map[string]string{"dev-key": `si_dev"key`, "prod-key": "si_prod"}, &runs)
+
+	env, err := cfg.GetEnv("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Endpoint != "acme-dev.on.simple.dev" || env.APIKey != `si_dev"key` {
+		t.Errorf("GetEnv() = %+v", env)
+	}
+	if runs != 1 {
+		t.Errorf("expected only dev's credential helper to run, ran %d", runs)
+	}
+	if cfg.Environments["dev"].APIKey != "cmd:dev-key" {
+		t.Errorf("GetEnv() modified the configuration: %+v", cfg.Environments["dev"])
+	}
+
+	// JSON escapes the quote in the key; the escaped form is redacted too.
+	if got := Redact(`{"key": "si_dev\"key"}`); got != `{"key": "[redacted]"}` {
+		t.Errorf("Redact() = %q", got)
+	}
+}
+
+func TestRegisterSecret_IgnoresShortValues(t *testing.T) {
+	resetSecrets(t)
+	RegisterSecret("key")
+	if got := Redact("api key"); got != "api key" {
+		t.Errorf("Redact() = %q", got)
+	}
+}
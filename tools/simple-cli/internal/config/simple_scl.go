@@ -3,85 +3,95 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"simple-cli/internal/scl"
+	"slices"
+	"sort"
 	"strings"
-
-	"github.com/joho/godotenv"
+	"sync"
+	"time"
 )
 
 // SimpleSCL represents the parsed structure of a simple.scl configuration file.
 // It contains critical deployment information such as tenant name and environment definitions.
 type SimpleSCL struct {
 	Tenant       string                  // Tenant name (e.g., "acme")
-	Environments map[string]*Environment // Environment configurations keyed by environment name
+	Environments map[string]*Environment // Environment configurations keyed by environment name, values as written
+	Pipeline     []string                // Environment names in promotion order (see Stages)
+	Timeouts     Timeouts                // Deploy phase timeouts, zero where not set
+
+	// Dir is the directory simple.scl and its dotenv files are in.
+	Dir string
+
+	// Resolver resolves environment values for GetEnv. When nil, each
+	// environment gets its own, looking variables up in its dotenv files.
+	Resolver *Resolver
+
+	mu        sync.Mutex
+	resolvers map[string]*Resolver
 }
 
 // Environment represents a specific deployment target configuration.
 type Environment struct {
 	Name     string // Environment name (e.g., "dev", "staging", "prod")
-	Endpoint string // Base endpoint URL (e.g., "acme-dev.on.simple.dev") (can be $ENV_VAR)
-	APIKey   string // API key for authentication (usually a $ENV_VAR reference)
+	Endpoint string // Base endpoint URL (e.g., "acme-dev.on.simple.dev") (can be $ENV_VAR, file: or cmd:)
+	APIKey   string // API key for authentication (usually a $ENV_VAR reference, file: or cmd:)
+	Release  bool   // Deploys are releases (1.2.0) rather than prereleases (1.2.0-dev.3)
+}
+
+// Timeouts are how long each phase of a deploy may take, from the timeouts
+// block. A phase simple.scl does not set is zero.
+type Timeouts struct {
+	Connect  time.Duration
+	Manifest time.Duration
+	Upload   time.Duration
+	Deploy   time.Duration
+	Install  time.Duration
 }
 
 // DevOpsEndpoint returns the WebSocket URL for the DevOps control plane.
 // Format: wss://devops.<endpoint>/socket/websocket
+//
+// An endpoint with a scheme, such as http://localhost:4080 for
+// `simple dev-server`, serves both services itself: it is used as is, with
+// http and https becoming ws and wss.
 func (e *Environment) DevOpsEndpoint() string {
+	if scheme, host, ok := strings.Cut(strings.TrimSuffix(e.Endpoint, "/"), "://"); ok {
+		switch scheme {
+		case "http":
+			return "ws://" + host
+		case "https":
+			return "wss://" + host
+		}
+		return scheme + "://" + host
+	}
 	return fmt.Sprintf("devops.%s", e.Endpoint)
 }
 
 // IdentityEndpoint returns the HTTP URL for the Identity/Auth service.
-// Format: identity.<endpoint>
+// Format: identity.<endpoint>, or the endpoint itself when it has a scheme.
 func (e *Environment) IdentityEndpoint() string {
+	if strings.Contains(e.Endpoint, "://") {
+		return strings.TrimSuffix(e.Endpoint, "/")
+	}
 	return fmt.Sprintf("identity.%s", e.Endpoint)
 }
 
-// SCLParser abstracts the underlying SCL parsing logic.
-// This interface allows for mocking the external 'scl-parser' CLI tool during tests.
+// SCLParser abstracts the underlying SCL parsing logic so tests can supply an
+// AST without a file to parse.
 type SCLParser interface {
-	Parse(path string) ([]SCLBlock, error)
+	Parse(path string) ([]*scl.Node, error)
 }
 
 // DefaultSCLParser is the production implementation of SCLParser.
-// It shells out to the 'scl-parser' binary to parse SCL files into JSON.
-type DefaultSCLParser struct {
-	ParserPath string
-}
-
-// SCLBlock represents a node in the SCL Abstract Syntax Tree (AST).
-// The scl-parser tool outputs a flat list of these blocks.
-// Types:
-//   - "kv" (key-value): A simple property (Key=Value).
-//   - "block": A nested structure (Key { ... Children ... }).
-type SCLBlock struct {
-	Type     string     `json:"type"`     // "kv" or "block"
-	Key      string     `json:"key"`      // Identifier (e.g., "tenant", "env")
-	Name     string     `json:"name"`     // Optional name for blocks (e.g., "dev" in "env dev { ... }")
-	Value    any        `json:"value"`    // Value for KV types
-	Children []SCLBlock `json:"children"` // Child nodes for block types
-}
-
-// Parse executes the scl-parser CLI tool against the given file path.
-// It returns the AST as a slice of SCLBlocks.
-func (p *DefaultSCLParser) Parse(path string) ([]SCLBlock, error) {
-	cmd := exec.Command(p.ParserPath, path)
-	output, err := cmd.Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("scl-parser failed: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("scl-parser execution failed: %w", err)
-	}
+// It parses SCL files natively with the scl package.
+type DefaultSCLParser struct{}
 
-	var blocks []SCLBlock
-	if err := json.Unmarshal(output, &blocks); err != nil {
-		return nil, fmt.Errorf("failed to parse scl-parser output: %w", err)
-	}
-
-	return blocks, nil
+// Parse reads and parses the SCL file at path.
+func (DefaultSCLParser) Parse(path string) ([]*scl.Node, error) {
+	return scl.ParseFile(path)
 }
 
 // Loader is responsible for discovering, reading, and parsing config files.
@@ -91,16 +101,15 @@ type Loader struct {
 }
 
 // NewLoader creates a new Loader instance.
-// parserPath is the absolute path to the scl-parser binary.
-func NewLoader(parserPath string) *Loader {
+func NewLoader() *Loader {
 	return &Loader{
-		Parser:     &DefaultSCLParser{ParserPath: parserPath},
+		Parser:     DefaultSCLParser{},
 		FileReader: os.ReadFile,
 	}
 }
 
 // LoadSimpleSCL loads and parses 'simple.scl' from the specified directory.
-// It also attempts to load a '.env' file if present to populate environment variables.
+// Dotenv files are read later, by GetEnv, for the environment asked for.
 func (l *Loader) LoadSimpleSCL(dir string) (*SimpleSCL, error) {
 	path := filepath.Join(dir, "simple.scl")
 
@@ -112,109 +121,246 @@ func (l *Loader) LoadSimpleSCL(dir string) (*SimpleSCL, error) {
 		return nil, fmt.Errorf("cannot access simple.scl: %w", err)
 	}
 
-	// Try to load .env file if it exists, but don't fail if it's missing or invalid
-	envPath := filepath.Join(dir, ".env")
-	if _, err := os.Stat(envPath); err == nil {
-		if err := godotenv.Load(envPath); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: failed to load .env file %s, continuing without it: %v\n", envPath, err)
-		}
-	}
-
-	// Parse SCL file to JSON AST
+	// Parse SCL file to AST
 	blocks, err := l.Parser.Parse(path)
 	if err != nil {
+		// Keys are lowercase with underscores, so a key with a dash in it is
+		// a syntax error rather than an unknown key; say which key it was.
+		if serr, ok := err.(*scl.Error); ok && serr.Text != "" {
+			if s := suggest(serr.Text, schemaKeys(SimpleSCLSchema)); s != "" {
+				serr.Msg += fmt.Sprintf("; did you mean %q?", s)
+			}
+		}
 		return nil, err
 	}
 
-	return extractConfig(blocks)
+	cfg, err := extractConfig(blocks)
+	if verr, ok := err.(*ValidationError); ok {
+		verr.Path = path
+	}
+	if cfg != nil {
+		cfg.Dir = dir
+	}
+	return cfg, err
 }
 
-// GetEnv retrieves the configuration for a named environment.
-// It resolves any environment variables references (starting with $) in values.
+// GetEnv retrieves the configuration for a named environment with its values
+// resolved: environment variables, ${NAME:-default} interpolation, file: and
+// cmd: secrets (see Resolver). Variables come from the process environment
+// and the environment's dotenv files (see Dotenv). Only the named environment
+// is resolved, and each value once per SimpleSCL. The API key is registered
+// as a secret, so Redact keeps it out of output and error messages.
 func (s *SimpleSCL) GetEnv(name string) (*Environment, error) {
 	env, ok := s.Environments[name]
 	if !ok {
-		return nil, fmt.Errorf("environment '%s' not defined in simple.scl", name)
+		return nil, s.unknownEnvError(name)
+	}
+
+	r, err := s.resolver(name)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create a copy to avoid modifying the original during resolution
-	resolved := &Environment{
-		Name:     env.Name,
-		Endpoint: resolveEnvVar(env.Endpoint),
-		APIKey:   resolveEnvVar(env.APIKey),
+	endpoint, err := r.Resolve(env.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint of environment '%s': %w", name, err)
 	}
+	apiKey, err := r.Resolve(env.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("api_key of environment '%s': %w", name, err)
+	}
+	RegisterSecret(apiKey)
 
 	// Validate API key availability after resolution
-	if resolved.APIKey == "" {
-		if strings.HasPrefix(env.APIKey, "$") {
-			return nil, fmt.Errorf("environment variable %s not set", strings.TrimPrefix(env.APIKey, "$"))
+	if apiKey == "" {
+		if v, set := r.variable(env.APIKey); v != "" && set {
+			return nil, fmt.Errorf("environment variable %s is empty", v)
+		} else if v != "" {
+			return nil, fmt.Errorf("environment variable %s not set", v)
 		}
 		return nil, fmt.Errorf("API key not configured for environment '%s'", name)
 	}
 
-	return resolved, nil
+	// Return a copy so the raw values stay as written
+	return &Environment{Name: env.Name, Endpoint: endpoint, APIKey: apiKey, Release: s.IsRelease(name)}, nil
 }
 
-// resolveEnvVar checks if a value starts with '$' and substitutes it with the OS environment variable.
-// Otherwise, it returns the value as is.
-func resolveEnvVar(value string) string {
-	if strings.HasPrefix(value, "$") {
-		envVar := strings.TrimPrefix(value, "$")
-		return os.Getenv(envVar)
+// Stages returns the environment names in the order versions are promoted
+// through them: Pipeline, which LoadSimpleSCL sets to the order simple.scl
+// declares its environments in when it has no pipeline. A SimpleSCL built
+// without one has no declared order, and gets its environment names sorted.
+func (s *SimpleSCL) Stages() []string {
+	if len(s.Pipeline) > 0 {
+		return s.Pipeline
+	}
+	names := make([]string, 0, len(s.Environments))
+	for name := range s.Environments {
+		names = append(names, name)
 	}
-	return value
+	sort.Strings(names)
+	return names
 }
 
-// extractConfig transforms the SCL AST into a strongly-typed SimpleSCL struct.
-func extractConfig(blocks []SCLBlock) (*SimpleSCL, error) {
-	cfg := &SimpleSCL{Environments: make(map[string]*Environment)}
+// IsRelease reports whether deploys to the named environment are releases.
+// The environments marked release are; when none is marked, prod is.
+func (s *SimpleSCL) IsRelease(name string) bool {
+	for _, env := range s.Environments {
+		if env.Release {
+			env, ok := s.Environments[name]
+			return ok && env.Release
+		}
+	}
+	return name == "prod"
+}
+
+// Releases returns the release environments in pipeline order.
+func (s *SimpleSCL) Releases() []string {
+	var names []string
+	for _, name := range s.Stages() {
+		if s.IsRelease(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Next returns the environment after name in the pipeline, or "" when name
+// is the last.
+func (s *SimpleSCL) Next(name string) string {
+	stages := s.Stages()
+	for i, stage := range stages {
+		if stage == name && i+1 < len(stages) {
+			return stages[i+1]
+		}
+	}
+	return ""
+}
+
+// CheckPromotion reports why a version cannot be promoted from one
+// environment to another: either is not defined, or to does not come after
+// from in the pipeline.
+func (s *SimpleSCL) CheckPromotion(from, to string) error {
+	for _, name := range []string{from, to} {
+		if _, ok := s.Environments[name]; !ok {
+			return s.unknownEnvError(name)
+		}
+	}
+	if from == to {
+		return fmt.Errorf("cannot promote from %s to itself", from)
+	}
+	if slices.Index(s.Stages(), to) < slices.Index(s.Stages(), from) {
+		return fmt.Errorf("cannot promote from %s to %s: %s comes before %s in the pipeline %s", from, to, to, from, strings.Join(s.Stages(), " → "))
+	}
+	return nil
+}
+
+// unknownEnvError reports an environment simple.scl does not define, with
+// the ones it does.
+func (s *SimpleSCL) unknownEnvError(name string) error {
+	return fmt.Errorf("environment '%s' not defined in simple.scl; expected one of %s", name, strings.Join(s.Stages(), ", "))
+}
+
+// Setting is one value of an environment as GetEnv resolves it, and where
+// the value came from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// Settings resolves a named environment like GetEnv and describes each of
+// its values. Secret values are returned redacted.
+func (s *SimpleSCL) Settings(name string) ([]Setting, error) {
+	env, err := s.GetEnv(name)
+	if err != nil {
+		return nil, err
+	}
+	r, _ := s.resolver(name)
+	raw := s.Environments[name]
+	return []Setting{
+		{Key: "tenant", Value: s.Tenant, Source: "simple.scl"},
+		{Key: "endpoint", Value: Redact(env.Endpoint), Source: r.Source(raw.Endpoint)},
+		{Key: "api_key", Value: redactedText, Source: r.Source(raw.APIKey), Secret: true},
+	}, nil
+}
+
+// resolver returns the Resolver for a named environment, reading its dotenv
+// files the first time.
+func (s *SimpleSCL) resolver(name string) (*Resolver, error) {
+	if s.Resolver != nil {
+		return s.Resolver, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.resolvers[name]; ok {
+		return r, nil
+	}
+	dir := s.Dir
+	if dir == "" {
+		dir = "."
+	}
+	vars, err := LoadDotenv(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	if s.resolvers == nil {
+		s.resolvers = map[string]*Resolver{}
+	}
+	s.resolvers[name] = NewResolver(vars)
+	return s.resolvers[name], nil
+}
+
+// extractConfig validates the SCL AST with ValidateSimpleSCL and
+// transforms it into a strongly-typed SimpleSCL struct. Any departure from the
+// schema is returned as a *ValidationError listing every problem found.
+func extractConfig(blocks []*scl.Node) (*SimpleSCL, error) {
+	if problems := ValidateSimpleSCL(blocks); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
 
+	cfg := &SimpleSCL{Environments: make(map[string]*Environment)}
+	var declared []string
 	for _, block := range blocks {
 		switch block.Key {
 		case "tenant":
-			// tenant is a KV, so Value contains the tenant name
-			if s, ok := block.Value.(string); ok {
-				cfg.Tenant = s
+			cfg.Tenant = block.Value().String()
+		case "pipeline":
+			cfg.Pipeline = block.Names()
+		case "timeouts":
+			for _, child := range block.Children {
+				d, _ := time.ParseDuration(child.Value().String())
+				switch child.Key {
+				case "connect":
+					cfg.Timeouts.Connect = d
+				case "manifest":
+					cfg.Timeouts.Manifest = d
+				case "upload":
+					cfg.Timeouts.Upload = d
+				case "deploy":
+					cfg.Timeouts.Deploy = d
+				case "install":
+					cfg.Timeouts.Install = d
+				}
 			}
 		case "env":
-			// env is a block with Name (the env name) and Children (properties)
-			if block.Name != "" {
-				envName := block.Name
-				env := &Environment{Name: envName}
-
-				for _, child := range block.Children {
-					if child.Type == "kv" {
-						switch child.Key {
-						case "endpoint":
-							if s, ok := child.Value.(string); ok {
-								env.Endpoint = s
-							}
-						case "api_key":
-							if s, ok := child.Value.(string); ok {
-								env.APIKey = s
-							}
-						}
-					}
-				}
-
-				if env.Endpoint == "" {
-					return nil, fmt.Errorf("environment '%s' missing endpoint", envName)
-				}
-				if env.APIKey == "" {
-					return nil, fmt.Errorf("environment '%s' missing api_key", envName)
+			env := &Environment{Name: block.Name()}
+			for _, child := range block.Children {
+				switch child.Key {
+				case "endpoint":
+					env.Endpoint = child.Value().String()
+				case "api_key":
+					env.APIKey = child.Value().String()
+				case "release":
+					env.Release = child.Value().Bool
 				}
-
-				cfg.Environments[envName] = env
 			}
+			cfg.Environments[env.Name] = env
+			declared = append(declared, env.Name)
 		}
 	}
-
-	if cfg.Tenant == "" {
-		return nil, fmt.Errorf("tenant not defined in simple.scl")
-	}
-
-	if len(cfg.Environments) == 0 {
-		return nil, fmt.Errorf("no environments defined in simple.scl")
+	if cfg.Pipeline == nil {
+		cfg.Pipeline = declared
 	}
 
 	return cfg, nil
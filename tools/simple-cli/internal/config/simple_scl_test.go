@@ -3,17 +3,28 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"simple-cli/internal/scl"
 	"strings"
 	"testing"
+	"time"
 )
 
 // MockSCLParser is a mock implementation of SCLParser for testing.
 type MockSCLParser struct {
-	Result []SCLBlock
+	Result []*scl.Node
 	Err    error
 }
 
-func (m *MockSCLParser) Parse(_ string) ([]SCLBlock, error) {
+// parseSCL parses SCL source for a test table, where there is no t to fail.
+func parseSCL(src string) []*scl.Node {
+	nodes, err := scl.Parse([]byte(src))
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+}
+
+func (m *MockSCLParser) Parse(_ string) ([]*scl.Node, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -41,36 +52,21 @@ func TestLoader_LoadSimpleSCL(t *testing.T) {
 				return dir
 			},
 			parser: &MockSCLParser{
-				Result: []SCLBlock{
-					{Type: "kv", Key: "tenant", Value: "acme"},
-					{
-						Type: "block",
-						Key:  "env",
-						Name: "dev",
-						Children: []SCLBlock{
-							{Type: "kv", Key: "endpoint", Value: "acme-dev.on.simple.dev"},
-							{Type: "kv", Key: "api_key", Value: "$SIMPLE_DEV_API_KEY"},
-						},
-					},
-					{
-						Type: "block",
-						Key:  "env",
-						Name: "staging",
-						Children: []SCLBlock{
-							{Type: "kv", Key: "endpoint", Value: "acme-staging.on.simple.dev"},
-							{Type: "kv", Key: "api_key", Value: "$SIMPLE_STAGING_API_KEY"},
-						},
-					},
-					{
-						Type: "block",
-						Key:  "env",
-						Name: "prod",
-						Children: []SCLBlock{
-							{Type: "kv", Key: "endpoint", Value: "acme.on.simple.dev"},
-							{Type: "kv", Key: "api_key", Value: "$SIMPLE_PROD_API_KEY"},
-						},
-					},
-				},
+				Result: parseSCL(`
+					tenant acme
+					env dev {
+					  endpoint acme-dev.on.simple.dev
+					  api_key $SIMPLE_DEV_API_KEY
+					}
+					env staging {
+					  endpoint acme-staging.on.simple.dev
+					  api_key $SIMPLE_STAGING_API_KEY
+					}
+					env prod {
+					  endpoint acme.on.simple.dev
+					  api_key $SIMPLE_PROD_API_KEY
+					}
+				`),
 			},
 			wantEnvs: []string{"dev", "staging", "prod"},
 			wantErr:  false,
@@ -111,18 +107,12 @@ func TestLoader_LoadSimpleSCL(t *testing.T) {
 				return dir
 			},
 			parser: &MockSCLParser{
-				Result: []SCLBlock{
-					{Type: "kv", Key: "tenant", Value: "test"},
-					{
-						Type: "block",
-						Key:  "env",
-						Name: "dev",
-						Children: []SCLBlock{
-							{Type: "kv", Key: "api_key", Value: "$SIMPLE_DEV_API_KEY"},
-							// Missing endpoint
-						},
-					},
-				},
+				Result: parseSCL(`
+					tenant test
+					env dev {
+					  api_key $SIMPLE_DEV_API_KEY
+					}
+				`),
 			},
 			wantErr:     true,
 			errContains: "missing endpoint",
@@ -138,18 +128,12 @@ func TestLoader_LoadSimpleSCL(t *testing.T) {
 				return dir
 			},
 			parser: &MockSCLParser{
-				Result: []SCLBlock{
-					{Type: "kv", Key: "tenant", Value: "test"},
-					{
-						Type: "block",
-						Key:  "env",
-						Name: "dev",
-						Children: []SCLBlock{
-							{Type: "kv", Key: "endpoint", Value: "test-dev.on.simple.dev"},
-							// Missing api_key
-						},
-					},
-				},
+				Result: parseSCL(`
+					tenant test
+					env dev {
+					  endpoint test-dev.on.simple.dev
+					}
+				`),
 			},
 			wantErr:     true,
 			errContains: "missing api_key",
@@ -165,9 +149,9 @@ func TestLoader_LoadSimpleSCL(t *testing.T) {
 				return dir
 			},
 			parser: &MockSCLParser{
-				Result: []SCLBlock{
-					{Type: "kv", Key: "other_block", Value: "something"},
-				},
+				Result: parseSCL(`
+					other_block something
+				`),
 			},
 			wantErr:     true,
 			errContains: "tenant not defined",
@@ -183,21 +167,16 @@ func TestLoader_LoadSimpleSCL(t *testing.T) {
 				return dir
 			},
 			parser: &MockSCLParser{
-				Result: []SCLBlock{
-					{Type: "kv", Key: "tenant", Value: "test"},
-					{
-						Type: "block",
-						Key:  "env",
-						Name: "", // Empty name
-						Children: []SCLBlock{
-							{Type: "kv", Key: "endpoint", Value: "test-dev.on.simple.dev"},
-							{Type: "kv", Key: "api_key", Value: "$SIMPLE_DEV_API_KEY"},
-						},
-					},
-				},
+				Result: parseSCL(`
+					tenant test
+					env {
+					  endpoint test-dev.on.simple.dev
+					  api_key $SIMPLE_DEV_API_KEY
+					}
+				`),
 			},
 			wantErr:     true,
-			errContains: "no environments defined",
+			errContains: "simple.scl:3:6: env block needs a name",
 		},
 	}
 
@@ -313,6 +292,22 @@ func TestSimpleSCL_GetEnv(t *testing.T) {
 			wantErr:      true,
 			errContains:  "UNSET_VAR not set",
 		},
+		{
+			name: "env var empty",
+			cfg: &SimpleSCL{
+				Environments: map[string]*Environment{
+					"dev": {
+						Name:     "dev",
+						Endpoint: "devops.acme.simple.lcl",
+						APIKey:   "${EMPTY_VAR}",
+					},
+				},
+			},
+			envName:      "dev",
+			setupEnvVars: map[string]string{"EMPTY_VAR": ""},
+			wantErr:      true,
+			errContains:  "environment variable EMPTY_VAR is empty",
+		},
 		{
 			name: "endpoint with env var",
 			cfg: &SimpleSCL{
@@ -367,57 +362,115 @@ func TestSimpleSCL_GetEnv(t *testing.T) {
 	}
 }
 
-func TestResolveEnvVar(t *testing.T) {
+func TestSimpleSCL_Pipeline(t *testing.T) {
+	env := func(name string, release bool) string {
+		src := "env " + name + " {\n  endpoint e\n  api_key k\n"
+		if release {
+			src += "  release true\n"
+		}
+		return src + "}\n"
+	}
 	tests := []struct {
-		name     string
-		value    string
-		envVars  map[string]string
-		expected string
+		name        string
+		src         string
+		wantStages  string
+		wantRelease string
 	}{
 		{
-			name:     "env var resolved",
-			value:    "$MY_VAR",
-			envVars:  map[string]string{"MY_VAR": "resolved-value"},
-			expected: "resolved-value",
+			name:        "declared order with prod as the release",
+			src:         "tenant acme\n" + env("dev", false) + env("staging", false) + env("prod", false),
+			wantStages:  "dev, staging, prod",
+			wantRelease: "prod",
 		},
 		{
-			name:     "literal value unchanged",
-			value:    "literal",
-			envVars:  nil,
-			expected: "literal",
+			name:        "pipeline overrides declared order",
+			src:         "tenant acme\npipeline dev, qa, uat, prod\n" + env("prod", true) + env("uat", false) + env("dev", false) + env("qa", false),
+			wantStages:  "dev, qa, uat, prod",
+			wantRelease: "prod",
 		},
 		{
-			name:     "unset env var returns empty",
-			value:    "$UNSET",
-			envVars:  nil,
-			expected: "",
+			name:        "release marks replace prod",
+			src:         "tenant acme\npipeline test, prod, live\n" + env("test", false) + env("prod", false) + env("live", true),
+			wantStages:  "test, prod, live",
+			wantRelease: "live",
 		},
 		{
-			name:     "dollar sign in middle preserved",
-			value:    "prefix$suffix",
-			envVars:  nil,
-			expected: "prefix$suffix",
+			name:        "several release environments",
+			src:         "tenant acme\npipeline dev, eu, us\n" + env("dev", false) + env("eu", true) + env("us", true),
+			wantStages:  "dev, eu, us",
+			wantRelease: "eu, us",
+		},
+		{
+			name:        "no release environment",
+			src:         "tenant acme\n" + env("dev", false) + env("qa", false),
+			wantStages:  "dev, qa",
+			wantRelease: "",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for k, v := range tt.envVars {
-				t.Setenv(k, v)
+			cfg, err := extractConfig(parseSCL(tt.src))
+			if err != nil {
+				t.Fatalf("extractConfig() error = %v", err)
+			}
+			if got := strings.Join(cfg.Stages(), ", "); got != tt.wantStages {
+				t.Errorf("Stages() = %q, want %q", got, tt.wantStages)
+			}
+			if got := strings.Join(cfg.Releases(), ", "); got != tt.wantRelease {
+				t.Errorf("Releases() = %q, want %q", got, tt.wantRelease)
 			}
 
-			result := resolveEnvVar(tt.value)
-			if result != tt.expected {
-				t.Errorf("resolveEnvVar(%q) = %q, want %q", tt.value, result, tt.expected)
+			_, err = cfg.GetEnv("staging-2")
+			want := "environment 'staging-2' not defined in simple.scl; expected one of " + tt.wantStages
+			if err == nil || err.Error() != want {
+				t.Errorf("GetEnv() error = %v, want %q", err, want)
 			}
 		})
 	}
 }
 
+func TestSimpleSCL_Stages_WithoutPipeline(t *testing.T) {
+	// Declared neither in pipeline nor alphabetical order: promotion follows
+	// the declarations, whatever the names.
+	src := "tenant acme\n"
+	for _, name := range []string{"staging", "prod", "dev"} {
+		src += "env " + name + " {\n  endpoint e\n  api_key k\n}\n"
+	}
+	cfg, err := extractConfig(parseSCL(src))
+	if err != nil {
+		t.Fatalf("extractConfig() error = %v", err)
+	}
+	if got := strings.Join(cfg.Stages(), ", "); got != "staging, prod, dev" {
+		t.Errorf("Stages() = %q, want the declared order", got)
+	}
+	if got := cfg.Next("staging"); got != "prod" {
+		t.Errorf("Next(staging) = %q, want prod", got)
+	}
+
+	// Built by hand, a config has no declared order to follow.
+	cfg = &SimpleSCL{Environments: map[string]*Environment{"staging": {}, "prod": {}, "dev": {}}}
+	if got := strings.Join(cfg.Stages(), ", "); got != "dev, prod, staging" {
+		t.Errorf("Stages() without a pipeline = %q, want the names sorted", got)
+	}
+}
+
+func TestSimpleSCL_Timeouts(t *testing.T) {
+	src := "tenant acme\ntimeouts {\n  manifest 1m\n  install 1h30m\n}\nenv dev {\n  endpoint e\n  api_key k\n}\n"
+	cfg, err := extractConfig(parseSCL(src))
+	if err != nil {
+		t.Fatalf("extractConfig() error = %v", err)
+	}
+	want := Timeouts{Manifest: time.Minute, Install: 90 * time.Minute}
+	if cfg.Timeouts != want {
+		t.Errorf("Timeouts = %+v, want %+v", cfg.Timeouts, want)
+	}
+}
+
 func TestExtractEnvironments(t *testing.T) {
 	tests := []struct {
 		name        string
-		blocks      []SCLBlock
+		blocks      []*scl.Node
 		wantEnvs    int
 		wantTenant  string
 		wantErr     bool
@@ -425,71 +478,62 @@ func TestExtractEnvironments(t *testing.T) {
 	}{
 		{
 			name: "multiple environments with tenant",
-			blocks: []SCLBlock{
-				{Type: "kv", Key: "tenant", Value: "acme"},
-				{
-					Type: "block",
-					Key:  "env",
-					Name: "dev",
-					Children: []SCLBlock{
-						{Type: "kv", Key: "endpoint", Value: "acme-dev.on.simple.dev"},
-						{Type: "kv", Key: "api_key", Value: "key1"},
-					},
-				},
-				{
-					Type: "block",
-					Key:  "env",
-					Name: "prod",
-					Children: []SCLBlock{
-						{Type: "kv", Key: "endpoint", Value: "acme.on.simple.dev"},
-						{Type: "kv", Key: "api_key", Value: "key2"},
-					},
-				},
-			},
+			blocks: parseSCL(`
+				tenant acme
+				env dev {
+				  endpoint acme-dev.on.simple.dev
+				  api_key key1
+				}
+				env prod {
+				  endpoint acme.on.simple.dev
+				  api_key key2
+				}
+			`),
 			wantEnvs:   2,
 			wantTenant: "acme",
 			wantErr:    false,
 		},
 		{
-			name: "ignores non-env blocks",
-			blocks: []SCLBlock{
-				{Type: "kv", Key: "tenant", Value: "test"},
-				{Type: "kv", Key: "other", Value: "something"},
-				{
-					Type: "block",
-					Key:  "env",
-					Name: "dev",
-					Children: []SCLBlock{
-						{Type: "kv", Key: "endpoint", Value: "dev.example.com"},
-						{Type: "kv", Key: "api_key", Value: "key1"},
-					},
-				},
-			},
-			wantEnvs:   1,
-			wantTenant: "test",
-			wantErr:    false,
+			name: "rejects unknown top-level keys",
+			blocks: parseSCL(`
+				tenant test
+				other something
+				env dev {
+				  endpoint dev.example.com
+				  api_key key1
+				}
+			`),
+			wantErr:     true,
+			errContains: `3:5: unknown key "other" in simple.scl; expected one of tenant, pipeline, timeouts, env`,
 		},
 		{
 			name:        "empty blocks",
-			blocks:      []SCLBlock{},
+			blocks:      nil,
 			wantErr:     true,
 			errContains: "tenant not defined",
 		},
+		{
+			name: "rejects unknown environment keys",
+			blocks: parseSCL(`
+				tenant myco
+				env dev {
+				  endpoint dev.example.com
+				  api_key key1
+				  extra 123
+				}
+			`),
+			wantErr:     true,
+			errContains: `unknown key "extra" in environment 'dev'`,
+		},
 		{
 			name: "handles non-string values gracefully",
-			blocks: []SCLBlock{
-				{Type: "kv", Key: "tenant", Value: "myco"},
-				{
-					Type: "block",
-					Key:  "env",
-					Name: "dev",
-					Children: []SCLBlock{
-						{Type: "kv", Key: "endpoint", Value: "dev.example.com"},
-						{Type: "kv", Key: "api_key", Value: "key1"},
-						{Type: "kv", Key: "extra", Value: 123}, // Non-string value
-					},
-				},
-			},
+			blocks: parseSCL(`
+				tenant myco
+				env dev {
+				  endpoint dev.example.com
+				  api_key 123
+				}
+			`),
 			wantEnvs:   1,
 			wantTenant: "myco",
 			wantErr:    false,
@@ -528,7 +572,7 @@ func TestExtractEnvironments(t *testing.T) {
 }
 
 func TestNewLoader(t *testing.T) {
-	loader := NewLoader("/path/to/scl-parser")
+	loader := NewLoader()
 
 	if loader == nil {
 		t.Fatal("NewLoader() returned nil")
@@ -542,12 +586,7 @@ func TestNewLoader(t *testing.T) {
 		t.Error("NewLoader() fileReader is nil")
 	}
 
-	// Check that parser has correct path
-	if parser, ok := loader.Parser.(*DefaultSCLParser); ok {
-		if parser.ParserPath != "/path/to/scl-parser" {
-			t.Errorf("NewLoader() parser path = %q, want %q", parser.ParserPath, "/path/to/scl-parser")
-		}
-	} else {
+	if _, ok := loader.Parser.(DefaultSCLParser); !ok {
 		t.Error("NewLoader() parser is not DefaultSCLParser")
 	}
 }
@@ -567,31 +606,28 @@ func (e *mockError) Error() string {
 func TestDefaultSCLParser_Parse(t *testing.T) {
 	tests := []struct {
 		name        string
-		parserPath  string
-		setupFile   func(t *testing.T) string
+		content     string
 		wantErr     bool
 		errContains string
 	}{
 		{
-			name:       "parser not found",
-			parserPath: "/nonexistent/scl-parser",
-			setupFile: func(t *testing.T) string {
-				dir := t.TempDir()
-				path := filepath.Join(dir, "test.scl")
-				_ = os.WriteFile(path, []byte("key value"), 0644)
-				return path
-			},
+			name:    "valid file",
+			content: "tenant acme\nenv dev {\n  endpoint acme-dev.on.simple.dev\n}\n",
+		},
+		{
+			name:        "syntax error is reported with its position",
+			content:     "tenant acme\nenv dev {\n",
 			wantErr:     true,
-			errContains: "execution failed",
+			errContains: "simple.scl:2:1: unterminated block `env`",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			path := tt.setupFile(t)
-			parser := &DefaultSCLParser{ParserPath: tt.parserPath}
+			path := filepath.Join(t.TempDir(), "simple.scl")
+			_ = os.WriteFile(path, []byte(tt.content), 0644)
 
-			_, err := parser.Parse(path)
+			nodes, err := DefaultSCLParser{}.Parse(path)
 
 			if tt.wantErr {
 				if err == nil {
@@ -606,6 +642,10 @@ func TestDefaultSCLParser_Parse(t *testing.T) {
 
 			if err != nil {
 				t.Errorf("Parse() unexpected error = %v", err)
+				return
+			}
+			if len(nodes) != 2 || nodes[1].Name() != "dev" {
+				t.Errorf("Parse() = %d nodes, want tenant and env dev", len(nodes))
 			}
 		})
 	}
@@ -675,18 +715,13 @@ func TestLoader_LoadSimpleSCL_WithDotEnv(t *testing.T) {
 
 	// Setup mocked parser
 	mockParser := &MockSCLParser{
-		Result: []SCLBlock{
-			{Type: "kv", Key: "tenant", Value: "acme"},
-			{
-				Type: "block",
-				Key:  "env",
-				Name: "dev",
-				Children: []SCLBlock{
-					{Type: "kv", Key: "endpoint", Value: "dev.example.com"},
-					{Type: "kv", Key: "api_key", Value: "$TEST_DOT_ENV_VAR"},
-				},
-			},
-		},
+		Result: parseSCL(`
+			tenant acme
+			env dev {
+			  endpoint dev.example.com
+			  api_key $TEST_DOT_ENV_VAR
+			}
+		`),
 	}
 
 	loader := &Loader{
@@ -694,18 +729,11 @@ func TestLoader_LoadSimpleSCL_WithDotEnv(t *testing.T) {
 		FileReader: os.ReadFile,
 	}
 
-	// Load should trigger .env loading
 	cfg, err := loader.LoadSimpleSCL(dir)
 	if err != nil {
 		t.Fatalf("LoadSimpleSCL() unexpected error: %v", err)
 	}
 
-	// Check if env var is set in process
-	val := os.Getenv("TEST_DOT_ENV_VAR")
-	if val != "loaded_from_env_file" {
-		t.Errorf("Expected env var TEST_DOT_ENV_VAR to be 'loaded_from_env_file', got %q", val)
-	}
-
 	// Check if GetEnv resolves it correctly
 	env, err := cfg.GetEnv("dev")
 	if err != nil {
@@ -715,6 +743,11 @@ func TestLoader_LoadSimpleSCL_WithDotEnv(t *testing.T) {
 	if env.APIKey != "loaded_from_env_file" {
 		t.Errorf("GetEnv() APIKey = %q, want 'loaded_from_env_file'", env.APIKey)
 	}
+
+	// .env is kept out of the process environment
+	if val, ok := os.LookupEnv("TEST_DOT_ENV_VAR"); ok {
+		t.Errorf("Expected TEST_DOT_ENV_VAR to stay unset in the process, got %q", val)
+	}
 }
 
 func TestEnvironment_DevOpsEndpoint(t *testing.T) {
@@ -733,6 +766,16 @@ func TestEnvironment_DevOpsEndpoint(t *testing.T) {
 			endpoint: "acme.on.simple.dev",
 			want:     "devops.acme.on.simple.dev",
 		},
+		{
+			name:     "dev server",
+			endpoint: "http://127.0.0.1:4080/",
+			want:     "ws://127.0.0.1:4080",
+		},
+		{
+			name:     "dev server over TLS",
+			endpoint: "https://ci.example.com",
+			want:     "wss://ci.example.com",
+		},
 	}
 
 	for _, tt := range tests {
@@ -762,6 +805,11 @@ func TestEnvironment_IdentityEndpoint(t *testing.T) {
 			endpoint: "acme.on.simple.dev",
 			want:     "identity.acme.on.simple.dev",
 		},
+		{
+			name:     "dev server",
+			endpoint: "http://127.0.0.1:4080/",
+			want:     "http://127.0.0.1:4080",
+		},
 	}
 
 	for _, tt := range tests {
@@ -774,3 +822,38 @@ func TestEnvironment_IdentityEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestSimpleSCL_CheckPromotion(t *testing.T) {
+	cfg, err := extractConfig(parseSCL("tenant acme\npipeline dev, qa, uat, prod\n" +
+		"env dev {\n  endpoint e\n  api_key k\n}\nenv qa {\n  endpoint e\n  api_key k\n}\n" +
+		"env uat {\n  endpoint e\n  api_key k\n}\nenv prod {\n  endpoint e\n  api_key k\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		wantErr  string
+	}{
+		{from: "dev", to: "qa"},
+		{from: "qa", to: "prod"},
+		{from: "uat", to: "prod"},
+		{from: "prod", to: "uat", wantErr: "cannot promote from prod to uat: uat comes before prod in the pipeline dev → qa → uat → prod"},
+		{from: "qa", to: "qa", wantErr: "cannot promote from qa to itself"},
+		{from: "staging", to: "prod", wantErr: "environment 'staging' not defined in simple.scl; expected one of dev, qa, uat, prod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			err := cfg.CheckPromotion(tt.from, tt.to)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("CheckPromotion() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	for from, want := range map[string]string{"dev": "qa", "uat": "prod", "prod": "", "nowhere": ""} {
+		if got := cfg.Next(from); got != want {
+			t.Errorf("Next(%q) = %q, want %q", from, got, want)
+		}
+	}
+}
@@ -185,7 +185,7 @@ func (a *Authenticator) enrollAndAuthenticate(ctx context.Context, endpoint, raw
 // enrollKey calls POST /auth/api-key/enroll to register this machine's public key.
 // This is called at most once per API key per machine.
 func (a *Authenticator) enrollKey(ctx context.Context, endpoint, rawAPIKey string, publicJWK map[string]string) error {
-	url := fmt.Sprintf("https://%s/auth/api-key/enroll", endpoint)
+	url := identityURL(endpoint, "/auth/api-key/enroll")
 	body, err := json.Marshal(map[string]interface{}{
 		"api_key":    rawAPIKey,
 		"public_key": publicJWK,
@@ -219,7 +219,7 @@ func (a *Authenticator) enrollKey(ctx context.Context, endpoint, rawAPIKey strin
 
 // loginWithPoP calls POST /auth/login with the composed PoP auth string.
 func (a *Authenticator) loginWithPoP(ctx context.Context, endpoint, authString string) (string, error) {
-	url := fmt.Sprintf("https://%s/auth/login", endpoint)
+	url := identityURL(endpoint, "/auth/login")
 	body, err := json.Marshal(map[string]string{"api_key": authString})
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal login request: %w", err)
@@ -257,6 +257,16 @@ func (a *Authenticator) loginWithPoP(ctx context.Context, endpoint, authString s
 	return result.AccessToken, nil
 }
 
+// identityURL returns the URL of path on the Identity service at endpoint,
+// over HTTPS unless endpoint names its own scheme, as that of a local
+// `simple dev-server` does.
+func identityURL(endpoint, path string) string {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/") + path
+}
+
 // TenantEnvKey standardizes the cache key format isolating multiple tenants across the single cache map securely.
 func TenantEnvKey(tenant, env string) string {
 	return fmt.Sprintf("%s::%s", tenant, env)
@@ -265,7 +275,7 @@ func TenantEnvKey(tenant, env string) string {
 // VerifyJWT checks the JWT signature against the Identity service's JWKS.
 func (a *Authenticator) VerifyJWT(ctx context.Context, endpoint, token string) error {
 	// Fetch JWKS
-	jwksURL := fmt.Sprintf("https://%s/.well-known/jwks.json", endpoint)
+	jwksURL := identityURL(endpoint, "/.well-known/jwks.json")
 	req, err := http.NewRequestWithContext(ctx, "GET", jwksURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create JWKS request: %w", err)
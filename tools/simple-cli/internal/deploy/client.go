@@ -1,8 +1,15 @@
 package deploy
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -10,43 +17,163 @@ import (
 
 // Client handles deployment via Phoenix Channel.
 type Client struct {
-	endpoint string
-	jwt      string
-	appID    string
-	socket   *PhoenixSocket
-	channel  *PhoenixChannel
-	timeout  time.Duration
+	endpoint    string
+	jwt         string
+	appID       string
+	socket      *PhoenixSocket
+	shared      bool // socket belongs to another client (see Share)
+	channel     *PhoenixChannel
+	timeouts    Timeouts
+	concurrency int
+	fileTimeout time.Duration
+	chunkSize   int
+	compress    bool
+	encoding    string // negotiated when the channel is joined
+	onUpload    func(UploadProgress)
+	onReconnect func(Reconnect)
+	onInstall   func(InstallEvent)
+
+	// manifest and version are what SendManifest last sent, re-sent when
+	// SendFiles resumes after a dropped connection.
+	manifest map[string]FileInfo
+	version  string
 }
 
 // ClientConfig holds configuration for creating a Client.
 type ClientConfig struct {
 	Endpoint string
 	JWT      string
+
+	// Timeouts bounds each phase of a deploy. A phase left zero waits
+	// Timeout when that is set, and its entry of DefaultTimeouts otherwise.
+	Timeouts Timeouts
 	Timeout  time.Duration
+
+	// UploadConcurrency bounds how many files SendFiles uploads, and
+	// FetchFiles fetches, at once (DefaultUploadConcurrency when zero).
+	UploadConcurrency int
+	// FileTimeout bounds the wait for the server to accept one uploaded
+	// file before it is retried (DefaultFileTimeout when zero).
+	FileTimeout time.Duration
+	// ChunkSize is the largest file sent in one frame; larger files are
+	// streamed in chunks of this size (DefaultChunkSize when zero).
+	ChunkSize int
+	// NoCompression stops the client offering to compress uploads.
+	NoCompression bool
+	// OnUpload, when set, is told about each file SendFiles uploads or
+	// retries, and about resumed uploads. Calls are never concurrent.
+	OnUpload func(UploadProgress)
+	// OnReconnect, when set, is told about each attempt at restoring a
+	// dropped connection, and when it is restored.
+	OnReconnect func(Reconnect)
+	// OnInstall, when set, is told about the progress the server reports
+	// while it installs. Calls are never concurrent.
+	OnInstall func(InstallEvent)
 }
 
-// DefaultTimeout is the fallback wait for a channel reply when a caller does
-// not set one. Installs run synchronously server-side and scale with app size
+// DefaultTimeout is how long an install may take when a caller does not
+// say. Installs run synchronously server-side and scale with app size
 // (record-heavy apps routinely exceed a minute), so this must stay generous:
 // a short default silently reports a false failure while the install is still
 // running to completion on the server.
 const DefaultTimeout = 15 * time.Minute
 
+// Timeouts bounds the phases of a deploy, each the longest its phase may
+// take. The other phases answer quickly when the server is healthy, so that
+// waiting as long as an install for them only hides a hung server.
+type Timeouts struct {
+	// Connect bounds dialing the server and joining the deploy channel.
+	Connect time.Duration
+	// Manifest bounds the reply to the manifest, and to lookups such as
+	// the versions deployed.
+	Manifest time.Duration
+	// Upload bounds all of SendFiles; FileTimeout bounds each file.
+	Upload time.Duration
+	// Deploy bounds the reply to deploy.
+	Deploy time.Duration
+	// Install bounds an install, from the request until the version is
+	// installed.
+	Install time.Duration
+}
+
+// DefaultTimeouts are the timeouts of the phases a caller does not set.
+var DefaultTimeouts = Timeouts{
+	Connect:  30 * time.Second,
+	Manifest: 2 * time.Minute,
+	Upload:   30 * time.Minute,
+	Deploy:   5 * time.Minute,
+	Install:  DefaultTimeout,
+}
+
+// withDefaults fills in the phases t leaves zero, with fallback when it is
+// set and from DefaultTimeouts otherwise.
+func (t Timeouts) withDefaults(fallback time.Duration) Timeouts {
+	fill := func(d *time.Duration, def time.Duration) {
+		switch {
+		case *d > 0:
+		case fallback > 0:
+			*d = fallback
+		default:
+			*d = def
+		}
+	}
+	fill(&t.Connect, DefaultTimeouts.Connect)
+	fill(&t.Manifest, DefaultTimeouts.Manifest)
+	fill(&t.Upload, DefaultTimeouts.Upload)
+	fill(&t.Deploy, DefaultTimeouts.Deploy)
+	fill(&t.Install, DefaultTimeouts.Install)
+	return t
+}
+
+// within bounds ctx by d, the timeout of a phase, so that a wait it cuts
+// short fails with timeout rather than the context's error. A zero d, as in
+// a Client not made by NewClient, leaves ctx unbounded.
+func within(ctx context.Context, d time.Duration, timeout string) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, d, errors.New(timeout))
+}
+
+// leaveTimeout bounds the wait for the server to acknowledge Close leaving
+// the channel.
+const leaveTimeout = 2 * time.Second
+
+// Upload defaults. A file is small next to an install, so its reply comes
+// quickly or, on a flaky link, not at all; waiting the full DefaultTimeout
+// for it would stall the whole upload.
+const (
+	DefaultUploadConcurrency = 8
+	DefaultFileTimeout       = 2 * time.Minute
+	DefaultChunkSize         = 1 << 20
+)
+
 // NewClient creates a deployment client.
 func NewClient(cfg ClientConfig) *Client {
-	timeout := cfg.Timeout
-	if timeout == 0 {
-		timeout = DefaultTimeout
+	concurrency := cfg.UploadConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultUploadConcurrency
+	}
+	fileTimeout := cfg.FileTimeout
+	if fileTimeout == 0 {
+		fileTimeout = DefaultFileTimeout
 	}
 	return &Client{
-		endpoint: cfg.Endpoint,
-		jwt:      cfg.JWT,
-		timeout:  timeout,
+		endpoint:    cfg.Endpoint,
+		jwt:         cfg.JWT,
+		timeouts:    cfg.Timeouts.withDefaults(cfg.Timeout),
+		concurrency: concurrency,
+		fileTimeout: fileTimeout,
+		chunkSize:   cfg.ChunkSize,
+		compress:    !cfg.NoCompression,
+		onUpload:    cfg.OnUpload,
+		onReconnect: cfg.OnReconnect,
+		onInstall:   cfg.OnInstall,
 	}
 }
 
 // Connect establishes WebSocket connection to the Phoenix server.
-func (c *Client) Connect() error {
+func (c *Client) Connect(ctx context.Context) error {
 	endpoint := c.endpoint
 	if !strings.Contains(endpoint, "://") {
 		endpoint = fmt.Sprintf("wss://%s", endpoint)
@@ -57,17 +184,38 @@ func (c *Client) Connect() error {
 		return fmt.Errorf("invalid endpoint URL: %w", err)
 	}
 
+	ctx, cancel := within(ctx, c.timeouts.Connect, "connect timeout")
+	defer cancel()
 	socket := NewPhoenixSocket(endpointURL)
-	if err := socket.Connect(); err != nil {
+	if err := socket.ConnectContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
 		return fmt.Errorf("websocket connect failed: %w", err)
 	}
 
 	c.socket = socket
+	c.shared = false
 	return nil
 }
 
-// JoinChannel joins the deploy channel for the app.
-func (c *Client) JoinChannel(appID string) error {
+// Share returns a client on c's connection, configured by cfg but for the
+// endpoint and token, which are c's. Several apps are so deployed over one
+// socket, each client joining a channel of its own. Closing the client
+// leaves its channel and keeps the connection, which c closes; should the
+// connection drop, the client reconnects on a socket of its own.
+func (c *Client) Share(cfg ClientConfig) *Client {
+	cfg.Endpoint, cfg.JWT = c.endpoint, c.jwt
+	shared := NewClient(cfg)
+	shared.socket = c.socket
+	shared.shared = true
+	return shared
+}
+
+// JoinChannel joins the deploy channel for the app. Unless NoCompression is
+// set, the join offers the encodings uploads can be compressed with, and the
+// one the server picks, if any, is used for every file sent.
+func (c *Client) JoinChannel(ctx context.Context, appID string) error {
 	if c.socket == nil {
 		return fmt.Errorf("not connected to socket")
 	}
@@ -75,16 +223,38 @@ func (c *Client) JoinChannel(appID string) error {
 	c.appID = appID
 	channel := c.socket.Channel(fmt.Sprintf("deploy:%s", appID))
 
-	if err := channel.Join(c.timeout); err != nil {
+	params := map[string]any{}
+	if c.compress {
+		params["compression"] = supportedEncodings
+	}
+	ctx, cancel := within(ctx, c.timeouts.Connect, "join timeout")
+	defer cancel()
+	response, err := channel.JoinWith(ctx, params)
+	if err != nil {
 		return fmt.Errorf("failed to join channel: %w", err)
 	}
 
+	c.encoding = ""
+	if c.compress {
+		c.encoding = negotiatedEncoding(response, supportedEncodings)
+	}
 	c.channel = channel
 	return nil
 }
 
-// SendManifest sends file manifest and returns paths of needed files.
-func (c *Client) SendManifest(files map[string]FileInfo, version string) ([]string, error) {
+// SendManifest sends file manifest and returns paths of needed files. It is
+// sent again on a restored connection if the connection drops first.
+func (c *Client) SendManifest(ctx context.Context, files map[string]FileInfo, version string) ([]string, error) {
+	var needed []string
+	err := c.withReconnect(ctx, func() error {
+		var err error
+		needed, err = c.sendManifest(ctx, files, version)
+		return err
+	})
+	return needed, err
+}
+
+func (c *Client) sendManifest(ctx context.Context, files map[string]FileInfo, version string) ([]string, error) {
 	if c.channel == nil {
 		return nil, fmt.Errorf("not joined to channel")
 	}
@@ -99,6 +269,9 @@ func (c *Client) SendManifest(files map[string]FileInfo, version string) ([]stri
 		})
 	}
 
+	ctx, cancel := within(ctx, c.timeouts.Manifest, "manifest response timeout")
+	defer cancel()
+	c.manifest, c.version = files, version
 	ref, err := c.channel.Push("manifest", map[string]interface{}{
 		"files":   fileList,
 		"version": version,
@@ -157,13 +330,21 @@ func (c *Client) SendManifest(files map[string]FileInfo, version string) ([]stri
 	select {
 	case result := <-done:
 		return result.files, result.err
-	case <-time.After(c.timeout):
-		return nil, fmt.Errorf("manifest response timeout")
+	case <-c.socket.Lost():
+		return nil, fmt.Errorf("manifest: %w", errConnectionLost)
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
 	}
 }
 
-// SendFiles uploads multiple files in parallel.
-func (c *Client) SendFiles(files map[string]FileInfo, neededPaths []string) error {
+// SendFiles uploads the files of files at neededPaths, at most
+// UploadConcurrency at once. A file whose push cannot be queued or whose
+// reply does not come within FileTimeout is retried with jittered backoff;
+// one the server rejects fails the upload. When the connection drops, the
+// client reconnects, rejoins and re-sends the manifest SendManifest last
+// sent, then uploads only the files the server still needs. The upload
+// stops when ctx ends or the Upload timeout passes.
+func (c *Client) SendFiles(ctx context.Context, files map[string]FileInfo, neededPaths []string) error {
 	if c.channel == nil {
 		return fmt.Errorf("not joined to channel")
 	}
@@ -172,45 +353,242 @@ func (c *Client) SendFiles(files map[string]FileInfo, neededPaths []string) erro
 		return nil
 	}
 
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(neededPaths))
+	ctx, cancel := within(ctx, c.timeouts.Upload, "upload timeout")
+	defer cancel()
+	tracker := newUploadTracker(files, neededPaths, c.onUpload)
+	pending := neededPaths
+	for resumes := 0; ; resumes++ {
+		err := c.uploadFiles(ctx, files, pending, tracker)
+		if !errors.Is(err, errConnectionLost) || c.manifest == nil || resumes == maxUploadResumes {
+			return err
+		}
+		pending, err = c.resumeUpload(ctx)
+		if err != nil {
+			return fmt.Errorf("upload interrupted and could not resume: %w", err)
+		}
+		tracker.resumed(pending)
+	}
+}
+
+// resumeUpload reconnects after a dropped connection and re-sends the last
+// manifest, returning the files the server still needs.
+func (c *Client) resumeUpload(ctx context.Context) ([]string, error) {
+	if err := c.reconnect(ctx); err != nil {
+		return nil, err
+	}
+	return c.SendManifest(ctx, c.manifest, c.version)
+}
+
+// uploadFiles uploads paths with a bounded pool of workers, stopping at the
+// first file that fails for good or when ctx ends.
+func (c *Client) uploadFiles(ctx context.Context, files map[string]FileInfo, paths []string, tracker *uploadTracker) error {
+	queue := make(chan string)
+	stop := make(chan struct{})
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			close(stop)
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			fail(context.Cause(ctx))
+		case <-stop:
+		}
+	}()
+	defer fail(nil)
 
-	for _, path := range neededPaths {
+	workers := c.concurrency
+	if workers <= 0 {
+		workers = DefaultUploadConcurrency
+	}
+	var wg sync.WaitGroup
+	for range min(workers, len(paths)) {
 		wg.Add(1)
-		go func(p string) {
+		go func() {
 			defer wg.Done()
-			if fi, ok := files[p]; ok {
-				if err := c.sendFile(p, fi); err != nil {
-					errChan <- err
+			for path := range queue {
+				if err := c.uploadFile(path, files[path], tracker, stop); err != nil {
+					fail(err)
 				}
 			}
-		}(path)
+		}()
 	}
 
+feed:
+	for _, path := range paths {
+		if _, ok := files[path]; !ok {
+			continue
+		}
+		select {
+		case queue <- path:
+		case <-stop:
+			break feed
+		}
+	}
+	close(queue)
 	wg.Wait()
-	close(errChan)
 
-	// Return first error if any
-	for err := range errChan {
-		return err
-	}
+	return firstErr
+}
 
-	return nil
+// uploadFile sends one file, retrying transient failures.
+func (c *Client) uploadFile(path string, fi FileInfo, tracker *uploadTracker, stop <-chan struct{}) error {
+	for attempt := 1; ; attempt++ {
+		err := c.sendFile(path, fi, stop)
+		if err == nil {
+			tracker.uploaded(path)
+			return nil
+		}
+		var transient *transientError
+		if !errors.As(err, &transient) || attempt > len(uploadBackoff) {
+			return err
+		}
+		tracker.retrying(path, attempt, err)
+
+		select {
+		case <-time.After(withJitter(uploadBackoff[attempt-1])):
+		case <-stop:
+			return errUploadStopped
+		case <-c.socket.Lost():
+			return fmt.Errorf("upload of %s: %w", path, errConnectionLost)
+		}
+	}
 }
 
 // sendFile sends a single file using Phoenix V2 binary protocol.
 // Format: [metadata_len (4 bytes)] [metadata_json] [file_content]
-func (c *Client) sendFile(path string, fi FileInfo) error {
+// A file larger than ChunkSize is streamed as a series of chunks instead, so
+// that neither the client nor a frame ever holds all of it. With an encoding
+// negotiated, each frame's content is compressed when that makes it smaller,
+// and its metadata names the encoding; hash is always of the original file.
+func (c *Client) sendFile(path string, fi FileInfo, stop <-chan struct{}) error {
+	r, err := fi.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	chunkSize := c.chunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if fi.Size > int64(chunkSize) {
+		return c.sendChunks(path, fi, r, chunkSize, stop)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(r, fi.Size+1))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := checkUnchanged(path, fi, int64(len(content)), sha256.Sum256(content)); err != nil {
+		return err
+	}
+
+	payload, encoding, err := encodeFrame(c.encoding, path, content)
+	if err != nil {
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
 	metadata := map[string]string{
 		"path": path,
 		"hash": fi.Hash,
 	}
+	if encoding != "" {
+		metadata["encoding"] = encoding
+	}
+	return c.pushFrame(path, stop, func() (uint64, error) {
+		return c.channel.PushBinaryFile(metadata, payload)
+	})
+}
 
-	ref, err := c.channel.PushBinaryFile(metadata, fi.Content)
+// sendChunks streams a file from r in chunks of chunkSize, each sent once
+// the server accepted the one before. The content is hashed as it goes, and
+// the final chunk is only sent if it still matches fi.
+func (c *Client) sendChunks(path string, fi FileInfo, r io.Reader, chunkSize int, stop <-chan struct{}) error {
+	buf := make([]byte, chunkSize)
+	h := sha256.New()
+	var offset int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		h.Write(buf[:n])
+		final := offset+int64(n) >= fi.Size || n < chunkSize
+		if final {
+			var sum [sha256.Size]byte
+			copy(sum[:], h.Sum(nil))
+			if extra, _ := r.Read(make([]byte, 1)); extra > 0 {
+				n++ // more content than hashed: report the size as larger
+			}
+			if err := checkUnchanged(path, fi, offset+int64(n), sum); err != nil {
+				return err
+			}
+		}
+
+		chunk, encoding, err := encodeFrame(c.encoding, path, buf[:n])
+		if err != nil {
+			return fmt.Errorf("failed to compress %s: %w", path, err)
+		}
+		metadata := map[string]any{
+			"path":   path,
+			"hash":   fi.Hash,
+			"offset": offset,
+			"size":   fi.Size,
+			"final":  final,
+		}
+		if encoding != "" {
+			metadata["encoding"] = encoding
+		}
+		if err := c.pushFrame(path, stop, func() (uint64, error) {
+			return c.channel.PushFileChunk(metadata, chunk)
+		}); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		offset += int64(n)
+	}
+}
+
+// checkUnchanged refuses to send a file read from disk whose content no
+// longer matches the size and hash it was collected with. Content held in
+// memory was hashed from itself, or checked when fetched.
+func checkUnchanged(path string, fi FileInfo, size int64, sum [sha256.Size]byte) error {
+	if fi.Content != nil || fi.Source == "" {
+		return nil
+	}
+	if size != fi.Size || hex.EncodeToString(sum[:]) != fi.Hash {
+		return fmt.Errorf("%s changed since it was collected; deploy again", path)
+	}
+	return nil
+}
+
+// pushFrame pushes one frame of path's upload and waits for the server to
+// accept it.
+func (c *Client) pushFrame(path string, stop <-chan struct{}, push func() (uint64, error)) error {
+	ref, err := push()
 	if err != nil {
+		select {
+		case <-c.socket.Lost():
+			return fmt.Errorf("upload of %s: %w", path, errConnectionLost)
+		default:
+		}
+		if errors.Is(err, errSendQueueFull) {
+			return &transientError{fmt.Errorf("file push failed for %s: %w", path, err)}
+		}
 		return fmt.Errorf("file push failed for %s: %w", path, err)
 	}
 
+	timeout := c.fileTimeout
+	if timeout == 0 {
+		timeout = DefaultFileTimeout
+	}
+
 	done := make(chan error, 1)
 	c.channel.onRef(ref, func(payload any) {
 		resp, ok := payload.(map[string]any)
@@ -228,13 +606,76 @@ func (c *Client) sendFile(path string, fi FileInfo) error {
 	select {
 	case err := <-done:
 		return err
-	case <-time.After(c.timeout):
-		return fmt.Errorf("timeout waiting for file response for %s", path)
+	case <-c.socket.Lost():
+		c.channel.bindings.Delete(ref)
+		return fmt.Errorf("upload of %s: %w", path, errConnectionLost)
+	case <-stop:
+		c.channel.bindings.Delete(ref)
+		return errUploadStopped
+	case <-time.After(timeout):
+		c.channel.bindings.Delete(ref)
+		return &transientError{fmt.Errorf("timeout waiting for file response for %s", path)}
+	}
+}
+
+// Deploy triggers the actual deployment. When the connection drops before
+// the reply, the client reconnects and looks for the version SendManifest
+// sent among those deployed: the deploy may have completed, and deploying
+// again would then create the version twice. Only if it is missing is the
+// deploy sent again, after the manifest and any files the server no longer
+// has: the server keeps the manifest per join, and the rejoin lost it.
+func (c *Client) Deploy(ctx context.Context) (*DeployResult, error) {
+	ctx, cancel := within(ctx, c.timeouts.Deploy, "deploy response timeout")
+	defer cancel()
+	for drops := 0; ; drops++ {
+		result, err := c.deploy(ctx)
+		if !errors.Is(err, errConnectionLost) || drops == maxReconnects {
+			return result, err
+		}
+		if err := c.reconnect(ctx); err != nil {
+			return nil, fmt.Errorf("deploy interrupted: %w", err)
+		}
+		if result, err := c.deployedVersion(ctx); result != nil || err != nil {
+			return result, err
+		}
+		if err := c.resendManifest(ctx); err != nil {
+			return nil, fmt.Errorf("deploy interrupted and could not resume: %w", err)
+		}
+	}
+}
+
+// resendManifest sends the manifest SendManifest last sent on the channel
+// joined since, and uploads the files the server asks for again.
+func (c *Client) resendManifest(ctx context.Context) error {
+	if c.manifest == nil {
+		return nil
+	}
+	needed, err := c.SendManifest(ctx, c.manifest, c.version)
+	if err != nil {
+		return err
 	}
+	return c.SendFiles(ctx, c.manifest, needed)
 }
 
-// Deploy triggers the actual deployment.
-func (c *Client) Deploy() (*DeployResult, error) {
+// deployedVersion returns the result of a deploy of the version SendManifest
+// sent, or nil if that version is not deployed.
+func (c *Client) deployedVersion(ctx context.Context) (*DeployResult, error) {
+	if c.version == "" {
+		return nil, nil
+	}
+	versions, err := c.Versions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.Version == c.version {
+			return &DeployResult{AppID: c.appID, Version: v.Version, FileCount: v.FileCount}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *Client) deploy(ctx context.Context) (*DeployResult, error) {
 	if c.channel == nil {
 		return nil, fmt.Errorf("not joined to channel")
 	}
@@ -292,8 +733,10 @@ func (c *Client) Deploy() (*DeployResult, error) {
 	select {
 	case result := <-done:
 		return result.result, result.err
-	case <-time.After(c.timeout):
-		return nil, fmt.Errorf("deploy response timeout")
+	case <-c.socket.Lost():
+		return nil, fmt.Errorf("deploy: %w", errConnectionLost)
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
 	}
 }
 
@@ -304,8 +747,111 @@ type InstallResult struct {
 	Success bool   `json:"success"`
 }
 
-// Install triggers the installation of the app version.
-func (c *Client) Install() (*InstallResult, error) {
+// InstallEvent is progress the server pushes while it installs: the step it
+// reached, from an "install_progress" event, or a line of its log, from an
+// "install_log" event.
+type InstallEvent struct {
+	// Step names the step under way, such as a migration; empty for a log
+	// line. Current and Total count the records the step has processed,
+	// when it counts them.
+	Step    string
+	Current int
+	Total   int
+	// Level is how serious a log line is: "info", "warning" or "error".
+	Level   string
+	Message string
+}
+
+// installEvent reads the payload of an install_progress or install_log event.
+func installEvent(event string, payload any) InstallEvent {
+	fields, _ := payload.(map[string]any)
+	e := InstallEvent{}
+	e.Message, _ = fields["message"].(string)
+	if event == "install_log" {
+		e.Level, _ = fields["level"].(string)
+		if e.Level == "" {
+			e.Level = "info"
+		}
+		return e
+	}
+	e.Step, _ = fields["step"].(string)
+	if current, ok := fields["current"].(float64); ok {
+		e.Current = int(current)
+	}
+	if total, ok := fields["total"].(float64); ok {
+		e.Total = int(total)
+	}
+	return e
+}
+
+// Install triggers the installation of the latest deployed version of the
+// app. The server resolves which version that is.
+func (c *Client) Install(ctx context.Context) (*InstallResult, error) {
+	return c.install(ctx, map[string]any{})
+}
+
+// InstallVersion installs a specific deployed version of the app, such as an
+// earlier one to roll back to.
+func (c *Client) InstallVersion(ctx context.Context, version string) (*InstallResult, error) {
+	return c.install(ctx, map[string]any{"version": version})
+}
+
+// installPollInterval is how often the client asks which version is
+// installed while it waits for an install whose reply was lost.
+var installPollInterval = 2 * time.Second
+
+// install sends the install request. The server carries on installing when
+// the connection drops, so after reconnecting the client waits for the
+// version to be installed instead of asking for it again.
+func (c *Client) install(ctx context.Context, payload map[string]any) (*InstallResult, error) {
+	ctx, cancel := within(ctx, c.timeouts.Install, "install response timeout")
+	defer cancel()
+	result, err := c.installOnce(ctx, payload)
+	if !errors.Is(err, errConnectionLost) {
+		return result, err
+	}
+	if err := c.reconnect(ctx); err != nil {
+		return nil, fmt.Errorf("install interrupted: %w", err)
+	}
+	requested, _ := payload["version"].(string)
+	return c.awaitInstall(ctx, requested)
+}
+
+// awaitInstall polls the installed version until it is version, or the
+// version last deployed when version is empty, as the server resolves it.
+func (c *Client) awaitInstall(ctx context.Context, version string) (*InstallResult, error) {
+	if version == "" {
+		version = c.version
+	}
+	if version == "" {
+		versions, err := c.Versions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, fmt.Errorf("install interrupted: no version of %s is deployed", c.appID)
+		}
+		version = versions[0].Version
+	}
+
+	for ctx.Err() == nil {
+		installed, err := c.Installed(ctx)
+		var notInstalled *NotInstalledError
+		switch {
+		case err == nil && installed.Version == version:
+			return &InstallResult{AppID: c.appID, Version: version, Success: true}, nil
+		case err != nil && !errors.As(err, &notInstalled) && ctx.Err() == nil:
+			return nil, err
+		}
+		select {
+		case <-time.After(installPollInterval):
+		case <-ctx.Done():
+		}
+	}
+	return nil, fmt.Errorf("install of %s@%s interrupted and not finished (%w); it may still be running", c.appID, version, context.Cause(ctx))
+}
+
+func (c *Client) installOnce(ctx context.Context, payload map[string]any) (*InstallResult, error) {
 	if !c.IsConnected() {
 		return nil, fmt.Errorf("client not connected")
 	}
@@ -315,8 +861,17 @@ func (c *Client) Install() (*InstallResult, error) {
 		err    error
 	}, 1)
 
-	// Send install event with empty payload
-	ref, err := c.channel.Push("install", map[string]any{})
+	if c.onInstall != nil {
+		for _, event := range []string{"install_progress", "install_log"} {
+			unsubscribe := c.channel.On(event, func(payload any) {
+				c.onInstall(installEvent(event, payload))
+			})
+			defer unsubscribe()
+		}
+	}
+
+	requested, _ := payload["version"].(string)
+	ref, err := c.channel.Push("install", payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send install command: %w", err)
 	}
@@ -347,7 +902,7 @@ func (c *Client) Install() (*InstallResult, error) {
 		}
 
 		response, _ := resp["response"].(map[string]any)
-		version := ""
+		version := requested
 		if v, ok := response["version"].(string); ok {
 			version = v
 		}
@@ -365,17 +920,279 @@ func (c *Client) Install() (*InstallResult, error) {
 	select {
 	case result := <-done:
 		return result.result, result.err
-	case <-time.After(c.timeout):
-		return nil, fmt.Errorf("install response timeout")
+	case <-c.socket.Lost():
+		return nil, fmt.Errorf("install: %w", errConnectionLost)
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// InstalledVersion is the version of an app installed in an environment and
+// the manifest it was deployed with. Files carry no Content until FetchFiles
+// downloads it.
+type InstalledVersion struct {
+	AppID   string
+	Version string
+	Files   map[string]FileInfo
+}
+
+// Installed asks which version of the app is installed, and for its manifest.
+func (c *Client) Installed(ctx context.Context) (*InstalledVersion, error) {
+	response, err := c.query(ctx, "installed", map[string]any{})
+	if err != nil {
+		return nil, fmt.Errorf("installed version lookup failed: %w", err)
+	}
+
+	version, _ := response["version"].(string)
+	if version == "" {
+		return nil, &NotInstalledError{AppID: c.appID}
+	}
+
+	list, _ := response["files"].([]any)
+	files := make(map[string]FileInfo, len(list))
+	for _, f := range list {
+		entry, _ := f.(map[string]any)
+		path, _ := entry["path"].(string)
+		hash, _ := entry["hash"].(string)
+		size, _ := entry["size"].(float64)
+		if path == "" || hash == "" {
+			return nil, fmt.Errorf("manifest of %s@%s has an entry without a path or hash", c.appID, version)
+		}
+		files[path] = FileInfo{Path: path, Hash: hash, Size: int64(size)}
 	}
+
+	return &InstalledVersion{AppID: c.appID, Version: version, Files: files}, nil
+}
+
+// NotInstalledError reports that no version of an app is installed in the
+// environment.
+type NotInstalledError struct {
+	AppID string
+}
+
+func (e *NotInstalledError) Error() string {
+	return fmt.Sprintf("no version of %s is installed", e.AppID)
+}
+
+// FetchFiles downloads the content of the given paths of files, at most
+// UploadConcurrency at once, checking each against the hash the manifest
+// gives it. Files not yet downloaded when the connection drops are fetched
+// on a restored one.
+func (c *Client) FetchFiles(ctx context.Context, files map[string]FileInfo, paths []string) error {
+	return c.withReconnect(ctx, func() error { return c.fetchFiles(ctx, files, paths) })
+}
+
+// fetchFiles fetches with a bounded pool of workers, as uploadFiles
+// uploads, so that only so many contents are in flight at once. The first
+// fetch that fails stops the rest.
+func (c *Client) fetchFiles(ctx context.Context, files map[string]FileInfo, paths []string) error {
+	if c.channel == nil {
+		return fmt.Errorf("not joined to channel")
+	}
+
+	var pending []FileInfo
+	for _, path := range paths {
+		if fi, ok := files[path]; ok && fi.Content == nil {
+			pending = append(pending, fi)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	workers := c.concurrency
+	if workers <= 0 {
+		workers = DefaultUploadConcurrency
+	}
+	queue := make(chan FileInfo)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range min(workers, len(pending)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fi := range queue {
+				if ctx.Err() != nil {
+					continue
+				}
+				content, err := c.fetchFile(ctx, fi)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				fi.Content = content
+				mu.Lock()
+				files[fi.Path] = fi
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, fi := range pending {
+		select {
+		case queue <- fi:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return firstErr
+}
+
+// fetchFile downloads one file by its hash.
+func (c *Client) fetchFile(ctx context.Context, fi FileInfo) ([]byte, error) {
+	response, err := c.request(ctx, "fetch_file", map[string]any{"path": fi.Path, "hash": fi.Hash})
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed for %s: %w", fi.Path, err)
+	}
+
+	encoded, _ := response["content"].(string)
+	content, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed for %s: invalid content: %w", fi.Path, err)
+	}
+	if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != fi.Hash {
+		return nil, fmt.Errorf("fetch failed for %s: content does not match hash %s", fi.Path, fi.Hash)
+	}
+	return content, nil
+}
+
+// DeployedVersion is one version of an app deployed to an environment.
+type DeployedVersion struct {
+	Version    string    `json:"version"`
+	DeployedAt time.Time `json:"deployed_at"`
+	FileCount  int       `json:"file_count"`
+	Installed  bool      `json:"installed"`
+}
+
+// Versions lists the versions of the app deployed to the environment, newest
+// first.
+func (c *Client) Versions(ctx context.Context) ([]DeployedVersion, error) {
+	response, err := c.query(ctx, "versions", map[string]any{})
+	if err != nil {
+		return nil, fmt.Errorf("version listing failed: %w", err)
+	}
+
+	list, _ := response["versions"].([]any)
+	versions := make([]DeployedVersion, 0, len(list))
+	for _, v := range list {
+		entry, _ := v.(map[string]any)
+		dv := DeployedVersion{}
+		dv.Version, _ = entry["version"].(string)
+		if dv.Version == "" {
+			continue
+		}
+		if at, ok := entry["deployed_at"].(string); ok {
+			dv.DeployedAt, _ = time.Parse(time.RFC3339, at)
+		}
+		if fc, ok := entry["file_count"].(float64); ok {
+			dv.FileCount = int(fc)
+		}
+		dv.Installed, _ = entry["installed"].(bool)
+		versions = append(versions, dv)
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].DeployedAt.After(versions[j].DeployedAt) })
+	return versions, nil
+}
+
+// request pushes event and waits for its reply, returning the reply's
+// response. An error reply becomes an error with the server's message.
+func (c *Client) request(ctx context.Context, event string, payload map[string]any) (map[string]any, error) {
+	if c.channel == nil {
+		return nil, fmt.Errorf("not joined to channel")
+	}
+
+	ctx, cancel := within(ctx, c.timeouts.Manifest, event+" response timeout")
+	defer cancel()
+
+	ref, err := c.channel.Push(event, payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s push failed: %w", event, err)
+	}
+
+	done := make(chan struct {
+		response map[string]any
+		err      error
+	}, 1)
+
+	c.channel.onRef(ref, func(payload any) {
+		resp, ok := payload.(map[string]any)
+		if !ok {
+			done <- struct {
+				response map[string]any
+				err      error
+			}{nil, fmt.Errorf("invalid response format")}
+			return
+		}
+
+		response, _ := resp["response"].(map[string]any)
+		if status, _ := resp["status"].(string); status != "ok" {
+			msg := "request rejected"
+			if m, ok := response["message"].(string); ok {
+				msg = m
+			}
+			done <- struct {
+				response map[string]any
+				err      error
+			}{nil, fmt.Errorf("%s", msg)}
+			return
+		}
+
+		done <- struct {
+			response map[string]any
+			err      error
+		}{response, nil}
+	})
+
+	select {
+	case result := <-done:
+		return result.response, result.err
+	case <-c.socket.Lost():
+		return nil, fmt.Errorf("%s: %w", event, errConnectionLost)
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// query is request for events that only read, sent again on a restored
+// connection when the connection drops before the reply.
+func (c *Client) query(ctx context.Context, event string, payload map[string]any) (map[string]any, error) {
+	var response map[string]any
+	err := c.withReconnect(ctx, func() error {
+		var err error
+		response, err = c.request(ctx, event, payload)
+		return err
+	})
+	return response, err
 }
 
-// Close disconnects from the socket.
+// Close leaves the deploy channel, waiting briefly for the server to
+// acknowledge it, and disconnects from the socket unless it is shared. It is
+// also how a cancelled deploy lets the server know the client is gone.
 func (c *Client) Close() {
-	if c.channel != nil {
-		_ = c.channel.Leave()
+	if c.channel != nil && c.IsConnected() {
+		ctx, cancel := context.WithTimeout(context.Background(), leaveTimeout)
+		_ = c.channel.LeaveGracefully(ctx)
+		cancel()
 	}
-	if c.socket != nil {
+	if c.socket != nil && !c.shared {
 		c.socket.Disconnect()
 	}
 }
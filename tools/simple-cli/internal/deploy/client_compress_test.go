@@ -0,0 +1,116 @@
+package deploy
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClient_SendFiles_Compressed(t *testing.T) {
+	random := make([]byte, 4096)
+	_, _ = rand.Read(random)
+	bundle := strings.Repeat("export function handler(ctx) { return ctx.records.find('orders'); }\n", 200)
+
+	tests := []struct {
+		name         string
+		server       string
+		cfg          ClientConfig
+		wantOffered  string
+		wantEncoding map[string]string
+	}{
+		{
+			name:         "gzip negotiated",
+			server:       "gzip",
+			wantOffered:  "[gzip]",
+			wantEncoding: map[string]string{"bundle.js": "gzip", "random.bin": "", "logo.png": ""},
+		},
+		{
+			name:         "server without compression",
+			wantOffered:  "[gzip]",
+			wantEncoding: map[string]string{"bundle.js": "", "random.bin": "", "logo.png": ""},
+		},
+		{
+			name:         "server picks an encoding not offered",
+			server:       "br",
+			wantOffered:  "[gzip]",
+			wantEncoding: map[string]string{"bundle.js": "", "random.bin": "", "logo.png": ""},
+		},
+		{
+			name:         "compression disabled",
+			server:       "gzip",
+			cfg:          ClientConfig{NoCompression: true},
+			wantOffered:  "[]",
+			wantEncoding: map[string]string{"bundle.js": "", "random.bin": "", "logo.png": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &uploadServer{compression: tt.server, verify: true}
+			files := diskFiles(t, map[string]string{"bundle.js": bundle, "random.bin": string(random), "logo.png": bundle})
+			client, needed, _ := uploadFixture(t, s, files, tt.cfg)
+
+			if err := client.SendFiles(t.Context(), files, needed); err != nil {
+				t.Fatalf("SendFiles() error = %v", err)
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if got := fmt.Sprint(s.offered); got != tt.wantOffered {
+				t.Errorf("client offered %s, want %s", got, tt.wantOffered)
+			}
+			for path, want := range tt.wantEncoding {
+				if got := s.encodings[path]; len(got) != 1 || got[0] != want {
+					t.Errorf("%s sent with encodings %q, want %q", path, got, want)
+				}
+				if !s.accepted[path] {
+					t.Errorf("%s was not accepted", path)
+				}
+			}
+			raw := 2*len(bundle) + len(random)
+			if compressed := tt.wantEncoding["bundle.js"] != ""; compressed != (s.wireBytes < raw) {
+				t.Errorf("sent %d bytes for %d bytes of files", s.wireBytes, raw)
+			}
+		})
+	}
+}
+
+func TestClient_SendFiles_CompressedChunks(t *testing.T) {
+	s := &uploadServer{compression: "gzip", verify: true}
+	content := strings.Repeat("table orders { field total { type decimal } }\n", 100)
+	files := diskFiles(t, map[string]string{"tables.scl": content})
+	client, needed, _ := uploadFixture(t, s, files, ClientConfig{ChunkSize: 1024})
+
+	if err := client.SendFiles(t.Context(), files, needed); err != nil {
+		t.Fatalf("SendFiles() error = %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chunks := len(s.chunks["tables.scl"])
+	if want := (len(content) + 1023) / 1024; chunks != want {
+		t.Errorf("tables.scl sent in %d chunks, want %d", chunks, want)
+	}
+	for i, encoding := range s.encodings["tables.scl"] {
+		if encoding != "gzip" {
+			t.Errorf("chunk %d sent with encoding %q, want gzip", i, encoding)
+		}
+	}
+	if string(s.content["tables.scl"]) != content || !s.accepted["tables.scl"] {
+		t.Errorf("server did not reassemble tables.scl")
+	}
+}
+
+func TestEncodeFrame(t *testing.T) {
+	text := []byte(strings.Repeat("abc", 100))
+	if out, encoding, err := encodeFrame("gzip", "app.scl", text); err != nil || encoding != "gzip" || len(out) >= len(text) {
+		t.Errorf("encodeFrame(gzip) = %d bytes, %q, %v", len(out), encoding, err)
+	}
+	if out, encoding, _ := encodeFrame("gzip", "tiny.txt", []byte("a")); encoding != "" || string(out) != "a" {
+		t.Errorf("encodeFrame() compressed a file it would grow: %q, %q", out, encoding)
+	}
+	if _, encoding, _ := encodeFrame("gzip", "assets/Logo.PNG", text); encoding != "" {
+		t.Errorf("encodeFrame() compressed an image")
+	}
+	if _, encoding, _ := encodeFrame("", "app.scl", text); encoding != "" {
+		t.Errorf("encodeFrame() compressed without an encoding")
+	}
+}
@@ -1,6 +1,7 @@
 package deploy
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -50,16 +51,16 @@ func TestClient_Install(t *testing.T) {
 		Timeout:  time.Second,
 	})
 
-	if err := client.Connect(); err != nil {
+	if err := client.Connect(t.Context()); err != nil {
 		t.Fatalf("Connect() error = %v", err)
 	}
 	defer client.Close()
 
-	if err := client.JoinChannel(appID); err != nil {
+	if err := client.JoinChannel(t.Context(), appID); err != nil {
 		t.Fatalf("JoinChannel() error = %v", err)
 	}
 
-	result, err := client.Install()
+	result, err := client.Install(t.Context())
 	if err != nil {
 		t.Fatalf("Install() error = %v", err)
 	}
@@ -114,16 +115,16 @@ func TestClient_Install_Error(t *testing.T) {
 		Timeout:  time.Second,
 	})
 
-	if err := client.Connect(); err != nil {
+	if err := client.Connect(t.Context()); err != nil {
 		t.Fatalf("Connect() error = %v", err)
 	}
 	defer client.Close()
 
-	if err := client.JoinChannel(appID); err != nil {
+	if err := client.JoinChannel(t.Context(), appID); err != nil {
 		t.Fatalf("JoinChannel() error = %v", err)
 	}
 
-	_, err := client.Install()
+	_, err := client.Install(t.Context())
 	if err == nil {
 		t.Fatal("Install() expected error, got nil")
 	}
@@ -132,3 +133,146 @@ func TestClient_Install_Error(t *testing.T) {
 		t.Errorf("Install() error = %v, want %v", err.Error(), errorMsg)
 	}
 }
+
+func TestClient_InstallVersionAndVersions(t *testing.T) {
+	installPayloads := make(chan map[string]any, 1)
+	server := startClientMockServer(t, func(conn *websocket.Conn) {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			msg := decodeJSONMessageFast(data)
+			if msg == nil {
+				continue
+			}
+
+			var reply map[string]any
+			switch msg.Event {
+			case "phx_join":
+				reply = map[string]any{"status": "ok", "response": map[string]any{}}
+			case "versions":
+				reply = map[string]any{"status": "ok", "response": map[string]any{"versions": []any{
+					map[string]any{"version": "1.4.2", "deployed_at": "2026-10-01T09:00:00Z", "file_count": 40},
+					map[string]any{"version": "1.4.3", "deployed_at": "2026-10-08T09:00:00Z", "file_count": 42, "installed": true},
+					map[string]any{"version": "1.4.1"},
+				}}}
+			case "install":
+				payload, _ := msg.Payload.(map[string]any)
+				installPayloads <- payload
+				reply = map[string]any{"status": "ok", "response": map[string]any{}}
+			default:
+				continue
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, encodeJSONMessageFast(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply", reply))
+		}
+	})
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		Endpoint: "ws" + strings.TrimPrefix(server.URL, "http"),
+		JWT:      "test-token",
+		Timeout:  time.Second,
+	})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.JoinChannel(t.Context(), "test.app"); err != nil {
+		t.Fatalf("JoinChannel() error = %v", err)
+	}
+
+	versions, err := client.Versions(t.Context())
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	var got []string
+	for _, v := range versions {
+		got = append(got, v.Version)
+	}
+	if strings.Join(got, " ") != "1.4.3 1.4.2 1.4.1" {
+		t.Errorf("Versions() order = %v, want newest first", got)
+	}
+	if !versions[0].Installed || versions[0].FileCount != 42 || versions[0].DeployedAt.Day() != 8 {
+		t.Errorf("Versions()[0] = %+v", versions[0])
+	}
+
+	result, err := client.InstallVersion(t.Context(), "1.4.2")
+	if err != nil {
+		t.Fatalf("InstallVersion() error = %v", err)
+	}
+	if payload := <-installPayloads; payload["version"] != "1.4.2" {
+		t.Errorf("install payload = %v, want version 1.4.2", payload)
+	}
+	if result.Version != "1.4.2" || !result.Success {
+		t.Errorf("InstallVersion() = %+v", result)
+	}
+}
+
+func TestClient_Install_StreamsProgress(t *testing.T) {
+	server := startClientMockServer(t, func(conn *websocket.Conn) {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			msg := decodeJSONMessageFast(data)
+			if msg == nil {
+				continue
+			}
+
+			switch msg.Event {
+			case "phx_join":
+				reply := encodeJSONMessageFast(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply",
+					map[string]any{"status": "ok", "response": map[string]any{}})
+				_ = conn.WriteMessage(websocket.TextMessage, reply)
+
+			case "install":
+				for _, push := range []struct {
+					event   string
+					payload map[string]any
+				}{
+					{"install_progress", map[string]any{"step": "migrate orders", "current": 1200, "total": 5000}},
+					{"install_log", map[string]any{"level": "warning", "message": "field orders.notes is deprecated"}},
+					{"install_log", map[string]any{"message": "seeded 12 records"}},
+					{"install_progress", map[string]any{"step": "activate", "message": "starting behaviors"}},
+				} {
+					event := encodeJSONMessageFast(msg.JoinRef, 0, msg.Topic, push.event, push.payload)
+					_ = conn.WriteMessage(websocket.TextMessage, event)
+				}
+				reply := encodeJSONMessageFast(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply",
+					map[string]any{"status": "ok", "response": map[string]any{"version": "1.2.3"}})
+				_ = conn.WriteMessage(websocket.TextMessage, reply)
+			}
+		}
+	})
+	defer server.Close()
+
+	var events []InstallEvent
+	client := NewClient(ClientConfig{
+		Endpoint:  "ws" + strings.TrimPrefix(server.URL, "http"),
+		JWT:       "test-token",
+		Timeout:   time.Second,
+		OnInstall: func(e InstallEvent) { events = append(events, e) },
+	})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.JoinChannel(t.Context(), "test.app"); err != nil {
+		t.Fatalf("JoinChannel() error = %v", err)
+	}
+
+	if _, err := client.Install(t.Context()); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	want := []InstallEvent{
+		{Step: "migrate orders", Current: 1200, Total: 5000},
+		{Level: "warning", Message: "field orders.notes is deprecated"},
+		{Level: "info", Message: "seeded 12 records"},
+		{Step: "activate", Message: "starting behaviors"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("install events = %+v, want %+v", events, want)
+	}
+}
@@ -0,0 +1,160 @@
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startSourceServer serves a deploy channel with version installed from the
+// given file contents. An empty version means nothing is installed.
+func startSourceServer(t *testing.T, version string, contents map[string]string) *Client {
+	t.Helper()
+	server := startClientMockServer(t, func(conn *websocket.Conn) {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			msg := decodeJSONMessageFast(data)
+			if msg == nil {
+				continue
+			}
+
+			var reply map[string]any
+			switch msg.Event {
+			case "phx_join":
+				reply = map[string]any{"status": "ok", "response": map[string]any{}}
+			case "installed":
+				files := []map[string]any{}
+				for path, content := range contents {
+					sum := sha256.Sum256([]byte(content))
+					files = append(files, map[string]any{"path": path, "hash": hex.EncodeToString(sum[:]), "size": len(content)})
+				}
+				reply = map[string]any{"status": "ok", "response": map[string]any{"version": version, "files": files}}
+			case "fetch_file":
+				payload, _ := msg.Payload.(map[string]any)
+				content, ok := contents[payload["path"].(string)]
+				if !ok {
+					reply = map[string]any{"status": "error", "response": map[string]any{"message": "no such file"}}
+					break
+				}
+				if payload["path"] == "tampered.js" {
+					content += "!"
+				}
+				reply = map[string]any{"status": "ok", "response": map[string]any{"content": base64.StdEncoding.EncodeToString([]byte(content))}}
+			default:
+				continue
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, encodeJSONMessageFast(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply", reply))
+		}
+	})
+	t.Cleanup(server.Close)
+
+	client := NewClient(ClientConfig{
+		Endpoint: "ws" + strings.TrimPrefix(server.URL, "http"),
+		JWT:      "test-token",
+		Timeout:  time.Second,
+	})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(client.Close)
+	if err := client.JoinChannel(t.Context(), "com.example.crm"); err != nil {
+		t.Fatalf("JoinChannel() error = %v", err)
+	}
+	return client
+}
+
+func TestClient_InstalledAndFetchFiles(t *testing.T) {
+	contents := map[string]string{"app.scl": "id com.example.crm\n", "actions/a/index.js": "export default 1\n"}
+	client := startSourceServer(t, "1.4.0-staging.3", contents)
+
+	installed, err := client.Installed(t.Context())
+	if err != nil {
+		t.Fatalf("Installed() error = %v", err)
+	}
+	if installed.Version != "1.4.0-staging.3" || installed.AppID != "com.example.crm" || len(installed.Files) != 2 {
+		t.Fatalf("Installed() = %+v", installed)
+	}
+	if fi := installed.Files["app.scl"]; fi.Size != int64(len(contents["app.scl"])) || fi.Content != nil {
+		t.Errorf("Installed() app.scl = %+v", fi)
+	}
+
+	if err := client.FetchFiles(t.Context(), installed.Files, []string{"actions/a/index.js"}); err != nil {
+		t.Fatalf("FetchFiles() error = %v", err)
+	}
+	if got := string(installed.Files["actions/a/index.js"].Content); got != contents["actions/a/index.js"] {
+		t.Errorf("FetchFiles() content = %q", got)
+	}
+	if installed.Files["app.scl"].Content != nil {
+		t.Error("FetchFiles() fetched a file that was not asked for")
+	}
+}
+
+func TestClient_FetchFiles_Errors(t *testing.T) {
+	client := startSourceServer(t, "1.0.0", map[string]string{"tampered.js": "x"})
+	installed, err := client.Installed(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.FetchFiles(t.Context(), installed.Files, []string{"tampered.js"})
+	if err == nil || !strings.Contains(err.Error(), "fetch failed for tampered.js: content does not match hash") {
+		t.Errorf("FetchFiles() error = %v", err)
+	}
+
+	installed.Files["missing.js"] = FileInfo{Path: "missing.js", Hash: "abc"}
+	err = client.FetchFiles(t.Context(), installed.Files, []string{"missing.js"})
+	if err == nil || err.Error() != "fetch failed for missing.js: no such file" {
+		t.Errorf("FetchFiles() error = %v", err)
+	}
+}
+
+func TestClient_Installed_NothingInstalled(t *testing.T) {
+	client := startSourceServer(t, "", nil)
+	_, err := client.Installed(t.Context())
+	if err == nil || err.Error() != "no version of com.example.crm is installed" {
+		t.Errorf("Installed() error = %v", err)
+	}
+}
+
+func TestClient_FetchFiles_BoundedAndStopsAtFirstError(t *testing.T) {
+	// The server fails f0 and never answers the others: only the fetches
+	// the pool has room for are sent, and the failure ends them all.
+	s := &channelServer{handle: func(_ string, payload map[string]any, _ int) (string, map[string]any, bool) {
+		if payload["path"] == "f0" {
+			return "error", map[string]any{"message": "no such file"}, false
+		}
+		return "", nil, false
+	}}
+	client := NewClient(ClientConfig{Endpoint: s.start(t), JWT: "test", Timeout: 5 * time.Second, UploadConcurrency: 2})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	if err := client.JoinChannel(t.Context(), "com.test.app"); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]FileInfo{}
+	var paths []string
+	for i := range 10 {
+		path := fmt.Sprintf("f%d", i)
+		files[path] = FileInfo{Path: path, Hash: "abc"}
+		paths = append(paths, path)
+	}
+	err := client.FetchFiles(t.Context(), files, paths)
+	if err == nil || err.Error() != "fetch failed for f0: no such file" {
+		t.Errorf("FetchFiles() error = %v", err)
+	}
+	if got := strings.Count(s.log(), "fetch_file"); got > 2 {
+		t.Errorf("server saw %d fetches at once, want at most 2", got)
+	}
+}
@@ -0,0 +1,253 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// channelServer is a mock deploy channel. Joins are accepted; every other
+// event is answered by handle, given the number of the connection it came
+// on, which also decides whether to drop the connection instead; an empty
+// status sends no reply. With
+// refuseAfter set, connections after that many are refused with a 401.
+type channelServer struct {
+	handle      func(event string, payload map[string]any, connection int) (status string, response map[string]any, drop bool)
+	refuseAfter int
+
+	mu          sync.Mutex
+	events      []string // connection/event of each push
+	connections int
+}
+
+func (s *channelServer) start(t *testing.T) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.connections++
+		connection := s.connections
+		s.mu.Unlock()
+		if s.refuseAfter > 0 && connection > s.refuseAfter {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			msg := decodeJSONMessageFast(data)
+			if msg == nil || msg.Topic == "phoenix" {
+				continue
+			}
+			status, response := "ok", map[string]any{}
+			if msg.Event != "phx_join" {
+				s.mu.Lock()
+				s.events = append(s.events, fmt.Sprintf("%d/%s", connection, msg.Event))
+				s.mu.Unlock()
+				payload, _ := msg.Payload.(map[string]any)
+				var drop bool
+				status, response, drop = s.handle(msg.Event, payload, connection)
+				if drop {
+					return
+				}
+				if status == "" {
+					continue
+				}
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, encodeJSONMessageFast(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply",
+				map[string]any{"status": status, "response": response}))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func (s *channelServer) log() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.events, " ")
+}
+
+// reconnectFixture returns a client joined to s that records its reconnect
+// reports, with the waits between attempts and polls cut short.
+func reconnectFixture(t *testing.T, s *channelServer) (*Client, *[]Reconnect) {
+	t.Helper()
+	oldBackoff, oldPoll := reconnectBackoff, installPollInterval
+	reconnectBackoff, installPollInterval = []time.Duration{0, 0, 0}, time.Millisecond
+	t.Cleanup(func() { reconnectBackoff, installPollInterval = oldBackoff, oldPoll })
+
+	var reports []Reconnect
+	client := NewClient(ClientConfig{
+		Endpoint:    s.start(t),
+		JWT:         "test",
+		Timeout:     5 * time.Second,
+		OnReconnect: func(r Reconnect) { reports = append(reports, r) },
+	})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(client.Close)
+	if err := client.JoinChannel(t.Context(), "com.test.app"); err != nil {
+		t.Fatalf("JoinChannel() error = %v", err)
+	}
+	return client, &reports
+}
+
+func versionsResponse(versions ...string) map[string]any {
+	list := []any{}
+	for _, v := range versions {
+		list = append(list, map[string]any{"version": v, "deployed_at": "2026-01-02T03:04:05Z", "file_count": 3})
+	}
+	return map[string]any{"versions": list}
+}
+
+func TestClient_Versions_ReplayedAfterDroppedConnection(t *testing.T) {
+	s := &channelServer{handle: func(_ string, _ map[string]any, connection int) (string, map[string]any, bool) {
+		return "ok", versionsResponse("1.0.0"), connection == 1
+	}}
+	client, reports := reconnectFixture(t, s)
+
+	versions, err := client.Versions(t.Context())
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	if len(versions) != 1 || versions[0].Version != "1.0.0" {
+		t.Errorf("Versions() = %+v", versions)
+	}
+	if got := s.log(); got != "1/versions 2/versions" {
+		t.Errorf("server saw %s", got)
+	}
+	if len(*reports) != 2 || (*reports)[0].Attempt != 1 || (*reports)[0].Restored || !(*reports)[1].Restored {
+		t.Errorf("reconnect reports = %+v", *reports)
+	}
+}
+
+func TestClient_Deploy_AfterDroppedConnection(t *testing.T) {
+	tests := []struct {
+		name     string
+		deployed []string
+		wantLog  string
+	}{
+		{
+			name:     "deploy completed",
+			deployed: []string{"1.2.0", "1.1.0"},
+			wantLog:  "1/manifest 1/deploy 2/versions",
+		},
+		{
+			name:     "deploy lost",
+			deployed: []string{"1.1.0"},
+			wantLog:  "1/manifest 1/deploy 2/versions 2/manifest 2/deploy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &channelServer{handle: func(event string, _ map[string]any, connection int) (string, map[string]any, bool) {
+				switch event {
+				case "deploy":
+					return "ok", map[string]any{"version": "1.2.0", "file_count": 3.0}, connection == 1
+				case "versions":
+					return "ok", versionsResponse(tt.deployed...), false
+				}
+				return "ok", map[string]any{"need_files": []any{}}, false
+			}}
+			client, _ := reconnectFixture(t, s)
+			if _, err := client.SendManifest(t.Context(), memFiles(3), "1.2.0"); err != nil {
+				t.Fatalf("SendManifest() error = %v", err)
+			}
+
+			result, err := client.Deploy(t.Context())
+			if err != nil {
+				t.Fatalf("Deploy() error = %v", err)
+			}
+			if result.Version != "1.2.0" || result.FileCount != 3 {
+				t.Errorf("Deploy() = %+v", result)
+			}
+			if got := s.log(); got != tt.wantLog {
+				t.Errorf("server saw %s, want %s", got, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestClient_Install_AwaitsInstallAfterDroppedConnection(t *testing.T) {
+	polls := 0
+	s := &channelServer{handle: func(event string, _ map[string]any, _ int) (string, map[string]any, bool) {
+		switch event {
+		case "install":
+			return "", nil, true
+		case "versions":
+			return "ok", versionsResponse("2.0.0", "1.0.0"), false
+		}
+		polls++
+		if polls < 3 {
+			return "ok", map[string]any{"version": "1.0.0", "files": []any{}}, false
+		}
+		return "ok", map[string]any{"version": "2.0.0", "files": []any{}}, false
+	}}
+	client, reports := reconnectFixture(t, s)
+
+	result, err := client.Install(t.Context())
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if result.Version != "2.0.0" || !result.Success {
+		t.Errorf("Install() = %+v", result)
+	}
+	if got := s.log(); got != "1/install 2/versions 2/installed 2/installed 2/installed" {
+		t.Errorf("server saw %s", got)
+	}
+	if len(*reports) != 2 {
+		t.Errorf("reconnect reports = %+v", *reports)
+	}
+}
+
+func TestClient_Install_GivesUpWhenInstallDoesNotFinish(t *testing.T) {
+	s := &channelServer{handle: func(event string, _ map[string]any, _ int) (string, map[string]any, bool) {
+		if event == "install" {
+			return "", nil, true
+		}
+		return "ok", map[string]any{}, false
+	}}
+	client, _ := reconnectFixture(t, s)
+	client.timeouts.Install = 50 * time.Millisecond
+
+	_, err := client.InstallVersion(t.Context(), "2.0.0")
+	if err == nil || !strings.Contains(err.Error(), "install of com.test.app@2.0.0 interrupted and not finished (install response timeout)") {
+		t.Errorf("InstallVersion() error = %v", err)
+	}
+	if strings.Count(s.log(), "install ") > 1 {
+		t.Errorf("install sent again: %s", s.log())
+	}
+}
+
+func TestClient_Reconnect_StopsWhenTokenRejected(t *testing.T) {
+	s := &channelServer{refuseAfter: 1, handle: func(string, map[string]any, int) (string, map[string]any, bool) {
+		return "", nil, true
+	}}
+	client, reports := reconnectFixture(t, s)
+
+	_, err := client.Versions(t.Context())
+	var authErr *AuthFailedError
+	if !errors.As(err, &authErr) {
+		t.Errorf("Versions() error = %v, want an auth failure", err)
+	}
+	if len(*reports) != 1 {
+		t.Errorf("reconnect reports = %+v, want one attempt", *reports)
+	}
+}
@@ -30,8 +30,20 @@ func TestNewClient(t *testing.T) {
 	if client.jwt != cfg.JWT {
 		t.Errorf("NewClient() jwt = %q, want %q", client.jwt, cfg.JWT)
 	}
-	if client.timeout != cfg.Timeout {
-		t.Errorf("NewClient() timeout = %v, want %v", client.timeout, cfg.Timeout)
+	// Timeout stands in for every phase not given a timeout of its own.
+	want := Timeouts{Connect: cfg.Timeout, Manifest: cfg.Timeout, Upload: cfg.Timeout, Deploy: cfg.Timeout, Install: cfg.Timeout}
+	if client.timeouts != want {
+		t.Errorf("NewClient() timeouts = %+v, want %+v", client.timeouts, want)
+	}
+}
+
+func TestNewClient_PhaseTimeouts(t *testing.T) {
+	client := NewClient(ClientConfig{Timeouts: Timeouts{Manifest: 10 * time.Second, Install: time.Hour}})
+
+	want := DefaultTimeouts
+	want.Manifest, want.Install = 10*time.Second, time.Hour
+	if client.timeouts != want {
+		t.Errorf("NewClient() timeouts = %+v, want %+v", client.timeouts, want)
 	}
 }
 
@@ -47,8 +59,8 @@ func TestNewClient_DefaultTimeout(t *testing.T) {
 	// Installs are synchronous server-side and scale with app size, so the
 	// default must be generous enough that a slow-but-healthy install is never
 	// reported as a failure.
-	if client.timeout != DefaultTimeout {
-		t.Errorf("NewClient() default timeout = %v, want %v", client.timeout, DefaultTimeout)
+	if client.timeouts.Install != DefaultTimeout {
+		t.Errorf("NewClient() default install timeout = %v, want %v", client.timeouts.Install, DefaultTimeout)
 	}
 
 	if DefaultTimeout < 10*time.Minute {
@@ -60,10 +72,10 @@ func TestClient_JoinChannel_NotConnected(t *testing.T) {
 	client := &Client{
 		endpoint: "test",
 		jwt:      "jwt",
-		timeout:  5 * time.Second,
+		timeouts: DefaultTimeouts,
 	}
 
-	err := client.JoinChannel("com.example.app")
+	err := client.JoinChannel(t.Context(), "com.example.app")
 	if err == nil {
 		t.Error("JoinChannel() expected error when not connected")
 	}
@@ -74,10 +86,10 @@ func TestClient_JoinChannel_NotConnected(t *testing.T) {
 
 func TestClient_SendManifest_NotJoined(t *testing.T) {
 	client := &Client{
-		timeout: 5 * time.Second,
+		timeouts: DefaultTimeouts,
 	}
 
-	_, err := client.SendManifest(nil, "1.0.0")
+	_, err := client.SendManifest(t.Context(), nil, "1.0.0")
 	if err == nil {
 		t.Error("SendManifest() expected error when not joined")
 	}
@@ -88,10 +100,10 @@ func TestClient_SendManifest_NotJoined(t *testing.T) {
 
 func TestClient_SendFiles_NotJoined(t *testing.T) {
 	client := &Client{
-		timeout: 5 * time.Second,
+		timeouts: DefaultTimeouts,
 	}
 
-	err := client.SendFiles(nil, []string{"file1.txt"})
+	err := client.SendFiles(t.Context(), nil, []string{"file1.txt"})
 	if err == nil {
 		t.Error("SendFiles() expected error when not joined")
 	}
@@ -102,10 +114,10 @@ func TestClient_SendFiles_NotJoined(t *testing.T) {
 
 func TestClient_SendFiles_EmptyList(t *testing.T) {
 	client := &Client{
-		timeout: 5 * time.Second,
+		timeouts: DefaultTimeouts,
 	}
 
-	err := client.SendFiles(nil, []string{})
+	err := client.SendFiles(t.Context(), nil, []string{})
 	if err != nil && !strings.Contains(err.Error(), "not joined") {
 		t.Errorf("SendFiles() unexpected error = %v", err)
 	}
@@ -113,10 +125,10 @@ func TestClient_SendFiles_EmptyList(t *testing.T) {
 
 func TestClient_Deploy_NotJoined(t *testing.T) {
 	client := &Client{
-		timeout: 5 * time.Second,
+		timeouts: DefaultTimeouts,
 	}
 
-	_, err := client.Deploy()
+	_, err := client.Deploy(t.Context())
 	if err == nil {
 		t.Error("Deploy() expected error when not joined")
 	}
@@ -242,7 +254,7 @@ func TestClient_SendManifest_Integration(t *testing.T) {
 		socket:   socket,
 		channel:  channel,
 		appID:    "com.test.app",
-		timeout:  5 * time.Second,
+		timeouts: DefaultTimeouts,
 		endpoint: wsURL,
 	}
 
@@ -250,7 +262,7 @@ func TestClient_SendManifest_Integration(t *testing.T) {
 		"file1.txt": {Path: "file1.txt", Hash: "abc123", Size: 100},
 	}
 
-	needed, err := client.SendManifest(files, "1.0.0")
+	needed, err := client.SendManifest(t.Context(), files, "1.0.0")
 	if err != nil {
 		t.Fatalf("SendManifest error: %v", err)
 	}
@@ -312,17 +324,17 @@ func TestClient_SendFiles_Integration(t *testing.T) {
 	}
 
 	client := &Client{
-		socket:  socket,
-		channel: channel,
-		appID:   "com.test.app",
-		timeout: 5 * time.Second,
+		socket:   socket,
+		channel:  channel,
+		appID:    "com.test.app",
+		timeouts: DefaultTimeouts,
 	}
 
 	files := map[string]FileInfo{
 		"file1.txt": {Path: "file1.txt", Hash: "abc123", Size: 11, Content: []byte("hello world")},
 	}
 
-	err := client.SendFiles(files, []string{"file1.txt"})
+	err := client.SendFiles(t.Context(), files, []string{"file1.txt"})
 	if err != nil {
 		t.Fatalf("SendFiles error: %v", err)
 	}
@@ -379,13 +391,13 @@ func TestClient_Deploy_Integration(t *testing.T) {
 	}
 
 	client := &Client{
-		socket:  socket,
-		channel: channel,
-		appID:   "com.test.app",
-		timeout: 5 * time.Second,
+		socket:   socket,
+		channel:  channel,
+		appID:    "com.test.app",
+		timeouts: DefaultTimeouts,
 	}
 
-	result, err := client.Deploy()
+	result, err := client.Deploy(t.Context())
 	if err != nil {
 		t.Fatalf("Deploy error: %v", err)
 	}
@@ -435,10 +447,10 @@ func TestClient_Close_WithConnection(t *testing.T) {
 	}
 
 	client := &Client{
-		socket:  socket,
-		channel: channel,
-		appID:   "com.test.app",
-		timeout: 5 * time.Second,
+		socket:   socket,
+		channel:  channel,
+		appID:    "com.test.app",
+		timeouts: DefaultTimeouts,
 	}
 
 	// Should not panic
@@ -0,0 +1,67 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_PhaseTimeouts(t *testing.T) {
+	s := &channelServer{handle: func(string, map[string]any, int) (string, map[string]any, bool) {
+		return "", nil, false // never replies
+	}}
+	client := NewClient(ClientConfig{
+		Endpoint: s.start(t),
+		JWT:      "test",
+		Timeouts: Timeouts{Manifest: 50 * time.Millisecond, Deploy: 80 * time.Millisecond},
+	})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.JoinChannel(t.Context(), "com.test.app"); err != nil {
+		t.Fatalf("JoinChannel() error = %v", err)
+	}
+
+	start := time.Now()
+	if _, err := client.SendManifest(t.Context(), memFiles(1), "1.0.0"); err == nil || err.Error() != "manifest response timeout" {
+		t.Errorf("SendManifest() error = %v, want manifest response timeout", err)
+	}
+	if _, err := client.Deploy(t.Context()); err == nil || err.Error() != "deploy response timeout" {
+		t.Errorf("Deploy() error = %v, want deploy response timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timeouts took %s", elapsed)
+	}
+}
+
+func TestClient_Install_CancelledLeavesChannel(t *testing.T) {
+	s := &channelServer{handle: func(string, map[string]any, int) (string, map[string]any, bool) {
+		return "", nil, false // the install never finishes
+	}}
+	client := NewClient(ClientConfig{Endpoint: s.start(t), JWT: "test"})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if err := client.JoinChannel(t.Context(), "com.test.app"); err != nil {
+		t.Fatalf("JoinChannel() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err := client.Install(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Install() error = %v, want context.Canceled", err)
+	}
+	client.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(s.log(), "phx_leave") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := s.log(); got != "1/install 1/phx_leave" {
+		t.Errorf("server saw %s, want the install, then the channel left", got)
+	}
+}
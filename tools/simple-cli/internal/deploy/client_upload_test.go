@@ -0,0 +1,463 @@
+package deploy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// uploadServer is a mock deploy channel that accepts file uploads. onFile
+// decides what happens to a file given how often it was pushed, and which
+// file push it is on which connection: "ok" or "error" replies, "ignore"
+// sends no reply and "drop" closes the connection. It is called with mu
+// held.
+//
+// When the client offers compression, the server picks compression if it is
+// among the offers. Compressed frames are decompressed, and with verify set a
+// file whose content does not match its hash is rejected.
+type uploadServer struct {
+	delay       time.Duration
+	onFile      func(path string, push, connection, connPush int) string
+	compression string
+	verify      bool
+
+	mu          sync.Mutex
+	pushes      map[string]int
+	accepted    map[string]bool
+	content     map[string][]byte
+	chunks      map[string][]string // offset/final of each file_chunk frame
+	offered     []any
+	encodings   map[string][]string // encoding of each frame of a file
+	wireBytes   int                 // frame content bytes, as sent
+	inFlight    int
+	maxInFlight int
+	connections int
+}
+
+func (s *uploadServer) start(t *testing.T) string {
+	t.Helper()
+	s.pushes, s.accepted = map[string]int{}, map[string]bool{}
+	s.content, s.chunks, s.encodings = map[string][]byte{}, map[string][]string{}, map[string][]string{}
+	server := startClientMockServer(t, func(conn *websocket.Conn) {
+		s.mu.Lock()
+		s.connections++
+		connection := s.connections
+		s.mu.Unlock()
+
+		var writeMu sync.Mutex
+		reply := func(msg *phoenixMessage, status string, response map[string]any) {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			_ = conn.WriteMessage(websocket.TextMessage, encodeJSONMessageFast(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply",
+				map[string]any{"status": status, "response": response}))
+		}
+
+		connPushes := 0
+		for {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if msgType == websocket.TextMessage {
+				msg := decodeJSONMessageFast(data)
+				switch {
+				case msg == nil:
+				case msg.Event == "phx_join":
+					params, _ := msg.Payload.(map[string]any)
+					offered, _ := params["compression"].([]any)
+					response := map[string]any{}
+					s.mu.Lock()
+					s.offered = offered
+					s.mu.Unlock()
+					if slices.Contains(offered, any(s.compression)) {
+						response["compression"] = s.compression
+					}
+					reply(msg, "ok", response)
+				case msg.Event == "manifest":
+					reply(msg, "ok", map[string]any{"need_files": s.needed(msg.Payload)})
+				}
+				continue
+			}
+
+			msg := decodeBinaryMessageFast(data)
+			raw, _ := msg.Payload.([]byte)
+			metaLen := binary.BigEndian.Uint32(raw[:4])
+			var meta map[string]any
+			_ = json.Unmarshal(raw[4:4+metaLen], &meta)
+			path, _ := meta["path"].(string)
+			final := msg.Event == "file" || meta["final"] == true
+			body := raw[4+metaLen:]
+			encoding, _ := meta["encoding"].(string)
+			decoded, decodeErr := decodeFrame(encoding, body)
+
+			connPushes++
+			s.mu.Lock()
+			s.wireBytes += len(body)
+			s.encodings[path] = append(s.encodings[path], encoding)
+			if msg.Event == "file_chunk" {
+				s.chunks[path] = append(s.chunks[path], fmt.Sprintf("%v/%v", meta["offset"], meta["final"]))
+				if meta["offset"] == 0.0 {
+					s.content[path] = nil
+				}
+				s.content[path] = append(s.content[path], decoded...)
+			} else {
+				s.content[path] = decoded
+			}
+			sum := sha256.Sum256(s.content[path])
+			corrupt := decodeErr != nil || (s.verify && final && hex.EncodeToString(sum[:]) != meta["hash"])
+			s.pushes[path]++
+			action := "ok"
+			if s.onFile != nil {
+				action = s.onFile(path, s.pushes[path], connection, connPushes)
+			}
+			s.mu.Unlock()
+			if corrupt {
+				action = "error"
+			}
+
+			switch action {
+			case "drop":
+				return
+			case "ignore":
+				continue
+			}
+			go func() {
+				s.mu.Lock()
+				s.inFlight++
+				s.maxInFlight = max(s.maxInFlight, s.inFlight)
+				s.mu.Unlock()
+				time.Sleep(s.delay)
+				s.mu.Lock()
+				s.inFlight--
+				if action == "ok" && final {
+					s.accepted[path] = true
+				}
+				s.mu.Unlock()
+				reply(msg, action, map[string]any{})
+			}()
+		}
+	})
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// decodeFrame undoes the encoding of a frame's content.
+func decodeFrame(encoding string, body []byte) ([]byte, error) {
+	switch encoding {
+	case "":
+		return body, nil
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
+
+// stats returns how often each path was pushed, and how many files the
+// server accepted.
+func (s *uploadServer) stats() (map[string]int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pushes := make(map[string]int, len(s.pushes))
+	for path, n := range s.pushes {
+		pushes[path] = n
+	}
+	return pushes, len(s.accepted)
+}
+
+// needed lists the files of a manifest the server has not accepted.
+func (s *uploadServer) needed(payload any) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	manifest, _ := payload.(map[string]any)
+	files, _ := manifest["files"].([]any)
+	need := []string{}
+	for _, f := range files {
+		path := f.(map[string]any)["path"].(string)
+		if !s.accepted[path] {
+			need = append(need, path)
+		}
+	}
+	return need
+}
+
+// memFiles returns n small files held in memory.
+func memFiles(n int) map[string]FileInfo {
+	files := map[string]FileInfo{}
+	for i := range n {
+		path := fmt.Sprintf("file%02d.txt", i)
+		files[path] = FileInfo{Path: path, Hash: fmt.Sprintf("hash%02d", i), Size: int64(10 + i), Content: []byte(path)}
+	}
+	return files
+}
+
+// uploadFixture connects a client to s and sends a manifest of files.
+func uploadFixture(t *testing.T, s *uploadServer, files map[string]FileInfo, cfg ClientConfig) (*Client, []string, *[]UploadProgress) {
+	t.Helper()
+	old, oldReconnect := uploadBackoff, reconnectBackoff
+	uploadBackoff, reconnectBackoff = []time.Duration{0, 0, 0}, []time.Duration{0}
+	t.Cleanup(func() { uploadBackoff, reconnectBackoff = old, oldReconnect })
+
+	var reports []UploadProgress
+	cfg.Endpoint = s.start(t)
+	cfg.JWT = "test"
+	cfg.Timeout = 5 * time.Second
+	cfg.OnUpload = func(p UploadProgress) { reports = append(reports, p) }
+	client := NewClient(cfg)
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(client.Close)
+	if err := client.JoinChannel(t.Context(), "com.test.app"); err != nil {
+		t.Fatalf("JoinChannel() error = %v", err)
+	}
+
+	needed, err := client.SendManifest(t.Context(), files, "1.0.0-dev.1")
+	if err != nil {
+		t.Fatalf("SendManifest() error = %v", err)
+	}
+	return client, needed, &reports
+}
+
+func TestClient_SendFiles_BoundedConcurrency(t *testing.T) {
+	s := &uploadServer{delay: 20 * time.Millisecond}
+	files := memFiles(20)
+	client, needed, reports := uploadFixture(t, s, files, ClientConfig{UploadConcurrency: 3})
+
+	if err := client.SendFiles(t.Context(), files, needed); err != nil {
+		t.Fatalf("SendFiles() error = %v", err)
+	}
+	s.mu.Lock()
+	maxInFlight := s.maxInFlight
+	s.mu.Unlock()
+	if maxInFlight > 3 || maxInFlight < 2 {
+		t.Errorf("server saw %d files in flight at once, want 2 or 3", maxInFlight)
+	}
+	if _, accepted := s.stats(); accepted != 20 {
+		t.Errorf("server accepted %d files, want 20", accepted)
+	}
+
+	var totalBytes int64
+	for _, fi := range files {
+		totalBytes += fi.Size
+	}
+	if len(*reports) != 20 {
+		t.Fatalf("got %d progress reports, want 20", len(*reports))
+	}
+	for i, p := range *reports {
+		if p.Event != FileUploaded || p.Done != i+1 || p.Total != 20 || p.TotalBytes != totalBytes {
+			t.Errorf("report %d = %+v", i, p)
+		}
+	}
+	if last := (*reports)[19]; last.Bytes != totalBytes {
+		t.Errorf("last report has %d of %d bytes", last.Bytes, totalBytes)
+	}
+}
+
+func TestClient_SendFiles_RetriesTransientFailures(t *testing.T) {
+	s := &uploadServer{onFile: func(path string, push, _, _ int) string {
+		if path == "file01.txt" && push < 3 {
+			return "ignore"
+		}
+		return "ok"
+	}}
+	files := memFiles(3)
+	client, needed, reports := uploadFixture(t, s, files, ClientConfig{FileTimeout: 100 * time.Millisecond})
+
+	if err := client.SendFiles(t.Context(), files, needed); err != nil {
+		t.Fatalf("SendFiles() error = %v", err)
+	}
+	if pushes, _ := s.stats(); pushes["file01.txt"] != 3 || pushes["file00.txt"] != 1 {
+		t.Errorf("server got pushes %v, want file01.txt three times and the others once", pushes)
+	}
+
+	var retries []int
+	for _, p := range *reports {
+		if p.Event == FileRetrying {
+			if p.Path != "file01.txt" || !strings.Contains(p.Err.Error(), "timeout waiting for file response") {
+				t.Errorf("unexpected retry report %+v", p)
+			}
+			retries = append(retries, p.Attempt)
+		}
+	}
+	if fmt.Sprint(retries) != "[1 2]" {
+		t.Errorf("retried attempts %v, want [1 2]", retries)
+	}
+}
+
+func TestClient_SendFiles_GivesUpAfterRetries(t *testing.T) {
+	s := &uploadServer{onFile: func(path string, _, _, _ int) string {
+		if path == "file00.txt" {
+			return "ignore"
+		}
+		return "ok"
+	}}
+	files := memFiles(1)
+	client, needed, _ := uploadFixture(t, s, files, ClientConfig{FileTimeout: 50 * time.Millisecond})
+
+	err := client.SendFiles(t.Context(), files, needed)
+	if err == nil || !strings.Contains(err.Error(), "timeout waiting for file response for file00.txt") {
+		t.Errorf("SendFiles() error = %v", err)
+	}
+	if pushes, _ := s.stats(); pushes["file00.txt"] != len(uploadBackoff)+1 {
+		t.Errorf("file00.txt pushed %d times, want %d", pushes["file00.txt"], len(uploadBackoff)+1)
+	}
+}
+
+func TestClient_SendFiles_RejectionIsNotRetried(t *testing.T) {
+	s := &uploadServer{onFile: func(path string, _, _, _ int) string {
+		if path == "file02.txt" {
+			return "error"
+		}
+		return "ok"
+	}}
+	files := memFiles(5)
+	client, needed, _ := uploadFixture(t, s, files, ClientConfig{UploadConcurrency: 1})
+
+	err := client.SendFiles(t.Context(), files, needed)
+	if err == nil || !strings.Contains(err.Error(), "file rejected for file02.txt") {
+		t.Errorf("SendFiles() error = %v", err)
+	}
+	if pushes, _ := s.stats(); pushes["file02.txt"] != 1 {
+		t.Errorf("file02.txt pushed %d times, want 1", pushes["file02.txt"])
+	}
+}
+
+func TestClient_SendFiles_ResumesAfterDroppedConnection(t *testing.T) {
+	s := &uploadServer{onFile: func(_ string, _, connection, connPush int) string {
+		if connection == 1 && connPush == 4 {
+			return "drop"
+		}
+		return "ok"
+	}}
+	files := memFiles(10)
+	client, needed, reports := uploadFixture(t, s, files, ClientConfig{UploadConcurrency: 1})
+
+	if err := client.SendFiles(t.Context(), files, needed); err != nil {
+		t.Fatalf("SendFiles() error = %v", err)
+	}
+	pushes, accepted := s.stats()
+	if accepted != 10 {
+		t.Errorf("server accepted %d files, want 10", accepted)
+	}
+	s.mu.Lock()
+	connections := s.connections
+	s.mu.Unlock()
+	if connections != 2 {
+		t.Errorf("client connected %d times, want 2", connections)
+	}
+	for path, n := range pushes {
+		if n > 2 {
+			t.Errorf("%s pushed %d times; only files the server lacked should be re-sent", path, n)
+		}
+	}
+
+	resumed := false
+	for _, p := range *reports {
+		resumed = resumed || p.Event == UploadResumed
+	}
+	last := (*reports)[len(*reports)-1]
+	if !resumed || last.Done != 10 || last.Total != 10 {
+		t.Errorf("resumed = %v, last report = %+v", resumed, last)
+	}
+}
+
+func TestClient_SendFiles_GivesUpAfterResumes(t *testing.T) {
+	s := &uploadServer{onFile: func(string, int, int, int) string { return "drop" }}
+	files := memFiles(2)
+	client, needed, _ := uploadFixture(t, s, files, ClientConfig{})
+
+	err := client.SendFiles(t.Context(), files, needed)
+	if err == nil || !strings.Contains(err.Error(), "connection lost") {
+		t.Errorf("SendFiles() error = %v", err)
+	}
+	s.mu.Lock()
+	connections := s.connections
+	s.mu.Unlock()
+	if connections != maxUploadResumes+1 {
+		t.Errorf("client connected %d times, want %d", connections, maxUploadResumes+1)
+	}
+}
+
+// diskFiles writes contents to disk and collects them as the deploy would.
+func diskFiles(t *testing.T, contents map[string]string) map[string]FileInfo {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]FileInfo{}
+	for path, content := range contents {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fi, err := hashFile(OSFileSystem{}, filepath.Join(dir, path), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[path] = *fi
+	}
+	return files
+}
+
+func TestClient_SendFiles_StreamsLargeFilesInChunks(t *testing.T) {
+	s := &uploadServer{}
+	files := diskFiles(t, map[string]string{"large.bin": "0123456789", "small.txt": "abcd"})
+	client, needed, reports := uploadFixture(t, s, files, ClientConfig{ChunkSize: 4})
+
+	if err := client.SendFiles(t.Context(), files, needed); err != nil {
+		t.Fatalf("SendFiles() error = %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if got := fmt.Sprint(s.chunks["large.bin"]); got != "[0/false 4/false 8/true]" {
+		t.Errorf("large.bin sent as chunks %s, want [0/false 4/false 8/true]", got)
+	}
+	if len(s.chunks["small.txt"]) != 0 {
+		t.Errorf("small.txt sent as chunks %v, want one frame", s.chunks["small.txt"])
+	}
+	if string(s.content["large.bin"]) != "0123456789" || string(s.content["small.txt"]) != "abcd" {
+		t.Errorf("server got content %q", s.content)
+	}
+	if !s.accepted["large.bin"] || !s.accepted["small.txt"] {
+		t.Errorf("server accepted %v", s.accepted)
+	}
+	if last := (*reports)[len(*reports)-1]; last.Done != 2 || last.Bytes != 14 {
+		t.Errorf("last report = %+v", last)
+	}
+}
+
+func TestClient_SendFiles_RefusesFilesChangedOnDisk(t *testing.T) {
+	for name, chunkSize := range map[string]int{"single frame": 0, "chunked": 4} {
+		t.Run(name, func(t *testing.T) {
+			s := &uploadServer{}
+			files := diskFiles(t, map[string]string{"app.scl": "id test\nversion 1.0.0"})
+			client, needed, _ := uploadFixture(t, s, files, ClientConfig{ChunkSize: chunkSize})
+			if err := os.WriteFile(files["app.scl"].Source, []byte("id test\nversion 1.0.1"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := client.SendFiles(t.Context(), files, needed)
+			if err == nil || err.Error() != "app.scl changed since it was collected; deploy again" {
+				t.Errorf("SendFiles() error = %v", err)
+			}
+			if _, accepted := s.stats(); accepted != 0 {
+				t.Errorf("server accepted %d files, want none", accepted)
+			}
+		})
+	}
+}
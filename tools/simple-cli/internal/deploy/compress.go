@@ -0,0 +1,64 @@
+package deploy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// encoders are the content encodings uploads can be compressed with, and
+// supportedEncodings the order they are offered to the server in when the
+// channel is joined. zstd would go first, but needs an encoder this module
+// does not vendor.
+var (
+	encoders = map[string]func(io.Writer) (io.WriteCloser, error){
+		"gzip": func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriterLevel(w, gzip.BestSpeed) },
+	}
+	supportedEncodings = []string{"gzip"}
+)
+
+// compressedExts are formats already compressed, not worth compressing again.
+var compressedExts = []string{
+	".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".ico",
+	".woff", ".woff2", ".zip", ".gz", ".br", ".zst", ".mp3", ".mp4", ".webm", ".pdf",
+}
+
+// negotiatedEncoding returns the encoding of the server's join reply, when
+// it is one the client offered.
+func negotiatedEncoding(response map[string]any, offered []string) string {
+	encoding, _ := response["compression"].(string)
+	if slices.Contains(offered, encoding) {
+		return encoding
+	}
+	return ""
+}
+
+// encodeFrame compresses content of the file at path with encoding. It
+// returns the content unchanged and no encoding when there is no encoding,
+// the file's format is already compressed, or compressing would not make
+// it smaller.
+func encodeFrame(encoding, path string, content []byte) ([]byte, string, error) {
+	newEncoder, ok := encoders[encoding]
+	if !ok || len(content) == 0 || slices.Contains(compressedExts, strings.ToLower(filepath.Ext(path))) {
+		return content, "", nil
+	}
+
+	var buf bytes.Buffer
+	w, err := newEncoder(&buf)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	if buf.Len() >= len(content) {
+		return content, "", nil
+	}
+	return buf.Bytes(), encoding, nil
+}
@@ -1,28 +1,82 @@
 package deploy
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"simple-cli/internal/build"
 	"strings"
 	"sync"
 )
 
+// behaviorsDir is where an app keeps its record behaviors, relative to the app root.
+var behaviorsDir = filepath.Join("scripts", "record-behaviors")
+
 // FileInfo represents a file to deploy.
 type FileInfo struct {
 	Path    string // Relative path from app root
 	Hash    string // SHA256 hash of content
 	Size    int64  // File size in bytes
-	Content []byte // File content (loaded in parallel)
+	Content []byte // File content, when held in memory (bundled behaviors, fetched files)
+	Source  string // Path on disk to read the content from when Content is nil
+}
+
+// Open returns the file's content, from memory or else read from Source.
+func (fi FileInfo) Open() (io.ReadCloser, error) {
+	if fi.Content != nil || fi.Source == "" {
+		return io.NopCloser(bytes.NewReader(fi.Content)), nil
+	}
+	return os.Open(fi.Source)
+}
+
+// fileOpener is a FileSystem that can stream a file rather than read it
+// whole. The collector hashes files from one without keeping their content.
+type fileOpener interface {
+	Open(path string) (io.ReadCloser, error)
+}
+
+// BehaviorBundler turns TypeScript record behaviors into the JavaScript the
+// platform runs. Check is called once per collection with every table that has
+// a TypeScript behavior, before any of them is bundled.
+type BehaviorBundler interface {
+	Check(appPath string, tables []string) error
+	Bundle(appPath, table string) ([]byte, error)
+}
+
+// buildBehaviorBundler regenerates each behavior's field types from tables.scl,
+// type-checks them all, and bundles each with esbuild.
+type buildBehaviorBundler struct{}
+
+func (buildBehaviorBundler) Check(appPath string, tables []string) error {
+	for _, table := range tables {
+		if err := build.WriteBehaviorTypes(appPath, table); err != nil {
+			return fmt.Errorf("failed to generate types for %s behavior: %w", table, err)
+		}
+	}
+	return build.TypeCheckBehaviors(appPath)
+}
+
+func (buildBehaviorBundler) Bundle(appPath, table string) ([]byte, error) {
+	return build.BundleBehavior(appPath, table)
 }
 
 // FileCollector handles parallel file collection with dependency injection.
 type FileCollector struct {
 	FS         FileSystem
 	NumWorkers int
+
+	// Behaviors bundles TypeScript record behaviors. Nil means esbuild via
+	// the build package.
+	Behaviors BehaviorBundler
+
+	// OnExclude, when set, is called with each file or directory the app's
+	// ignore rules keep out of the deploy.
+	OnExclude func(Exclusion)
 }
 
 // NewFileCollector creates a FileCollector with default settings.
@@ -30,11 +84,21 @@ func NewFileCollector() *FileCollector {
 	return &FileCollector{
 		FS:         OSFileSystem{},
 		NumWorkers: runtime.NumCPU(),
+		Behaviors:  buildBehaviorBundler{},
 	}
 }
 
+func (c *FileCollector) behaviors() BehaviorBundler {
+	if c.Behaviors == nil {
+		return buildBehaviorBundler{}
+	}
+	return c.Behaviors
+}
+
 // CollectFiles gathers all deployable files with MAXIMUM parallelization.
-// Mirrors Publisher.stream_files/1 logic from simple_devops.
+// Mirrors Publisher.stream_files/1 logic from simple_devops. Files are hashed
+// as they stream from disk and keep no Content, so memory does not grow with
+// the app; SendFiles reads a file again only when the server asks for it.
 func (c *FileCollector) CollectFiles(appPath string) (map[string]FileInfo, error) {
 	// Collect file paths first (fast, single-threaded)
 	paths, err := c.collectPaths(appPath)
@@ -46,6 +110,18 @@ func (c *FileCollector) CollectFiles(appPath string) (map[string]FileInfo, error
 		return make(map[string]FileInfo), nil
 	}
 
+	// TypeScript behaviors deploy as the JavaScript they bundle to, so they
+	// are checked up front, once, rather than one worker at a time.
+	tables, err := typeScriptBehaviors(paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) > 0 {
+		if err := c.behaviors().Check(appPath, tables); err != nil {
+			return nil, err
+		}
+	}
+
 	// Process files in parallel
 	numWorkers := c.NumWorkers
 	if numWorkers < 1 {
@@ -109,32 +185,57 @@ func (c *FileCollector) CollectFiles(appPath string) (map[string]FileInfo, error
 }
 
 // collectPaths returns all file paths to be deployed.
-// Mirrors the allowlist in SimpleDevOps.Publisher.stream_files/1
+// Mirrors the allowlist in SimpleDevOps.Publisher.stream_files/1, less what
+// the built-in and .simpleignore rules exclude.
 func (c *FileCollector) collectPaths(appPath string) ([]string, error) {
+	rules, err := c.loadIgnoreRules(appPath)
+	if err != nil {
+		return nil, err
+	}
 	var paths []string
 
-	// Root config files
+	// Root config files. app.scl is never ignored: there is no app without it.
 	for _, name := range []string{"app.scl", "tables.scl"} {
 		fullPath := filepath.Join(appPath, name)
-		if _, err := c.FS.Stat(fullPath); err == nil {
+		if _, err := c.FS.Stat(fullPath); err == nil && (name == "app.scl" || c.keep(rules, name, false)) {
 			paths = append(paths, name)
 		}
 	}
 
 	// Security directory - all files
-	paths = append(paths, c.globFiles(appPath, "security")...)
+	paths = append(paths, c.globFiles(appPath, "security", rules)...)
 
-	// Scripts directory - all files
-	paths = append(paths, c.globFiles(appPath, "scripts")...)
+	// Scripts directory - all files, except what only the TypeScript compiler
+	// reads: generated behavior types, the behaviors' tsconfig.json, and
+	// TypeScript helpers beside the behaviors, which are named after no table
+	// and bundled into the behaviors that import them
+	tables, err := build.DeclaredTables(filepath.Join(appPath, "tables.scl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables.scl: %w", err)
+	}
+	declared := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		declared[table] = true
+	}
+	for _, p := range c.globFiles(appPath, "scripts", rules) {
+		if strings.HasPrefix(p, filepath.Join(behaviorsDir, build.BehaviorTypesDir)+string(filepath.Separator)) ||
+			p == filepath.Join(behaviorsDir, "tsconfig.json") {
+			continue
+		}
+		if table, ok := typeScriptBehavior(p); ok && !declared[table] {
+			continue
+		}
+		paths = append(paths, p)
+	}
 
 	// Records directory - all files
-	paths = append(paths, c.globFiles(appPath, "records")...)
+	paths = append(paths, c.globFiles(appPath, "records", rules)...)
 
 	// Assets directory - all files
-	paths = append(paths, c.globFiles(appPath, "assets")...)
+	paths = append(paths, c.globFiles(appPath, "assets", rules)...)
 
 	// Knowledge directory - all files
-	paths = append(paths, c.globFiles(appPath, "knowledge")...)
+	paths = append(paths, c.globFiles(appPath, "knowledge", rules)...)
 
 	// Actions - only WASM build outputs
 	actionsDir := filepath.Join(appPath, "actions")
@@ -147,12 +248,12 @@ func (c *FileCollector) collectPaths(appPath string) ([]string, error) {
 		if entry.IsDir() {
 			// release.wasm
 			wasmPath := filepath.Join("actions", entry.Name(), "build", "release.wasm")
-			if _, err := c.FS.Stat(filepath.Join(appPath, wasmPath)); err == nil {
+			if _, err := c.FS.Stat(filepath.Join(appPath, wasmPath)); err == nil && c.keep(rules, wasmPath, false) {
 				paths = append(paths, wasmPath)
 			}
 			// release.async.wasm
 			asyncWasmPath := filepath.Join("actions", entry.Name(), "build", "release.async.wasm")
-			if _, err := c.FS.Stat(filepath.Join(appPath, asyncWasmPath)); err == nil {
+			if _, err := c.FS.Stat(filepath.Join(appPath, asyncWasmPath)); err == nil && c.keep(rules, asyncWasmPath, false) {
 				paths = append(paths, asyncWasmPath)
 			}
 		}
@@ -166,16 +267,22 @@ func (c *FileCollector) collectPaths(appPath string) ([]string, error) {
 	}
 
 	for _, entry := range spaceEntries {
-		if entry.IsDir() {
-			paths = append(paths, c.globFiles(appPath, filepath.Join("spaces", entry.Name(), "dist"))...)
+		if !entry.IsDir() {
+			continue
+		}
+		for _, p := range c.globFiles(appPath, filepath.Join("spaces", entry.Name(), "dist"), rules) {
+			if filepath.Base(p) != build.SourcesStamp {
+				paths = append(paths, p)
+			}
 		}
 	}
 
 	return paths, nil
 }
 
-// globFiles recursively finds all files in a directory.
-func (c *FileCollector) globFiles(appPath, dir string) []string {
+// globFiles recursively finds all files in a directory that rules keep.
+// An excluded directory is skipped whole.
+func (c *FileCollector) globFiles(appPath, dir string, rules ignoreRules) []string {
 	var result []string
 	dirPath := filepath.Join(appPath, dir)
 
@@ -183,32 +290,90 @@ func (c *FileCollector) globFiles(appPath, dir string) []string {
 		if err != nil {
 			return err
 		}
+		rel, _ := filepath.Rel(appPath, path)
 		if d.IsDir() {
-			// Skip coverage directories
-			if d.Name() == "coverage" {
+			if path != dirPath && !c.keep(rules, rel, true) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-
-		// Skip test files
-		if strings.HasSuffix(d.Name(), ".test.js") || strings.HasSuffix(d.Name(), ".test.ts") {
-			return nil
+		if c.keep(rules, rel, false) {
+			result = append(result, rel)
 		}
-
-		rel, _ := filepath.Rel(appPath, path)
-		result = append(result, rel)
 		return nil
 	})
 
 	return result
 }
 
-// processFile reads a file, computes hash, and returns FileInfo.
+// keep reports whether rules let the file or directory at rel, relative to
+// the app root, deploy, passing it to OnExclude when they do not.
+func (c *FileCollector) keep(rules ignoreRules, rel string, isDir bool) bool {
+	rule := rules.match(rel, isDir)
+	if rule == nil {
+		return true
+	}
+	if c.OnExclude != nil {
+		c.OnExclude(rule.exclusion(rel, isDir))
+	}
+	return false
+}
+
+// typeScriptBehavior reports the table of a record behavior written in
+// TypeScript, given its path relative to the app root. collectPaths keeps
+// only the files it names a table for that tables.scl declares.
+func typeScriptBehavior(relPath string) (string, bool) {
+	if filepath.Dir(relPath) != behaviorsDir {
+		return "", false
+	}
+	name := filepath.Base(relPath)
+	if !strings.HasSuffix(name, ".ts") || strings.HasSuffix(name, ".d.ts") {
+		return "", false
+	}
+	return strings.TrimSuffix(name, ".ts"), true
+}
+
+// typeScriptBehaviors returns the tables whose behavior paths holds as
+// TypeScript. A table with both a .ts and a .js behavior is refused: both
+// would deploy as <table>.js, and which one won would be an accident.
+func typeScriptBehaviors(paths []string) ([]string, error) {
+	present := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		present[p] = true
+	}
+
+	var tables []string
+	for _, p := range paths {
+		table, ok := typeScriptBehavior(p)
+		if !ok {
+			continue
+		}
+		if js := filepath.Join(behaviorsDir, table+".js"); present[js] {
+			return nil, fmt.Errorf("record behavior for %s exists as both %s and %s; keep one", table, js, p)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// processFile hashes a file and returns its FileInfo.
+// A TypeScript behavior is bundled and returned as the <table>.js it deploys as.
 func (c *FileCollector) processFile(appPath, relPath string) (*FileInfo, error) {
 	absPath := filepath.Join(appPath, relPath)
 
-	content, err := c.FS.ReadFile(absPath)
+	var content []byte
+	var err error
+	if table, ok := typeScriptBehavior(relPath); ok {
+		content, err = c.behaviors().Bundle(appPath, table)
+		if err != nil {
+			return nil, err
+		}
+		relPath = filepath.Join(behaviorsDir, table+".js")
+	} else if opener, ok := c.FS.(fileOpener); ok {
+		return hashFile(opener, absPath, relPath)
+	} else {
+		content, err = c.FS.ReadFile(absPath)
+	}
 	if err != nil {
 		// File might have been deleted between path collection and processing
 		if os.IsNotExist(err) {
@@ -217,13 +382,44 @@ func (c *FileCollector) processFile(appPath, relPath string) (*FileInfo, error)
 		return nil, err
 	}
 
-	hash := sha256.Sum256(content)
+	file := MemFile(relPath, content)
+	return &file, nil
+}
 
-	return &FileInfo{
+// MemFile returns the FileInfo of content held in memory, deployed at relPath.
+func MemFile(relPath string, content []byte) FileInfo {
+	hash := sha256.Sum256(content)
+	return FileInfo{
 		Path:    relPath,
 		Hash:    hex.EncodeToString(hash[:]),
 		Size:    int64(len(content)),
 		Content: content,
+	}
+}
+
+// hashFile streams the file at absPath through SHA-256.
+func hashFile(opener fileOpener, absPath, relPath string) (*FileInfo, error) {
+	f, err := opener.Open(absPath)
+	if err != nil {
+		// File might have been deleted between path collection and processing
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+	}
+
+	return &FileInfo{
+		Path:   relPath,
+		Hash:   hex.EncodeToString(h.Sum(nil)),
+		Size:   size,
+		Source: absPath,
 	}, nil
 }
 
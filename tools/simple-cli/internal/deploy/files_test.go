@@ -3,9 +3,12 @@ package deploy
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -95,9 +98,17 @@ func TestFileCollector_CollectFiles(t *testing.T) {
 		t.Errorf("CollectFiles() app.scl hash mismatch")
 	}
 
-	// Verify content is loaded
-	if string(files["app.scl"].Content) != "id test\nversion 1.0.0" {
-		t.Errorf("CollectFiles() app.scl content mismatch")
+	// Verify content is left on disk until it is opened
+	if files["app.scl"].Content != nil || files["app.scl"].Source != appSCL {
+		t.Errorf("CollectFiles() app.scl = %+v, want no content and source %s", files["app.scl"], appSCL)
+	}
+	r, err := files["app.scl"].Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	if content, _ := io.ReadAll(r); string(content) != "id test\nversion 1.0.0" {
+		t.Errorf("CollectFiles() app.scl content = %q", content)
 	}
 
 	// Verify size
@@ -268,6 +279,89 @@ func TestFileCollector_Parallelization(t *testing.T) {
 	}
 }
 
+// stubBehaviorBundler records what it was asked to check and bundles every
+// behavior to a fixed marker, so collection can be tested without Node.
+type stubBehaviorBundler struct {
+	checked []string
+	mu      sync.Mutex
+}
+
+func (b *stubBehaviorBundler) Check(appPath string, tables []string) error {
+	b.checked = append(b.checked, tables...)
+	return nil
+}
+
+func (b *stubBehaviorBundler) Bundle(appPath, table string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return []byte("bundled " + table), nil
+}
+
+func TestFileCollector_CollectFiles_TypeScriptBehaviors(t *testing.T) {
+	dir := t.TempDir()
+
+	behaviors := filepath.Join(dir, "scripts", "record-behaviors")
+	_ = os.MkdirAll(filepath.Join(behaviors, "types"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "tables.scl"), []byte("table order {\n  required total, :decimal\n}\n"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "order.ts"), []byte("import { round } from './utils'\nexport default async () => {}"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "utils.ts"), []byte("export const round = (n: number) => Math.round(n)"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "order.test.ts"), []byte("// test"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "invoice.js"), []byte("export default async () => {}"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "tsconfig.json"), []byte("{}"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "types", "order.ts"), []byte("export interface OrderRecord {}"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "types", "behavior.ts"), []byte("export {}"), 0644)
+
+	bundler := &stubBehaviorBundler{}
+	collector := &FileCollector{FS: OSFileSystem{}, NumWorkers: 4, Behaviors: bundler}
+
+	files, err := collector.CollectFiles(dir)
+	if err != nil {
+		t.Fatalf("CollectFiles() unexpected error = %v", err)
+	}
+
+	if len(files) != 3 {
+		t.Errorf("CollectFiles() expected 3 files, got %d: %v", len(files), files)
+	}
+	if _, ok := files["scripts/record-behaviors/utils.js"]; ok {
+		t.Error("CollectFiles() bundled utils.ts, a helper named after no table, as a behavior")
+	}
+	if _, ok := files["scripts/record-behaviors/utils.ts"]; ok {
+		t.Error("CollectFiles() deployed utils.ts, which order.js bundles")
+	}
+
+	bundled, ok := files["scripts/record-behaviors/order.js"]
+	if !ok {
+		t.Fatal("CollectFiles() should deploy order.ts as order.js")
+	}
+	if string(bundled.Content) != "bundled order" {
+		t.Errorf("order.js content = %q, want the bundle", bundled.Content)
+	}
+	if _, ok := files["scripts/record-behaviors/invoice.js"]; !ok {
+		t.Error("CollectFiles() should still deploy a JavaScript behavior as it is")
+	}
+
+	if len(bundler.checked) != 1 || bundler.checked[0] != "order" {
+		t.Errorf("expected only order to be type-checked, got %v", bundler.checked)
+	}
+}
+
+func TestFileCollector_CollectFiles_BehaviorInBothLanguages(t *testing.T) {
+	dir := t.TempDir()
+
+	behaviors := filepath.Join(dir, "scripts", "record-behaviors")
+	_ = os.MkdirAll(behaviors, 0755)
+	_ = os.WriteFile(filepath.Join(dir, "tables.scl"), []byte("table order {}"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "order.ts"), []byte("// ts"), 0644)
+	_ = os.WriteFile(filepath.Join(behaviors, "order.js"), []byte("// js"), 0644)
+
+	collector := &FileCollector{FS: OSFileSystem{}, NumWorkers: 1, Behaviors: &stubBehaviorBundler{}}
+
+	_, err := collector.CollectFiles(dir)
+	if err == nil || !strings.Contains(err.Error(), "both") {
+		t.Errorf("expected a behavior in both languages to be refused, got %v", err)
+	}
+}
+
 func TestNewFileCollector(t *testing.T) {
 	collector := NewFileCollector()
 	if collector == nil {
@@ -0,0 +1,249 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the files that keep files out of a deploy, in
+// gitignore syntax: one in the app directory, and one at the workspace
+// root that applies to every app.
+const IgnoreFile = ".simpleignore"
+
+// Exclusion is a file, or a directory with everything in it, kept out of a
+// deploy, and the rule that kept it out.
+type Exclusion struct {
+	Path   string `json:"path"`   // relative to the app root; a directory ends in /
+	Rule   string `json:"rule"`   // the pattern as written
+	Source string `json:"source"` // file:line of the rule, or "built-in"
+}
+
+// builtinIgnores are excluded unless a .simpleignore says otherwise: test
+// files and coverage reports, which only tests read, and the files editors
+// and file managers leave behind.
+var builtinIgnores = []string{
+	"coverage/",
+	"*.test.js",
+	"*.test.ts",
+	".DS_Store",
+	"*.swp",
+	"*~",
+}
+
+// ignoreRule is one pattern of an ignore file.
+type ignoreRule struct {
+	pattern string
+	source  string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+	// prefix is the path from the directory of the rule's file to the app
+	// root, which app-relative paths are matched under.
+	prefix string
+}
+
+// ignoreRules are the rules applying to one app, in order: the last rule
+// matching a path decides it.
+type ignoreRules []ignoreRule
+
+// loadIgnoreRules reads the rules applying to the app at appPath: the
+// built-in ones, those of the workspace root's .simpleignore, then the
+// app's own.
+func (c *FileCollector) loadIgnoreRules(appPath string) (ignoreRules, error) {
+	var rules ignoreRules
+	for _, pattern := range builtinIgnores {
+		rule, ok, err := parseIgnoreRule(pattern, "built-in", "")
+		if err != nil || !ok {
+			return nil, fmt.Errorf("built-in ignore rule %q: %v", pattern, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if root, app, ok := c.workspaceRoot(appPath); ok {
+		prefix, err1 := filepath.Rel(root, app)
+		up, err2 := filepath.Rel(app, root)
+		if err1 == nil && err2 == nil && prefix != "." {
+			// Reached from appPath, the file is named as the app's is.
+			more, err := c.readIgnoreFile(filepath.Join(appPath, up, IgnoreFile), filepath.ToSlash(prefix)+"/")
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, more...)
+		}
+	}
+
+	more, err := c.readIgnoreFile(filepath.Join(appPath, IgnoreFile), "")
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, more...), nil
+}
+
+// workspaceRoot finds the workspace the app at appPath is in: the nearest
+// directory above it with a simple.scl. Both come back absolute.
+func (c *FileCollector) workspaceRoot(appPath string) (root, app string, ok bool) {
+	app, err := filepath.Abs(appPath)
+	if err != nil {
+		return "", "", false
+	}
+	for dir := filepath.Dir(app); ; dir = filepath.Dir(dir) {
+		if _, err := c.FS.Stat(filepath.Join(dir, "simple.scl")); err == nil {
+			return dir, app, true
+		}
+		if dir == filepath.Dir(dir) {
+			return "", "", false
+		}
+	}
+}
+
+// readIgnoreFile reads the rules of the ignore file at path, if there is one.
+func (c *FileCollector) readIgnoreFile(path, prefix string) (ignoreRules, error) {
+	data, err := c.FS.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var rules ignoreRules
+	for i, line := range strings.Split(string(data), "\n") {
+		source := fmt.Sprintf("%s:%d", filepath.ToSlash(path), i+1)
+		rule, ok, err := parseIgnoreRule(line, source, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// parseIgnoreRule parses one line of an ignore file, as git would. ok is
+// false for a blank line or a comment.
+func parseIgnoreRule(line, source, prefix string) (rule ignoreRule, ok bool, err error) {
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false, nil
+	}
+	rule = ignoreRule{pattern: line, source: source, prefix: prefix}
+
+	pattern := line
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	// A pattern with a slash other than a trailing one is relative to the
+	// directory of its file; one without matches at any depth.
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return rule, false, nil
+	}
+
+	expr := globToRegexp(pattern)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+	rule.re, err = regexp.Compile(expr)
+	if err != nil {
+		return rule, false, fmt.Errorf("invalid pattern %q", line)
+	}
+	return rule, true, nil
+}
+
+// globToRegexp translates a gitignore glob: * and ? never match a slash,
+// ** matches across directories, and [...] is a character class.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob):
+			b.WriteString(".*")
+			i++
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		case ch == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case ch == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	return b.String()
+}
+
+// match returns the rule excluding path, relative to the app root, or nil
+// when none does. A path in an excluded directory is excluded by the rule
+// that excluded the directory, as in git, whatever rules follow.
+func (rules ignoreRules) match(path string, isDir bool) *ignoreRule {
+	path = filepath.ToSlash(path)
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if rule := rules.decide(strings.Join(parts[:i], "/"), true); rule != nil {
+			return rule
+		}
+	}
+	return rules.decide(path, isDir)
+}
+
+// decide applies the rules to path alone, the last matching one winning.
+func (rules ignoreRules) decide(path string, isDir bool) *ignoreRule {
+	var excluded *ignoreRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if !rule.re.MatchString(rule.prefix + path) {
+			continue
+		}
+		if rule.negate {
+			excluded = nil
+		} else {
+			excluded = rule
+		}
+	}
+	return excluded
+}
+
+// exclusion describes the exclusion of path by rule.
+func (rule *ignoreRule) exclusion(path string, isDir bool) Exclusion {
+	path = filepath.ToSlash(path)
+	if isDir {
+		path += "/"
+	}
+	return Exclusion{Path: path, Rule: rule.pattern, Source: rule.source}
+}
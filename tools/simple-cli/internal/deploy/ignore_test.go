@@ -0,0 +1,141 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules_Match(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   string
+		prefix  string
+		path    string
+		isDir   bool
+		wantHit string // the pattern that excludes path, or "" for none
+	}{
+		{"basename at any depth", "*.psd", "", "assets/art/logo.psd", false, "*.psd"},
+		{"no match", "*.psd", "", "assets/logo.png", false, ""},
+		{"comment and blank lines", "# *.png\n\n", "", "assets/logo.png", false, ""},
+		{"escaped hash", `\#notes`, "", "assets/#notes", false, `\#notes`},
+		{"directory rule skips files of that name", "fixtures/", "", "scripts/fixtures", false, ""},
+		{"directory rule excludes its files", "fixtures/", "", "scripts/fixtures/users.json", false, "fixtures/"},
+		{"anchored to the ignore file", "/assets/raw", "", "assets/raw/a.png", false, "/assets/raw"},
+		{"anchored does not match deeper", "/raw", "", "assets/raw/a.png", false, ""},
+		{"middle slash anchors", "assets/*.tmp", "", "assets/sub/a.tmp", false, ""},
+		{"star stays in one directory", "scripts/*.js", "", "scripts/lib/a.js", false, ""},
+		{"double star crosses directories", "scripts/**/*.spec.js", "", "scripts/lib/deep/a.spec.js", false, "scripts/**/*.spec.js"},
+		{"leading double star", "**/__snapshots__", "", "scripts/lib/__snapshots__/a.snap", false, "**/__snapshots__"},
+		{"trailing double star", "knowledge/drafts/**", "", "knowledge/drafts/x/y.md", false, "knowledge/drafts/**"},
+		{"question mark and class", "v?.[ab]", "", "assets/v1.a", false, "v?.[ab]"},
+		{"negated class", "v[!0-9].txt", "", "assets/v1.txt", false, ""},
+		{"last rule wins", "*.json\n!keep.json", "", "records/keep.json", false, ""},
+		{"negation then exclusion", "!keep.json\n*.json", "", "records/keep.json", false, "*.json"},
+		{"no re-including inside an excluded directory", "drafts/\n!drafts/keep.md", "", "knowledge/drafts/keep.md", false, "drafts/"},
+		{"root rule under the app's prefix", "apps/crm/assets/raw/", "apps/crm/", "assets/raw/a.png", false, "apps/crm/assets/raw/"},
+		{"root rule for another app", "apps/hr/assets/", "apps/crm/", "assets/a.png", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules ignoreRules
+			for _, line := range strings.Split(tt.lines, "\n") {
+				rule, ok, err := parseIgnoreRule(line, "test", tt.prefix)
+				if err != nil {
+					t.Fatalf("parseIgnoreRule(%q) error = %v", line, err)
+				}
+				if ok {
+					rules = append(rules, rule)
+				}
+			}
+			got := ""
+			if rule := rules.match(tt.path, tt.isDir); rule != nil {
+				got = rule.pattern
+			}
+			if got != tt.wantHit {
+				t.Errorf("match(%q) = %q, want %q", tt.path, got, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestFileCollector_CollectFiles_SimpleIgnore(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "apps", "crm")
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("simple.scl", "")
+	write(".simpleignore", "# workspace\n*.psd\napps/crm/assets/raw/\n")
+	write("apps/crm/.simpleignore", "__snapshots__/\nfixtures/\n!keep.test.js\n")
+	write("apps/crm/app.scl", "id crm\nversion 1.0.0")
+	write("apps/crm/scripts/main.js", "main()")
+	write("apps/crm/scripts/main.test.js", "test()")
+	write("apps/crm/scripts/keep.test.js", "kept()")
+	write("apps/crm/scripts/__snapshots__/main.snap", "snap")
+	write("apps/crm/scripts/fixtures/users.json", "[]")
+	write("apps/crm/scripts/coverage/lcov.info", "lcov")
+	write("apps/crm/assets/logo.png", "png")
+	write("apps/crm/assets/logo.psd", "psd")
+	write("apps/crm/assets/.DS_Store", "ds")
+	write("apps/crm/assets/raw/photo.png", "raw")
+
+	collector := NewFileCollector()
+	var excluded []Exclusion
+	collector.OnExclude = func(e Exclusion) { excluded = append(excluded, e) }
+	files, err := collector.CollectFiles(app)
+	if err != nil {
+		t.Fatalf("CollectFiles() error = %v", err)
+	}
+
+	var got []string
+	for path := range files {
+		got = append(got, filepath.ToSlash(path))
+	}
+	sort.Strings(got)
+	want := []string{"app.scl", "assets/logo.png", "scripts/keep.test.js", "scripts/main.js"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	rootIgnore := filepath.ToSlash(filepath.Join(root, IgnoreFile))
+	appIgnore := filepath.ToSlash(filepath.Join(app, IgnoreFile))
+	wantExcluded := map[string]Exclusion{
+		"scripts/main.test.js":   {Rule: "*.test.js", Source: "built-in"},
+		"scripts/__snapshots__/": {Rule: "__snapshots__/", Source: appIgnore + ":1"},
+		"scripts/fixtures/":      {Rule: "fixtures/", Source: appIgnore + ":2"},
+		"scripts/coverage/":      {Rule: "coverage/", Source: "built-in"},
+		"assets/logo.psd":        {Rule: "*.psd", Source: rootIgnore + ":2"},
+		"assets/.DS_Store":       {Rule: ".DS_Store", Source: "built-in"},
+		"assets/raw/":            {Rule: "apps/crm/assets/raw/", Source: rootIgnore + ":3"},
+	}
+	if len(excluded) != len(wantExcluded) {
+		t.Errorf("excluded = %+v, want %d exclusions", excluded, len(wantExcluded))
+	}
+	for _, e := range excluded {
+		w, ok := wantExcluded[e.Path]
+		if !ok || e.Rule != w.Rule || e.Source != w.Source {
+			t.Errorf("excluded %s by %q at %s, want %q at %s", e.Path, e.Rule, e.Source, w.Rule, w.Source)
+		}
+	}
+}
+
+func TestFileCollector_CollectFiles_InvalidIgnorePattern(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "app.scl"), []byte("id test"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("*.png\n[z-a]\n"), 0644)
+
+	_, err := NewFileCollector().CollectFiles(dir)
+	if err == nil || !strings.Contains(err.Error(), IgnoreFile+":2: invalid pattern") {
+		t.Errorf("CollectFiles() error = %v, want the bad line named", err)
+	}
+}
@@ -2,8 +2,10 @@ package deploy
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -61,13 +63,18 @@ type PhoenixSocket struct {
 	refCounter uint64
 	channels   sync.Map // map[string]*PhoenixChannel - concurrent safe
 	done       chan struct{}
+	lost       chan struct{} // closed when the read loop stops
 	sendCh     chan outgoingMsg
 	connMu     sync.RWMutex
+
+	heartbeatInterval time.Duration
+	pendingHeartbeat  uint64 // ref of the heartbeat awaiting its reply, 0 if none
 }
 
 type outgoingMsg struct {
 	msgType int
 	data    []byte
+	written chan struct{} // closed once data is written, when not nil
 }
 
 // PhoenixChannel represents a joined channel on the socket.
@@ -76,6 +83,10 @@ type PhoenixChannel struct {
 	topic    string
 	joinRef  uint64
 	bindings sync.Map // map[uint64]func(any) - concurrent safe
+
+	subsMu sync.Mutex
+	subs   map[string]map[uint64]func(any) // event → subscription → callback
+	subID  uint64
 }
 
 // phoenixMessage is the decoded Phoenix channel message.
@@ -93,7 +104,10 @@ func NewPhoenixSocket(endpointURL *url.URL) *PhoenixSocket {
 	return &PhoenixSocket{
 		endpoint: endpointURL,
 		done:     make(chan struct{}),
+		lost:     make(chan struct{}),
 		sendCh:   make(chan outgoingMsg, messageQueueLength),
+
+		heartbeatInterval: defaultHeartbeatInterval,
 	}
 }
 
@@ -108,6 +122,12 @@ func (e *AuthFailedError) Error() string {
 
 // Connect establishes the WebSocket connection.
 func (s *PhoenixSocket) Connect() error {
+	return s.ConnectContext(context.Background())
+}
+
+// ConnectContext establishes the WebSocket connection, giving up when ctx
+// ends.
+func (s *PhoenixSocket) ConnectContext(ctx context.Context) error {
 	wsURL := *s.endpoint
 	wsURL.Path = path.Join(wsURL.Path, "websocket")
 	q := wsURL.Query()
@@ -121,12 +141,12 @@ func (s *PhoenixSocket) Connect() error {
 		wsURL.Scheme = "ws"
 	}
 
-	dialer := websocket.DefaultDialer
+	dialer := *websocket.DefaultDialer
 	dialer.HandshakeTimeout = defaultConnectTimeout
 	dialer.ReadBufferSize = 16384
 	dialer.WriteBufferSize = 16384
 
-	conn, resp, err := dialer.Dial(wsURL.String(), http.Header{})
+	conn, resp, err := dialer.DialContext(ctx, wsURL.String(), http.Header{})
 	if err != nil {
 		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
 			return &AuthFailedError{StatusCode: resp.StatusCode}
@@ -193,6 +213,10 @@ func (s *PhoenixSocket) nextRef() uint64 {
 	return atomic.AddUint64(&s.refCounter, 1)
 }
 
+// errSendQueueFull reports a message not queued because the socket is not
+// keeping up; sending it again later may succeed.
+var errSendQueueFull = errors.New("send queue full")
+
 // send queues a message for sending (non-blocking with large buffer).
 func (s *PhoenixSocket) send(msgType int, data []byte) error {
 	select {
@@ -208,7 +232,7 @@ func (s *PhoenixSocket) send(msgType int, data []byte) error {
 		case <-s.done:
 			return fmt.Errorf("socket closed")
 		case <-time.After(100 * time.Millisecond):
-			return fmt.Errorf("send queue full")
+			return errSendQueueFull
 		}
 	}
 }
@@ -225,11 +249,22 @@ func (s *PhoenixSocket) writeLoop() {
 			if conn != nil {
 				_ = conn.WriteMessage(msg.msgType, msg.data)
 			}
+			if msg.written != nil {
+				close(msg.written)
+			}
 		}
 	}
 }
 
+// Lost returns a channel that is closed once the socket stops reading,
+// because the connection dropped or Disconnect was called. Replies to
+// pushes still outstanding then never arrive.
+func (s *PhoenixSocket) Lost() <-chan struct{} {
+	return s.lost
+}
+
 func (s *PhoenixSocket) readLoop() {
+	defer close(s.lost)
 	for {
 		select {
 		case <-s.done:
@@ -262,22 +297,42 @@ func (s *PhoenixSocket) readLoop() {
 			continue
 		}
 
+		if msg.Topic == "phoenix" {
+			atomic.CompareAndSwapUint64(&s.pendingHeartbeat, msg.Ref, 0)
+			continue
+		}
+
 		if ch, ok := s.channels.Load(msg.Topic); ok {
 			ch.(*PhoenixChannel).handleMessage(msg)
 		}
 	}
 }
 
+// heartbeatLoop sends a heartbeat every interval. A heartbeat still
+// unanswered when the next is due means the connection is dead even if the
+// OS has not noticed yet, as after a laptop switches networks; the
+// connection is then closed so that the socket reports it lost.
 func (s *PhoenixSocket) heartbeatLoop() {
-	ticker := time.NewTicker(defaultHeartbeatInterval)
+	ticker := time.NewTicker(s.heartbeatInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-s.done:
 			return
+		case <-s.lost:
+			return
 		case <-ticker.C:
+			if atomic.LoadUint64(&s.pendingHeartbeat) != 0 {
+				s.connMu.RLock()
+				if s.conn != nil {
+					_ = s.conn.Close()
+				}
+				s.connMu.RUnlock()
+				return
+			}
 			ref := s.nextRef()
+			atomic.StoreUint64(&s.pendingHeartbeat, ref)
 			data := encodeJSONMessageFast(0, ref, "phoenix", "heartbeat", nil)
 			_ = s.send(websocket.TextMessage, data)
 		}
@@ -479,36 +534,51 @@ func parseRefFast(raw json.RawMessage) uint64 {
 
 // Join sends a join message and waits for response.
 func (c *PhoenixChannel) Join(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), timeout, errors.New("join timeout"))
+	defer cancel()
+	_, err := c.JoinWith(ctx, nil)
+	return err
+}
+
+// JoinWith joins with payload as the join parameters and returns the
+// response of the server's reply. It gives up with the context's cause when
+// ctx ends first.
+func (c *PhoenixChannel) JoinWith(ctx context.Context, payload any) (map[string]any, error) {
 	ref := c.socket.nextRef()
 	c.joinRef = ref
 
-	data := encodeJSONMessageFast(ref, ref, c.topic, "phx_join", nil)
+	data := encodeJSONMessageFast(ref, ref, c.topic, "phx_join", payload)
 
-	done := make(chan error, 1)
+	type joinReply struct {
+		response map[string]any
+		err      error
+	}
+	done := make(chan joinReply, 1)
 	c.bindings.Store(ref, func(payload any) {
 		resp, ok := payload.(map[string]any)
 		if !ok {
-			done <- nil
+			done <- joinReply{}
 			return
 		}
 		if status, ok := resp["status"].(string); ok && status == "error" {
-			done <- fmt.Errorf("join error: %v", resp["response"])
+			done <- joinReply{err: fmt.Errorf("join error: %v", resp["response"])}
 			return
 		}
-		done <- nil
+		response, _ := resp["response"].(map[string]any)
+		done <- joinReply{response: response}
 	})
 
 	if err := c.socket.send(websocket.TextMessage, data); err != nil {
 		c.bindings.Delete(ref)
-		return err
+		return nil, err
 	}
 
 	select {
-	case err := <-done:
-		return err
-	case <-time.After(timeout):
+	case reply := <-done:
+		return reply.response, reply.err
+	case <-ctx.Done():
 		c.bindings.Delete(ref)
-		return fmt.Errorf("join timeout")
+		return nil, context.Cause(ctx)
 	}
 }
 
@@ -537,6 +607,19 @@ func (c *PhoenixChannel) PushBinary(event string, payload []byte) (uint64, error
 // PushBinaryFile sends a file with metadata in our custom format.
 // Returns an error if the combined payload size would exceed safe limits.
 func (c *PhoenixChannel) PushBinaryFile(metadata map[string]string, content []byte) (uint64, error) {
+	return c.pushFileFrame("file", metadata, content)
+}
+
+// PushFileChunk sends one piece of a file too large for a single frame, in
+// the same format as PushBinaryFile under the "file_chunk" event. metadata
+// carries the file's path and hash, the chunk's byte offset, the file's
+// total size and whether the chunk is the final one; the server assembles
+// the chunks and checks the hash when the final one arrives.
+func (c *PhoenixChannel) PushFileChunk(metadata map[string]any, chunk []byte) (uint64, error) {
+	return c.pushFileFrame("file_chunk", metadata, chunk)
+}
+
+func (c *PhoenixChannel) pushFileFrame(event string, metadata any, content []byte) (uint64, error) {
 	metaJSON, err := json.Marshal(metadata)
 	if err != nil {
 		return 0, err
@@ -560,7 +643,7 @@ func (c *PhoenixChannel) PushBinaryFile(metadata map[string]string, content []by
 	copy(payload[4:4+len(metaJSON)], metaJSON)
 	copy(payload[4+len(metaJSON):], content)
 
-	return c.PushBinary("file", payload)
+	return c.PushBinary(event, payload)
 }
 
 // onRef registers a one-time callback for a specific ref.
@@ -568,7 +651,45 @@ func (c *PhoenixChannel) onRef(ref uint64, callback func(any)) {
 	c.bindings.Store(ref, callback)
 }
 
+// On subscribes fn to event when the server pushes it on the channel of its
+// own accord, rather than in reply to a push, until the returned func is
+// called. fn runs on the socket's read loop, one message at a time, and
+// must not block.
+func (c *PhoenixChannel) On(event string, fn func(payload any)) func() {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	if c.subs == nil {
+		c.subs = map[string]map[uint64]func(any){}
+	}
+	if c.subs[event] == nil {
+		c.subs[event] = map[uint64]func(any){}
+	}
+	c.subID++
+	id := c.subID
+	c.subs[event][id] = fn
+	return func() {
+		c.subsMu.Lock()
+		defer c.subsMu.Unlock()
+		delete(c.subs[event], id)
+	}
+}
+
 func (c *PhoenixChannel) handleMessage(msg *phoenixMessage) {
+	if msg.Event != "phx_reply" {
+		c.subsMu.Lock()
+		subscribers := make([]func(any), 0, len(c.subs[msg.Event]))
+		for _, fn := range c.subs[msg.Event] {
+			subscribers = append(subscribers, fn)
+		}
+		c.subsMu.Unlock()
+		for _, fn := range subscribers {
+			fn(msg.Payload)
+		}
+		if len(subscribers) > 0 {
+			return
+		}
+	}
+
 	if callback, ok := c.bindings.Load(msg.Ref); ok {
 		fn := callback.(func(any))
 
@@ -593,6 +714,30 @@ func (c *PhoenixChannel) Leave() error {
 	return c.socket.send(websocket.TextMessage, data)
 }
 
+// LeaveGracefully sends a leave message and waits until it is written to
+// the connection, so that disconnecting afterwards does not cut it off. It
+// gives up when the connection drops or ctx ends first.
+func (c *PhoenixChannel) LeaveGracefully(ctx context.Context) error {
+	ref := c.socket.nextRef()
+	data := encodeJSONMessageFast(c.joinRef, ref, c.topic, "phx_leave", nil)
+	written := make(chan struct{})
+	select {
+	case c.socket.sendCh <- outgoingMsg{msgType: websocket.TextMessage, data: data, written: written}:
+	case <-c.socket.done:
+		return fmt.Errorf("socket closed")
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+	select {
+	case <-written:
+		return nil
+	case <-c.socket.Lost():
+		return errConnectionLost
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
 // Legacy compatibility functions
 
 func decodeJSONMessage(data []byte) (*phoenixMessage, error) {
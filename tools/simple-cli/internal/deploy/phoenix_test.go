@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -607,6 +608,101 @@ func TestPhoenixSocketConnectAndJoin(t *testing.T) {
 	}
 }
 
+func TestPhoenixSocketHeartbeat(t *testing.T) {
+	for _, answer := range []bool{true, false} {
+		t.Run(fmt.Sprintf("answered=%v", answer), func(t *testing.T) {
+			server := startMockPhoenixServer(t, func(conn *websocket.Conn) {
+				for {
+					_, data, err := conn.ReadMessage()
+					if err != nil {
+						return
+					}
+					if msg := decodeJSONMessageFast(data); answer && msg != nil && msg.Event == "heartbeat" {
+						reply := encodeJSONMessageFast(0, msg.Ref, "phoenix", "phx_reply",
+							map[string]any{"status": "ok", "response": map[string]any{}})
+						_ = conn.WriteMessage(websocket.TextMessage, reply)
+					}
+				}
+			})
+			defer server.Close()
+
+			u, _ := url.Parse("ws" + strings.TrimPrefix(server.URL, "http") + "/socket")
+			socket := NewPhoenixSocket(u)
+			socket.heartbeatInterval = 20 * time.Millisecond
+			if err := socket.Connect(); err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+			defer socket.Disconnect()
+
+			select {
+			case <-socket.Lost():
+				if answer {
+					t.Error("socket lost although heartbeats were answered")
+				}
+			case <-time.After(200 * time.Millisecond):
+				if !answer {
+					t.Error("socket not lost although heartbeats went unanswered")
+				}
+			}
+		})
+	}
+}
+
+func TestPhoenixChannelOn(t *testing.T) {
+	server := startMockPhoenixServer(t, func(conn *websocket.Conn) {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			msg := decodeJSONMessageFast(data)
+			if msg == nil {
+				continue
+			}
+			if msg.Event == "ping" {
+				// A pushed event, then the reply to the push.
+				_ = conn.WriteMessage(websocket.TextMessage, encodeJSONMessageFast(msg.JoinRef, 0, msg.Topic, "news", map[string]any{"n": msg.Payload}))
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, encodeJSONMessageFast(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply",
+				map[string]any{"status": "ok", "response": map[string]any{}}))
+		}
+	})
+	defer server.Close()
+
+	u, _ := url.Parse("ws" + strings.TrimPrefix(server.URL, "http") + "/socket")
+	socket := NewPhoenixSocket(u)
+	if err := socket.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer socket.Disconnect()
+	ch := socket.Channel("test:room")
+	if err := ch.Join(5 * time.Second); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+
+	first, second := make(chan any, 10), make(chan any, 10)
+	unsubscribe := ch.On("news", func(payload any) { first <- payload })
+	ping := func(n int, received chan any) {
+		t.Helper()
+		if _, err := ch.Push("ping", n); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatalf("news of ping %d not received", n)
+		}
+	}
+
+	ping(1, first)
+	unsubscribe()
+	ch.On("news", func(payload any) { second <- payload })
+	ping(2, second)
+	if len(first) != 0 {
+		t.Errorf("news received after unsubscribing: %v", <-first)
+	}
+}
+
 func TestPhoenixSocketConnectAuthFailure(t *testing.T) {
 	// Start mock server that returns 401
 	server := startMockPhoenixServer(t, func(conn *websocket.Conn) {
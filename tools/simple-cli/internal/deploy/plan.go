@@ -0,0 +1,39 @@
+package deploy
+
+import "sort"
+
+// Plan is how a deploy would change an environment's files: each path of the
+// local manifest or the installed one, sorted, in exactly one of New,
+// Changed, Unchanged and Removed.
+type Plan struct {
+	New       []string `json:"new"`
+	Changed   []string `json:"changed"`
+	Unchanged []string `json:"unchanged"`
+	Removed   []string `json:"removed"`
+}
+
+// DiffManifests compares the files about to be deployed with the manifest of
+// the installed version, which is empty when nothing is installed.
+func DiffManifests(local, installed map[string]FileInfo) Plan {
+	p := Plan{New: []string{}, Changed: []string{}, Unchanged: []string{}, Removed: []string{}}
+	for path, fi := range local {
+		old, ok := installed[path]
+		switch {
+		case !ok:
+			p.New = append(p.New, path)
+		case old.Hash != fi.Hash:
+			p.Changed = append(p.Changed, path)
+		default:
+			p.Unchanged = append(p.Unchanged, path)
+		}
+	}
+	for path := range installed {
+		if _, ok := local[path]; !ok {
+			p.Removed = append(p.Removed, path)
+		}
+	}
+	for _, paths := range [][]string{p.New, p.Changed, p.Unchanged, p.Removed} {
+		sort.Strings(paths)
+	}
+	return p
+}
@@ -0,0 +1,38 @@
+package deploy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffManifests(t *testing.T) {
+	local := map[string]FileInfo{
+		"app.scl":            {Hash: "a2"},
+		"tables.scl":         {Hash: "t1"},
+		"scripts/new.js":     {Hash: "n1"},
+		"records/seed.json":  {Hash: "s1"},
+		"scripts/another.js": {Hash: "x1"},
+	}
+	installed := map[string]FileInfo{
+		"app.scl":           {Hash: "a1"},
+		"tables.scl":        {Hash: "t1"},
+		"records/seed.json": {Hash: "s1"},
+		"scripts/old.js":    {Hash: "o1"},
+	}
+
+	got := DiffManifests(local, installed)
+	want := Plan{
+		New:       []string{"scripts/another.js", "scripts/new.js"},
+		Changed:   []string{"app.scl"},
+		Unchanged: []string{"records/seed.json", "tables.scl"},
+		Removed:   []string{"scripts/old.js"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffManifests() = %+v, want %+v", got, want)
+	}
+
+	got = DiffManifests(local, nil)
+	if len(got.New) != len(local) || len(got.Changed)+len(got.Unchanged)+len(got.Removed) != 0 {
+		t.Errorf("DiffManifests() with nothing installed = %+v, want every file new", got)
+	}
+}
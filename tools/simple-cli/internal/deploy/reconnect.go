@@ -0,0 +1,83 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Reconnect reports on the client restoring a dropped connection. It is
+// sent before each attempt, with Err why the previous one failed, and once
+// more with Restored set when the channel is joined again.
+type Reconnect struct {
+	Attempt  int
+	Wait     time.Duration
+	Err      error
+	Restored bool
+}
+
+// reconnectBackoff is how long the client waits before each attempt at
+// reconnecting, doubling up to about half a minute in all. Jitter is added
+// as for uploads.
+var reconnectBackoff = []time.Duration{
+	500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second,
+}
+
+// maxReconnects is how many dropped connections one request survives.
+const maxReconnects = 3
+
+// reconnect replaces a dropped connection with a new one and rejoins the
+// deploy channel of the app, backing off between attempts. A rejected token
+// ends the attempts at once: it will not be accepted on the next one either.
+// So does ctx ending.
+func (c *Client) reconnect(ctx context.Context) error {
+	if c.socket != nil {
+		c.socket.Disconnect()
+	}
+
+	var err error
+	for i, wait := range reconnectBackoff {
+		wait = withJitter(wait)
+		c.reportReconnect(Reconnect{Attempt: i + 1, Wait: wait, Err: err})
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+
+		if err = c.Connect(ctx); err == nil {
+			if err = c.JoinChannel(ctx, c.appID); err == nil {
+				c.reportReconnect(Reconnect{Attempt: i + 1, Restored: true})
+				return nil
+			}
+			c.socket.Disconnect()
+		}
+		var authErr *AuthFailedError
+		if errors.As(err, &authErr) {
+			break
+		}
+	}
+	return fmt.Errorf("could not reconnect: %w", err)
+}
+
+func (c *Client) reportReconnect(r Reconnect) {
+	if c.onReconnect != nil {
+		c.onReconnect(r)
+	}
+}
+
+// withReconnect runs call, and runs it again on a restored connection each
+// time the connection drops before call has its reply. It suits calls the
+// server can safely be sent twice.
+func (c *Client) withReconnect(ctx context.Context, call func() error) error {
+	for drops := 0; ; drops++ {
+		err := call()
+		if !errors.Is(err, errConnectionLost) || drops == maxReconnects {
+			return err
+		}
+		if err := c.reconnect(ctx); err != nil {
+			return fmt.Errorf("connection lost: %w", err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package deploy
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// UploadEvent says what an UploadProgress reports.
+type UploadEvent int
+
+const (
+	// FileUploaded reports that the server accepted Path.
+	FileUploaded UploadEvent = iota
+	// FileRetrying reports that attempt Attempt at uploading Path failed
+	// with Err and that the file will be sent again.
+	FileRetrying
+	// UploadResumed reports that the connection dropped and was restored,
+	// the manifest re-sent, and that Total-Done files are left to upload.
+	UploadResumed
+)
+
+// UploadProgress reports on an upload by SendFiles. Done and Bytes count the
+// files the server has, out of Total and TotalBytes; a file the server turns
+// out to have after a resume counts as done without being sent again.
+type UploadProgress struct {
+	Event      UploadEvent
+	Path       string
+	Attempt    int
+	Err        error
+	Done       int
+	Total      int
+	Bytes      int64
+	TotalBytes int64
+}
+
+// uploadBackoff is how long a file waits before each retry. Up to half as
+// much again is added at random, so that files failing together do not all
+// retry together.
+var uploadBackoff = []time.Duration{500 * time.Millisecond, 2 * time.Second, 5 * time.Second}
+
+// maxUploadResumes is how many dropped connections one SendFiles survives.
+const maxUploadResumes = 3
+
+var (
+	// errConnectionLost reports that the socket stopped reading mid-upload.
+	errConnectionLost = errors.New("connection lost")
+	// errUploadStopped ends a file's upload once another file has failed.
+	errUploadStopped = errors.New("upload stopped")
+)
+
+// transientError is an upload failure worth retrying.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+func withJitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int64N(int64(d)/2+1))
+}
+
+// uploadTracker counts the files of an upload and reports on them, one
+// report at a time.
+type uploadTracker struct {
+	mu       sync.Mutex
+	files    map[string]FileInfo
+	tracked  map[string]bool // path → done
+	progress UploadProgress
+	report   func(UploadProgress)
+}
+
+func newUploadTracker(files map[string]FileInfo, paths []string, report func(UploadProgress)) *uploadTracker {
+	t := &uploadTracker{files: files, tracked: map[string]bool{}, report: report}
+	t.track(paths)
+	return t
+}
+
+// track adds the paths not tracked yet to the totals.
+func (t *uploadTracker) track(paths []string) {
+	for _, path := range paths {
+		fi, ok := t.files[path]
+		if _, seen := t.tracked[path]; seen || !ok {
+			continue
+		}
+		t.tracked[path] = false
+		t.progress.Total++
+		t.progress.TotalBytes += fi.Size
+	}
+}
+
+func (t *uploadTracker) done(path string) {
+	if done, ok := t.tracked[path]; ok && !done {
+		t.tracked[path] = true
+		t.progress.Done++
+		t.progress.Bytes += t.files[path].Size
+	}
+}
+
+func (t *uploadTracker) emit(event UploadEvent, path string, attempt int, err error) {
+	if t.report == nil {
+		return
+	}
+	p := t.progress
+	p.Event, p.Path, p.Attempt, p.Err = event, path, attempt, err
+	t.report(p)
+}
+
+func (t *uploadTracker) uploaded(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done(path)
+	t.emit(FileUploaded, path, 0, nil)
+}
+
+func (t *uploadTracker) retrying(path string, attempt int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.emit(FileRetrying, path, attempt, err)
+}
+
+// resumed marks every file the server no longer needs as done.
+func (t *uploadTracker) resumed(pending []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.track(pending)
+	still := make(map[string]bool, len(pending))
+	for _, path := range pending {
+		still[path] = true
+	}
+	for path := range t.tracked {
+		if !still[path] {
+			t.done(path)
+		}
+	}
+	t.emit(UploadResumed, "", 0, nil)
+}
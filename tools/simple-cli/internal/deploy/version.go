@@ -2,11 +2,12 @@
 package deploy
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"simple-cli/internal/config"
+	"simple-cli/internal/scl"
 	"strconv"
 	"strings"
 )
@@ -33,57 +34,34 @@ func (OSFileSystem) Stat(path string) (os.FileInfo, error) {
 	return os.Stat(path)
 }
 
-// SCLParser abstracts the scl-parser CLI for testing.
-type SCLParser interface {
-	Parse(path string) ([]SCLBlock, error)
-}
-
-// DefaultSCLParser uses the scl-parser CLI binary.
-type DefaultSCLParser struct {
-	ParserPath string
+func (OSFileSystem) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
 }
 
-// SCLBlock represents a block in the SCL AST.
-type SCLBlock struct {
-	Type     string     `json:"type"`
-	Key      string     `json:"key"`
-	Name     string     `json:"name"`
-	Value    any        `json:"value"`
-	Children []SCLBlock `json:"children"`
+// SCLParser abstracts SCL parsing for testing.
+type SCLParser interface {
+	Parse(path string) ([]*scl.Node, error)
 }
 
-// Parse executes scl-parser CLI and returns the AST.
-func (p *DefaultSCLParser) Parse(path string) ([]SCLBlock, error) {
-	cmd := exec.Command(p.ParserPath, path)
-	output, err := cmd.Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("scl-parser failed: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("scl-parser execution failed: %w", err)
-	}
-
-	var blocks []SCLBlock
-	if err := json.Unmarshal(output, &blocks); err != nil {
-		return nil, fmt.Errorf("failed to parse scl-parser output: %w", err)
-	}
+// DefaultSCLParser parses SCL files natively with the scl package.
+type DefaultSCLParser struct{}
 
-	return blocks, nil
+// Parse reads and parses the SCL file at path.
+func (DefaultSCLParser) Parse(path string) ([]*scl.Node, error) {
+	return scl.ParseFile(path)
 }
 
 // VersionManager handles version bumping and app.scl updates.
 type VersionManager struct {
-	FS         FileSystem
-	Parser     SCLParser
-	ParserPath string
+	FS     FileSystem
+	Parser SCLParser
 }
 
 // NewVersionManager creates a VersionManager with the default filesystem.
-func NewVersionManager(parserPath string) *VersionManager {
+func NewVersionManager() *VersionManager {
 	return &VersionManager{
-		FS:         OSFileSystem{},
-		Parser:     &DefaultSCLParser{ParserPath: parserPath},
-		ParserPath: parserPath,
+		FS:     OSFileSystem{},
+		Parser: DefaultSCLParser{},
 	}
 }
 
@@ -91,9 +69,12 @@ func NewVersionManager(parserPath string) *VersionManager {
 type AppSCL struct {
 	ID      string
 	Version string
+	// DependsOn lists the apps this one needs installed first, as
+	// `depends_on com.example.core, com.example.billing` declares.
+	DependsOn []string
 }
 
-// ParseAppSCL parses app.scl using scl-parser and extracts id and version.
+// ParseAppSCL parses app.scl and extracts id and version.
 func (vm *VersionManager) ParseAppSCL(appPath string) (*AppSCL, error) {
 	sclPath := filepath.Join(appPath, "app.scl")
 
@@ -106,6 +87,11 @@ func (vm *VersionManager) ParseAppSCL(appPath string) (*AppSCL, error) {
 
 	// Extract properties via helper
 	app.ID, app.Version = extractFromBlocks(blocks)
+	for _, block := range blocks {
+		if !block.IsBlock() && block.Key == "depends_on" {
+			app.DependsOn = append(app.DependsOn, block.Names()...)
+		}
+	}
 
 	if app.ID == "" {
 		return nil, fmt.Errorf("id not found in app.scl")
@@ -117,55 +103,61 @@ func (vm *VersionManager) ParseAppSCL(appPath string) (*AppSCL, error) {
 	return app, nil
 }
 
-// extractFromBlocks handles the scl-parser's actual output format
-func extractFromBlocks(blocks []SCLBlock) (id, version string) {
+// extractFromBlocks reads id and version off the top-level key-values of app.scl.
+func extractFromBlocks(blocks []*scl.Node) (id, version string) {
 	for _, block := range blocks {
-		// Handle KV properties
-		if block.Type == "kv" {
-			switch block.Key {
-			case "id":
-				if s, ok := block.Value.(string); ok {
-					id = s
-				}
-			case "version":
-				if s, ok := block.Value.(string); ok {
-					version = s
-				}
-			}
+		if block.IsBlock() {
+			continue
+		}
+		switch block.Key {
+		case "id":
+			id = block.Value().String()
+		case "version":
+			version = block.Value().String()
 		}
 	}
 	return
 }
 
-// BumpVersion calculates the new version and updates app.scl.
-// Returns the new version string.
-func (vm *VersionManager) BumpVersion(appPath, env, bumpType string) (string, error) {
-	appSCLPath := filepath.Join(appPath, "app.scl")
-
+// NextVersion calculates the version the next deploy of the app at appPath
+// to env gets, leaving app.scl alone, and returns app.scl as BumpVersion
+// would rewrite it. cfg is the workspace's simple.scl, whose pipeline the
+// version follows (see ComputeNewVersion).
+func (vm *VersionManager) NextVersion(appPath, env, bumpType string, cfg *config.SimpleSCL) (string, []byte, error) {
 	// Read current content for modification
-	content, err := vm.FS.ReadFile(appSCLPath)
+	content, err := vm.FS.ReadFile(filepath.Join(appPath, "app.scl"))
 	if err != nil {
-		return "", fmt.Errorf("failed to read app.scl: %w", err)
+		return "", nil, fmt.Errorf("failed to read app.scl: %w", err)
 	}
 
 	// Parse to get current version
 	app, err := vm.ParseAppSCL(appPath)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	newVersion, err := ComputeNewVersion(app.Version, env, bumpType)
+	newVersion, err := ComputeNewVersion(app.Version, env, bumpType, cfg)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// Update app.scl with new version - use minimal string replacement
 	// This is the ONLY place we use string manipulation (not regex)
 	newContent := replaceVersionInContent(string(content), app.Version, newVersion)
-	if err := vm.FS.WriteFile(appSCLPath, []byte(newContent), 0644); err != nil {
+	return newVersion, []byte(newContent), nil
+}
+
+// BumpVersion calculates the new version and updates app.scl.
+// Returns the new version string. cfg is the workspace's simple.scl (see
+// NextVersion).
+func (vm *VersionManager) BumpVersion(appPath, env, bumpType string, cfg *config.SimpleSCL) (string, error) {
+	newVersion, content, err := vm.NextVersion(appPath, env, bumpType, cfg)
+	if err != nil {
+		return "", err
+	}
+	if err := vm.FS.WriteFile(filepath.Join(appPath, "app.scl"), content, 0644); err != nil {
 		return "", fmt.Errorf("failed to write app.scl: %w", err)
 	}
-
 	return newVersion, nil
 }
 
@@ -187,41 +179,69 @@ func replaceVersionInContent(content, oldVersion, newVersion string) string {
 	return content
 }
 
-// ComputeNewVersion implements the version state machine.
+// ComputeNewVersion implements the version state machine, following the
+// pipeline of cfg. Deploys to a release environment, as simple.scl declares
+// them, get plain versions; deploys to any other environment get a
+// prerelease named after it.
 //
-// Version Flow:
-//   - Prod → Non-prod (requires --bump): 1.0.0 + patch → 1.0.1-dev.1
-//   - Non-prod → Same env: 1.0.1-dev.1 → 1.0.1-dev.2
-//   - Non-prod → Different env: 1.0.1-dev.5 → 1.0.1-staging.1
-//   - Non-prod → Prod: 1.0.1-staging.3 → 1.0.1
-//   - Prod → Prod (requires --bump): 1.0.0 + patch → 1.0.1
-func ComputeNewVersion(current, env, bumpType string) (string, error) {
+// Version Flow, with dev → staging → prod and prod the release environment:
+//   - Release → Non-release (requires --bump): 1.0.0 + patch → 1.0.1-dev.1
+//   - Non-release → Same env: 1.0.1-dev.1 → 1.0.1-dev.2
+//   - Non-release → Later env: 1.0.1-dev.5 → 1.0.1-staging.1
+//   - Non-release → Earlier env (requires --bump): 1.0.1-staging.3 + patch → 1.0.2-dev.1
+//   - Non-release → Release: 1.0.1-staging.3 → 1.0.1
+//   - Release → Release (requires --bump): 1.0.0 + patch → 1.0.1
+//
+// A plain version does not say which release environment it went to, so
+// one deployed to a release environment after the first of the pipeline
+// keeps it, as the first has already released it. With --bump it gets a new
+// one instead.
+func ComputeNewVersion(current, env, bumpType string, cfg *config.SimpleSCL) (string, error) {
 	major, minor, patch, prerelease := ParseVersion(current)
 
-	isProd := env == "prod"
 	hasPrerelease := prerelease != ""
+	currentEnv := extractEnvFromPrerelease(prerelease)
+	currentCounter := extractCounter(prerelease)
+
+	// A prerelease only moves forward through the pipeline; going back to an
+	// earlier environment starts the next version.
+	if hasPrerelease && currentEnv != env {
+		if _, ok := cfg.Environments[currentEnv]; ok {
+			if err := cfg.CheckPromotion(currentEnv, env); err != nil {
+				if bumpType == "" {
+					return "", fmt.Errorf("--bump required to deploy %s to %s: %w", current, env, err)
+				}
+				base, err := bumpMajorMinorPatch(major, minor, patch, bumpType)
+				if err != nil || cfg.IsRelease(env) {
+					return base, err
+				}
+				return fmt.Sprintf("%s-%s.1", base, env), nil
+			}
+		}
+	}
 
-	if isProd {
-		// Prod: strip prerelease
+	if cfg.IsRelease(env) {
+		// Release: strip prerelease
 		if hasPrerelease {
-			// Already has version from dev/staging, just strip prerelease
+			// Already has version from an earlier environment, just strip prerelease
 			return fmt.Sprintf("%d.%d.%d", major, minor, patch), nil
 		}
-		// No prerelease means we need --bump
 		if bumpType == "" {
-			return "", fmt.Errorf("--bump required for prod deployment from prod version")
+			// Released by an earlier release environment: keep the version
+			if releases := cfg.Releases(); len(releases) > 0 && releases[0] != env {
+				return current, nil
+			}
+			// No prerelease means we need --bump
+			return "", fmt.Errorf("--bump required for %s deployment from release version %s", env, current)
 		}
 		return bumpMajorMinorPatch(major, minor, patch, bumpType)
 	}
 
-	// Non-prod environments
-	currentEnv := extractEnvFromPrerelease(prerelease)
-	currentCounter := extractCounter(prerelease)
-
+	// Non-release environments
 	if !hasPrerelease {
-		// Starting from a prod version, need --bump
+		// Starting from a release version, need --bump
 		if bumpType == "" {
-			return "", fmt.Errorf("--bump required for first deploy after prod release")
+			return "", fmt.Errorf("--bump required for first deploy after release %s", current)
 		}
 		// Bump and add prerelease
 		base, err := bumpMajorMinorPatch(major, minor, patch, bumpType)
@@ -236,7 +256,7 @@ func ComputeNewVersion(current, env, bumpType string) (string, error) {
 		return fmt.Sprintf("%d.%d.%d-%s.%d", major, minor, patch, env, currentCounter+1), nil
 	}
 
-	// Different environment, reset counter
+	// Later environment, reset counter
 	return fmt.Sprintf("%d.%d.%d-%s.1", major, minor, patch, env), nil
 }
 
@@ -254,6 +274,50 @@ func bumpMajorMinorPatch(major, minor, patch int, bumpType string) (string, erro
 	}
 }
 
+// CompareVersions orders two versions the state machine produced, returning
+// -1, 0 or 1 as a is behind, level with or ahead of b. Major, minor and patch
+// come first; of equal ones a release is ahead of any prerelease, and
+// prereleases are ordered by the rank of their environment, then counter.
+// rank gives an environment's position in the pipeline; when it is nil, or
+// ranks both the same, environment names are compared as text.
+func CompareVersions(a, b string, rank func(env string) int) int {
+	aMajor, aMinor, aPatch, aPre := ParseVersion(a)
+	bMajor, bMinor, bPatch, bPre := ParseVersion(b)
+	for _, d := range [][2]int{{aMajor, bMajor}, {aMinor, bMinor}, {aPatch, bPatch}} {
+		if d[0] != d[1] {
+			return cmpInt(d[0], d[1])
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	aEnv, bEnv := extractEnvFromPrerelease(aPre), extractEnvFromPrerelease(bPre)
+	if aEnv != bEnv {
+		if rank != nil && rank(aEnv) != rank(bEnv) {
+			return cmpInt(rank(aEnv), rank(bEnv))
+		}
+		return strings.Compare(aEnv, bEnv)
+	}
+	return cmpInt(extractCounter(aPre), extractCounter(bPre))
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
 // ParseVersion extracts version components from a version string.
 // Supports formats: "1.2.3" and "1.2.3-prerelease.5"
 func ParseVersion(v string) (major, minor, patch int, prerelease string) {
@@ -300,9 +364,9 @@ func extractCounter(pr string) int {
 	return 0
 }
 
-// ExtractAppID extracts the app ID from app.scl using scl-parser.
-func ExtractAppID(parserPath, appPath string) (string, error) {
-	vm := NewVersionManager(parserPath)
+// ExtractAppID extracts the app ID from app.scl.
+func ExtractAppID(appPath string) (string, error) {
+	vm := NewVersionManager()
 	app, err := vm.ParseAppSCL(appPath)
 	if err != nil {
 		return "", err
@@ -310,9 +374,9 @@ func ExtractAppID(parserPath, appPath string) (string, error) {
 	return app.ID, nil
 }
 
-// ExtractVersion extracts the version from app.scl using scl-parser.
-func ExtractVersion(parserPath, appPath string) (string, error) {
-	vm := NewVersionManager(parserPath)
+// ExtractVersion extracts the version from app.scl.
+func ExtractVersion(appPath string) (string, error) {
+	vm := NewVersionManager()
 	app, err := vm.ParseAppSCL(appPath)
 	if err != nil {
 		return "", err
@@ -3,6 +3,9 @@ package deploy
 import (
 	"os"
 	"path/filepath"
+	"simple-cli/internal/config"
+	"simple-cli/internal/scl"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -53,17 +56,36 @@ func (m *MockFileSystem) Stat(path string) (os.FileInfo, error) {
 
 // MockSCLParser implements SCLParser for testing.
 type MockSCLParser struct {
-	Result []SCLBlock
+	Result []*scl.Node
 	Err    error
 }
 
-func (m *MockSCLParser) Parse(_ string) ([]SCLBlock, error) {
+// parseSCL parses SCL source for a test table, where there is no t to fail.
+func parseSCL(src string) []*scl.Node {
+	nodes, err := scl.Parse([]byte(src))
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+}
+
+func (m *MockSCLParser) Parse(_ string) ([]*scl.Node, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
 	return m.Result, nil
 }
 
+// pipeline returns a simple.scl with the environments of stages, promoted
+// through in that order, and releases marked as release environments.
+func pipeline(stages []string, releases ...string) *config.SimpleSCL {
+	cfg := &config.SimpleSCL{Environments: map[string]*config.Environment{}, Pipeline: stages}
+	for _, name := range stages {
+		cfg.Environments[name] = &config.Environment{Name: name, Release: slices.Contains(releases, name)}
+	}
+	return cfg
+}
+
 func TestComputeNewVersion(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -87,10 +109,14 @@ func TestComputeNewVersion(t *testing.T) {
 		{"staging to staging", "1.0.1-staging.5", "staging", "", "1.0.1-staging.6", false, ""},
 		{"qa to qa", "2.0.0-qa.10", "qa", "", "2.0.0-qa.11", false, ""},
 
-		// Non-prod → Different env (reset counter)
+		// Non-prod → Later env (reset counter)
 		{"dev to staging", "1.0.1-dev.5", "staging", "", "1.0.1-staging.1", false, ""},
-		{"staging to dev", "1.0.1-staging.3", "dev", "", "1.0.1-dev.1", false, ""},
 		{"dev to qa", "1.0.1-dev.10", "qa", "", "1.0.1-qa.1", false, ""},
+		{"env not in the pipeline", "1.0.1-test.2", "dev", "", "1.0.1-dev.1", false, ""},
+
+		// Non-prod → Earlier env (requires --bump)
+		{"staging to dev", "1.0.1-staging.3", "dev", "", "", true, "--bump required to deploy 1.0.1-staging.3 to dev: cannot promote from staging to dev"},
+		{"staging to dev with patch", "1.0.1-staging.3", "dev", "patch", "1.0.2-dev.1", false, ""},
 
 		// Non-prod → Prod (strip prerelease)
 		{"dev to prod", "1.0.1-dev.5", "prod", "", "1.0.1", false, ""},
@@ -116,7 +142,8 @@ func TestComputeNewVersion(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := ComputeNewVersion(tt.current, tt.env, tt.bump)
+			cfg := pipeline([]string{"dev", "qa", "staging", "prod"})
+			result, err := ComputeNewVersion(tt.current, tt.env, tt.bump, cfg)
 
 			if tt.expectError {
 				if err == nil {
@@ -145,6 +172,157 @@ func TestComputeNewVersion(t *testing.T) {
 	}
 }
 
+func TestComputeNewVersion_CustomPipelines(t *testing.T) {
+	// Each step deploys to one environment of a pipeline, starting from the
+	// version the step before it produced.
+	type step struct {
+		env  string
+		bump string
+		want string
+	}
+	tests := []struct {
+		name     string
+		stages   []string
+		releases []string
+		start    string
+		steps    []step
+		wantErr  string
+	}{
+		{
+			name:     "dev → qa → uat → prod",
+			stages:   []string{"dev", "qa", "uat", "prod"},
+			releases: []string{"prod"},
+			start:    "1.4.0",
+			steps: []step{
+				{"dev", "minor", "1.5.0-dev.1"},
+				{"dev", "", "1.5.0-dev.2"},
+				{"qa", "", "1.5.0-qa.1"},
+				{"uat", "", "1.5.0-uat.1"},
+				{"uat", "", "1.5.0-uat.2"},
+				{"prod", "", "1.5.0"},
+			},
+		},
+		{
+			name:     "prod is a prerelease when live is the release",
+			stages:   []string{"test", "prod", "live"},
+			releases: []string{"live"},
+			start:    "2.0.0",
+			steps: []step{
+				{"test", "patch", "2.0.1-test.1"},
+				{"prod", "", "2.0.1-prod.1"},
+				{"live", "", "2.0.1"},
+				{"live", "patch", "2.0.2"},
+			},
+		},
+		{
+			name:     "several release environments",
+			stages:   []string{"dev", "eu", "us"},
+			releases: []string{"eu", "us"},
+			start:    "0.9.0-dev.3",
+			steps: []step{
+				{"eu", "", "0.9.0"},
+				{"us", "", "0.9.0"},
+				{"us", "minor", "0.10.0"},
+			},
+		},
+		{
+			name:     "the first release environment again needs a bump",
+			stages:   []string{"dev", "eu", "us"},
+			releases: []string{"eu", "us"},
+			start:    "0.9.0",
+			steps:    []step{{"eu", "", ""}},
+			wantErr:  "--bump required for eu deployment from release version 0.9.0",
+		},
+		{
+			name:     "back to an earlier environment with a bump",
+			stages:   []string{"dev", "qa", "uat", "prod"},
+			releases: []string{"prod"},
+			start:    "1.0.1-uat.2",
+			steps: []step{
+				{"dev", "patch", "1.0.2-dev.1"},
+				{"dev", "", "1.0.2-dev.2"},
+				{"uat", "", "1.0.2-uat.1"},
+			},
+		},
+		{
+			name:     "back to an earlier environment needs a bump",
+			stages:   []string{"dev", "qa", "uat", "prod"},
+			releases: []string{"prod"},
+			start:    "1.0.1-uat.2",
+			steps:    []step{{"dev", "", ""}},
+			wantErr:  "--bump required to deploy 1.0.1-uat.2 to dev: cannot promote from uat to dev: dev comes before uat in the pipeline dev → qa → uat → prod",
+		},
+		{
+			name:     "release again needs a bump",
+			stages:   []string{"test", "live"},
+			releases: []string{"live"},
+			start:    "2.0.1",
+			steps:    []step{{"live", "", ""}},
+			wantErr:  "--bump required for live deployment from release version 2.0.1",
+		},
+		{
+			name:     "leaving a release needs a bump",
+			stages:   []string{"qa", "uat-release"},
+			releases: []string{"uat-release"},
+			start:    "3.1.0",
+			steps:    []step{{"qa", "", ""}},
+			wantErr:  "--bump required for first deploy after release 3.1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := pipeline(tt.stages, tt.releases...)
+			version := tt.start
+			for _, s := range tt.steps {
+				got, err := ComputeNewVersion(version, s.env, s.bump, cfg)
+				if tt.wantErr != "" {
+					if err == nil || err.Error() != tt.wantErr {
+						t.Fatalf("ComputeNewVersion(%q, %q) error = %v, want %q", version, s.env, err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("ComputeNewVersion(%q, %q) error = %v", version, s.env, err)
+				}
+				if got != s.want {
+					t.Fatalf("ComputeNewVersion(%q, %q, %q) = %q, want %q", version, s.env, s.bump, got, s.want)
+				}
+				version = got
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	pipeline := []string{"dev", "qa", "uat", "prod"}
+	rank := func(env string) int { return slices.Index(pipeline, env) }
+
+	tests := []struct {
+		a, b string
+		rank func(string) int
+		want int
+	}{
+		{a: "1.4.0", b: "1.4.0", want: 0},
+		{a: "1.4.1", b: "1.4.0", want: 1},
+		{a: "1.4.0", b: "1.10.0", want: -1},
+		{a: "2.0.0", b: "1.9.9", want: 1},
+		{a: "1.4.0", b: "1.4.0-uat.3", want: 1},
+		{a: "1.4.0-dev.2", b: "1.4.0", want: -1},
+		{a: "1.4.0-dev.10", b: "1.4.0-dev.9", want: 1},
+		{a: "1.4.0-dev.5", b: "1.4.0-qa.1", rank: rank, want: -1},
+		{a: "1.4.0-uat.1", b: "1.4.0-qa.4", rank: rank, want: 1},
+		// Without a pipeline, qa sorts after dev and before uat as text.
+		{a: "1.4.0-uat.1", b: "1.4.0-qa.4", want: 1},
+		{a: "1.5.0-dev.1", b: "1.4.0", want: 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b, tt.rank); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
 func TestParseVersion(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -242,46 +420,58 @@ func TestReplaceVersionInContent(t *testing.T) {
 func TestVersionManager_ParseAppSCL(t *testing.T) {
 	tests := []struct {
 		name         string
-		parserBlocks []SCLBlock
+		parserBlocks []*scl.Node
 		parserErr    error
 		wantID       string
 		wantVersion  string
+		wantDeps     []string
 		wantErr      bool
 		errContains  string
 	}{
 		{
 			name: "valid app.scl",
-			parserBlocks: []SCLBlock{
-				{Type: "kv", Key: "id", Value: "com.example.app"},
-				{Type: "kv", Key: "version", Value: "1.0.0"},
-			},
+			parserBlocks: parseSCL(`
+				id com.example.app
+				version 1.0.0
+			`),
 			wantID:      "com.example.app",
 			wantVersion: "1.0.0",
 			wantErr:     false,
 		},
 		{
 			name: "valid app.scl with prerelease",
-			parserBlocks: []SCLBlock{
-				{Type: "kv", Key: "id", Value: "com.test.myapp"},
-				{Type: "kv", Key: "version", Value: "1.2.3-dev.5"},
-			},
+			parserBlocks: parseSCL(`
+				id com.test.myapp
+				version 1.2.3-dev.5
+			`),
 			wantID:      "com.test.myapp",
 			wantVersion: "1.2.3-dev.5",
 			wantErr:     false,
 		},
+		{
+			name: "dependencies",
+			parserBlocks: parseSCL(`
+				id com.example.crm
+				version 1.0.0
+				depends_on com.example.core, com.example.billing
+			`),
+			wantID:      "com.example.crm",
+			wantVersion: "1.0.0",
+			wantDeps:    []string{"com.example.core", "com.example.billing"},
+		},
 		{
 			name: "missing id",
-			parserBlocks: []SCLBlock{
-				{Type: "kv", Key: "version", Value: "1.0.0"},
-			},
+			parserBlocks: parseSCL(`
+				version 1.0.0
+			`),
 			wantErr:     true,
 			errContains: "id not found",
 		},
 		{
 			name: "missing version",
-			parserBlocks: []SCLBlock{
-				{Type: "kv", Key: "id", Value: "com.example.app"},
-			},
+			parserBlocks: parseSCL(`
+				id com.example.app
+			`),
 			wantErr:     true,
 			errContains: "version not found",
 		},
@@ -329,6 +519,9 @@ func TestVersionManager_ParseAppSCL(t *testing.T) {
 			if app.Version != tt.wantVersion {
 				t.Errorf("ParseAppSCL() Version = %q, want %q", app.Version, tt.wantVersion)
 			}
+			if !slices.Equal(app.DependsOn, tt.wantDeps) {
+				t.Errorf("ParseAppSCL() DependsOn = %q, want %q", app.DependsOn, tt.wantDeps)
+			}
 		})
 	}
 }
@@ -337,7 +530,7 @@ func TestVersionManager_BumpVersion(t *testing.T) {
 	tests := []struct {
 		name         string
 		files        map[string][]byte
-		parserBlocks []SCLBlock
+		parserBlocks []*scl.Node
 		parserErr    error
 		appPath      string
 		env          string
@@ -351,10 +544,10 @@ func TestVersionManager_BumpVersion(t *testing.T) {
 			files: map[string][]byte{
 				"/apps/myapp/app.scl": []byte("id \"com.example.app\"\nversion \"1.0.0\"\n"),
 			},
-			parserBlocks: []SCLBlock{
-				{Type: "kv", Key: "id", Value: "com.example.app"},
-				{Type: "kv", Key: "version", Value: "1.0.0"},
-			},
+			parserBlocks: parseSCL(`
+				id com.example.app
+				version 1.0.0
+			`),
 			appPath:     "/apps/myapp",
 			env:         "dev",
 			bump:        "patch",
@@ -364,10 +557,10 @@ func TestVersionManager_BumpVersion(t *testing.T) {
 		{
 			name:  "file not found",
 			files: map[string][]byte{},
-			parserBlocks: []SCLBlock{
-				{Type: "kv", Key: "id", Value: "com.example.app"},
-				{Type: "kv", Key: "version", Value: "1.0.0"},
-			},
+			parserBlocks: parseSCL(`
+				id com.example.app
+				version 1.0.0
+			`),
 			appPath:     "/apps/missing",
 			env:         "dev",
 			bump:        "patch",
@@ -401,7 +594,7 @@ func TestVersionManager_BumpVersion(t *testing.T) {
 				Parser: mockParser,
 			}
 
-			result, err := vm.BumpVersion(tt.appPath, tt.env, tt.bump)
+			result, err := vm.BumpVersion(tt.appPath, tt.env, tt.bump, pipeline([]string{"dev", "prod"}))
 
 			if tt.wantErr {
 				if err == nil {
@@ -439,10 +632,10 @@ func TestVersionManager_BumpVersion_WriteError(t *testing.T) {
 		WriteErr: os.ErrPermission,
 	}
 	mockParser := &MockSCLParser{
-		Result: []SCLBlock{
-			{Type: "kv", Key: "id", Value: "com.example.app"},
-			{Type: "kv", Key: "version", Value: "1.0.0"},
-		},
+		Result: parseSCL(`
+			id com.example.app
+			version 1.0.0
+		`),
 	}
 
 	vm := &VersionManager{
@@ -450,7 +643,7 @@ func TestVersionManager_BumpVersion_WriteError(t *testing.T) {
 		Parser: mockParser,
 	}
 
-	_, err := vm.BumpVersion("/apps/myapp", "dev", "patch")
+	_, err := vm.BumpVersion("/apps/myapp", "dev", "patch", pipeline([]string{"dev", "prod"}))
 	if err == nil {
 		t.Error("BumpVersion() expected error on write failure, got nil")
 		return
@@ -461,7 +654,7 @@ func TestVersionManager_BumpVersion_WriteError(t *testing.T) {
 }
 
 func TestNewVersionManager(t *testing.T) {
-	vm := NewVersionManager("/path/to/scl-parser")
+	vm := NewVersionManager()
 	if vm == nil {
 		t.Fatal("NewVersionManager() returned nil")
 	}
@@ -471,9 +664,6 @@ func TestNewVersionManager(t *testing.T) {
 	if vm.Parser == nil {
 		t.Error("NewVersionManager() Parser is nil")
 	}
-	if vm.ParserPath != "/path/to/scl-parser" {
-		t.Errorf("NewVersionManager() ParserPath = %q, want %q", vm.ParserPath, "/path/to/scl-parser")
-	}
 }
 
 func TestExtractEnvFromPrerelease(t *testing.T) {
@@ -560,57 +750,60 @@ func TestBumpMajorMinorPatch(t *testing.T) {
 }
 
 func TestDefaultSCLParser_Parse(t *testing.T) {
-	// Test that non-existent parser returns error
-	parser := &DefaultSCLParser{ParserPath: "/nonexistent/scl-parser"}
-
 	dir := t.TempDir()
-	testFile := filepath.Join(dir, "test.scl")
-	_ = os.WriteFile(testFile, []byte("key value"), 0644)
+	testFile := filepath.Join(dir, "app.scl")
+	_ = os.WriteFile(testFile, []byte("id com.test.app\nversion 1.2.3-dev.4\n"), 0644)
 
-	_, err := parser.Parse(testFile)
-	if err == nil {
-		t.Error("Parse() expected error for nonexistent parser, got nil")
+	blocks, err := DefaultSCLParser{}.Parse(testFile)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error = %v", err)
+	}
+	id, version := extractFromBlocks(blocks)
+	if id != "com.test.app" || version != "1.2.3-dev.4" {
+		t.Errorf("Parse() gave id %q version %q", id, version)
 	}
-	if !strings.Contains(err.Error(), "execution failed") {
-		t.Errorf("Parse() error = %v, want containing 'execution failed'", err)
+
+	_ = os.WriteFile(testFile, []byte("id com.test.app\nversion \"1.2.3\n"), 0644)
+	if _, err := (DefaultSCLParser{}).Parse(testFile); err == nil || !strings.Contains(err.Error(), "unexpected newline") {
+		t.Errorf("Parse() error = %v, want a syntax error", err)
 	}
 }
 
 func TestExtractFromBlocks(t *testing.T) {
 	tests := []struct {
 		name        string
-		blocks      []SCLBlock
+		blocks      []*scl.Node
 		wantID      string
 		wantVersion string
 	}{
 		{
 			name: "both id and version",
-			blocks: []SCLBlock{
-				{Type: "kv", Key: "id", Value: "com.test.app"},
-				{Type: "kv", Key: "version", Value: "2.0.0"},
-			},
+			blocks: parseSCL(`
+				id com.test.app
+				version 2.0.0
+			`),
 			wantID:      "com.test.app",
 			wantVersion: "2.0.0",
 		},
 		{
 			name: "only id",
-			blocks: []SCLBlock{
-				{Type: "kv", Key: "id", Value: "com.test.app"},
-			},
+			blocks: parseSCL(`
+				id com.test.app
+			`),
 			wantID:      "com.test.app",
 			wantVersion: "",
 		},
 		{
 			name: "only version",
-			blocks: []SCLBlock{
-				{Type: "kv", Key: "version", Value: "2.0.0"},
-			},
+			blocks: parseSCL(`
+				version 2.0.0
+			`),
 			wantID:      "",
 			wantVersion: "2.0.0",
 		},
 		{
 			name:        "empty",
-			blocks:      []SCLBlock{},
+			blocks:      nil,
 			wantID:      "",
 			wantVersion: "",
 		},
@@ -0,0 +1,516 @@
+package devserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// connection is one client's socket and the channels it has joined.
+type connection struct {
+	server   *Server
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+	channels map[string]*channel // by topic
+}
+
+// channel is a deploy:<app> channel a connection has joined, with what the
+// client has sent on it towards a deploy.
+type channel struct {
+	app      string
+	joinRef  string
+	encoding string                    // negotiated for uploads, or ""
+	manifest *deployment               // the last manifest, until deployed
+	uploads  map[string]*chunkedUpload // files arriving in chunks, by path
+}
+
+// message is a Phoenix V2 message from the client, in a text frame or, with
+// a binary payload, in a binary one.
+type message struct {
+	joinRef, ref, topic, event string
+	payload                    json.RawMessage
+	binary                     []byte
+}
+
+// serveSocket handles /socket/websocket, accepting the connection when its
+// auth_token is a session token the server issued.
+func (s *Server) serveSocket(w http.ResponseWriter, r *http.Request) {
+	if err := s.identity.verifyToken(r.URL.Query().Get("auth_token")); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &connection{server: s, conn: conn, channels: map[string]*channel{}}
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		for _, ch := range c.channels {
+			ch.abandonUploads()
+		}
+		_ = conn.Close()
+	}()
+
+	for {
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg *message
+		if kind == websocket.BinaryMessage {
+			msg, err = decodeBinary(data)
+		} else {
+			msg, err = decodeText(data)
+		}
+		if err != nil {
+			continue
+		}
+		c.handle(msg)
+	}
+}
+
+func decodeText(data []byte) (*message, error) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil || len(parts) != 5 {
+		return nil, errors.New("not a Phoenix V2 message")
+	}
+	msg := &message{payload: parts[4]}
+	_ = json.Unmarshal(parts[0], &msg.joinRef) // null leaves ""
+	_ = json.Unmarshal(parts[1], &msg.ref)
+	_ = json.Unmarshal(parts[2], &msg.topic)
+	_ = json.Unmarshal(parts[3], &msg.event)
+	return msg, nil
+}
+
+// decodeBinary decodes a binary push: a kind byte, the sizes of the join
+// ref, ref, topic and event, those four, then the payload.
+func decodeBinary(data []byte) (*message, error) {
+	if len(data) < 5 {
+		return nil, errors.New("short binary message")
+	}
+	var fields [4]string
+	offset := 5
+	for i, size := range data[1:5] {
+		if offset+int(size) > len(data) {
+			return nil, errors.New("short binary message")
+		}
+		fields[i] = string(data[offset : offset+int(size)])
+		offset += int(size)
+	}
+	return &message{joinRef: fields[0], ref: fields[1], topic: fields[2], event: fields[3], binary: data[offset:]}, nil
+}
+
+// send writes a message; empty refs are sent as null.
+func (c *connection) send(joinRef, ref, topic, event string, payload any) {
+	nullable := func(ref string) any {
+		if ref == "" {
+			return nil
+		}
+		return ref
+	}
+	data, err := json.Marshal([]any{nullable(joinRef), nullable(ref), topic, event, payload})
+	if err != nil {
+		return
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// respond replies to msg with response, or with err's message.
+func (c *connection) respond(msg *message, response any, err error) {
+	status := "ok"
+	if err != nil {
+		status, response = "error", map[string]any{"message": err.Error()}
+	}
+	if response == nil {
+		response = map[string]any{}
+	}
+	c.send(msg.joinRef, msg.ref, msg.topic, "phx_reply", map[string]any{"status": status, "response": response})
+}
+
+func (c *connection) handle(msg *message) {
+	if msg.topic == "phoenix" {
+		c.respond(msg, nil, nil) // heartbeat
+		return
+	}
+	if msg.event == "phx_join" {
+		response, err := c.join(msg)
+		c.respond(msg, response, err)
+		return
+	}
+	ch := c.channels[msg.topic]
+	if ch == nil {
+		c.send(msg.joinRef, msg.ref, msg.topic, "phx_reply", map[string]any{"status": "error", "response": map[string]any{"reason": "unmatched topic"}})
+		return
+	}
+
+	var response any
+	var err error
+	switch msg.event {
+	case "phx_leave":
+		ch.abandonUploads()
+		delete(c.channels, msg.topic)
+	case "manifest":
+		response, err = c.receiveManifest(ch, msg)
+	case "file":
+		err = c.receiveFile(msg)
+	case "file_chunk":
+		err = c.receiveChunk(ch, msg)
+	case "deploy":
+		response, err = c.deploy(ch)
+	case "install":
+		// Installs take a while; the socket carries on meanwhile, heartbeats
+		// included, and the install finishes even if the client is gone.
+		go func() {
+			response, err := c.install(ch, msg)
+			c.respond(msg, response, err)
+		}()
+		return
+	case "installed":
+		response, err = c.installed(ch)
+	case "versions":
+		response, err = c.versions(ch)
+	case "fetch_file":
+		response, err = c.fetchFile(msg)
+	default:
+		err = fmt.Errorf("unknown event %q", msg.event)
+	}
+	c.respond(msg, response, err)
+}
+
+// join joins deploy:<app>, picking gzip for uploads when the client offers it.
+func (c *connection) join(msg *message) (any, error) {
+	app, ok := strings.CutPrefix(msg.topic, "deploy:")
+	if !ok {
+		return nil, fmt.Errorf("unknown topic %q", msg.topic)
+	}
+	if err := checkName("app ID", app); err != nil {
+		return nil, err
+	}
+	var params struct {
+		Compression []string `json:"compression"`
+	}
+	_ = json.Unmarshal(msg.payload, &params)
+
+	ch := &channel{app: app, joinRef: msg.joinRef, uploads: map[string]*chunkedUpload{}}
+	response := map[string]any{}
+	if slices.Contains(params.Compression, "gzip") {
+		ch.encoding = "gzip"
+		response["compression"] = "gzip"
+	}
+	if old := c.channels[msg.topic]; old != nil {
+		old.abandonUploads()
+	}
+	c.channels[msg.topic] = ch
+	c.server.logf("joined %s", msg.topic)
+	return response, nil
+}
+
+// receiveManifest takes the files of the version to deploy next and replies
+// with the paths of those whose content the server does not have yet.
+func (c *connection) receiveManifest(ch *channel, msg *message) (any, error) {
+	var req struct {
+		Version string         `json:"version"`
+		Files   []manifestFile `json:"files"`
+	}
+	if err := json.Unmarshal(msg.payload, &req); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if err := checkName("version", req.Version); err != nil {
+		return nil, err
+	}
+	existing, err := c.server.store.deployment(ch.app, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("version %s of %s is already deployed", req.Version, ch.app)
+	}
+
+	need := []string{}
+	for _, f := range req.Files {
+		if f.Path == "" || !hashRe.MatchString(f.Hash) {
+			return nil, fmt.Errorf("manifest entry %q needs a path and a SHA-256 hash", f.Path)
+		}
+		if !c.server.store.hasBlob(f.Hash) {
+			need = append(need, f.Path)
+		}
+	}
+	ch.manifest = &deployment{Version: req.Version, Files: req.Files}
+	c.server.logf("%s: manifest of %s, %d of %d files needed", ch.app, req.Version, len(need), len(req.Files))
+	return map[string]any{"need_files": need}, nil
+}
+
+// fileFrame splits the payload of a file or file_chunk push into its
+// metadata and its content, decompressing the content as the metadata says.
+func fileFrame(msg *message, meta any) ([]byte, error) {
+	data := msg.binary
+	if len(data) < 4 {
+		return nil, errors.New("file frame too short")
+	}
+	size := binary.BigEndian.Uint32(data[:4])
+	if uint64(len(data)-4) < uint64(size) {
+		return nil, errors.New("file frame too short")
+	}
+	if err := json.Unmarshal(data[4:4+size], meta); err != nil {
+		return nil, fmt.Errorf("invalid file metadata: %w", err)
+	}
+	var encoding struct {
+		Encoding string `json:"encoding"`
+	}
+	_ = json.Unmarshal(data[4:4+size], &encoding)
+	content := data[4+size:]
+	switch encoding.Encoding {
+	case "":
+		return content, nil
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip content: %w", err)
+		}
+		return io.ReadAll(r)
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", encoding.Encoding)
+}
+
+func (c *connection) receiveFile(msg *message) error {
+	var meta struct {
+		Path string `json:"path"`
+		Hash string `json:"hash"`
+	}
+	content, err := fileFrame(msg, &meta)
+	if err != nil {
+		return err
+	}
+	if !hashRe.MatchString(meta.Hash) {
+		return fmt.Errorf("%s: invalid hash %q", meta.Path, meta.Hash)
+	}
+	if err := c.server.store.putBlob(meta.Hash, content); err != nil {
+		return fmt.Errorf("%s: %w", meta.Path, err)
+	}
+	return nil
+}
+
+func (c *connection) receiveChunk(ch *channel, msg *message) error {
+	var meta struct {
+		Path   string `json:"path"`
+		Hash   string `json:"hash"`
+		Offset int64  `json:"offset"`
+		Size   int64  `json:"size"`
+		Final  bool   `json:"final"`
+	}
+	chunk, err := fileFrame(msg, &meta)
+	if err != nil {
+		return err
+	}
+	if !hashRe.MatchString(meta.Hash) {
+		return fmt.Errorf("%s: invalid hash %q", meta.Path, meta.Hash)
+	}
+
+	upload := ch.uploads[meta.Path]
+	if meta.Offset == 0 {
+		if upload != nil {
+			upload.abandon()
+		}
+		if upload, err = c.server.store.startUpload(meta.Hash); err != nil {
+			return err
+		}
+		ch.uploads[meta.Path] = upload
+	}
+	if upload == nil || upload.hash != meta.Hash {
+		return fmt.Errorf("%s: chunk at offset %d of an upload that was not started", meta.Path, meta.Offset)
+	}
+	if err := upload.write(meta.Offset, chunk); err != nil {
+		upload.abandon()
+		delete(ch.uploads, meta.Path)
+		return fmt.Errorf("%s: %w", meta.Path, err)
+	}
+	if !meta.Final {
+		return nil
+	}
+	delete(ch.uploads, meta.Path)
+	if err := c.server.store.finishUpload(upload, meta.Size); err != nil {
+		return fmt.Errorf("%s: %w", meta.Path, err)
+	}
+	return nil
+}
+
+func (ch *channel) abandonUploads() {
+	for path, upload := range ch.uploads {
+		upload.abandon()
+		delete(ch.uploads, path)
+	}
+}
+
+// deploy records the version of the last manifest, once every file of it
+// has been uploaded.
+func (c *connection) deploy(ch *channel) (any, error) {
+	if ch.manifest == nil {
+		return nil, errors.New("no manifest sent")
+	}
+	var missing []string
+	for _, f := range ch.manifest.Files {
+		if !c.server.store.hasBlob(f.Hash) {
+			missing = append(missing, f.Path)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%d files of %s were not uploaded: %s", len(missing), ch.manifest.Version, strings.Join(missing, ", "))
+	}
+
+	d := *ch.manifest
+	d.DeployedAt = time.Now().UTC()
+	if err := c.server.store.deploy(ch.app, d); err != nil {
+		return nil, err
+	}
+	ch.manifest = nil
+	c.server.logf("%s: deployed %s (%d files)", ch.app, d.Version, len(d.Files))
+	return map[string]any{"version": d.Version, "file_count": len(d.Files)}, nil
+}
+
+// install installs the version asked for, or the latest deployed, pushing
+// its progress as install_progress and install_log events.
+func (c *connection) install(ch *channel, msg *message) (any, error) {
+	var req struct {
+		Version string `json:"version"`
+	}
+	_ = json.Unmarshal(msg.payload, &req)
+
+	s := c.server
+	deployed, err := s.store.deployments(ch.app)
+	if err != nil {
+		return nil, err
+	}
+	var d *deployment
+	for i := range deployed {
+		if req.Version == "" || deployed[i].Version == req.Version {
+			d = &deployed[i]
+			break
+		}
+	}
+	switch {
+	case d == nil && req.Version == "":
+		return nil, fmt.Errorf("no version of %s is deployed", ch.app)
+	case d == nil:
+		return nil, fmt.Errorf("version %s of %s is not deployed", req.Version, ch.app)
+	}
+
+	installed, err := s.store.installed(ch.app)
+	if err != nil {
+		return nil, err
+	}
+	if installed == d.Version {
+		return nil, fmt.Errorf("Version `%s` of application `%s` is already installed", d.Version, ch.app)
+	}
+
+	s.mu.Lock()
+	busy := s.installing[ch.app]
+	s.installing[ch.app] = true
+	s.mu.Unlock()
+	if busy {
+		return nil, fmt.Errorf("an install of %s is already running", ch.app)
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.installing, ch.app)
+		s.mu.Unlock()
+	}()
+
+	s.logf("%s: installing %s", ch.app, d.Version)
+	topic := "deploy:" + ch.app
+	steps := []struct{ step, message string }{
+		{"verify", fmt.Sprintf("checking %d files", len(d.Files))},
+		{"migrate", "applying migrations"},
+		{"activate", "switching to " + d.Version},
+	}
+	for _, step := range steps {
+		c.send(ch.joinRef, "", topic, "install_progress", map[string]any{"step": step.step, "message": step.message})
+		time.Sleep(s.InstallDelay / time.Duration(len(steps)))
+	}
+	for _, f := range d.Files {
+		if !s.store.hasBlob(f.Hash) {
+			c.send(ch.joinRef, "", topic, "install_log", map[string]any{"level": "error", "message": "missing content of " + f.Path})
+			return nil, fmt.Errorf("install of %s failed: content of %s is missing", d.Version, f.Path)
+		}
+	}
+
+	if err := s.store.install(ch.app, d.Version); err != nil {
+		return nil, err
+	}
+	c.send(ch.joinRef, "", topic, "install_log", map[string]any{"level": "info", "message": fmt.Sprintf("installed %s@%s", ch.app, d.Version)})
+	s.logf("%s: installed %s", ch.app, d.Version)
+	return map[string]any{"version": d.Version}, nil
+}
+
+// installed replies with the version installed and its manifest, or with
+// no version when none is installed.
+func (c *connection) installed(ch *channel) (any, error) {
+	version, err := c.server.store.installed(ch.app)
+	if err != nil || version == "" {
+		return nil, err
+	}
+	d, err := c.server.store.deployment(ch.app, version)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, fmt.Errorf("installed version %s of %s has no manifest", version, ch.app)
+	}
+	return map[string]any{"version": d.Version, "files": d.Files}, nil
+}
+
+func (c *connection) versions(ch *channel) (any, error) {
+	deployed, err := c.server.store.deployments(ch.app)
+	if err != nil {
+		return nil, err
+	}
+	installed, err := c.server.store.installed(ch.app)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]map[string]any, 0, len(deployed))
+	for _, d := range deployed {
+		list = append(list, map[string]any{
+			"version":     d.Version,
+			"deployed_at": d.DeployedAt.Format(time.RFC3339Nano),
+			"file_count":  len(d.Files),
+			"installed":   d.Version == installed,
+		})
+	}
+	return map[string]any{"versions": list}, nil
+}
+
+func (c *connection) fetchFile(msg *message) (any, error) {
+	var req struct {
+		Path string `json:"path"`
+		Hash string `json:"hash"`
+	}
+	_ = json.Unmarshal(msg.payload, &req)
+	content, err := c.server.store.blob(req.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("no content for %s", req.Path)
+	}
+	return map[string]any{"content": base64.StdEncoding.EncodeToString(content)}, nil
+}
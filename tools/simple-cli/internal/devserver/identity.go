@@ -0,0 +1,233 @@
+package devserver
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"simple-cli/internal/deploy"
+)
+
+// APIKey is an API key the dev server accepts, for an environment of
+// simple.scl to point at it with. Any key in the format of a real one is
+// accepted as well; none is checked against a tenant.
+var APIKey = "si_devserver" + strings.Repeat("0", 64)
+
+const (
+	// signingKeyID is the kid of the tokens the server signs, and of the
+	// key it publishes for them in its JWKS.
+	signingKeyID = "simple-dev-server"
+
+	// tokenLifetime is how long a session token is valid.
+	tokenLifetime = time.Hour
+)
+
+// identity stands in for the Identity service: it enrolls the public keys
+// of API keys, exchanges proof-of-possession JWTs signed with them for
+// session tokens, and checks those tokens when a socket connects. The keys
+// enrolled and the one tokens are signed with are kept in a file, so that
+// machines stay enrolled and tokens stay valid across restarts.
+type identity struct {
+	path string
+
+	mu         sync.Mutex
+	signingKey ed25519.PrivateKey
+	keys       map[string]ed25519.PublicKey // by the id suffix of the API key
+}
+
+// identityFile is the file identity is kept in.
+type identityFile struct {
+	SigningKey []byte            `json:"signing_key"` // Ed25519 seed
+	Keys       map[string][]byte `json:"keys"`
+}
+
+// loadIdentity reads the identity kept at path, creating one with a new
+// signing key when there is none.
+func loadIdentity(path string) (*identity, error) {
+	id := &identity{path: path, keys: map[string]ed25519.PublicKey{}}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		_, id.signingKey, err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return id, id.save()
+	case err != nil:
+		return nil, err
+	}
+
+	var f identityFile
+	if err := json.Unmarshal(data, &f); err != nil || len(f.SigningKey) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s is not a dev server identity", path)
+	}
+	id.signingKey = ed25519.NewKeyFromSeed(f.SigningKey)
+	for suffix, key := range f.Keys {
+		id.keys[suffix] = ed25519.PublicKey(key)
+	}
+	return id, nil
+}
+
+// save writes id to its file; the caller holds mu or has id to itself.
+func (id *identity) save() error {
+	f := identityFile{SigningKey: id.signingKey.Seed(), Keys: map[string][]byte{}}
+	for suffix, key := range id.keys {
+		f.Keys[suffix] = key
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(id.path, data, 0600)
+}
+
+// enroll handles POST /auth/api-key/enroll, registering the public key a
+// machine will sign its logins with for an API key.
+func (id *identity) enroll(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		APIKey    string            `json:"api_key"`
+		PublicKey map[string]string `json:"public_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid enroll request", http.StatusBadRequest)
+		return
+	}
+	suffix, err := deploy.ParseIDSuffix(req.APIKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	jwk := req.PublicKey
+	x, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk["x"], "="))
+	if jwk["kty"] != "OKP" || jwk["crv"] != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+		http.Error(w, "public_key must be an Ed25519 JWK", http.StatusBadRequest)
+		return
+	}
+
+	id.mu.Lock()
+	id.keys[suffix] = ed25519.PublicKey(x)
+	err = id.save()
+	id.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "enrolled"})
+}
+
+// login handles POST /auth/login, exchanging "si_<id suffix>.<PoP JWT>"
+// for a session token when the JWT is signed with the key enrolled for the
+// API key and has not expired.
+func (id *identity) login(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		APIKey string `json:"api_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid login request", http.StatusBadRequest)
+		return
+	}
+	suffix, pop, ok := strings.Cut(strings.TrimPrefix(req.APIKey, "si_"), ".")
+	if !ok || !strings.HasPrefix(req.APIKey, "si_") {
+		http.Error(w, "api_key must be si_<id>.<proof-of-possession JWT>", http.StatusUnauthorized)
+		return
+	}
+
+	id.mu.Lock()
+	key, enrolled := id.keys[suffix]
+	id.mu.Unlock()
+	if !enrolled {
+		http.Error(w, fmt.Sprintf("API key %s is not enrolled with this dev server; run simple auth enroll", suffix), http.StatusUnauthorized)
+		return
+	}
+	claims, err := verifyJWT(pop, key)
+	if err == nil && claims["sub"] != "KEY"+suffix {
+		err = errors.New("proof-of-possession JWT is for another key")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	token := signJWT(id.signingKey, signingKeyID, map[string]any{
+		"sub": "KEY" + suffix,
+		"iat": now.Unix(),
+		"exp": now.Add(tokenLifetime).Unix(),
+	})
+	writeJSON(w, map[string]any{"access_token": token, "token_type": "Bearer", "expires_in": int(tokenLifetime.Seconds())})
+}
+
+// jwks handles GET /.well-known/jwks.json, publishing the key session
+// tokens are signed with.
+func (id *identity) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := id.signingKey.Public().(ed25519.PublicKey)
+	writeJSON(w, map[string]any{"keys": []map[string]string{{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"kid": signingKeyID,
+		"x":   base64.RawURLEncoding.EncodeToString(pub),
+	}}})
+}
+
+// verifyToken checks a session token the server signed.
+func (id *identity) verifyToken(token string) error {
+	_, err := verifyJWT(token, id.signingKey.Public().(ed25519.PublicKey))
+	return err
+}
+
+// signJWT returns a compact EdDSA JWT of claims.
+func signJWT(key ed25519.PrivateKey, kid string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "EdDSA", "typ": "JWT", "kid": kid})
+	body, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	return input + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(key, []byte(input)))
+}
+
+// verifyJWT checks that token is an EdDSA JWT signed with key and not
+// expired, and returns its claims.
+func verifyJWT(token string, key ed25519.PublicKey) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "EdDSA" {
+		return nil, errors.New("JWT must be signed with EdDSA")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !ed25519.Verify(key, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, errors.New("invalid JWT signature")
+	}
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.New("malformed JWT claims")
+	}
+	if exp, _ := claims["exp"].(float64); time.Now().Unix() >= int64(exp) {
+		return nil, errors.New("JWT expired")
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
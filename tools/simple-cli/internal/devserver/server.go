@@ -0,0 +1,86 @@
+// Package devserver is a local stand-in for the Identity and DevOps services
+// of a Simple tenant, for testing deploy scripts without one. It speaks the
+// protocol the CLI does: over HTTP, API key enrollment, proof-of-possession
+// login and the JWKS session tokens are checked against; over a WebSocket at
+// /socket/websocket, the deploy:<app> Phoenix channel with its manifest,
+// file and file_chunk pushes, deploy, install, installed, versions and
+// fetch_file.
+//
+// Both services are served from one address, which an environment of
+// simple.scl points at with an endpoint such as http://127.0.0.1:4080.
+// Uploaded files, the versions deployed and the version installed are kept
+// on disk under the server's directory, and outlive a restart; so do the
+// enrolled keys and the key tokens are signed with.
+package devserver
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Server serves the Identity and DevOps protocols. The zero value is not
+// usable; create one with New.
+type Server struct {
+	// InstallDelay is how long each install takes, spread over its steps,
+	// standing in for the migrations a real install runs.
+	InstallDelay time.Duration
+	// Logf, when set, is told about each deploy, install and channel joined.
+	Logf func(format string, args ...any)
+
+	store    *store
+	identity *identity
+	mux      *http.ServeMux
+
+	mu         sync.Mutex
+	conns      map[*connection]struct{}
+	installing map[string]bool // apps with an install running
+}
+
+// New returns a server keeping its state in dir, which is created if need be.
+func New(dir string) (*Server, error) {
+	st, err := newStore(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot use %s: %w", dir, err)
+	}
+	id, err := loadIdentity(filepath.Join(dir, "identity.json"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot use %s: %w", dir, err)
+	}
+
+	s := &Server{
+		store:      st,
+		identity:   id,
+		mux:        http.NewServeMux(),
+		conns:      map[*connection]struct{}{},
+		installing: map[string]bool{},
+	}
+	s.mux.HandleFunc("POST /auth/api-key/enroll", id.enroll)
+	s.mux.HandleFunc("POST /auth/login", id.login)
+	s.mux.HandleFunc("GET /.well-known/jwks.json", id.jwks)
+	s.mux.HandleFunc("GET /socket/websocket", s.serveSocket)
+	return s, nil
+}
+
+// ServeHTTP serves both services.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close drops every open socket. http.Server's Shutdown leaves them open, as
+// it does every connection it no longer manages.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		_ = c.conn.Close()
+	}
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
@@ -0,0 +1,194 @@
+package devserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"simple-cli/internal/deploy"
+)
+
+// startServer serves a dev server keeping its state in dir, and returns its
+// endpoint as simple.scl would give it.
+func startServer(t *testing.T, dir string) (*Server, string) {
+	t.Helper()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	hs := httptest.NewServer(s)
+	t.Cleanup(func() {
+		s.Close()
+		hs.Close()
+	})
+	return s, hs.URL
+}
+
+// login enrolls a key for APIKey with the server at endpoint and returns a
+// session token, as the CLI does.
+func login(t *testing.T, endpoint string) string {
+	t.Helper()
+	auth := deploy.NewAuthenticator()
+	auth.Store = &deploy.FileTokenStore{ConfigDir: t.TempDir()}
+	token, err := auth.GetJWT(t.Context(), endpoint, APIKey, deploy.TenantEnvKey("acme", "local"))
+	if err != nil {
+		t.Fatalf("GetJWT() error = %v", err)
+	}
+	return token
+}
+
+func connect(t *testing.T, endpoint, token string, chunkSize int) *deploy.Client {
+	t.Helper()
+	client := deploy.NewClient(deploy.ClientConfig{
+		Endpoint:  "ws" + strings.TrimPrefix(endpoint, "http"),
+		JWT:       token,
+		ChunkSize: chunkSize,
+	})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(client.Close)
+	if err := client.JoinChannel(t.Context(), "com.example.crm"); err != nil {
+		t.Fatalf("JoinChannel() error = %v", err)
+	}
+	return client
+}
+
+func files(contents map[string]string) map[string]deploy.FileInfo {
+	files := map[string]deploy.FileInfo{}
+	for path, content := range contents {
+		sum := sha256.Sum256([]byte(content))
+		files[path] = deploy.FileInfo{Path: path, Hash: hex.EncodeToString(sum[:]), Size: int64(len(content)), Content: []byte(content)}
+	}
+	return files
+}
+
+func TestServer_DeployAndInstall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	_, endpoint := startServer(t, dir)
+	client := connect(t, endpoint, login(t, endpoint), 16)
+
+	v1 := files(map[string]string{"app.scl": "id com.example.crm\nversion 1.0.0\n", "scripts/big.js": strings.Repeat("console.log(1);\n", 40)})
+	need, err := client.SendManifest(t.Context(), v1, "1.0.0")
+	if err != nil || len(need) != 2 {
+		t.Fatalf("SendManifest() = %v, %v; want both files needed", need, err)
+	}
+	if err := client.SendFiles(t.Context(), v1, need); err != nil {
+		t.Fatalf("SendFiles() error = %v", err)
+	}
+	result, err := client.Deploy(t.Context())
+	if err != nil || result.Version != "1.0.0" || result.FileCount != 2 {
+		t.Fatalf("Deploy() = %+v, %v", result, err)
+	}
+
+	var steps []string
+	client = deploy.NewClient(deploy.ClientConfig{
+		Endpoint:  "ws" + strings.TrimPrefix(endpoint, "http"),
+		JWT:       login(t, endpoint),
+		OnInstall: func(e deploy.InstallEvent) { steps = append(steps, e.Step) },
+	})
+	if err := client.Connect(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	if err := client.JoinChannel(t.Context(), "com.example.crm"); err != nil {
+		t.Fatal(err)
+	}
+	installed, err := client.Install(t.Context())
+	if err != nil || installed.Version != "1.0.0" {
+		t.Fatalf("Install() = %+v, %v", installed, err)
+	}
+	if got := strings.Join(steps, " "); got != "verify migrate activate " {
+		t.Errorf("install events = %q", got)
+	}
+	if _, err := client.InstallVersion(t.Context(), "1.0.0"); err == nil || !strings.Contains(err.Error(), "Version `1.0.0` of application `com.example.crm` is already installed") {
+		t.Errorf("InstallVersion() of the installed version error = %v", err)
+	}
+
+	// A second version uploads only what changed, and survives a restart.
+	v2 := files(map[string]string{"app.scl": "id com.example.crm\nversion 1.1.0\n", "scripts/big.js": strings.Repeat("console.log(1);\n", 40)})
+	need, err = client.SendManifest(t.Context(), v2, "1.1.0")
+	if err != nil || strings.Join(need, ",") != "app.scl" {
+		t.Fatalf("SendManifest() = %v, %v; want only app.scl needed", need, err)
+	}
+	if err := client.SendFiles(t.Context(), v2, need); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Deploy(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, endpoint = startServer(t, dir)
+	client = connect(t, endpoint, login(t, endpoint), 0)
+	versions, err := client.Versions(t.Context())
+	if err != nil || len(versions) != 2 || versions[0].Version != "1.1.0" || !versions[1].Installed {
+		t.Fatalf("Versions() after restart = %+v, %v", versions, err)
+	}
+	current, err := client.Installed(t.Context())
+	if err != nil || current.Version != "1.0.0" || len(current.Files) != 2 {
+		t.Fatalf("Installed() = %+v, %v", current, err)
+	}
+	if err := client.FetchFiles(t.Context(), current.Files, []string{"scripts/big.js"}); err != nil {
+		t.Fatalf("FetchFiles() error = %v", err)
+	}
+	if string(current.Files["scripts/big.js"].Content) != string(v1["scripts/big.js"].Content) {
+		t.Errorf("fetched content = %q", current.Files["scripts/big.js"].Content)
+	}
+	if _, err := client.SendManifest(t.Context(), v2, "1.1.0"); err == nil || !strings.Contains(err.Error(), "already deployed") {
+		t.Errorf("SendManifest() of a deployed version error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "apps", "com.example.crm", "versions", "1.1.0.json")); err != nil {
+		t.Errorf("version not stored on disk: %v", err)
+	}
+}
+
+func TestServer_RejectsUnknownTokensAndKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	_, endpoint := startServer(t, t.TempDir())
+
+	client := deploy.NewClient(deploy.ClientConfig{Endpoint: "ws" + strings.TrimPrefix(endpoint, "http"), JWT: "not-a-token"})
+	var authErr *deploy.AuthFailedError
+	if err := client.Connect(t.Context()); !errors.As(err, &authErr) {
+		t.Errorf("Connect() with a foreign token error = %v, want an auth failure", err)
+	}
+
+	// A machine enrolled with another server is unknown to this one.
+	login(t, endpoint)
+	_, other := startServer(t, t.TempDir())
+	auth := deploy.NewAuthenticator()
+	auth.Store = &deploy.FileTokenStore{ConfigDir: t.TempDir()}
+	_, err := auth.GetJWT(t.Context(), other, APIKey, deploy.TenantEnvKey("acme", "local"))
+	if err == nil || !strings.Contains(err.Error(), "not enrolled with this dev server") {
+		t.Errorf("GetJWT() error = %v", err)
+	}
+}
+
+func TestServer_DeployResumesOnANewJoin(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s, endpoint := startServer(t, t.TempDir())
+	client := connect(t, endpoint, login(t, endpoint), 0)
+
+	v1 := files(map[string]string{"app.scl": "id com.example.crm\nversion 1.0.0\n"})
+	need, err := client.SendManifest(t.Context(), v1, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendFiles(t.Context(), v1, need); err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection drops before the deploy reaches the server: the join
+	// that replaces it has no manifest until the client sends it again.
+	s.Close()
+	result, err := client.Deploy(t.Context())
+	if err != nil || result.Version != "1.0.0" || result.FileCount != 1 {
+		t.Fatalf("Deploy() after a dropped connection = %+v, %v", result, err)
+	}
+}
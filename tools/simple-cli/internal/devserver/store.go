@@ -0,0 +1,234 @@
+package devserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// store keeps what was deployed on disk, under dir:
+//
+//	blobs/<hash>                      the content of every file uploaded
+//	uploads/                          files being uploaded in chunks
+//	apps/<app>/versions/<version>.json the manifest of each version deployed
+//	apps/<app>/installed              the version installed
+//
+// Files are stored once by hash, however many versions and apps share them.
+type store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// manifestFile is one file of a manifest, as the CLI sends and reads it.
+type manifestFile struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// deployment is a version of an app deployed, and the files it is made of.
+type deployment struct {
+	Version    string         `json:"version"`
+	DeployedAt time.Time      `json:"deployed_at"`
+	Files      []manifestFile `json:"files"`
+}
+
+var (
+	hashRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+)
+
+func newStore(dir string) (*store, error) {
+	for _, sub := range []string{"blobs", "uploads", "apps"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &store{dir: dir}, nil
+}
+
+// checkName refuses app IDs and versions that cannot safely name a file.
+func checkName(kind, name string) error {
+	if !nameRe.MatchString(name) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid %s %q", kind, name)
+	}
+	return nil
+}
+
+func (st *store) blobPath(hash string) string {
+	return filepath.Join(st.dir, "blobs", hash)
+}
+
+func (st *store) hasBlob(hash string) bool {
+	_, err := os.Stat(st.blobPath(hash))
+	return err == nil
+}
+
+// putBlob stores content under hash, which must be its SHA-256.
+func (st *store) putBlob(hash string, content []byte) error {
+	if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != hash {
+		return errors.New("content does not match its hash")
+	}
+	tmp, err := os.CreateTemp(filepath.Join(st.dir, "uploads"), hash+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), st.blobPath(hash))
+}
+
+func (st *store) blob(hash string) ([]byte, error) {
+	if !hashRe.MatchString(hash) {
+		return nil, fmt.Errorf("invalid hash %q", hash)
+	}
+	return os.ReadFile(st.blobPath(hash))
+}
+
+// chunkedUpload is a file arriving in chunks, written to a temporary file
+// until the final chunk is checked against the file's hash.
+type chunkedUpload struct {
+	hash    string
+	file    *os.File
+	sha     hash.Hash
+	written int64
+}
+
+// startUpload begins receiving the file with hash in chunks.
+func (st *store) startUpload(hash string) (*chunkedUpload, error) {
+	f, err := os.CreateTemp(filepath.Join(st.dir, "uploads"), hash+"-*")
+	if err != nil {
+		return nil, err
+	}
+	return &chunkedUpload{hash: hash, file: f, sha: sha256.New()}, nil
+}
+
+// write appends a chunk, which must start where the last one ended.
+func (u *chunkedUpload) write(offset int64, chunk []byte) error {
+	if offset != u.written {
+		return fmt.Errorf("chunk at offset %d, expected %d", offset, u.written)
+	}
+	if _, err := u.file.Write(chunk); err != nil {
+		return err
+	}
+	u.sha.Write(chunk)
+	u.written += int64(len(chunk))
+	return nil
+}
+
+// finishUpload checks the file received against size and the hash, and stores it.
+func (st *store) finishUpload(u *chunkedUpload, size int64) error {
+	if err := u.file.Close(); err != nil {
+		return err
+	}
+	switch {
+	case u.written != size:
+		_ = os.Remove(u.file.Name())
+		return fmt.Errorf("received %d bytes of %d", u.written, size)
+	case hex.EncodeToString(u.sha.Sum(nil)) != u.hash:
+		_ = os.Remove(u.file.Name())
+		return errors.New("content does not match its hash")
+	}
+	return os.Rename(u.file.Name(), st.blobPath(u.hash))
+}
+
+// abandon drops an upload that will not be finished.
+func (u *chunkedUpload) abandon() {
+	_ = u.file.Close()
+	_ = os.Remove(u.file.Name())
+}
+
+func (st *store) appDir(app string) string {
+	return filepath.Join(st.dir, "apps", app)
+}
+
+// deployments lists the versions of app deployed, newest first.
+func (st *store) deployments(app string) ([]deployment, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(st.appDir(app), "versions", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]deployment, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var d deployment
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		list = append(list, d)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].DeployedAt.After(list[j].DeployedAt) })
+	return list, nil
+}
+
+// deployment returns the version of app deployed as version, or nil.
+func (st *store) deployment(app, version string) (*deployment, error) {
+	list, err := st.deployments(app)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range list {
+		if d.Version == version {
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+// deploy records d as a version of app.
+func (st *store) deploy(app string, d deployment) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	dir := filepath.Join(st.appDir(app), "versions")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, d.Version+".json"), data, 0644)
+}
+
+// installed returns the version of app installed, or "" when none is.
+func (st *store) installed(app string) (string, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(st.appDir(app), "installed"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(data)), err
+}
+
+func (st *store) install(app, version string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(st.appDir(app), "installed"), []byte(version+"\n"), 0644)
+}
@@ -0,0 +1,388 @@
+// Package lint checks that the SCL of an app agrees with itself and with the
+// app's directory, catching before install the inconsistencies the server
+// would otherwise refuse: records that point at actions, tables or variables
+// that do not exist, records defined twice, and values outside their domain.
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"simple-cli/internal/build"
+	"simple-cli/internal/fsx"
+	"simple-cli/internal/scl"
+)
+
+// Diagnostic is one problem, positioned in the file it was found in. A
+// problem with a file or directory as a whole has a zero Pos.
+type Diagnostic struct {
+	Path string
+	Pos  scl.Pos
+	Msg  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.Path, d.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.Path, d.Pos.Line, d.Pos.Col, d.Msg)
+}
+
+// executionEnvironments are the values an action's execution_environment may
+// take, in the order they are named back in a message.
+var executionEnvironments = []string{"server", "client", "both"}
+
+var (
+	// varRefRe matches a $var('name') reference in an expression.
+	varRefRe = regexp.MustCompile(`\$var\(\s*(?:'([^']*)'|"([^"]*)")\s*\)`)
+
+	// logicNameRe and tableNameRe read the name a var's lookup query filters
+	// on, the way the trigger templates write those queries.
+	logicNameRe = regexp.MustCompile(`dev_simple_system__logics\s*\([^)]*?\bname:\s*\{\s*_eq:\s*"([^"]*)"`)
+	tableNameRe = regexp.MustCompile(`dev_simple_system__tables\s*\([^)]*?\bname:\s*\{\s*_eq:\s*"([^"]*)"`)
+)
+
+// findActions lists an app's action directories. It is a variable so tests
+// can stub it, like the build package's own tool functions.
+var findActions = build.FindActions
+
+// AppFiles lists the SCL files of the app at appDir that exist: app.scl,
+// tables.scl, then records/*.scl in name order.
+func AppFiles(fsys fsx.FileSystem, appDir string) []string {
+	var files []string
+	for _, name := range []string{"app.scl", "tables.scl"} {
+		if p := filepath.Join(appDir, name); exists(fsys, p) {
+			files = append(files, p)
+		}
+	}
+	return append(files, recordFiles(fsys, appDir)...)
+}
+
+func recordFiles(fsys fsx.FileSystem, appDir string) []string {
+	recordsDir := filepath.Join(appDir, "records")
+	entries, err := fsys.ReadDir(recordsDir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".scl") {
+			files = append(files, filepath.Join(recordsDir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func exists(fsys fsx.FileSystem, path string) bool {
+	_, err := fsys.Stat(path)
+	return err == nil
+}
+
+// record is one `set <type>, <key>` block and the file it is in.
+type record struct {
+	file *file
+	node *scl.Node
+	typ  string
+	key  string
+}
+
+type file struct {
+	path  string
+	nodes []*scl.Node
+	vars  map[string]*scl.Node
+}
+
+type linter struct {
+	appDir  string
+	diags   []Diagnostic
+	records []record
+	tables  map[string]bool
+}
+
+func (l *linter) report(path string, pos scl.Pos, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{Path: path, Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// App lints the app at appDir and returns its problems ordered by file and
+// position. A file that does not parse is reported as a problem of its own
+// and left out of the checks that need it; only failing to read the app is
+// an error.
+func App(fsys fsx.FileSystem, appDir string) ([]Diagnostic, error) {
+	l := &linter{appDir: appDir, tables: map[string]bool{}}
+
+	// Nothing checks app.scl beyond its syntax, but a file that does not
+	// parse is a problem all the same.
+	if appPath := filepath.Join(appDir, "app.scl"); exists(fsys, appPath) {
+		if _, _, err := l.parse(fsys, appPath); err != nil {
+			return nil, err
+		}
+	}
+
+	tablesPath := filepath.Join(appDir, "tables.scl")
+	if exists(fsys, tablesPath) {
+		if nodes, ok, err := l.parse(fsys, tablesPath); err != nil {
+			return nil, err
+		} else if ok {
+			for _, n := range nodes {
+				if n.IsBlock() && n.Key == "table" && len(n.Values) > 0 {
+					l.tables[n.Name()] = true
+				}
+			}
+		}
+	}
+
+	var files []*file
+	for _, path := range recordFiles(fsys, appDir) {
+		nodes, ok, err := l.parse(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		f := &file{path: path, nodes: nodes, vars: map[string]*scl.Node{}}
+		for _, n := range nodes {
+			if n.IsBlock() && n.Key == "var" && len(n.Values) > 0 {
+				if _, dup := f.vars[n.Name()]; !dup {
+					f.vars[n.Name()] = n
+				}
+			}
+			if n.IsBlock() && n.Key == "set" && len(n.Values) >= 2 {
+				l.records = append(l.records, record{file: f, node: n, typ: n.Values[0].String(), key: n.Values[1].String()})
+			}
+		}
+		files = append(files, f)
+	}
+
+	for _, f := range files {
+		l.checkVarRefs(f)
+	}
+	l.checkDuplicateSets()
+	l.checkActions()
+	l.checkTriggerLinks()
+	l.checkDBTriggers()
+
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		a, b := l.diags[i], l.diags[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Pos.Line != b.Pos.Line {
+			return a.Pos.Line < b.Pos.Line
+		}
+		return a.Pos.Col < b.Pos.Col
+	})
+	return l.diags, nil
+}
+
+// parse reads and parses path, reporting a syntax error as a diagnostic.
+func (l *linter) parse(fsys fsx.FileSystem, path string) ([]*scl.Node, bool, error) {
+	src, err := fsys.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	nodes, err := scl.Parse(src)
+	if err != nil {
+		if serr, ok := err.(*scl.Error); ok {
+			l.report(path, serr.Pos, "%s", serr.Msg)
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return nodes, true, nil
+}
+
+// isType reports whether a record's type is the named system table, whatever
+// the namespace in front of it.
+func (r record) isType(table string) bool {
+	return r.typ == table || strings.HasSuffix(r.typ, "."+table)
+}
+
+// checkVarRefs reports every $var reference in f that no var block earlier in
+// f defines. A var is visible from the statement after it to the end of its
+// file, and nowhere else.
+func (l *linter) checkVarRefs(f *file) {
+	defined := map[string]bool{}
+	var walk func(n *scl.Node)
+	walk = func(n *scl.Node) {
+		for _, v := range n.Values {
+			if v.Kind != scl.String {
+				continue
+			}
+			for _, m := range varRefRe.FindAllStringSubmatchIndex(v.Str, -1) {
+				var name string
+				if m[2] >= 0 {
+					name = v.Str[m[2]:m[3]]
+				} else {
+					name = v.Str[m[4]:m[5]]
+				}
+				if defined[name] {
+					continue
+				}
+				pos := refPos(v, m[0])
+				if later, ok := f.vars[name]; ok {
+					l.report(f.path, pos, "$var('%s') is used before its var block on line %d", name, later.KeyPos.Line)
+				} else {
+					l.report(f.path, pos, "$var('%s') has no var block in this file", name)
+				}
+			}
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	for _, n := range f.nodes {
+		walk(n)
+		if n.IsBlock() && n.Key == "var" && len(n.Values) > 0 {
+			defined[n.Name()] = true
+		}
+	}
+}
+
+// refPos is where byte offset off of v's text is in the source. Text that
+// spans lines is positioned at the start of the value.
+func refPos(v scl.Value, off int) scl.Pos {
+	if v.Quote == scl.Unquoted || v.Quote == scl.TripleBacktick || strings.Contains(v.Str, "\n") {
+		return v.Pos
+	}
+	// One column for the opening quote. Escaped quotes make this approximate,
+	// and expressions do not contain them.
+	return scl.Pos{Line: v.Pos.Line, Col: v.Pos.Col + 1 + utf8.RuneCountInString(v.Str[:off])}
+}
+
+// checkDuplicateSets reports a `set` of a type and key that an earlier one
+// already set, which the server would apply twice or refuse.
+func (l *linter) checkDuplicateSets() {
+	first := map[string]record{}
+	for _, r := range l.records {
+		id := r.typ + ", " + r.key
+		if prev, ok := first[id]; ok {
+			l.report(r.file.path, r.node.KeyPos, "duplicate set %s; first set at %s:%d", id, prev.file.path, prev.node.KeyPos.Line)
+			continue
+		}
+		first[id] = r
+	}
+}
+
+// actionName is the name an action record gives its action: its name, or,
+// without one, its key.
+func (r record) actionName() string {
+	if n := r.node.Child("name"); n != nil && !n.IsBlock() && n.Value().String() != "" {
+		return n.Value().String()
+	}
+	return r.key
+}
+
+// checkActions reports action records with no action directory, action
+// directories with no record, and execution environments the platform does
+// not have.
+func (l *linter) checkActions() {
+	dirs, _ := findActions(l.appDir)
+	byName := map[string]string{}
+	for _, dir := range dirs {
+		byName[filepath.Base(dir)] = dir
+	}
+
+	named := map[string]bool{}
+	for _, r := range l.records {
+		if !r.isType("logic") {
+			continue
+		}
+		name := r.actionName()
+		named[name] = true
+		named[build.NormalizeActionName(name)] = true
+
+		if _, ok := byName[name]; !ok {
+			l.report(r.file.path, r.node.KeyPos, "action %s has no directory %s", name, filepath.Join(l.appDir, "actions", name))
+		}
+
+		if env := r.node.Child("execution_environment"); env != nil && !env.IsBlock() {
+			v := env.Value()
+			if !slices.Contains(executionEnvironments, v.String()) {
+				l.report(r.file.path, v.Pos, "invalid execution_environment %q; expected %s", v.String(), strings.Join(executionEnvironments, ", "))
+			}
+		}
+	}
+
+	for name, dir := range byName {
+		if !named[name] && !named[build.NormalizeActionName(name)] {
+			l.report(dir, scl.Pos{}, "action directory has no record in %s", filepath.Join(l.appDir, "records", "10_actions.scl"))
+		}
+	}
+}
+
+// checkTriggerLinks reports trigger-action links whose logic lookup names an
+// action no record defines.
+func (l *linter) checkTriggerLinks() {
+	actions := map[string]bool{}
+	for _, r := range l.records {
+		if r.isType("logic") {
+			actions[r.actionName()] = true
+		}
+	}
+
+	for _, r := range l.records {
+		if !r.isType("logic_trigger") {
+			continue
+		}
+		for _, name := range l.lookups(r, "logic_id", logicNameRe) {
+			if !actions[name.text] {
+				l.report(r.file.path, name.pos, "trigger %s points at action %q, which no action record defines", r.key, name.text)
+			}
+		}
+	}
+}
+
+// checkDBTriggers reports database triggers on tables tables.scl does not
+// declare.
+func (l *linter) checkDBTriggers() {
+	for _, r := range l.records {
+		if !r.isType("db_event") {
+			continue
+		}
+		for _, name := range l.lookups(r, "table_id", tableNameRe) {
+			if !l.tables[name.text] {
+				l.report(r.file.path, name.pos, "db trigger %s watches table %q, which tables.scl does not declare", r.key, name.text)
+			}
+		}
+	}
+}
+
+type lookup struct {
+	text string
+	pos  scl.Pos
+}
+
+// lookups follows the $var references in a record's field to the var blocks
+// they name and returns what each var's query filters on, positioned at the
+// field. A var that does not exist is checkVarRefs' to report.
+func (l *linter) lookups(r record, field string, re *regexp.Regexp) []lookup {
+	n := r.node.Child(field)
+	if n == nil || n.IsBlock() {
+		return nil
+	}
+	var out []lookup
+	for _, v := range n.Values {
+		for _, m := range varRefRe.FindAllStringSubmatch(v.Str, -1) {
+			name := m[1] + m[2]
+			def, ok := r.file.vars[name]
+			if !ok {
+				continue
+			}
+			query := def.Child("query")
+			if query == nil {
+				continue
+			}
+			for _, q := range re.FindAllStringSubmatch(query.Value().String(), -1) {
+				out = append(out, lookup{text: q[1], pos: v.Pos})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,209 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"simple-cli/internal/fsx"
+)
+
+const (
+	lintTables = `table order {
+  required total, :decimal
+}
+`
+
+	lintActions = `set dev_simple_system.logic, send_email {
+  name "send-email"
+  execution_environment server
+}
+
+set dev_simple_system.logic, orphan {
+  name "orphan"
+  execution_environment cloud
+}
+
+set dev_simple_system.logic, send_email {
+  name "send-email"
+}
+`
+
+	lintTriggers = `var on_order_tables {
+  query ` + "```" + `
+  query get_on_order_tables {
+    tables: dev_simple_system__tables(
+      where: {name: {_eq: "invoice"}, application_id: {_eq: "com.acme.crm"}}
+    ) {
+      id
+    }
+  }
+  ` + "```" + `
+}
+
+set dev_simple_system.db_event, on_order {
+  name "on-order"
+  table_id ` + "`$var('on_order_tables') |> $jq('.tables[0].id')`" + `
+  trigger_id ` + "`$var('on_order_trigger') |> $jq('.trigger[0].id')`" + `
+}
+`
+
+	lintLinks = `set dev_simple_system.logic_trigger, on_order {
+  logic_id ` + "`$var('on_order_link') |> $jq('.logic[0].id')`" + `
+}
+
+var on_order_link {
+  query ` + "```" + `
+  query get_on_order_link {
+    logic: dev_simple_system__logics(
+      where: {
+        application_id: {_eq: "com.acme.crm"},
+        name: {_eq: "notify"}
+      }
+    ) {
+      id
+    }
+  }
+  ` + "```" + `
+}
+`
+)
+
+func writeApp(t *testing.T, files map[string]string) string {
+	t.Helper()
+	appDir := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(appDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return appDir
+}
+
+func stubActions(t *testing.T, names ...string) {
+	t.Helper()
+	orig := findActions
+	t.Cleanup(func() { findActions = orig })
+	findActions = func(appDir string) ([]string, error) {
+		var dirs []string
+		for _, n := range names {
+			dirs = append(dirs, filepath.Join(appDir, "actions", n))
+		}
+		return dirs, nil
+	}
+}
+
+func TestApp(t *testing.T) {
+	appDir := writeApp(t, map[string]string{
+		"tables.scl":                      lintTables,
+		"records/10_actions.scl":          lintActions,
+		"records/20_triggers_db.scl":      lintTriggers,
+		"records/30_trigger_actions.scl":  lintLinks,
+		"actions/send-email/src/index.ts": "",
+	})
+	stubActions(t, "send-email", "unrecorded")
+
+	diags, err := App(fsx.OSFileSystem{}, appDir)
+	if err != nil {
+		t.Fatalf("App() error = %v", err)
+	}
+
+	rel := func(p string) string { return filepath.Join(appDir, filepath.FromSlash(p)) }
+	want := []string{
+		rel("actions/unrecorded") + ": action directory has no record in " + rel("records/10_actions.scl"),
+		rel("records/10_actions.scl") + ":6:1: action orphan has no directory " + rel("actions/orphan"),
+		rel("records/10_actions.scl") + `:8:25: invalid execution_environment "cloud"; expected server, client, both`,
+		rel("records/10_actions.scl") + ":11:1: duplicate set dev_simple_system.logic, send_email; first set at " + rel("records/10_actions.scl") + ":1",
+		rel("records/20_triggers_db.scl") + `:15:12: db trigger on_order watches table "invoice", which tables.scl does not declare`,
+		rel("records/20_triggers_db.scl") + ":16:15: $var('on_order_trigger') has no var block in this file",
+		rel("records/30_trigger_actions.scl") + `:2:12: trigger on_order points at action "notify", which no action record defines`,
+		rel("records/30_trigger_actions.scl") + ":2:13: $var('on_order_link') is used before its var block on line 5",
+	}
+
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("App() =\n%s\n\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestApp_Clean(t *testing.T) {
+	appDir := writeApp(t, map[string]string{
+		"tables.scl": lintTables,
+		"records/10_actions.scl": `set dev_simple_system.logic, send_email {
+  name "send-email"
+  execution_environment both
+}
+`,
+		"records/20_triggers_db.scl": strings.ReplaceAll(
+			strings.ReplaceAll(lintTriggers, `"invoice"`, `"order"`),
+			"  trigger_id `$var('on_order_trigger') |> $jq('.trigger[0].id')`\n", ""),
+	})
+	stubActions(t, "send-email")
+
+	diags, err := App(fsx.OSFileSystem{}, appDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("expected no problems, got %v", diags)
+	}
+}
+
+func TestApp_SyntaxError(t *testing.T) {
+	appDir := writeApp(t, map[string]string{
+		"records/10_actions.scl": "set dev_simple_system.logic, a {\n",
+	})
+	stubActions(t)
+
+	diags, err := App(fsx.OSFileSystem{}, appDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].String(), "10_actions.scl:1:1: unterminated block `set`") {
+		t.Errorf("expected the syntax error as a diagnostic, got %v", diags)
+	}
+}
+
+func TestApp_AppSCLSyntaxError(t *testing.T) {
+	appDir := writeApp(t, map[string]string{
+		"app.scl": "id com.acme.crm\n}\n",
+	})
+	stubActions(t)
+
+	diags, err := App(fsx.OSFileSystem{}, appDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Path != filepath.Join(appDir, "app.scl") || diags[0].Pos.Line != 2 {
+		t.Errorf("expected the app.scl syntax error on line 2, got %v", diags)
+	}
+}
+
+func TestAppFiles(t *testing.T) {
+	appDir := writeApp(t, map[string]string{
+		"app.scl":                "",
+		"records/20_b.scl":       "",
+		"records/10_a.scl":       "",
+		"records/notes.md":       "",
+		"actions/x/action.scl":   "",
+		"scripts/record-x/y.scl": "",
+	})
+
+	got := AppFiles(fsx.OSFileSystem{}, appDir)
+	want := []string{
+		filepath.Join(appDir, "app.scl"),
+		filepath.Join(appDir, "records", "10_a.scl"),
+		filepath.Join(appDir, "records", "20_b.scl"),
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("AppFiles() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package lsp
+
+// recordType describes one dev_simple_system table an app's records can set:
+// what it is for and the keys a `set dev_simple_system.<name>` block takes.
+type recordType struct {
+	doc  string
+	keys []recordKey
+}
+
+type recordKey struct {
+	name   string
+	doc    string
+	values []string // the values the key may take, when they are a closed set
+}
+
+// systemNamespace is the namespace of the tables catalog describes.
+const systemNamespace = "dev_simple_system"
+
+// catalog is the record types the templates and the context docs write, with
+// their keys in the order the templates write them.
+var catalog = map[string]recordType{
+	"custom_view": {
+		doc: "A custom UI view, rendered for a table's records or as a dashboard.",
+		keys: []recordKey{
+			{name: "display_name", doc: "UI display name."},
+			{name: "type", doc: "Where the view renders.", values: []string{"record", "list", "dashboard"}},
+			{name: "target_table_id", doc: "The table the view shows, usually a `$var` lookup."},
+		},
+	},
+	"db_event": {
+		doc: "Fires a trigger when records of a table are inserted, updated or deleted.",
+		keys: []recordKey{
+			{name: "name", doc: "**Required**. Unique system name for this event hook."},
+			{name: "display_name", doc: "UI-friendly name."},
+			{name: "description", doc: "Description of the event's purpose."},
+			{name: "table_id", doc: "**Required**. The table to monitor."},
+			{name: "trigger_id", doc: "**Required**. The trigger record to fire."},
+			{name: "operations", doc: "**Required**. JSON array of database operations: `[\"insert\", \"update\", \"delete\"]`."},
+			{name: "condition", doc: "`jq` filter expression. The trigger fires only if it yields `true`."},
+			{name: "is_active", doc: "Default `true`. Set to `false` to disable.", values: []string{"true", "false"}},
+		},
+	},
+	"logic": {
+		doc: "Registers an action. Its name matches the action's directory under actions/.",
+		keys: []recordKey{
+			{name: "name", doc: "Matches the action folder name (kebab-case)."},
+			{name: "display_name", doc: "UI display name."},
+			{name: "description", doc: "Detailed description."},
+			{name: "execution_environment", doc: "Where the action runs.", values: []string{"server", "client", "both"}},
+			{name: "language", doc: "Source language.", values: []string{"typescript", "go", "rust"}},
+			{name: "application_id", doc: "The app ID."},
+			{name: "is_active", doc: "Set to `false` to disable the action.", values: []string{"true", "false"}},
+		},
+	},
+	"logic_trigger": {
+		doc: "Binds an action to a trigger, so the trigger firing runs the action.",
+		keys: []recordKey{
+			{name: "is_active", doc: "Set to `false` to disable the binding.", values: []string{"true", "false"}},
+			{name: "logic_id", doc: "The action to run, usually a `$var` lookup on `dev_simple_system__logics`."},
+			{name: "trigger_id", doc: "The trigger that runs it, usually a `$var` lookup on `dev_simple_system__triggers`."},
+		},
+	},
+	"record_behavior": {
+		doc: "Attaches a form script to a table's record form.",
+		keys: []recordKey{
+			{name: "table_id", doc: "The table whose form runs the script."},
+			{name: "script", doc: "The compiled behavior script, usually `$file(...)`."},
+		},
+	},
+	"setting": {
+		doc: "An app setting, readable by actions at runtime.",
+		keys: []recordKey{
+			{name: "name", doc: "The setting's key."},
+			{name: "display_name", doc: "UI display name."},
+			{name: "value", doc: "The setting's value."},
+			{name: "secret_value", doc: "A value stored encrypted and never shown back."},
+		},
+	},
+	"space": {
+		doc: "A space: a page of the app's UI built from widgets.",
+		keys: []recordKey{
+			{name: "name", doc: "Internal identifier (kebab-case)."},
+			{name: "display_name", doc: "UI display name."},
+			{name: "description", doc: "Description of the space."},
+			{name: "is_active", doc: "Set to `false` to hide the space.", values: []string{"true", "false"}},
+			{name: "permissions", doc: "Who may open the space."},
+		},
+	},
+	"table": {
+		doc: "Display metadata for one of the app's tables.",
+		keys: []recordKey{
+			{name: "id", doc: "The table, usually a `$var` lookup."},
+			{name: "display_name", doc: "UI display name."},
+			{name: "description", doc: "Description of the table."},
+			{name: "icon", doc: "Icon name (e.g., `users`, `file-text`)."},
+			{name: "hidden", doc: "Hide the table from navigation.", values: []string{"true", "false"}},
+		},
+	},
+	"table_field": {
+		doc: "Display metadata for one field of a table.",
+		keys: []recordKey{
+			{name: "id", doc: "The field, usually a `$var` lookup."},
+			{name: "display_name", doc: "UI display name."},
+			{name: "help_text", doc: "Hint shown under the field."},
+			{name: "position", doc: "Order of the field in forms and lists."},
+			{name: "hidden", doc: "Hide the field.", values: []string{"true", "false"}},
+			{name: "readonly", doc: "Show the field but do not let users edit it.", values: []string{"true", "false"}},
+		},
+	},
+	"table_relationship": {
+		doc: "A link between tables, including tables of other apps.",
+		keys: []recordKey{
+			{name: "name", doc: "Internal identifier of the relationship."},
+			{name: "display_name", doc: "UI display name."},
+			{name: "kind", doc: "Relationship direction.", values: []string{"has", "belongs"}},
+			{name: "cardinality", doc: "One-to-one or one-to-many.", values: []string{"one", "many"}},
+			{name: "source_table_id", doc: "Table with the relationship."},
+			{name: "target_table_id", doc: "Related table."},
+			{name: "target_field_id", doc: "Foreign key field."},
+		},
+	},
+	"trigger": {
+		doc: "Something that can run actions: a schedule, a database event or a webhook.",
+		keys: []recordKey{
+			{name: "key", doc: "Unique key of the trigger within the app."},
+			{name: "name", doc: "Display name."},
+			{name: "description", doc: "Description of what fires it."},
+			{name: "time_schedule", doc: "JSON schedule for timed triggers: `frequency`, `timezone`, `interval`, `time`, `days`, ..."},
+		},
+	},
+	"user": {
+		doc: "A user seeded with the app.",
+		keys: []recordKey{
+			{name: "email", doc: "The user's email, which identifies them."},
+			{name: "first_name", doc: "First name."},
+			{name: "last_name", doc: "Last name."},
+			{name: "is_active", doc: "Set to `false` to disable the user.", values: []string{"true", "false"}},
+			{name: "roles", doc: "The roles the user has."},
+		},
+	},
+	"view_action": {
+		doc: "A button on a custom view that runs a trigger.",
+		keys: []recordKey{
+			{name: "name", doc: "Internal identifier (kebab-case)."},
+			{name: "label", doc: "Button text."},
+			{name: "icon", doc: "Icon name (e.g., `file-text`, `download`, `send`)."},
+			{name: "type", doc: "Button style.", values: []string{"primary", "outline", "danger"}},
+			{name: "custom_view_id", doc: "The view the button is on."},
+			{name: "trigger_id", doc: "The trigger the button invokes."},
+		},
+	},
+	"webhook": {
+		doc: "An HTTP endpoint that fires a trigger: `POST /api/hooks/:app_id/:name`.",
+		keys: []recordKey{
+			{name: "name", doc: "**Required**. URL slug of the endpoint."},
+			{name: "display_name", doc: "UI display name."},
+			{name: "description", doc: "Description of the webhook."},
+			{name: "trigger_id", doc: "**Required**. The trigger record to fire."},
+			{name: "method", doc: "**Required**. HTTP method.", values: []string{"get", "post", "put", "delete"}},
+			{name: "is_public", doc: "`true` allows anonymous access. `false` requires Platform Auth (default).", values: []string{"true", "false"}},
+		},
+	},
+}
+
+// key looks up a key of the record type, if the catalog has it.
+func (t recordType) key(name string) (recordKey, bool) {
+	for _, k := range t.keys {
+		if k.name == name {
+			return k, true
+		}
+	}
+	return recordKey{}, false
+}
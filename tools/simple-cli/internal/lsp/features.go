@@ -0,0 +1,439 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"simple-cli/internal/lint"
+	"simple-cli/internal/scl"
+)
+
+// varRefRe matches a $var('name') reference, as lint reads them.
+var varRefRe = regexp.MustCompile(`\$var\(\s*(?:'([^']*)'|"([^"]*)")\s*\)`)
+
+// varRefAt returns the name of the $var reference that line has at rune
+// column col, if any.
+func varRefAt(line string, col int) (string, bool) {
+	for _, m := range varRefRe.FindAllStringSubmatchIndex(line, -1) {
+		start := utf8.RuneCountInString(line[:m[0]])
+		end := utf8.RuneCountInString(line[:m[1]])
+		if col < start || col > end {
+			continue
+		}
+		if m[2] >= 0 {
+			return line[m[2]:m[3]], true
+		}
+		return line[m[4]:m[5]], true
+	}
+	return "", false
+}
+
+// wordAt returns the name-like word of line around rune column col.
+func wordAt(line string, col int) string {
+	runes := []rune(line)
+	isWord := func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	}
+	start, end := min(col, len(runes)), min(col, len(runes))
+	for start > 0 && isWord(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isWord(runes[end]) {
+		end++
+	}
+	return string(runes[start:end])
+}
+
+// varBlock returns the top-level var block of nodes with the given name.
+func varBlock(nodes []*scl.Node, name string) *scl.Node {
+	for _, n := range nodes {
+		if n.IsBlock() && n.Key == "var" && n.Name() == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// recordTypeOf returns the catalog name of a `set <namespace>.<type>, <key>`
+// block's type, or "" when the node is not a set of a system table.
+func recordTypeOf(n *scl.Node) string {
+	if !n.IsBlock() || n.Key != "set" || len(n.Values) == 0 {
+		return ""
+	}
+	typ, ok := strings.CutPrefix(n.Values[0].String(), systemNamespace+".")
+	if !ok {
+		return ""
+	}
+	return typ
+}
+
+// target is a place definitions resolve to.
+type target struct {
+	path string
+	pos  scl.Pos
+	n    int // the length of the name at pos
+}
+
+// appIndex is what go-to-definition needs to know about an app: where its
+// tables and actions are declared.
+type appIndex struct {
+	tables  map[string]target
+	actions map[string]target
+}
+
+// index reads the app at appDir. Files that do not parse are left out.
+func (s *Server) index(appDir string) appIndex {
+	idx := appIndex{tables: map[string]target{}, actions: map[string]target{}}
+	fsys := s.files()
+	for _, path := range lint.AppFiles(fsys, appDir) {
+		src, err := fsys.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		nodes, err := scl.Parse(src)
+		if err != nil {
+			continue
+		}
+		for _, n := range nodes {
+			switch {
+			case n.IsBlock() && n.Key == "table" && len(n.Values) > 0:
+				v := n.Values[0]
+				if _, dup := idx.tables[v.String()]; !dup {
+					idx.tables[v.String()] = target{path, v.Pos, utf8.RuneCountInString(v.String())}
+				}
+			case recordTypeOf(n) == "logic" && len(n.Values) >= 2:
+				name, at := n.Values[1].String(), target{path, n.KeyPos, len("set")}
+				if c := n.Child("name"); c != nil && !c.IsBlock() && c.Value().String() != "" {
+					name = c.Value().String()
+					at = target{path, c.KeyPos, len(c.Key)}
+				}
+				if _, dup := idx.actions[name]; !dup {
+					idx.actions[name] = at
+				}
+			}
+		}
+	}
+	return idx
+}
+
+func (s *Server) location(t target) Location {
+	uri := pathToURI(t.path)
+	if doc, ok := s.docs[t.path]; ok {
+		uri = doc.uri
+	}
+	return Location{URI: uri, Range: s.text(t.path).span(t.pos, t.n)}
+}
+
+// definition resolves the $var reference, table name or action name under
+// the cursor to where it is declared: the var block in the same file, the
+// table block in tables.scl, the logic record that names the action.
+func (s *Server) definition(params TextDocumentPositionParams) []Location {
+	doc := s.document(params.TextDocument.URI)
+	if doc == nil {
+		return nil
+	}
+	line := doc.text.line(params.Position.Line)
+	col := doc.text.runeCol(params.Position)
+
+	if name, ok := varRefAt(line, col); ok {
+		if n := varBlock(doc.nodes, name); n != nil {
+			return []Location{s.location(target{doc.path, n.Values[0].Pos, utf8.RuneCountInString(name)})}
+		}
+		return nil
+	}
+
+	appDir := s.appDir(doc.path)
+	word := wordAt(line, col)
+	if appDir == "" || word == "" {
+		return nil
+	}
+	idx := s.index(appDir)
+	// A table is also named by the last part of a dotted name, like the
+	// order in com_acme_crm.order.
+	candidates := []string{word}
+	if i := strings.LastIndex(word, "."); i >= 0 {
+		candidates = append(candidates, word[i+1:])
+	}
+	for _, w := range candidates {
+		if t, ok := idx.tables[w]; ok {
+			return []Location{s.location(t)}
+		}
+		if t, ok := idx.actions[w]; ok {
+			return []Location{s.location(t)}
+		}
+	}
+	return nil
+}
+
+// hover documents the $var reference, record type or record key under the
+// cursor.
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	doc := s.document(params.TextDocument.URI)
+	if doc == nil {
+		return nil
+	}
+	line := doc.text.line(params.Position.Line)
+	col := doc.text.runeCol(params.Position)
+
+	if name, ok := varRefAt(line, col); ok {
+		n := varBlock(doc.nodes, name)
+		if n == nil {
+			return nil
+		}
+		md := fmt.Sprintf("**var %s**", name)
+		if q := n.Child("query"); q != nil && !q.IsBlock() {
+			md += "\n\n```graphql\n" + strings.TrimSpace(q.Value().String()) + "\n```"
+		}
+		return markdown(md)
+	}
+
+	lineNo := params.Position.Line + 1
+	for _, n := range doc.nodes {
+		if n.KeyPos.Line > lineNo || n.EndPos.Line < lineNo {
+			continue
+		}
+		typ := recordTypeOf(n)
+		rt, ok := catalog[typ]
+		if !ok {
+			return nil
+		}
+		if n.KeyPos.Line == lineNo {
+			return markdown(fmt.Sprintf("**%s.%s**\n\n%s", systemNamespace, typ, rt.doc))
+		}
+		for _, c := range n.Children {
+			if c.KeyPos.Line != lineNo || col < c.KeyPos.Col-1 || col > c.KeyPos.Col-1+utf8.RuneCountInString(c.Key) {
+				continue
+			}
+			if k, ok := rt.key(c.Key); ok {
+				return markdown(keyDoc(typ, k))
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func keyDoc(typ string, k recordKey) string {
+	md := fmt.Sprintf("**%s** (%s)\n\n%s", k.name, typ, k.doc)
+	if len(k.values) > 0 {
+		md += "\n\nOne of: `" + strings.Join(k.values, "`, `") + "`"
+	}
+	return md
+}
+
+func markdown(md string) *Hover {
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: md}}
+}
+
+var (
+	// setTypePrefixRe matches a set statement being written up to its type.
+	setTypePrefixRe = regexp.MustCompile(`^\s*set\s+` + systemNamespace + `\.(\w*)$`)
+	// setTypeRe reads the record type of a block's header.
+	setTypeRe = regexp.MustCompile(`^set\s+` + systemNamespace + `\.(\w+)\s*,`)
+	// keyPrefixRe and valuePrefixRe match a line being written up to a key,
+	// and up to the value of a key.
+	keyPrefixRe   = regexp.MustCompile(`^\s*(\w*)$`)
+	valuePrefixRe = regexp.MustCompile(`^\s*(\w+)\s+(\w*)$`)
+)
+
+// completion offers record types after `set dev_simple_system.`, the keys a
+// record block does not have yet at the start of a line in it, and the
+// values of keys that take a closed set of them.
+func (s *Server) completion(params TextDocumentPositionParams) []CompletionItem {
+	items := []CompletionItem{}
+	doc := s.document(params.TextDocument.URI)
+	if doc == nil {
+		return items
+	}
+	line := []rune(doc.text.line(params.Position.Line))
+	before := string(line[:min(len(line), doc.text.runeCol(params.Position))])
+
+	if setTypePrefixRe.MatchString(before) {
+		names := make([]string, 0, len(catalog))
+		for name := range catalog {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			items = append(items, CompletionItem{
+				Label:         name,
+				Kind:          completionClass,
+				Documentation: &MarkupContent{Kind: "markdown", Value: catalog[name].doc},
+			})
+		}
+		return items
+	}
+
+	block := enclosingBlock(doc.text.src, doc.text.offset(params.Position), params.Position.Line)
+	if block == nil {
+		return items
+	}
+	m := setTypeRe.FindStringSubmatch(block.header)
+	if m == nil {
+		return items
+	}
+	typ := m[1]
+	rt, ok := catalog[typ]
+	if !ok {
+		return items
+	}
+
+	if m := valuePrefixRe.FindStringSubmatch(before); m != nil {
+		if k, ok := rt.key(m[1]); ok {
+			for _, v := range k.values {
+				items = append(items, CompletionItem{Label: v, Kind: completionValue, Detail: k.name})
+			}
+		}
+		return items
+	}
+	if keyPrefixRe.MatchString(before) {
+		for _, k := range rt.keys {
+			if block.keys[k.name] {
+				continue
+			}
+			items = append(items, CompletionItem{
+				Label:         k.name,
+				Kind:          completionProperty,
+				Detail:        typ,
+				Documentation: &MarkupContent{Kind: "markdown", Value: keyDoc(typ, k)},
+			})
+		}
+	}
+	return items
+}
+
+// openBlock is a block found by scanning the text: its header, up to the
+// `{`, and the keys of its statements.
+type openBlock struct {
+	header string
+	keys   map[string]bool
+}
+
+// enclosingBlock returns the innermost block around byte offset off of src,
+// or nil. It scans the text rather than the syntax tree because the text
+// being edited rarely parses. The statement on skipLine, the one being
+// written, does not count among the block's keys.
+func enclosingBlock(src string, off, skipLine int) *openBlock {
+	var stack, found []*openBlock
+	reached := false
+	lineNo, lineStart := 0, 0
+	atStatement := true
+
+	for i := 0; i < len(src); i++ {
+		if i >= off && !reached {
+			found, reached = slices.Clone(stack), true
+		}
+		c := src[i]
+		switch {
+		case c == '\n':
+			lineNo++
+			lineStart = i + 1
+			atStatement = true
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			continue
+		case c == '#':
+			for i+1 < len(src) && src[i+1] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "```"):
+			end := strings.Index(src[i+3:], "```")
+			if end < 0 {
+				i = len(src)
+			} else {
+				end += i + 3
+				lineNo += strings.Count(src[i:end], "\n")
+				if nl := strings.LastIndex(src[:end], "\n"); nl >= lineStart {
+					lineStart = nl + 1
+				}
+				i = end + 2
+			}
+		case c == '"' || c == '\'' || c == '`':
+			for i+1 < len(src) && src[i+1] != c && src[i+1] != '\n' {
+				if src[i+1] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case c == '{':
+			stack = append(stack, &openBlock{header: strings.TrimSpace(src[lineStart:i]), keys: map[string]bool{}})
+			atStatement = true
+			continue
+		case c == '}':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			if atStatement && len(stack) > 0 && lineNo != skipLine {
+				end := i
+				for end < len(src) && (src[end] == '_' || src[end] >= 'a' && src[end] <= 'z' || src[end] >= 'A' && src[end] <= 'Z' || src[end] >= '0' && src[end] <= '9') {
+					end++
+				}
+				stack[len(stack)-1].keys[src[i:end]] = true
+			}
+		}
+		atStatement = false
+	}
+	if !reached {
+		found = stack
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return found[len(found)-1]
+}
+
+// symbols outlines a document: its var, set and table blocks, with their
+// statements nested under them.
+func (s *Server) symbols(uri string) []DocumentSymbol {
+	doc := s.document(uri)
+	if doc == nil {
+		return nil
+	}
+	out := []DocumentSymbol{}
+	for _, n := range doc.nodes {
+		out = append(out, symbol(doc.text, n, ""))
+	}
+	return out
+}
+
+func symbol(t *text, n *scl.Node, parent string) DocumentSymbol {
+	sym := DocumentSymbol{
+		Name:           n.Key,
+		Detail:         strings.Join(n.Names(), ", "),
+		Kind:           symbolProperty,
+		Range:          t.lineEnd(n.KeyPos),
+		SelectionRange: t.span(n.KeyPos, utf8.RuneCountInString(n.Key)),
+	}
+	if n.IsBlock() {
+		sym.Range = Range{Start: t.position(n.KeyPos), End: t.position(scl.Pos{Line: n.EndPos.Line, Col: n.EndPos.Col + 1})}
+		sym.Kind = symbolNamespace
+		switch n.Key {
+		case "var":
+			sym.Kind = symbolVariable
+			sym.Name, sym.Detail = n.Name(), "var"
+		case "set":
+			sym.Kind = symbolObject
+			sym.Name, sym.Detail = strings.Join(n.Names(), ", "), "set"
+		case "table":
+			sym.Kind = symbolClass
+			sym.Name, sym.Detail = n.Name(), "table"
+		}
+	}
+	if parent == "table" && len(n.Values) > 0 {
+		// A field, `required email, :string`: named by the field.
+		sym.Kind = symbolField
+		sym.Name = n.Values[0].String()
+		sym.Detail = strings.TrimSpace(n.Key + " " + strings.Join(n.Names()[1:], ", "))
+	}
+	for _, c := range n.Children {
+		sym.Children = append(sym.Children, symbol(t, c, n.Key))
+	}
+	return sym
+}
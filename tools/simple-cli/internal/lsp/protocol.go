@@ -0,0 +1,222 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"simple-cli/internal/scl"
+)
+
+// The subset of the Language Server Protocol the server speaks. Field names
+// follow the specification so the JSON needs no further mapping.
+
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+const severityError = 1
+
+type TextDocumentItem struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+	Text    string `json:"text"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument   TextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Range *Range `json:"range,omitempty"`
+		Text  string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type DidSaveTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Text         *string                `json:"text,omitempty"`
+}
+
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type CompletionItem struct {
+	Label         string         `json:"label"`
+	Kind          int            `json:"kind,omitempty"`
+	Detail        string         `json:"detail,omitempty"`
+	Documentation *MarkupContent `json:"documentation,omitempty"`
+}
+
+// Completion item kinds.
+const (
+	completionProperty = 10
+	completionValue    = 12
+	completionClass    = 7
+)
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// Symbol kinds.
+const (
+	symbolNamespace = 3
+	symbolClass     = 5
+	symbolProperty  = 7
+	symbolField     = 8
+	symbolVariable  = 13
+	symbolObject    = 19
+)
+
+// uriToPath turns a file:// URI into a path of this OS.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+// pathToURI is uriToPath's inverse for an absolute path.
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// text is a document split into lines, for converting between the 1-based
+// character columns of scl and the 0-based UTF-16 columns of LSP.
+type text struct {
+	src   string
+	lines []string
+}
+
+func newText(src string) *text {
+	return &text{src: src, lines: strings.Split(src, "\n")}
+}
+
+func (t *text) line(n int) string {
+	if n < 0 || n >= len(t.lines) {
+		return ""
+	}
+	return strings.TrimSuffix(t.lines[n], "\r")
+}
+
+// position converts an scl position. The zero Pos, used for problems with a
+// file as a whole, is the start of the file.
+func (t *text) position(p scl.Pos) Position {
+	if p.Line == 0 {
+		return Position{}
+	}
+	line := t.line(p.Line - 1)
+	units := 0
+	for i, r := range []rune(line) {
+		if i >= p.Col-1 {
+			break
+		}
+		units += utf16Len(r)
+	}
+	return Position{Line: p.Line - 1, Character: units}
+}
+
+// runeCol is the 0-based character index of an LSP position in its line.
+func (t *text) runeCol(p Position) int {
+	units, col := 0, 0
+	for _, r := range t.line(p.Line) {
+		if units >= p.Character {
+			break
+		}
+		units += utf16Len(r)
+		col++
+	}
+	return col
+}
+
+// span is the range of n characters from p.
+func (t *text) span(p scl.Pos, n int) Range {
+	return Range{Start: t.position(p), End: t.position(scl.Pos{Line: p.Line, Col: p.Col + n})}
+}
+
+// lineEnd is the range from p to the end of its line.
+func (t *text) lineEnd(p scl.Pos) Range {
+	return t.span(p, utf8.RuneCountInString(t.line(p.Line-1))-p.Col+1)
+}
+
+// offset is the byte offset of an LSP position in the source.
+func (t *text) offset(p Position) int {
+	off := 0
+	for i := 0; i < p.Line && i < len(t.lines); i++ {
+		off += len(t.lines[i]) + 1
+	}
+	if p.Line >= len(t.lines) {
+		return len(t.src)
+	}
+	runes := []rune(t.lines[p.Line])
+	return off + len(string(runes[:min(len(runes), t.runeCol(p))]))
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,387 @@
+// Package lsp is a Language Server Protocol server for SCL files. It speaks
+// JSON-RPC over a pair of streams, normally the stdio of `simple lsp`, and
+// gives an editor what the CLI already knows about an app: syntax errors from
+// the scl parser and the cross-file problems of `simple lint` as diagnostics,
+// go-to-definition for $var references, action names and table names,
+// completion and hover docs for the keys of dev_simple_system records, and an
+// outline of each file.
+//
+// Documents are synced in full on every change. Diagnostics cover the whole
+// app the changed file belongs to, reading open documents from the editor and
+// every other file from disk.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"simple-cli/internal/config"
+	"simple-cli/internal/fsx"
+	"simple-cli/internal/lint"
+	"simple-cli/internal/scl"
+)
+
+// document is a file the editor has open, as the editor has it.
+type document struct {
+	uri   string
+	path  string
+	text  *text
+	nodes []*scl.Node // nil when the text does not parse
+	err   *scl.Error
+}
+
+// Server is an SCL language server. The zero value is not usable; create one
+// with NewServer.
+type Server struct {
+	// Version is reported to the editor in the initialize response.
+	Version string
+
+	fsys      fsx.FileSystem
+	out       io.Writer
+	docs      map[string]*document
+	published map[string]map[string]bool // app directory -> files with diagnostics
+
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer returns a server that reads files that are not open from fsys.
+func NewServer(fsys fsx.FileSystem) *Server {
+	return &Server{
+		fsys:      fsys,
+		docs:      map[string]*document{},
+		published: map[string]map[string]bool{},
+	}
+}
+
+// errExit stops Run when the client sends exit.
+var errExit = errors.New("exit")
+
+// Run serves requests from in, writing responses and notifications to out,
+// until the client sends exit or closes in. Exiting without a shutdown
+// request first is an error, as the protocol makes it.
+func (s *Server) Run(in io.Reader, out io.Writer) error {
+	s.out = out
+	r := bufio.NewReader(in)
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			var rerr *responseError
+			if errors.As(err, &rerr) {
+				if werr := s.reply(nil, nil, rerr); werr != nil {
+					return werr
+				}
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := s.handle(msg); err != nil {
+			if errors.Is(err, errExit) {
+				if !s.shutdown {
+					return errors.New("exit before shutdown")
+				}
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// handle dispatches one message. Only failing to write is an error; what
+// goes wrong with a request is answered to the client.
+func (s *Server) handle(msg *message) error {
+	if msg.ID == nil {
+		return s.notify(msg)
+	}
+	if msg.Method == "" {
+		return nil // a response to a request the server never sends
+	}
+
+	if !s.initialized && msg.Method != "initialize" {
+		return s.reply(msg.ID, nil, &responseError{Code: codeNotInitialized, Message: "server not initialized"})
+	}
+	if s.shutdown {
+		return s.reply(msg.ID, nil, &responseError{Code: codeInvalidRequest, Message: "server is shutting down"})
+	}
+
+	var pos TextDocumentPositionParams
+	switch msg.Method {
+	case "initialize":
+		s.initialized = true
+		return s.reply(msg.ID, map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": map[string]any{
+					"openClose": true,
+					"change":    1, // full
+					"save":      map[string]any{"includeText": false},
+				},
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]any{"triggerCharacters": []string{".", " "}},
+			},
+			"serverInfo": map[string]any{"name": "simple", "version": s.Version},
+		}, nil)
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(msg.ID, nil, nil)
+	case "textDocument/definition":
+		if err := json.Unmarshal(msg.Params, &pos); err != nil {
+			return s.invalidParams(msg, err)
+		}
+		return s.reply(msg.ID, s.definition(pos), nil)
+	case "textDocument/hover":
+		if err := json.Unmarshal(msg.Params, &pos); err != nil {
+			return s.invalidParams(msg, err)
+		}
+		return s.reply(msg.ID, s.hover(pos), nil)
+	case "textDocument/completion":
+		if err := json.Unmarshal(msg.Params, &pos); err != nil {
+			return s.invalidParams(msg, err)
+		}
+		return s.reply(msg.ID, s.completion(pos), nil)
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.invalidParams(msg, err)
+		}
+		return s.reply(msg.ID, s.symbols(params.TextDocument.URI), nil)
+	default:
+		return s.reply(msg.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not supported: " + msg.Method})
+	}
+}
+
+// notify handles a notification. Malformed ones are dropped, since there is
+// no one to answer.
+func (s *Server) notify(msg *message) error {
+	switch msg.Method {
+	case "exit":
+		return errExit
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return nil
+		}
+		doc := s.open(params.TextDocument.URI, params.TextDocument.Text)
+		return s.diagnose(doc.path)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		// The server asks for full sync, so the last change is the whole text.
+		doc := s.open(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		return s.diagnose(doc.path)
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return nil
+		}
+		if params.Text != nil {
+			s.open(params.TextDocument.URI, *params.Text)
+		}
+		return s.diagnose(uriToPath(params.TextDocument.URI))
+	case "textDocument/didClose":
+		var params DocumentSymbolParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return nil
+		}
+		path := uriToPath(params.TextDocument.URI)
+		delete(s.docs, path)
+		// What is on disk may differ from what the editor had.
+		return s.diagnose(path)
+	}
+	return nil
+}
+
+func (s *Server) invalidParams(msg *message, err error) error {
+	return s.reply(msg.ID, nil, &responseError{Code: codeInvalidParams, Message: err.Error()})
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, rerr *responseError) error {
+	msg := &message{ID: id, Error: rerr}
+	if id == nil {
+		null := json.RawMessage("null")
+		msg.ID = &null
+	}
+	if rerr == nil {
+		body, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		msg.Result = body
+	}
+	return writeMessage(s.out, msg)
+}
+
+func (s *Server) send(method string, params any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, &message{Method: method, Params: body})
+}
+
+// open records the editor's text of a document and parses it.
+func (s *Server) open(uri, src string) *document {
+	path := uriToPath(uri)
+	doc := &document{uri: uri, path: path, text: newText(src)}
+	nodes, err := scl.Parse([]byte(src))
+	if serr, ok := err.(*scl.Error); ok {
+		doc.err = serr
+	} else if err == nil {
+		doc.nodes = nodes
+	}
+	s.docs[path] = doc
+	return doc
+}
+
+// document returns the open document with the given URI, or nil.
+func (s *Server) document(uri string) *document {
+	return s.docs[uriToPath(uri)]
+}
+
+// files is the view of the filesystem the server lints: open documents as
+// the editor has them, everything else as it is on disk.
+func (s *Server) files() fsx.FileSystem {
+	return overlay{FileSystem: s.fsys, docs: s.docs}
+}
+
+type overlay struct {
+	fsx.FileSystem
+	docs map[string]*document
+}
+
+func (o overlay) ReadFile(name string) ([]byte, error) {
+	if doc, ok := o.docs[filepath.Clean(name)]; ok {
+		return []byte(doc.text.src), nil
+	}
+	return o.FileSystem.ReadFile(name)
+}
+
+// appDir is the app directory an SCL file belongs to, or "" for a file that
+// is not part of an app, like simple.scl.
+func (s *Server) appDir(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) == "records" {
+		dir = filepath.Dir(dir)
+	}
+	for _, marker := range []string{"app.scl", "tables.scl", "records"} {
+		if _, err := s.fsys.Stat(filepath.Join(dir, marker)); err == nil {
+			return dir
+		}
+	}
+	if _, ok := s.docs[filepath.Join(dir, "app.scl")]; ok {
+		return dir
+	}
+	return ""
+}
+
+// diagnose publishes the problems of the app path belongs to, for every file
+// of it that has any and every file that had some before, or just path's
+// syntax error when it is not in an app. simple.scl is checked against its
+// schema.
+func (s *Server) diagnose(path string) error {
+	appDir := s.appDir(path)
+	if appDir == "" {
+		var diags []lint.Diagnostic
+		if doc, ok := s.docs[path]; ok {
+			switch {
+			case doc.err != nil:
+				diags = append(diags, lint.Diagnostic{Path: path, Pos: doc.err.Pos, Msg: doc.err.Msg})
+			case filepath.Base(path) == "simple.scl":
+				for _, p := range config.ValidateSimpleSCL(doc.nodes) {
+					diags = append(diags, lint.Diagnostic{Path: path, Pos: p.Pos, Msg: p.Msg})
+				}
+			}
+		}
+		return s.publish(path, diags)
+	}
+
+	found, err := lint.App(s.files(), appDir)
+	if err != nil {
+		return nil // the app cannot be read; the next change tries again
+	}
+
+	byPath := map[string][]lint.Diagnostic{}
+	for _, d := range found {
+		if d.Pos.Line == 0 && filepath.Ext(d.Path) != ".scl" {
+			// A problem with a directory: show it where the fix goes.
+			d.Msg = d.Path + ": " + d.Msg
+			d.Path = filepath.Join(appDir, "records", "10_actions.scl")
+		}
+		byPath[d.Path] = append(byPath[d.Path], d)
+	}
+	// lint.App parses only the files it checks; the syntax error of any other
+	// SCL file open in the app is the parser's to report.
+	if doc, ok := s.docs[path]; ok && doc.err != nil {
+		d := lint.Diagnostic{Path: path, Pos: doc.err.Pos, Msg: doc.err.Msg}
+		if !slices.Contains(byPath[path], d) {
+			byPath[path] = append(byPath[path], d)
+		}
+	}
+
+	targets := map[string]bool{}
+	for p := range s.published[appDir] {
+		targets[p] = true
+	}
+	for p := range byPath {
+		targets[p] = true
+	}
+	if _, ok := s.docs[path]; ok {
+		targets[path] = true
+	}
+
+	paths := make([]string, 0, len(targets))
+	for p := range targets {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	s.published[appDir] = map[string]bool{}
+	for _, p := range paths {
+		if len(byPath[p]) > 0 {
+			s.published[appDir][p] = true
+		}
+		if err := s.publish(p, byPath[p]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) publish(path string, diags []lint.Diagnostic) error {
+	t := s.text(path)
+	out := make([]Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		start := t.position(d.Pos)
+		r := Range{Start: start, End: start}
+		if d.Pos.Line > 0 {
+			r = t.lineEnd(d.Pos)
+		}
+		out = append(out, Diagnostic{Range: r, Severity: severityError, Source: "simple", Message: d.Msg})
+	}
+	uri := pathToURI(path)
+	if doc, ok := s.docs[path]; ok {
+		uri = doc.uri
+	}
+	return s.send("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: out})
+}
+
+// text returns a file's text as the server sees it.
+func (s *Server) text(path string) *text {
+	src, err := s.files().ReadFile(path)
+	if err != nil {
+		return newText("")
+	}
+	return newText(strings.ToValidUTF8(string(src), "�"))
+}
@@ -0,0 +1,468 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"simple-cli/internal/fsx"
+	"simple-cli/internal/scl"
+)
+
+const (
+	testTables = `table order {
+  required total, :decimal
+}
+`
+
+	testActions = `set dev_simple_system.logic, send_email {
+  name "send-email"
+  execution_environment server
+}
+`
+
+	testTriggers = `set dev_simple_system.db_event, on_order {
+  table_id ` + "`$var('order_tables') |> $jq('.tables[0].id')`" + `
+}
+
+var order_tables {
+  query ` + "```" + `
+  query get_order_tables {
+    tables: dev_simple_system__tables(where: {name: {_eq: "order"}}) {
+      id
+    }
+  }
+  ` + "```" + `
+}
+`
+)
+
+// testApp lays out an app with one action and returns its directory.
+func testApp(t *testing.T) string {
+	t.Helper()
+	appDir := t.TempDir()
+	files := map[string]string{
+		"app.scl":                       "id com.acme.crm\n",
+		"tables.scl":                    testTables,
+		"records/10_actions.scl":        testActions,
+		"records/20_triggers_db.scl":    testTriggers,
+		"actions/send-email/action.scl": "",
+	}
+	for rel, content := range files {
+		path := filepath.Join(appDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return appDir
+}
+
+// session is the messages a client sends, written up front and played to a
+// server in one go.
+type session struct {
+	in bytes.Buffer
+	id int
+}
+
+func (c *session) request(method string, params any) int {
+	c.id++
+	c.write(map[string]any{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params})
+	return c.id
+}
+
+func (c *session) notify(method string, params any) {
+	c.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (c *session) write(v any) {
+	body, _ := json.Marshal(v)
+	fmt.Fprintf(&c.in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+type reply struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// run plays the session to a server and returns its responses by request id
+// and its notifications in order.
+func (c *session) run(t *testing.T) (map[int]reply, []reply) {
+	t.Helper()
+	var out bytes.Buffer
+	s := NewServer(fsx.OSFileSystem{})
+	if err := s.Run(&c.in, &out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	responses := map[int]reply{}
+	var notifications []reply
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		body, _ := json.Marshal(msg)
+		var rep reply
+		if err := json.Unmarshal(body, &rep); err != nil {
+			t.Fatal(err)
+		}
+		if msg.ID != nil {
+			responses[rep.ID] = rep
+		} else {
+			notifications = append(notifications, rep)
+		}
+	}
+	return responses, notifications
+}
+
+func newSession(t *testing.T) *session {
+	t.Helper()
+	c := &session{}
+	c.request("initialize", map[string]any{})
+	c.notify("initialized", map[string]any{})
+	return c
+}
+
+func (c *session) open(t *testing.T, path, text string) {
+	t.Helper()
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": pathToURI(path), "languageId": "scl", "version": 1, "text": text},
+	})
+}
+
+func (c *session) close() {
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+}
+
+func position(path string, line, char int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": pathToURI(path)},
+		"position":     map[string]any{"line": line, "character": char},
+	}
+}
+
+func TestServer_Initialize(t *testing.T) {
+	c := newSession(t)
+	c.close()
+	responses, _ := c.run(t)
+
+	var result struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	if err := json.Unmarshal(responses[1].Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	for _, capability := range []string{"definitionProvider", "hoverProvider", "documentSymbolProvider", "completionProvider", "textDocumentSync"} {
+		if _, ok := result.Capabilities[capability]; !ok {
+			t.Errorf("initialize does not announce %s: %s", capability, responses[1].Result)
+		}
+	}
+	if string(responses[2].Result) != "null" {
+		t.Errorf("shutdown result = %s, want null", responses[2].Result)
+	}
+}
+
+func TestServer_RequestBeforeInitialize(t *testing.T) {
+	c := &session{}
+	c.request("textDocument/hover", position("/x.scl", 0, 0))
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+	var out bytes.Buffer
+	s := NewServer(fsx.OSFileSystem{})
+	if err := s.Run(&c.in, &out); err == nil {
+		t.Fatal("expected exit without an accepted shutdown to be an error")
+	}
+	if !strings.Contains(out.String(), `"code":-32002`) {
+		t.Errorf("expected a not-initialized error, got %s", out.String())
+	}
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	appDir := testApp(t)
+	actions := filepath.Join(appDir, "records", "10_actions.scl")
+
+	c := newSession(t)
+	c.open(t, actions, strings.Replace(testActions, "server", "edge", 1))
+	c.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": pathToURI(actions), "version": 2},
+		"contentChanges": []map[string]any{{"text": "set dev_simple_system.logic, send_email {\n"}},
+	})
+	c.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": pathToURI(actions), "version": 3},
+		"contentChanges": []map[string]any{{"text": testActions}},
+	})
+	c.close()
+	_, notifications := c.run(t)
+
+	var published []PublishDiagnosticsParams
+	for _, n := range notifications {
+		if n.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var p PublishDiagnosticsParams
+		if err := json.Unmarshal(n.Params, &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.URI == pathToURI(actions) {
+			published = append(published, p)
+		}
+	}
+	if len(published) != 3 {
+		t.Fatalf("expected diagnostics for each of 3 versions, got %d", len(published))
+	}
+
+	lintErr := published[0].Diagnostics
+	if len(lintErr) != 1 || lintErr[0].Message != `invalid execution_environment "edge"; expected server, client, both` {
+		t.Fatalf("unexpected diagnostics for the first version: %+v", lintErr)
+	}
+	if want := (Range{Start: Position{Line: 2, Character: 24}, End: Position{Line: 2, Character: 28}}); lintErr[0].Range != want {
+		t.Errorf("range = %+v, want %+v", lintErr[0].Range, want)
+	}
+
+	// A file that does not parse also leaves the app's action without its
+	// record, which is reported too.
+	var syntaxErr *Diagnostic
+	for _, d := range published[1].Diagnostics {
+		if strings.Contains(d.Message, "unterminated block") {
+			syntaxErr = &d
+		}
+	}
+	if syntaxErr == nil || syntaxErr.Range.Start != (Position{}) {
+		t.Errorf("expected the syntax error of the second version, got %+v", published[1].Diagnostics)
+	}
+
+	if len(published[2].Diagnostics) != 0 {
+		t.Errorf("expected the fixed version to clear diagnostics, got %+v", published[2].Diagnostics)
+	}
+}
+
+func TestServer_Definition(t *testing.T) {
+	appDir := testApp(t)
+	triggers := filepath.Join(appDir, "records", "20_triggers_db.scl")
+	links := filepath.Join(appDir, "records", "30_trigger_actions.scl")
+	linkText := `var link {
+  query "{ logic: dev_simple_system__logics(where: {name: {_eq: \"send-email\"}}) { id } }"
+}
+`
+
+	c := newSession(t)
+	c.open(t, triggers, testTriggers)
+	c.open(t, links, linkText)
+	varRef := c.request("textDocument/definition", position(triggers, 1, 15))
+	table := c.request("textDocument/definition", position(triggers, 7, 60))
+	action := c.request("textDocument/definition", position(links, 1, 75))
+	nothing := c.request("textDocument/definition", position(triggers, 0, 0))
+	c.close()
+	responses, _ := c.run(t)
+
+	check := func(id int, path string, want Range) {
+		t.Helper()
+		var locs []Location
+		if err := json.Unmarshal(responses[id].Result, &locs); err != nil {
+			t.Fatal(err)
+		}
+		if len(locs) != 1 || locs[0].URI != pathToURI(path) || locs[0].Range != want {
+			t.Errorf("definition = %+v, want %s %+v", locs, path, want)
+		}
+	}
+	check(varRef, triggers, Range{Start: Position{Line: 4, Character: 4}, End: Position{Line: 4, Character: 16}})
+	check(table, filepath.Join(appDir, "tables.scl"), Range{Start: Position{Line: 0, Character: 6}, End: Position{Line: 0, Character: 11}})
+	check(action, filepath.Join(appDir, "records", "10_actions.scl"), Range{Start: Position{Line: 1, Character: 2}, End: Position{Line: 1, Character: 6}})
+	if string(responses[nothing].Result) != "null" {
+		t.Errorf("expected no definition for a keyword, got %s", responses[nothing].Result)
+	}
+}
+
+func TestServer_Completion(t *testing.T) {
+	appDir := testApp(t)
+	path := filepath.Join(appDir, "records", "10_actions.scl")
+	text := "set dev_simple_system.logic, send_email {\n" +
+		"  name \"send-email\"\n" +
+		"  ex\n" +
+		"  execution_environment \n" +
+		"}\n" +
+		"\n" +
+		"set dev_simple_system.\n"
+
+	c := newSession(t)
+	c.open(t, path, text)
+	keys := c.request("textDocument/completion", position(path, 2, 4))
+	values := c.request("textDocument/completion", position(path, 3, 24))
+	types := c.request("textDocument/completion", position(path, 6, 22))
+	outside := c.request("textDocument/completion", position(path, 5, 0))
+	c.close()
+	responses, _ := c.run(t)
+
+	labels := func(id int) []string {
+		t.Helper()
+		var items []CompletionItem
+		if err := json.Unmarshal(responses[id].Result, &items); err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, it := range items {
+			out = append(out, it.Label)
+		}
+		return out
+	}
+
+	if got, want := strings.Join(labels(keys), ","), "display_name,description,language,application_id,is_active"; got != want {
+		t.Errorf("key completion = %s, want %s", got, want)
+	}
+	if got, want := strings.Join(labels(values), ","), "server,client,both"; got != want {
+		t.Errorf("value completion = %s, want %s", got, want)
+	}
+	if got := labels(types); len(got) != len(catalog) || got[0] != "custom_view" {
+		t.Errorf("type completion = %v", got)
+	}
+	if got := labels(outside); len(got) != 0 {
+		t.Errorf("expected no completion outside a record, got %v", got)
+	}
+}
+
+func TestServer_Hover(t *testing.T) {
+	appDir := testApp(t)
+	triggers := filepath.Join(appDir, "records", "20_triggers_db.scl")
+
+	c := newSession(t)
+	c.open(t, triggers, testTriggers)
+	key := c.request("textDocument/hover", position(triggers, 1, 4))
+	typ := c.request("textDocument/hover", position(triggers, 0, 10))
+	varRef := c.request("textDocument/hover", position(triggers, 1, 20))
+	c.close()
+	responses, _ := c.run(t)
+
+	content := func(id int) string {
+		t.Helper()
+		var h *Hover
+		if err := json.Unmarshal(responses[id].Result, &h); err != nil {
+			t.Fatal(err)
+		}
+		if h == nil {
+			return ""
+		}
+		return h.Contents.Value
+	}
+	if got := content(key); !strings.Contains(got, "**table_id** (db_event)") || !strings.Contains(got, "The table to monitor") {
+		t.Errorf("key hover = %q", got)
+	}
+	if got := content(typ); !strings.Contains(got, "dev_simple_system.db_event") {
+		t.Errorf("type hover = %q", got)
+	}
+	if got := content(varRef); !strings.Contains(got, "query get_order_tables") {
+		t.Errorf("var hover = %q", got)
+	}
+}
+
+func TestServer_DocumentSymbols(t *testing.T) {
+	appDir := testApp(t)
+	tables := filepath.Join(appDir, "tables.scl")
+	triggers := filepath.Join(appDir, "records", "20_triggers_db.scl")
+
+	c := newSession(t)
+	c.open(t, tables, testTables)
+	c.open(t, triggers, testTriggers)
+	tableSyms := c.request("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": pathToURI(tables)}})
+	triggerSyms := c.request("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": pathToURI(triggers)}})
+	c.close()
+	responses, _ := c.run(t)
+
+	var syms []DocumentSymbol
+	if err := json.Unmarshal(responses[tableSyms].Result, &syms); err != nil {
+		t.Fatal(err)
+	}
+	if len(syms) != 1 || syms[0].Name != "order" || syms[0].Kind != symbolClass ||
+		len(syms[0].Children) != 1 || syms[0].Children[0].Name != "total" || syms[0].Children[0].Detail != "required decimal" {
+		t.Errorf("tables.scl symbols = %+v", syms)
+	}
+	if want := (Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 2, Character: 1}}); len(syms) == 1 && syms[0].Range != want {
+		t.Errorf("table range = %+v, want %+v", syms[0].Range, want)
+	}
+
+	syms = nil
+	if err := json.Unmarshal(responses[triggerSyms].Result, &syms); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range syms {
+		names = append(names, s.Name)
+	}
+	if got, want := strings.Join(names, "|"), "dev_simple_system.db_event, on_order|order_tables"; got != want {
+		t.Errorf("symbols = %s, want %s", got, want)
+	}
+}
+
+func TestText_UTF16(t *testing.T) {
+	tx := newText("name \"😀x\"\n")
+	// x is the 8th character, but the emoji before it is two UTF-16 units.
+	pos := tx.position(scl.Pos{Line: 1, Col: 8})
+	if pos != (Position{Line: 0, Character: 8}) {
+		t.Errorf("position = %+v", pos)
+	}
+	if col := tx.runeCol(pos); col != 7 {
+		t.Errorf("runeCol = %d, want 7", col)
+	}
+}
+
+func TestServer_SimpleSCLDiagnostics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "simple.scl")
+
+	c := newSession(t)
+	c.open(t, path, "tenant acme\nenv dev {\n  endpoint e\n  apikey k\n}\n")
+	c.close()
+	_, notifications := c.run(t)
+
+	if len(notifications) != 1 {
+		t.Fatalf("expected one publish, got %d", len(notifications))
+	}
+	var p PublishDiagnosticsParams
+	if err := json.Unmarshal(notifications[0].Params, &p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Diagnostics) != 1 || p.Diagnostics[0].Message != `unknown key "apikey" in environment 'dev'; did you mean "api_key"?` ||
+		p.Diagnostics[0].Range.Start != (Position{Line: 3, Character: 2}) {
+		t.Errorf("diagnostics = %+v", p.Diagnostics)
+	}
+}
+
+func TestServer_AppFileSyntaxErrors(t *testing.T) {
+	appDir := testApp(t)
+	appSCL := filepath.Join(appDir, "app.scl")
+	other := filepath.Join(appDir, "settings.scl") // an app file lint does not check
+
+	c := newSession(t)
+	c.open(t, appSCL, "id com.acme.crm\n}\n")
+	c.open(t, other, "theme {\n")
+	c.close()
+	_, notifications := c.run(t)
+
+	published := map[string][]Diagnostic{}
+	for _, n := range notifications {
+		if n.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var p PublishDiagnosticsParams
+		if err := json.Unmarshal(n.Params, &p); err != nil {
+			t.Fatal(err)
+		}
+		published[p.URI] = p.Diagnostics
+	}
+	for _, path := range []string{appSCL, other} {
+		if diags := published[pathToURI(path)]; len(diags) != 1 {
+			t.Errorf("%s: expected its syntax error, got %+v", filepath.Base(path), diags)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"simple-cli/internal/build"
 	"simple-cli/internal/fsx"
 	"strings"
 	"text/template"
@@ -100,9 +101,14 @@ func CreateMonorepoStructure(fsys fsx.FileSystem, tplFS fsx.TemplateFS, rootPath
 // URL structure:
 //   - Non-prod: <tenant>-<env>.on.simple.dev
 //   - Prod: <tenant>.on.simple.dev
+//
+// The pipeline orders the environments versions are promoted through, and
+// prod is marked as the one whose deploys are releases.
 func generateSimpleSCL(tenant string) string {
 	return fmt.Sprintf(`tenant %s
 
+pipeline dev, staging, prod
+
 env dev {
   endpoint %s-dev.on.simple.dev
   api_key $SIMPLE_DEV_API_KEY
@@ -116,6 +122,7 @@ env staging {
 env prod {
   endpoint %s.on.simple.dev
   api_key $SIMPLE_PROD_API_KEY
+  release true
 }
 `, tenant, tenant, tenant, tenant)
 }
@@ -675,19 +682,48 @@ func renderTemplate(fsys fsx.FileSystem, tplFS fsx.TemplateFS, src, dst string,
 	return nil
 }
 
+// LanguageJavaScript is the language of a record behavior written as plain
+// JavaScript. Behaviors take either it or LanguageTypeScript; whichever is
+// chosen, the platform runs <table>.js, so the choice never reaches SCL.
+const LanguageJavaScript = "javascript"
+
 // BehaviorConfig holds configuration for creating a new record behavior.
 type BehaviorConfig struct {
 	AppID     string
 	TableName string
+
+	// Language selects JavaScript or TypeScript sources. An empty value means
+	// JavaScript, which is what every caller meant before TypeScript existed.
+	Language string
 }
 
+// readTableFields returns the fields tables.scl declares for a table. It is a
+// package-level variable, like checkSCLEntityMatchType, so tests can stub it.
+var readTableFields = build.ReadTableFields
+
 // CreateBehaviorStructure scaffolds a new record behavior.
 //
-// It creates:
+// For JavaScript it creates:
 //   - apps/<appID>/scripts/record-behaviors/<tableName>.js
 //   - apps/<appID>/scripts/record-behaviors/<tableName>.test.js
+//
+// For TypeScript it creates:
+//   - apps/<appID>/scripts/record-behaviors/<tableName>.ts
+//   - apps/<appID>/scripts/record-behaviors/<tableName>.test.ts
+//   - apps/<appID>/scripts/record-behaviors/types/<tableName>.ts (generated from tables.scl)
+//   - apps/<appID>/scripts/record-behaviors/types/behavior.ts and tsconfig.json (if absent)
+//
+// and in both cases:
 //   - apps/<appID>/records/10_behaviors.scl (appended or created)
 func CreateBehaviorStructure(fsys fsx.FileSystem, tplFS fsx.TemplateFS, rootPath string, cfg BehaviorConfig) error {
+	language := cfg.Language
+	if language == "" {
+		language = LanguageJavaScript
+	}
+	if language != LanguageJavaScript && language != LanguageTypeScript {
+		return fmt.Errorf("unsupported behavior language: %s", language)
+	}
+
 	appPath := filepath.Join(rootPath, "apps", cfg.AppID)
 
 	// Validate: app must exist
@@ -705,26 +741,42 @@ func CreateBehaviorStructure(fsys fsx.FileSystem, tplFS fsx.TemplateFS, rootPath
 		return fmt.Errorf("failed to create records directory: %w", err)
 	}
 
-	// Check for duplicate behavior script
-	scriptFile := filepath.Join(scriptsPath, cfg.TableName+".js")
-	if PathExists(fsys, scriptFile) {
-		return fmt.Errorf("behavior script already exists: %s", scriptFile)
+	// Check for duplicate behavior script, in either language: the two would
+	// both deploy as <tableName>.js.
+	for _, ext := range []string{".js", ".ts"} {
+		existing := filepath.Join(scriptsPath, cfg.TableName+ext)
+		if PathExists(fsys, existing) {
+			return fmt.Errorf("behavior script already exists: %s", existing)
+		}
 	}
 
+	ext := ".js"
+	if language == LanguageTypeScript {
+		ext = ".ts"
+	}
+	scriptFile := filepath.Join(scriptsPath, cfg.TableName+ext)
+
 	// Template data
 	data := map[string]string{
 		"AppID":     cfg.AppID,
 		"TableName": cfg.TableName,
 	}
 
-	// Render script.js
-	if err := renderTemplate(fsys, tplFS, "templates/behavior/script.js", scriptFile, data); err != nil {
+	if language == LanguageTypeScript {
+		typeName, err := writeBehaviorTypes(fsys, tplFS, appPath, scriptsPath, cfg.TableName)
+		if err != nil {
+			return err
+		}
+		data["TypeName"] = typeName
+	}
+
+	// Render the script and its test
+	if err := renderTemplate(fsys, tplFS, "templates/behavior/script"+ext, scriptFile, data); err != nil {
 		return err
 	}
 
-	// Render script.test.js
-	testFile := filepath.Join(scriptsPath, cfg.TableName+".test.js")
-	if err := renderTemplate(fsys, tplFS, "templates/behavior/script.test.js", testFile, data); err != nil {
+	testFile := filepath.Join(scriptsPath, cfg.TableName+".test"+ext)
+	if err := renderTemplate(fsys, tplFS, "templates/behavior/script.test"+ext, testFile, data); err != nil {
 		return err
 	}
 
@@ -750,6 +802,43 @@ func CreateBehaviorStructure(fsys fsx.FileSystem, tplFS fsx.TemplateFS, rootPath
 	return nil
 }
 
+// writeBehaviorTypes writes what a TypeScript behavior is checked against: the
+// shared context types and tsconfig.json, once per app, and the record type of
+// tableName generated from the app's tables.scl. It returns the name the
+// generated types are prefixed with, e.g. "OrderLine" for order_line.
+func writeBehaviorTypes(fsys fsx.FileSystem, tplFS fsx.TemplateFS, appPath, scriptsPath, tableName string) (string, error) {
+	fields, err := readTableFields(filepath.Join(appPath, "tables.scl"), tableName)
+	if err != nil {
+		return "", fmt.Errorf("failed to read fields of table %s: %w", tableName, err)
+	}
+
+	typesPath := filepath.Join(scriptsPath, build.BehaviorTypesDir)
+	if err := fsys.MkdirAll(typesPath, fsx.DirPerm); err != nil {
+		return "", fmt.Errorf("failed to create types directory: %w", err)
+	}
+
+	// Shared by every behavior in the app, and theirs to edit once written.
+	shared := []struct{ src, dst string }{
+		{"templates/behavior/types/behavior.ts", filepath.Join(typesPath, "behavior.ts")},
+		{"templates/behavior/tsconfig.json", filepath.Join(scriptsPath, "tsconfig.json")},
+	}
+	for _, f := range shared {
+		if PathExists(fsys, f.dst) {
+			continue
+		}
+		if err := copyTemplate(fsys, tplFS, f.src, f.dst); err != nil {
+			return "", err
+		}
+	}
+
+	dst := filepath.Join(typesPath, tableName+".ts")
+	if err := fsys.WriteFile(dst, build.RenderBehaviorTypes(tableName, fields), fsx.FilePerm); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+
+	return build.BehaviorTypeName(tableName), nil
+}
+
 // appendBehaviorRecord appends a behavior record to the 10_behaviors.scl file.
 func appendBehaviorRecord(fsys fsx.FileSystem, tplFS fsx.TemplateFS, dst string, data map[string]string) error {
 	// Read the template
@@ -810,3 +810,63 @@ func TestTheScaffoldedRustActionDeclaresThePayloadTheGeneratorLooksFor(t *testin
 		t.Fatal("a scaffolded Rust action's payload declares no member, so it advertises no input whatever it is called")
 	}
 }
+
+func TestCreateBehaviorStructure_TypeScript(t *testing.T) {
+	root := t.TempDir()
+	appPath := filepath.Join(root, "apps", "com.test")
+	if err := os.MkdirAll(appPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	origRead := readTableFields
+	defer func() { readTableFields = origRead }()
+	readTableFields = func(tablesPath, table string) ([]build.TableField, error) {
+		if tablesPath != filepath.Join(appPath, "tables.scl") || table != "order_line" {
+			t.Errorf("unexpected table lookup: %s in %s", table, tablesPath)
+		}
+		return []build.TableField{
+			{Name: "id", Type: "string"},
+			{Name: "quantity", Type: "integer", Required: true},
+			{Name: "status", Type: "enum", Values: []string{"Open", "Closed"}},
+		}, nil
+	}
+
+	cfg := BehaviorConfig{AppID: "com.test", TableName: "order_line", Language: LanguageTypeScript}
+	if err := CreateBehaviorStructure(fsx.OSFileSystem{}, TemplatesFS, root, cfg); err != nil {
+		t.Fatalf("CreateBehaviorStructure failed: %v", err)
+	}
+
+	scripts := filepath.Join(appPath, "scripts", "record-behaviors")
+	for _, name := range []string{"order_line.ts", "order_line.test.ts", "tsconfig.json", "types/behavior.ts"} {
+		if _, err := os.Stat(filepath.Join(scripts, name)); err != nil {
+			t.Errorf("expected %s to be scaffolded: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(scripts, "order_line.js")); err == nil {
+		t.Error("a TypeScript behavior should not scaffold a .js script")
+	}
+
+	script, _ := os.ReadFile(filepath.Join(scripts, "order_line.ts"))
+	if !strings.Contains(string(script), "OrderLineBehaviorContext") {
+		t.Errorf("script is not typed against its table, got:\n%s", script)
+	}
+
+	types, _ := os.ReadFile(filepath.Join(scripts, "types", "order_line.ts"))
+	for _, want := range []string{"id?: string", "quantity: number", "status?: 'Open' | 'Closed'"} {
+		if !strings.Contains(string(types), want) {
+			t.Errorf("generated types lack %q, got:\n%s", want, types)
+		}
+	}
+
+	// The platform runs the bundle as .js, so registration never changes.
+	scl, _ := os.ReadFile(filepath.Join(appPath, "records", "10_behaviors.scl"))
+	if !strings.Contains(string(scl), "scripts/record-behaviors/order_line.js") {
+		t.Errorf("behavior registered under the wrong path, got:\n%s", scl)
+	}
+
+	// A second behavior for the same table, in the other language, is refused.
+	cfg.Language = LanguageJavaScript
+	if err := CreateBehaviorStructure(fsx.OSFileSystem{}, TemplatesFS, root, cfg); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected a duplicate behavior to be refused, got: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSCLEntityMatchType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "10_actions.scl")
+	content := `table user {
+  required email, :string
+}
+
+set dev_simple_system.logic, send_email {
+  name send_email
+}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                        string
+		entityName, entityType, key string
+		want                        bool
+	}{
+		{"set with type and name", "send_email", "dev_simple_system.logic", "set", true},
+		{"set with another type", "send_email", "dev_simple_system.trigger", "set", false},
+		{"set with another name", "notify", "dev_simple_system.logic", "set", false},
+		{"simple block by name", "user", "", "table", true},
+		{"simple block with a type", "user", "dev_simple_system.logic", "table", false},
+		{"another key", "user", "", "set", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkSCLEntityMatchType(path, tt.entityName, tt.entityType, tt.key)
+			if err != nil {
+				t.Fatalf("checkSCLEntityMatchType() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("checkSCLEntityMatchType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSCLEntityMatchType_SyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "10_actions.scl")
+	if err := os.WriteFile(path, []byte("table user {\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := checkSCLEntityMatchType(path, "user", "", "table"); err == nil {
+		t.Error("expected a syntax error to be reported")
+	}
+}
@@ -0,0 +1,56 @@
+/**
+ * Tests for Record Behavior: {{.TableName}}
+ */
+import type { {{.TypeName}}BehaviorContext } from './types/{{.TableName}}'
+import { describe, expect, it, vi } from 'vitest'
+import behavior from './{{.TableName}}'
+
+describe('record Behavior: {{.TableName}}', () => {
+  // Mock Context
+  const $form = Object.assign(
+    vi.fn((_field: string) => ({
+      editable: vi.fn(),
+      error: vi.fn(),
+      set: vi.fn(),
+      value: vi.fn(),
+      visible: vi.fn(),
+    })),
+    {
+      error: vi.fn(),
+      event: 'load',
+      record: vi.fn(() => ({})),
+      updated: vi.fn(),
+    },
+  )
+
+  const mockContext = {
+    $ai: {},
+    $db: {
+      query: vi.fn(),
+    },
+    $form,
+    $user: {
+      email: 'user@example.com',
+      id: 'USR000001',
+      name: 'Test User',
+    },
+  } as unknown as {{.TypeName}}BehaviorContext
+
+  it('should handle load event', async () => {
+    $form.event = 'load'
+    await behavior(mockContext)
+    expect($form).toBeDefined()
+  })
+
+  it('should handle update event', async () => {
+    $form.event = 'update'
+    await behavior(mockContext)
+    expect($form).toBeDefined()
+  })
+
+  it('should handle submit event', async () => {
+    $form.event = 'submit'
+    await behavior(mockContext)
+    expect($form).toBeDefined()
+  })
+})
@@ -0,0 +1,30 @@
+/**
+ * Record Behavior: {{.TableName}}
+ *
+ * Events: load, update, submit
+ * Reference: .simple/context/08-record-behaviors.md
+ *
+ * Field names and values are checked against the {{.TableName}} table in
+ * tables.scl through ./types/{{.TableName}}, which is regenerated on deploy.
+ */
+import type { {{.TypeName}}BehaviorContext } from './types/{{.TableName}}'
+
+export default async ({ $form }: {{.TypeName}}BehaviorContext) => {
+  // Handle 'load' event (Server + Client)
+  if ($form.event === 'load') {
+    // Logic for setting defaults, visibility, etc.
+    // Example: $form('status').set('Draft');
+  }
+
+  // Handle 'update' event (Client Only)
+  if ($form.event === 'update') {
+    // Logic for reacting to field changes
+    // Example: if ($form.updated('field')) { ... }
+  }
+
+  // Handle 'submit' event (Server + Client)
+  if ($form.event === 'submit') {
+    // Logic for validation
+    // Example: if (($form('value').value() ?? 0) < 0) $form.error('Value must be positive');
+  }
+}
@@ -0,0 +1,45 @@
+/**
+ * The context every record behavior receives, typed over the record of the
+ * table it is attached to. Reference: .simple/context/08-record-behaviors.md
+ *
+ * Scaffolded once per app; the per-table records beside it are generated.
+ */
+
+/** A single field of the form, with chainable setters. */
+export interface Field<T> {
+  value: () => T | null
+  set: (value: T | null) => Field<T>
+  visible: (visible: boolean) => Field<T>
+  required: (required: boolean) => Field<T>
+  editable: (editable: boolean) => Field<T>
+  error: (message: string) => Field<T>
+  info: (message: string) => Field<T>
+}
+
+/** The form API: call it with a field name to reach that field. */
+export interface Form<R> {
+  <K extends keyof R & string>(field: K): Field<Exclude<R[K], undefined>>
+  readonly event: 'load' | 'update' | 'submit'
+  record: () => Partial<R>
+  updated: (...fields: Array<keyof R & string>) => boolean
+  error: (message: string) => void
+  info: (message: string) => void
+}
+
+/** Read-only GraphQL access. Mutations are not allowed in record behaviors. */
+export interface Db {
+  query: <T = any>(query: string, variables?: Record<string, unknown>) => Promise<T>
+}
+
+export interface User {
+  id: string
+  name: string
+  email: string
+}
+
+export interface BehaviorContext<R> {
+  $ai: unknown
+  $db: Db
+  $form: Form<R>
+  $user: User
+}
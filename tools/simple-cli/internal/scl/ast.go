@@ -0,0 +1,223 @@
+// Package scl parses the Simple Configuration Language into a typed AST.
+//
+// It is a port of the Elixir parser in packages/scl_parser, which is the
+// reference for the grammar: the same tokens, the same statements, the same
+// typed values and the same error messages. The one intended difference is
+// positions, which this package keeps right after a comment where the Elixir
+// tokenizer restarts its line count.
+//
+// A document is a list of statements. A statement is a name followed by
+// comma-separated values, and either ends at the end of the line, making it a
+// key-value, or opens a block with `{`, in which case the values are the
+// block's name:
+//
+//	tenant acme
+//	env dev {
+//	  endpoint "acme-dev.on.simple.dev"
+//	}
+package scl
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Pos is a 1-based line and column in the source. Columns count characters,
+// not bytes, so they line up with what an editor shows.
+type Pos struct {
+	Line int
+	Col  int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Kind is the type of a value.
+type Kind int
+
+const (
+	Nil Kind = iota // a key written with no value
+	String
+	Int
+	Float
+	Bool
+	Atom // :name
+)
+
+// Quote is how a string value was written in the source. Values of other
+// kinds are always Unquoted.
+type Quote int
+
+const (
+	Unquoted Quote = iota
+	DoubleQuoted
+	SingleQuoted
+	Backtick
+	TripleBacktick
+)
+
+// Value is one typed value together with where it was written.
+type Value struct {
+	Kind  Kind
+	Str   string // the text of a String, the name of an Atom (without the colon)
+	Int   int64
+	Float float64
+	Bool  bool
+
+	// Raw is the source text of a number or boolean, so that 3.10 and 007 can
+	// be written back as they were rather than as 3.1 and 7.
+	Raw   string
+	Quote Quote
+	Pos   Pos
+}
+
+// String renders the value as plain text: a string's content, an atom's
+// name, a number or boolean as written. Nil renders as the empty string.
+func (v Value) String() string {
+	switch v.Kind {
+	case String, Atom:
+		return v.Str
+	case Int, Float, Bool:
+		return v.Raw
+	default:
+		return ""
+	}
+}
+
+// Interface returns the value as the Go value it holds: a string for strings
+// and atoms, int64, float64, bool, or nil.
+func (v Value) Interface() any {
+	switch v.Kind {
+	case String, Atom:
+		return v.Str
+	case Int:
+		return v.Int
+	case Float:
+		return v.Float
+	case Bool:
+		return v.Bool
+	default:
+		return nil
+	}
+}
+
+// NodeKind is the type of a statement.
+type NodeKind int
+
+const (
+	KV NodeKind = iota
+	Block
+)
+
+// Node is one statement: a key-value, or a block with its children.
+type Node struct {
+	Kind   NodeKind
+	Key    string
+	KeyPos Pos
+
+	// Values holds a key-value's values, or a block's name. A block written
+	// `table user, users {` has the name values user and users; `env {` has none.
+	Values []Value
+
+	// BracePos and EndPos are where a block's `{` and `}` are.
+	BracePos Pos
+	EndPos   Pos
+
+	Children []*Node
+
+	// Comments are the comment lines directly above the statement, and
+	// Comment the one at the end of its first line. EndComments are the
+	// comment lines after a block's last child, and EndComment the one after
+	// its `}`.
+	Comments    []Comment
+	Comment     *Comment
+	EndComments []Comment
+	EndComment  *Comment
+
+	// BlankBefore reports whether a blank line separates the statement from
+	// what comes before it, its own Comments included.
+	BlankBefore bool
+}
+
+// IsBlock reports whether the node is a block.
+func (n *Node) IsBlock() bool {
+	return n.Kind == Block
+}
+
+// Value returns the first value of the node. A key written with no value
+// yields a Nil value positioned at the key.
+func (n *Node) Value() Value {
+	if len(n.Values) == 0 {
+		return Value{Kind: Nil, Pos: n.KeyPos}
+	}
+	return n.Values[0]
+}
+
+// Name returns a block's first name value as text, "" when it has none.
+func (n *Node) Name() string {
+	if len(n.Values) == 0 {
+		return ""
+	}
+	return n.Values[0].String()
+}
+
+// Names returns every value of the node as text.
+func (n *Node) Names() []string {
+	out := make([]string, len(n.Values))
+	for i, v := range n.Values {
+		out[i] = v.String()
+	}
+	return out
+}
+
+// Child returns the first direct child with the given key, or nil.
+func (n *Node) Child(key string) *Node {
+	for _, c := range n.Children {
+		if c.Key == key {
+			return c
+		}
+	}
+	return nil
+}
+
+// Comment is one # comment. Text is everything after the # up to the end of
+// the line.
+type Comment struct {
+	Text string
+	Pos  Pos
+
+	// BlankBefore reports whether a blank line separates the comment from
+	// what comes before it.
+	BlankBefore bool
+}
+
+// Document is a parsed SCL file: its statements, and the comment lines after
+// the last of them.
+type Document struct {
+	Nodes    []*Node
+	Comments []Comment
+}
+
+// Error is a syntax error, positioned where it was found.
+type Error struct {
+	Path string // the file being parsed, when known
+	Pos  Pos
+	Msg  string
+
+	// Text is the source text of the token the error is about, when it is
+	// about one, so callers can say what was probably meant.
+	Text string
+}
+
+func (e *Error) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Pos.Line, e.Pos.Col, e.Msg)
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Pos.Line, e.Pos.Col, e.Msg)
+}
+
+// quoteText renders text the way the reference parser's error messages do.
+func quoteText(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,216 @@
+package scl
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// indent is one level of block nesting in formatted output.
+const indent = "  "
+
+// Format parses src and writes it back in canonical layout:
+//
+//   - two spaces of indentation per block level;
+//   - values separated by ", ", and a block's `{` one space after its name;
+//   - the values of consecutive key-values lined up in one column, a run of
+//     them ending at a blank line or a block;
+//   - at most one blank line in a row, none at the start or end of the file
+//     or of a block, and exactly one around every top-level block;
+//   - comments kept where they were, with trailing whitespace removed;
+//   - strings written with the quotes they were written with, and the text of
+//     a triple-backtick string left exactly as it was.
+//
+// Formatting is idempotent, and the formatted document parses to the same
+// statements as src. A syntax error is returned as an *Error.
+func Format(src []byte) ([]byte, error) {
+	doc, err := ParseDocument(src)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Format(), nil
+}
+
+// Format renders the document in canonical layout. See Format.
+func (d *Document) Format() []byte {
+	var pr printer
+	pr.list(d.Nodes, d.Comments, 0)
+	return pr.buf.Bytes()
+}
+
+type printer struct {
+	buf bytes.Buffer
+}
+
+// list writes the statements of one level followed by the comment lines
+// after them.
+func (pr *printer) list(nodes []*Node, trailing []Comment, depth int) {
+	widths := keyWidths(nodes)
+	pad := strings.Repeat(indent, depth)
+	first := true
+
+	// blank writes the separator before an item: nothing before the first item
+	// of a level, a blank line where the source had one.
+	blank := func(want bool) {
+		if !first && want {
+			pr.buf.WriteByte('\n')
+		}
+		first = false
+	}
+
+	for i, n := range nodes {
+		// Top-level blocks always stand apart from what surrounds them.
+		around := depth == 0 && (n.IsBlock() || (i > 0 && nodes[i-1].IsBlock()))
+
+		for j, c := range n.Comments {
+			blank(c.BlankBefore || (j == 0 && around))
+			pr.comment(pad, c)
+		}
+		blank(n.BlankBefore || (len(n.Comments) == 0 && around))
+		pr.node(n, widths[i], depth)
+	}
+
+	for j, c := range trailing {
+		blank(c.BlankBefore || (j == 0 && depth == 0 && len(nodes) > 0 && nodes[len(nodes)-1].IsBlock()))
+		pr.comment(pad, c)
+	}
+}
+
+func (pr *printer) node(n *Node, width, depth int) {
+	pad := strings.Repeat(indent, depth)
+	pr.buf.WriteString(pad)
+	pr.buf.WriteString(n.Key)
+
+	if len(n.Values) > 0 {
+		pr.buf.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(n.Key)+1))
+		for i, v := range n.Values {
+			if i > 0 {
+				pr.buf.WriteString(", ")
+			}
+			pr.buf.WriteString(formatValue(v))
+		}
+	}
+
+	if !n.IsBlock() {
+		pr.lineComment(n.Comment)
+		return
+	}
+
+	if len(n.Children) == 0 && len(n.EndComments) == 0 && n.Comment == nil {
+		pr.buf.WriteString(" {}")
+		pr.lineComment(n.EndComment)
+		return
+	}
+
+	pr.buf.WriteString(" {")
+	pr.lineComment(n.Comment)
+	pr.list(n.Children, n.EndComments, depth+1)
+	pr.buf.WriteString(pad)
+	pr.buf.WriteString("}")
+	pr.lineComment(n.EndComment)
+}
+
+// lineComment ends the current line, with c on it when there is one.
+func (pr *printer) lineComment(c *Comment) {
+	if c != nil {
+		pr.buf.WriteString(" ")
+		pr.buf.WriteString(commentText(*c))
+	}
+	pr.buf.WriteByte('\n')
+}
+
+func (pr *printer) comment(pad string, c Comment) {
+	pr.buf.WriteString(pad)
+	pr.buf.WriteString(commentText(c))
+	pr.buf.WriteByte('\n')
+}
+
+func commentText(c Comment) string {
+	return "#" + strings.TrimRight(c.Text, " \t")
+}
+
+// keyWidths returns, for each statement, the width its key is padded to so
+// that the values of a run of key-values start in one column. A run is broken
+// by a blank line, by a block, and by a key-value whose value spans lines.
+func keyWidths(nodes []*Node) []int {
+	widths := make([]int, len(nodes))
+	start := 0
+	flush := func(end int) {
+		width := 0
+		for _, n := range nodes[start:end] {
+			if len(n.Values) > 0 {
+				width = max(width, utf8.RuneCountInString(n.Key))
+			}
+		}
+		for i := start; i < end; i++ {
+			widths[i] = width
+		}
+		start = end
+	}
+
+	for i, n := range nodes {
+		if n.IsBlock() {
+			flush(i)
+			widths[i] = utf8.RuneCountInString(n.Key)
+			start = i + 1
+			continue
+		}
+		if n.BlankBefore || (len(n.Comments) > 0 && n.Comments[0].BlankBefore) {
+			flush(i)
+		}
+		if multiline(n) {
+			flush(i + 1)
+		}
+	}
+	flush(len(nodes))
+	return widths
+}
+
+func multiline(n *Node) bool {
+	for _, v := range n.Values {
+		if v.Quote == TripleBacktick && strings.Contains(v.Str, "\n") {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue writes a value back the way it was written: a string in its
+// own quotes, an atom with its colon, a number or boolean as its source text.
+func formatValue(v Value) string {
+	switch v.Kind {
+	case Atom:
+		return ":" + v.Str
+	case Int, Float, Bool:
+		return v.Raw
+	case String:
+		switch v.Quote {
+		case DoubleQuoted:
+			return quote(v.Str, '"')
+		case SingleQuoted:
+			return quote(v.Str, '\'')
+		case Backtick:
+			return quote(v.Str, '`')
+		case TripleBacktick:
+			return "```" + v.Str + "```"
+		default:
+			return v.Str
+		}
+	default:
+		return ""
+	}
+}
+
+// quote wraps s in q, escaping q the one way the lexer reads it back.
+func quote(s string, q byte) string {
+	var sb strings.Builder
+	sb.WriteByte(q)
+	for i := 0; i < len(s); i++ {
+		if s[i] == q {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte(q)
+	return sb.String()
+}
@@ -0,0 +1,119 @@
+package scl
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty document",
+			input: "\n\n",
+			want:  "",
+		},
+		{
+			name:  "aligns a run of key-values",
+			input: "id com.acme.crm\nversion   0.0.1\ndisplay_name \"CRM\"\n",
+			want:  "id           com.acme.crm\nversion      0.0.1\ndisplay_name \"CRM\"\n",
+		},
+		{
+			name:  "a blank line starts a new run",
+			input: "id com.acme.crm\nversion 0.0.1\n\n\n\ndisplay_name \"CRM\"\n",
+			want:  "id      com.acme.crm\nversion 0.0.1\n\ndisplay_name \"CRM\"\n",
+		},
+		{
+			name:  "indents blocks and separates top-level blocks",
+			input: "set dev_simple_system.logic,send_email {\nname \"send_email\"\n    is_active true\n}\nset dev_simple_system.logic, notify {\n\n  name \"notify\"\n\n}\n\n\n",
+			want: "set dev_simple_system.logic, send_email {\n  name      \"send_email\"\n  is_active true\n}\n\n" +
+				"set dev_simple_system.logic, notify {\n  name \"notify\"\n}\n",
+		},
+		{
+			name:  "nested blocks",
+			input: "table order {\nrequired status, :enum {\nvalues \"Open\",\"Closed\"\n}\n}\n",
+			want:  "table order {\n  required status, :enum {\n    values \"Open\", \"Closed\"\n  }\n}\n",
+		},
+		{
+			name:  "empty block",
+			input: "env dev {\n\n}\n",
+			want:  "env dev {}\n",
+		},
+		{
+			name:  "keeps comments where they were",
+			input: "# Workspace\ntenant acme   # the tenant  \n\n# Environments\nenv dev { # development\n  # where\n  endpoint \"x\"\n  # end of dev\n} # dev\n# trailing\n",
+			want:  "# Workspace\ntenant acme # the tenant\n\n# Environments\nenv dev { # development\n  # where\n  endpoint \"x\"\n  # end of dev\n} # dev\n\n# trailing\n",
+		},
+		{
+			name:  "a comment-only block keeps its braces apart",
+			input: "env dev {\n  # nothing yet\n}\n",
+			want:  "env dev {\n  # nothing yet\n}\n",
+		},
+		{
+			name:  "keeps how values were written",
+			input: "v 007,3.10 , :ok,'it\\'s',`b`, \"say \\\"hi\\\"\", false\n",
+			want:  "v 007, 3.10, :ok, 'it\\'s', `b`, \"say \\\"hi\\\"\", false\n",
+		},
+		{
+			name:  "leaves triple-backtick text alone",
+			input: "table t {\n  script ```\n    return 1\n  ```\n  other 1\n}\n",
+			want:  "table t {\n  script ```\n    return 1\n  ```\n  other 1\n}\n",
+		},
+		{
+			name:  "normalises line endings",
+			input: "a 1\r\nb 2\r\n",
+			want:  "a 1\nb 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Format()\n got %q\nwant %q", got, tt.want)
+			}
+			if again, _ := Format(got); string(again) != string(got) {
+				t.Errorf("Format() is not idempotent: %q became %q", got, again)
+			}
+		})
+	}
+}
+
+func TestFormat_SyntaxError(t *testing.T) {
+	_, err := Format([]byte("env dev {\n"))
+	if _, ok := err.(*Error); !ok {
+		t.Fatalf("Format() error = %v, want an *Error", err)
+	}
+}
+
+// Every document of the reference corpus formats to one that parses to the
+// same tree, and formatting that again changes nothing.
+func TestFormat_PreservesTreeAndIsIdempotent(t *testing.T) {
+	for _, tt := range referenceCorpus {
+		t.Run(tt.name, func(t *testing.T) {
+			once, err := Format([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			nodes, err := Parse(once)
+			if err != nil {
+				t.Fatalf("formatted output does not parse: %v\n%s", err, once)
+			}
+			if got := dump(nodes); got != tt.want {
+				t.Errorf("formatted output parses to\n %s\nwant %s\noutput:\n%s", got, tt.want, once)
+			}
+			twice, err := Format(once)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(twice) != string(once) {
+				t.Errorf("Format() is not idempotent:\nonce:\n%s\ntwice:\n%s", once, twice)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package scl
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenType int
+
+const (
+	tNewline tokenType = iota
+	tLBrace
+	tRBrace
+	tComma
+	tComment
+	tBool
+	tNumber
+	tAtom
+	tUnquoted
+	tDouble
+	tSingle
+	tBacktick
+	tTriple
+)
+
+// tokenNames are the names the reference parser uses for token types in its
+// error messages.
+var tokenNames = map[tokenType]string{
+	tNewline:  "newline",
+	tLBrace:   "lbrace",
+	tRBrace:   "rbrace",
+	tComma:    "comma",
+	tComment:  "comment",
+	tBool:     "boolean",
+	tNumber:   "number",
+	tAtom:     "colon atom",
+	tUnquoted: "unquoted string",
+	tDouble:   "quoted string",
+	tSingle:   "single quoted string",
+	tBacktick: "backtick string",
+	tTriple:   "triple string",
+}
+
+type token struct {
+	typ  tokenType
+	text string
+	pos  Pos
+}
+
+// isValue reports whether the token can be a value of a statement.
+func (t token) isValue() bool {
+	return t.typ >= tBool
+}
+
+var (
+	intRe   = regexp.MustCompile(`^[0-9]+$`)
+	floatRe = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+)
+
+// lexer turns source text into tokens, tracking line and column.
+type lexer struct {
+	src  string
+	off  int
+	line int
+	col  int
+	toks []token
+}
+
+func tokenize(src string) ([]token, error) {
+	lx := &lexer{src: src, line: 1, col: 1}
+	if err := lx.run(); err != nil {
+		return nil, err
+	}
+	return lx.toks, nil
+}
+
+func (lx *lexer) emit(typ tokenType, text string, pos Pos) {
+	lx.toks = append(lx.toks, token{typ: typ, text: text, pos: pos})
+}
+
+func (lx *lexer) pos() Pos {
+	return Pos{Line: lx.line, Col: lx.col}
+}
+
+func (lx *lexer) errorf(pos Pos, msg string) error {
+	return &Error{Pos: pos, Msg: msg}
+}
+
+// newlineAt returns the length of the line break starting at off, or 0.
+// \r\n, \n and a lone \r all end a line.
+func (lx *lexer) newlineAt(off int) int {
+	if strings.HasPrefix(lx.src[off:], "\r\n") {
+		return 2
+	}
+	if c := lx.src[off]; c == '\n' || c == '\r' {
+		return 1
+	}
+	return 0
+}
+
+// advance moves past n bytes that do not contain a line break.
+func (lx *lexer) advance(n int) {
+	lx.col += utf8.RuneCountInString(lx.src[lx.off : lx.off+n])
+	lx.off += n
+}
+
+func (lx *lexer) breakLine(n int) {
+	lx.off += n
+	lx.line++
+	lx.col = 1
+}
+
+func (lx *lexer) run() error {
+	for lx.off < len(lx.src) {
+		start := lx.pos()
+		c := lx.src[lx.off]
+
+		if n := lx.newlineAt(lx.off); n > 0 {
+			lx.emit(tNewline, "", start)
+			lx.breakLine(n)
+			continue
+		}
+
+		switch {
+		case c == ' ' || c == '\t':
+			lx.advance(1)
+
+		case c == '#':
+			// A comment runs to the end of the line and ends the statement
+			// the way the line break after it does.
+			end := strings.IndexAny(lx.src[lx.off:], "\r\n")
+			if end < 0 {
+				end = len(lx.src) - lx.off
+			}
+			lx.emit(tComment, lx.src[lx.off+1:lx.off+end], start)
+			lx.advance(end)
+
+		case c == '{':
+			lx.emit(tLBrace, "{", start)
+			lx.advance(1)
+		case c == '}':
+			lx.emit(tRBrace, "}", start)
+			lx.advance(1)
+		case c == ',':
+			lx.emit(tComma, ",", start)
+			lx.advance(1)
+
+		case strings.HasPrefix(lx.src[lx.off:], "```"):
+			lx.advance(3)
+			text, err := lx.tripleString()
+			if err != nil {
+				return err
+			}
+			lx.emit(tTriple, text, start)
+
+		case c == '`':
+			lx.advance(1)
+			text, err := lx.quoted('`', "backtick-quoted")
+			if err != nil {
+				return err
+			}
+			lx.emit(tBacktick, text, start)
+		case c == '"':
+			lx.advance(1)
+			text, err := lx.quoted('"', "double-quoted")
+			if err != nil {
+				return err
+			}
+			lx.emit(tDouble, text, start)
+		case c == '\'':
+			lx.advance(1)
+			text, err := lx.quoted('\'', "single-quoted")
+			if err != nil {
+				return err
+			}
+			lx.emit(tSingle, text, start)
+
+		case c == ':':
+			lx.advance(1)
+			n := 0
+			for lx.off+n < len(lx.src) && isNameChar(lx.src[lx.off+n]) {
+				n++
+			}
+			if n == 0 {
+				return lx.errorf(start, "invalid or empty atom")
+			}
+			name := lx.src[lx.off : lx.off+n]
+			lx.advance(n)
+			lx.emit(tAtom, name, start)
+
+		default:
+			text := lx.unquoted()
+			lx.emit(classifyUnquoted(text), text, start)
+		}
+	}
+	return nil
+}
+
+func isNameChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_'
+}
+
+// tripleString consumes up to and including the closing ```. Line breaks of
+// any kind become \n and there are no escapes.
+func (lx *lexer) tripleString() (string, error) {
+	var sb strings.Builder
+	for {
+		if lx.off >= len(lx.src) {
+			return "", lx.errorf(lx.pos(), "unterminated triple-backtick string")
+		}
+		if strings.HasPrefix(lx.src[lx.off:], "```") {
+			lx.advance(3)
+			return sb.String(), nil
+		}
+		if n := lx.newlineAt(lx.off); n > 0 {
+			sb.WriteByte('\n')
+			lx.breakLine(n)
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(lx.src[lx.off:])
+		sb.WriteString(lx.src[lx.off : lx.off+size])
+		lx.advance(size)
+	}
+}
+
+// quoted consumes a single-line string closed by quote, in which a backslash
+// escapes that quote and nothing else.
+func (lx *lexer) quoted(quote byte, what string) (string, error) {
+	var sb strings.Builder
+	for {
+		if lx.off >= len(lx.src) {
+			return "", lx.errorf(lx.pos(), "unterminated "+what+" string")
+		}
+		c := lx.src[lx.off]
+		switch {
+		case c == quote:
+			lx.advance(1)
+			return sb.String(), nil
+		case c == '\\' && lx.off+1 < len(lx.src) && lx.src[lx.off+1] == quote:
+			sb.WriteByte(quote)
+			lx.advance(2)
+		case c == '\n' || c == '\r':
+			return "", lx.errorf(lx.pos(), "unexpected newline in "+what+" string")
+		default:
+			_, size := utf8.DecodeRuneInString(lx.src[lx.off:])
+			sb.WriteString(lx.src[lx.off : lx.off+size])
+			lx.advance(size)
+		}
+	}
+}
+
+// unquoted consumes a bare word: everything up to whitespace, a brace, a
+// comma, a quote, a comment or a triple backtick. A single backtick inside a
+// word is part of it.
+func (lx *lexer) unquoted() string {
+	n := 0
+	for lx.off+n < len(lx.src) {
+		rest := lx.src[lx.off+n:]
+		if strings.HasPrefix(rest, "```") || strings.IndexByte(" \t\r\n{},\"#'", rest[0]) >= 0 {
+			break
+		}
+		n++
+	}
+	text := lx.src[lx.off : lx.off+n]
+	lx.advance(n)
+	return text
+}
+
+func classifyUnquoted(text string) tokenType {
+	switch {
+	case text == "true" || text == "false":
+		return tBool
+	case intRe.MatchString(text) || floatRe.MatchString(text):
+		return tNumber
+	default:
+		return tUnquoted
+	}
+}
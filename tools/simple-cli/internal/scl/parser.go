@@ -0,0 +1,237 @@
+package scl
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+var nameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Parse parses an SCL document into its statements.
+// A syntax error is returned as an *Error.
+func Parse(src []byte) ([]*Node, error) {
+	doc, err := ParseDocument(src)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Nodes, nil
+}
+
+// ParseDocument parses an SCL document into its statements, keeping its
+// comments and where its blank lines were, which is everything a formatter
+// needs to write it back.
+func ParseDocument(src []byte) (*Document, error) {
+	toks, err := tokenize(string(src))
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	nodes, comments, err := p.list(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Nodes: nodes, Comments: comments}, nil
+}
+
+// ParseFile reads and parses the SCL file at path. A syntax error is
+// returned as an *Error carrying the path.
+func ParseFile(path string) ([]*Node, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := Parse(src)
+	if err != nil {
+		if serr, ok := err.(*Error); ok {
+			serr.Path = path
+		}
+		return nil, err
+	}
+	return nodes, nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.i >= len(p.toks) {
+		return token{}, false
+	}
+	return p.toks[p.i], true
+}
+
+// list parses statements and the comments around them, either at the root,
+// where block is nil and the list runs to the end of input, or in the body of
+// block, where it runs to the block's `}`. Comment lines after the last
+// statement are returned on their own.
+func (p *parser) list(block *Node) ([]*Node, []Comment, error) {
+	var (
+		nodes    []*Node
+		pending  []Comment // comment lines not yet followed by a statement
+		last     *Node     // the statement the current line started with
+		newlines int       // line breaks since the last statement or comment
+	)
+	for {
+		tok, ok := p.peek()
+		if !ok {
+			if block != nil {
+				return nil, nil, &Error{
+					Pos: block.KeyPos,
+					Msg: fmt.Sprintf("unterminated block `%s` started on line %d, column %d (missing `}`)",
+						block.Key, block.KeyPos.Line, block.KeyPos.Col),
+				}
+			}
+			return nodes, pending, nil
+		}
+		switch tok.typ {
+		case tRBrace:
+			if block == nil {
+				return nil, nil, &Error{Pos: tok.pos, Msg: "unexpected `}` at root level"}
+			}
+			p.i++
+			block.EndPos = tok.pos
+			return nodes, pending, nil
+		case tNewline:
+			newlines++
+			p.i++
+		case tComment:
+			p.i++
+			c := Comment{Text: tok.text, Pos: tok.pos, BlankBefore: newlines > 1}
+			switch {
+			case newlines == 0 && last != nil && last.IsBlock():
+				last.EndComment = &c
+			case newlines == 0 && last != nil:
+				last.Comment = &c
+			case newlines == 0 && block != nil && len(nodes) == 0 && len(pending) == 0:
+				block.Comment = &c
+			default:
+				pending = append(pending, c)
+			}
+			last, newlines = nil, 0
+		default:
+			expected := "block or key name"
+			if block != nil {
+				expected = "block statement name"
+			}
+			node, err := p.statement(expected)
+			if err != nil {
+				return nil, nil, err
+			}
+			node.BlankBefore = newlines > 1
+			node.Comments, pending = pending, nil
+			nodes = append(nodes, node)
+			last, newlines = node, 0
+		}
+	}
+}
+
+// statement parses a name, its values, and the block they open if any.
+func (p *parser) statement(expected string) (*Node, error) {
+	tok, _ := p.peek()
+	if tok.typ != tUnquoted || !nameRe.MatchString(tok.text) {
+		return nil, unexpected(tok, expected)
+	}
+	p.i++
+
+	node := &Node{Kind: KV, Key: tok.text, KeyPos: tok.pos}
+
+	values, err := p.values()
+	if err != nil {
+		return nil, err
+	}
+	node.Values = values
+
+	if next, ok := p.peek(); ok && next.typ == tLBrace {
+		p.i++
+		node.Kind = Block
+		node.BracePos = next.pos
+		if node.Children, node.EndComments, err = p.list(node); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+// values reads comma-separated values up to a line break, a brace, or the end
+// of input. Commas are separators only: two values need one between them, but
+// extra commas, leading or trailing, are passed over.
+func (p *parser) values() ([]Value, error) {
+	var values []Value
+	sawValue := false
+	for {
+		tok, ok := p.peek()
+		if !ok {
+			return values, nil
+		}
+		switch {
+		case tok.typ == tNewline || tok.typ == tComment || tok.typ == tLBrace || tok.typ == tRBrace:
+			return values, nil
+		case tok.typ == tComma:
+			sawValue = false
+			p.i++
+		case tok.isValue():
+			if sawValue {
+				return nil, &Error{Pos: tok.pos, Msg: "expected comma before " + quoteText(tok.text)}
+			}
+			v, err := convert(tok)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			sawValue = true
+			p.i++
+		}
+	}
+}
+
+func unexpected(tok token, expected string) error {
+	return &Error{
+		Pos:  tok.pos,
+		Msg:  fmt.Sprintf("unexpected %s %s; expected %s", tokenNames[tok.typ], quoteText(tok.text), expected),
+		Text: tok.text,
+	}
+}
+
+// convert turns a value token into a typed Value.
+func convert(tok token) (Value, error) {
+	v := Value{Pos: tok.pos}
+	switch tok.typ {
+	case tBool:
+		v.Kind, v.Bool, v.Raw = Bool, tok.text == "true", tok.text
+	case tNumber:
+		v.Raw = tok.text
+		if floatRe.MatchString(tok.text) {
+			f, err := strconv.ParseFloat(tok.text, 64)
+			if err != nil {
+				return v, &Error{Pos: tok.pos, Msg: "number out of range: " + tok.text}
+			}
+			v.Kind, v.Float = Float, f
+		} else {
+			n, err := strconv.ParseInt(tok.text, 10, 64)
+			if err != nil {
+				return v, &Error{Pos: tok.pos, Msg: "number out of range: " + tok.text}
+			}
+			v.Kind, v.Int = Int, n
+		}
+	case tAtom:
+		v.Kind, v.Str = Atom, tok.text
+	default:
+		v.Kind, v.Str = String, tok.text
+		switch tok.typ {
+		case tDouble:
+			v.Quote = DoubleQuoted
+		case tSingle:
+			v.Quote = SingleQuoted
+		case tBacktick:
+			v.Quote = Backtick
+		case tTriple:
+			v.Quote = TripleBacktick
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,280 @@
+package scl
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// dump renders nodes compactly, without positions, so a case can state the
+// whole tree it expects on one line:
+//
+//	key=value, value           a key-value
+//	key=nil                    a key with no value
+//	key(name, name){...; ...}  a block
+//
+// Strings are quoted, atoms keep their colon.
+func dump(nodes []*Node) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		if n.IsBlock() {
+			parts[i] = n.Key + "(" + dumpValues(n.Values) + "){" + dump(n.Children) + "}"
+			continue
+		}
+		if len(n.Values) == 0 {
+			parts[i] = n.Key + "=nil"
+			continue
+		}
+		parts[i] = n.Key + "=" + dumpValues(n.Values)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func dumpValues(values []Value) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		switch v.Kind {
+		case String:
+			parts[i] = strconv.Quote(v.Str)
+		case Atom:
+			parts[i] = ":" + v.Str
+		case Int:
+			parts[i] = strconv.FormatInt(v.Int, 10)
+		case Float:
+			parts[i] = strconv.FormatFloat(v.Float, 'g', -1, 64)
+		case Bool:
+			parts[i] = strconv.FormatBool(v.Bool)
+		default:
+			parts[i] = "nil"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// The positive cases of packages/scl_parser/test/scl_parser_test.exs, one for
+// one, so this parser accepts what the reference accepts and builds the same tree.
+var referenceCorpus = []struct {
+	name  string
+	input string
+	want  string
+}{
+	{"empty string returns empty AST", "", ""},
+	{"only whitespace returns empty AST", "   \t   \r\n  ", ""},
+	{"only newlines returns empty AST", "\n\n\n", ""},
+	{"single root key-value (unquoted)", "foo bar\n", `foo="bar"`},
+	{"root key-value with trailing whitespace", "foo bar    \n  ", `foo="bar"`},
+	{"root key-value with boolean", "active true\n", `active=true`},
+	{"root key-value with float", "price 123.45\n", `price=123.45`},
+	{"root key-value with colon atom", "kind :test_atom", `kind=:test_atom`},
+	{"multiple root lines with mixed data", "foo bar\nversion 2\nenabled false\n", `foo="bar"; version=2; enabled=false`},
+	{"root line with multiple unquoted values", "foo bar, baz", `foo="bar", "baz"`},
+	{"root line with multiple quoted values", `languages "elixir", "erlang"`, `languages="elixir", "erlang"`},
+	{"root line with mixed numeric, atom, and string values", `stuff 123, :atom, "hello"`, `stuff=123, :atom, "hello"`},
+	{"root line with booleans and float", "flags true, 3.14, false", `flags=true, 3.14, false`},
+	{"block with single key-value line", "table employees {\n  name John\n}\n", `table("employees"){name="John"}`},
+	{"multiple blocks at root", "table first {\n  key val\n}\ntable second {\n  key2 val2\n}\n", `table("first"){key="val"}; table("second"){key2="val2"}`},
+	{"block with multiple lines", "table employees {\n  name John\n  dept HR\n  active true\n}\n", `table("employees"){name="John"; dept="HR"; active=true}`},
+	{"nested block (one level)", "table employees {\n  info personal {\n    phone \"555-1234\"\n  }\n}\n", `table("employees"){info("personal"){phone="555-1234"}}`},
+	{"nested block (multiple levels)", "root top {\n  level1 middle {\n    level2 inner {\n      data :ok\n    }\n  }\n}\n", `root("top"){level1("middle"){level2("inner"){data=:ok}}}`},
+	{"block with multiple attributes (unquoted and colon-atom)", "data foo, :bar, baz {\n  key1 42\n}\n", `data("foo", :bar, "baz"){key1=42}`},
+	{"block with multiple attributes (numbers, booleans, strings)", "config 1, true, \"extra\" {\n  setting \"some_value\"\n}\n", `config(1, true, "extra"){setting="some_value"}`},
+	{"block with zero attributes (no name) and multiple lines", "section {\n  title \"My Section\"\n  active true\n}\n", `section(){title="My Section"; active=true}`},
+	{"multiple no-attr blocks at root", "table {\n  name \"first\"\n}\nsection {\n  name \"second\"\n}\n", `table(){name="first"}; section(){name="second"}`},
+	{"inline comment after root key-value", "foo bar # this is a comment", `foo="bar"`},
+	{"inline comment in a block line", "table employees {\n  name John # last name unknown\n}\n", `table("employees"){name="John"}`},
+	{"full line comment before root statement", "# This is a comment line\nfoo bar\n", `foo="bar"`},
+	{"full line comment inside block", "table test {\n  # This line is commented out\n  key val\n}\n", `table("test"){key="val"}`},
+	{"comment next to block opening", "table test { # comment\n  key val\n}\n", `table("test"){key="val"}`},
+	{"multiple comment lines and trailing whitespace", "# comment 1\n\n# comment 2\nfoo bar  # inline comment\n", `foo="bar"`},
+	{"root key-value with # in quoted string", `foo "hello #world"`, `foo="hello #world"`},
+	{"comment after float in root", "pi 3.1415 # approximate\n", `pi=3.1415`},
+	{"comment consumes remainder of line even if text follows", "foo hello#123", `foo="hello"`},
+	{"triple backtick string at root level (multi-line)", "description ```\nLine 1\nLine 2\n```\n", `description="\nLine 1\nLine 2\n"`},
+	{"triple backtick string at root level with escaped backtick", "info ```\nSome text\n\\` -> should become a real backtick here: `\nEnd\n```\n", "info=\"\\nSome text\\n\\\\` -> should become a real backtick here: `\\nEnd\\n\""},
+	{"triple backtick string in a block (multi-line + normal lines)", "section intro {\n  title \"Welcome!\"\n  body ```\n  This is a multi-line block.\n  ```\n}\n", `section("intro"){title="Welcome!"; body="\n  This is a multi-line block.\n  "}`},
+	{"triple backtick empty string", "notes ``````", `notes=""`},
+	{"triple backtick with no newline inside", "greeting ```Hello world```", `greeting="Hello world"`},
+	{"carriage return (\\r) with no \\n", "foo bar\rtable staff { name John }", `foo="bar"; table("staff"){name="John"}`},
+	{"triple-backtick with Windows line break (\\r\\n)", "message ```\r\nlineA\r\nlineB\r\n```\n", `message="\nlineA\nlineB\n"`},
+	{"triple backtick with carriage-return inside content", "note ```Line1\rLine2\r```", `note="Line1\nLine2\n"`},
+	{"double-quoted string with escaped quote", `foo "hello \"escaped\" world"`, `foo="hello \"escaped\" world"`},
+	{"root key-value with no values => nil", "loner\n", `loner=nil`},
+	{"block statement with extra blank line", "table staff {\n\n  name John\n}\n", `table("staff"){name="John"}`},
+	{"empty block with no lines", "empty_block {}", `empty_block(){}`},
+	{"double-quoted empty string", `foo ""`, `foo=""`},
+	{"unquoted string with special characters", "misc &^%$!", `misc="&^%$!"`},
+	{"root key-value with zero", "zero 0", `zero=0`},
+	{"root key-value with multiple zeros", "count 000", `count=0`},
+	{"root key-value with negative integer recognized as unquoted string", "neg -123", `neg="-123"`},
+	{"block with multiple comma-separated values in a line", "multi_block {\n  line_a val1, val2, val3\n}\n", `multi_block(){line_a="val1", "val2", "val3"}`},
+	{"block with triple-backtick as name attribute", "stuff ```my stuff``` {\n  key val\n}\n", `stuff("my stuff"){key="val"}`},
+	{"single-quoted string at root level", "message 'hello world'", `message="hello world"`},
+	{"single-quoted string with escaped quote", `note 'It\'s important'`, `note="It's important"`},
+	{"empty single-quoted string", "empty ''", `empty=""`},
+	{"root line with multiple single-quoted values", "items 'one', 'two'", `items="one", "two"`},
+	{"root line with mixed quoted string types", "mixed \"double\", 'single', ```triple```", `mixed="double", "single", "triple"`},
+	{"block with single-quoted string value", "block {\n  setting 'enabled'\n}\n", `block(){setting="enabled"}`},
+	{"block with single-quoted string attribute", "block 'my name' { key val }", `block("my name"){key="val"}`},
+	{"backtick-quoted string at root level", "message `hello world`", `message="hello world"`},
+	{"backtick-quoted string with escaped backtick", "note `It\\`s important`", "note=\"It`s important\""},
+	{"empty backtick-quoted string", "empty ``", `empty=""`},
+	{"root line with multiple backtick-quoted values", "items `one`, `two`", `items="one", "two"`},
+	{"root line with mixed quoted string types including backticks", "mixed \"double\", 'single', ```triple```, `backtick`", `mixed="double", "single", "triple", "backtick"`},
+	{"block with backtick-quoted string value", "block {\n  setting `enabled`\n}\n", `block(){setting="enabled"}`},
+	{"block with backtick-quoted string attribute", "block `my name` { key val }", `block("my name"){key="val"}`},
+}
+
+func TestParse_ReferenceCorpus(t *testing.T) {
+	for _, tt := range referenceCorpus {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got := dump(nodes); got != tt.want {
+				t.Errorf("Parse(%q)\n got %s\nwant %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// The negative cases of the reference corpus: the same input is refused with
+// the same message.
+func TestParse_ReferenceCorpusErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unexpected character at root", "\u0000", "unexpected unquoted string"},
+		{"invalid atom format", "key :InvalidAtom", "invalid or empty atom"},
+		{"unexpected `}` at root", "}", "unexpected `}` at root level"},
+		{"unterminated block (EOF)", "table {", "unterminated block"},
+		{"unterminated block (nested)", "outer {\n  inner {\n}\n", "unterminated block"},
+		{"expected comma between values", "nums 1 2", "expected comma"},
+		{"expected comma between values (mixed)", "vals true false", "expected comma"},
+		{"expected comma in block name list", "table 1 2 {", "expected comma"},
+		{"unterminated double-quoted string", "foo \"bar", "unterminated double-quoted string"},
+		{"unterminated triple-backtick string", "foo ```bar", "unterminated triple-backtick string"},
+		{"newline in double-quoted string", "foo \"line1\nline2\"", "unexpected newline"},
+		{"invalid key name (starts with number)", "1foo bar", "unexpected unquoted string"},
+		{"unexpected token structure in block statement", "block {\n  123 val\n}\n", "unexpected number"},
+		{"unterminated single-quoted string", "foo 'bar", "unterminated single-quoted string"},
+		{"newline in single-quoted string", "foo 'line1\nline2'", "unexpected newline"},
+		{"unterminated backtick-quoted string", "foo `bar", "unterminated backtick-quoted string"},
+		{"newline in backtick-quoted string", "foo `line1\nline2`", "unexpected newline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want an error containing %q", tt.input, tt.want)
+			}
+			serr, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("Parse(%q) error is %T, want *Error", tt.input, err)
+			}
+			if !strings.Contains(serr.Msg, tt.want) {
+				t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.input, serr.Msg, tt.want)
+			}
+		})
+	}
+}
+
+// The reference corpus pins these positions exactly.
+func TestParse_ReferencePositions(t *testing.T) {
+	nodes, err := Parse([]byte("foo bar\rtable staff { name John }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(what string, got, want Pos) {
+		t.Helper()
+		if got != want {
+			t.Errorf("%s at %v, want %v", what, got, want)
+		}
+	}
+	check("foo", nodes[0].KeyPos, Pos{1, 1})
+	check("bar", nodes[0].Values[0].Pos, Pos{1, 5})
+	check("table", nodes[1].KeyPos, Pos{2, 1})
+	check("staff's brace", nodes[1].BracePos, Pos{2, 13})
+	check("name", nodes[1].Children[0].KeyPos, Pos{2, 15})
+	check("John", nodes[1].Children[0].Values[0].Pos, Pos{2, 20})
+
+	nodes, err = Parse([]byte("empty_block {}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("empty_block", nodes[0].KeyPos, Pos{1, 1})
+}
+
+// Lines after a comment keep their numbers. The reference tokenizer restarts
+// its count after one; a linter pointing at the wrong line is worse than useless.
+func TestParse_PositionsAfterComments(t *testing.T) {
+	src := "# header\ntenant acme # inline\n\nenv dev {\n  # note\n  endpoint \"x\"\n}\n"
+	nodes, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := nodes[0].KeyPos; got != (Pos{2, 1}) {
+		t.Errorf("tenant at %v, want 2:1", got)
+	}
+	env := nodes[1]
+	if got := env.KeyPos; got != (Pos{4, 1}) {
+		t.Errorf("env at %v, want 4:1", got)
+	}
+	if got := env.Child("endpoint").Values[0].Pos; got != (Pos{6, 12}) {
+		t.Errorf("endpoint value at %v, want 6:12", got)
+	}
+	if got := env.EndPos; got != (Pos{7, 1}) {
+		t.Errorf("env's closing brace at %v, want 7:1", got)
+	}
+}
+
+func TestParse_ValueTypes(t *testing.T) {
+	nodes, err := Parse([]byte("v 007, 3.10, :ok, 'q', true\nnone\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := nodes[0].Values
+	if values[0].Kind != Int || values[0].Int != 7 || values[0].String() != "007" {
+		t.Errorf("007 = %+v, want Int 7 written as 007", values[0])
+	}
+	if values[1].Kind != Float || values[1].Float != 3.1 || values[1].String() != "3.10" {
+		t.Errorf("3.10 = %+v, want Float 3.1 written as 3.10", values[1])
+	}
+	if values[2].Kind != Atom || values[2].Interface() != "ok" {
+		t.Errorf(":ok = %+v, want the atom ok", values[2])
+	}
+	if values[3].Quote != SingleQuoted {
+		t.Errorf("'q' quote = %v, want SingleQuoted", values[3].Quote)
+	}
+	if values[4].Interface() != true {
+		t.Errorf("true = %+v, want the boolean", values[4])
+	}
+
+	if v := nodes[1].Value(); v.Kind != Nil || v.Interface() != nil || v.Pos != (Pos{2, 1}) {
+		t.Errorf("none = %+v, want Nil at the key", v)
+	}
+}
+
+func TestParseFile_ErrorCarriesPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.scl")
+	if err := os.WriteFile(path, []byte("id com.example\nversion 1 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ParseFile(path)
+	if err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	want := path + `:2:11: expected comma before "2"`
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
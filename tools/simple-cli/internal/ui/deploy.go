@@ -21,6 +21,7 @@ const (
 	PhaseManifest
 	PhaseUpload
 	PhaseDeploy
+	PhaseInstall
 	PhaseDone
 )
 
@@ -32,12 +33,27 @@ type DeployStatus struct {
 	Error       error
 	FilesCached int
 	FilesTotal  int
+	// Reconnecting is the attempt at restoring a dropped connection under
+	// way, or 0 while connected.
+	Reconnecting int
 }
 
+// DeployLogMsg is a line of the server's log during a phase, such as a
+// warning raised by an install. Level is "info", "warning" or "error".
+type DeployLogMsg struct {
+	Level string
+	Text  string
+}
+
+// maxDeployLogs is how many of the latest log lines the deploy UI shows.
+const maxDeployLogs = 5
+
 // DeployModel is the Bubble Tea model for deploy progress UI.
 type DeployModel struct {
 	spinner   spinner.Model
 	status    DeployStatus
+	logs      []DeployLogMsg
+	warnings  int
 	startTime time.Time
 	quitting  bool
 	width     int
@@ -82,6 +98,16 @@ func (m DeployModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case DeployLogMsg:
+		if msg.Level == "warning" {
+			m.warnings++
+		}
+		m.logs = append(m.logs, msg)
+		if len(m.logs) > maxDeployLogs {
+			m.logs = m.logs[len(m.logs)-maxDeployLogs:]
+		}
+		return m, nil
+
 	case DeployUpdateMsg:
 		m.status = DeployStatus(msg)
 		if m.status.Phase == PhaseDone || m.status.Error != nil {
@@ -120,6 +146,7 @@ func (m DeployModel) View() string {
 		{PhaseManifest, "Sending manifest", "📋"},
 		{PhaseUpload, "Uploading files", "⬆️"},
 		{PhaseDeploy, "Deploying", "🚀"},
+		{PhaseInstall, "Installing", "📥"},
 	}
 
 	for _, p := range phases {
@@ -127,6 +154,13 @@ func (m DeployModel) View() string {
 		s.WriteString(line)
 	}
 
+	if len(m.logs) > 0 {
+		s.WriteString("\n")
+	}
+	for _, l := range m.logs {
+		s.WriteString(renderLog(l))
+	}
+
 	// Elapsed time
 	elapsed := time.Since(m.startTime).Round(time.Millisecond)
 	timeStyle := lipgloss.NewStyle().Faint(true)
@@ -148,6 +182,10 @@ func (m DeployModel) renderPhase(phase DeployPhase, name, icon string) string {
 		if m.status.Error != nil {
 			status = fmt.Sprintf("  ❌ %s %s: %v\n", icon, name, m.status.Error)
 			style = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		} else if m.status.Reconnecting > 0 {
+			status = fmt.Sprintf("  %s %s %s: connection lost, reconnecting (attempt %d)\n",
+				m.spinner.View(), icon, name, m.status.Reconnecting)
+			style = lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
 		} else if phase == PhaseUpload && m.status.FilesTotal > 0 {
 			// Show upload progress
 			cached := m.status.FilesCached
@@ -168,6 +206,16 @@ func (m DeployModel) renderPhase(phase DeployPhase, name, icon string) string {
 	return style.Render(status)
 }
 
+func renderLog(l DeployLogMsg) string {
+	switch l.Level {
+	case "warning":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("220")).Render(fmt.Sprintf("  ⚠️  %s\n", l.Text))
+	case "error":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("  ❌ %s\n", l.Text))
+	}
+	return lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("  │ %s\n", l.Text))
+}
+
 func (m DeployModel) finalView() string {
 	elapsed := time.Since(m.startTime).Round(time.Millisecond)
 
@@ -177,15 +225,21 @@ func (m DeployModel) finalView() string {
 			Render(fmt.Sprintf("\n  ❌ Deployment failed: %v\n  Duration: %s\n\n", m.status.Error, elapsed))
 	}
 
+	warnings := ""
+	if m.warnings > 0 {
+		warnings = fmt.Sprintf(" (%d warnings)", m.warnings)
+	}
 	return lipgloss.NewStyle().
 		Foreground(lipgloss.Color("82")).
-		Render(fmt.Sprintf("\n  ✅ Deployed successfully in %s\n\n", elapsed))
+		Render(fmt.Sprintf("\n  ✅ Deployed successfully in %s%s\n\n", elapsed, warnings))
 }
 
 // SimpleProgress is a non-interactive progress display for CI/non-TTY environments.
 type SimpleProgress struct {
-	phase   DeployPhase
-	started time.Time
+	phase        DeployPhase
+	reconnecting int
+	step         string
+	started      time.Time
 }
 
 // NewSimpleProgress creates a simple progress reporter.
@@ -195,6 +249,14 @@ func NewSimpleProgress() *SimpleProgress {
 
 // Update updates the simple progress display.
 func (p *SimpleProgress) Update(status DeployStatus) {
+	if status.Reconnecting != p.reconnecting {
+		if status.Reconnecting > p.reconnecting {
+			fmt.Printf("🔌 Connection lost, reconnecting (attempt %d)...\n", status.Reconnecting)
+		} else if status.Reconnecting == 0 {
+			fmt.Println("🔌 Reconnected")
+		}
+		p.reconnecting = status.Reconnecting
+	}
 	if status.Phase != p.phase {
 		p.phase = status.Phase
 		switch status.Phase {
@@ -213,6 +275,8 @@ func (p *SimpleProgress) Update(status DeployStatus) {
 				status.FilesTotal-status.FilesCached, status.FilesCached)
 		case PhaseDeploy:
 			fmt.Println("🚀 Deploying...")
+		case PhaseInstall:
+			fmt.Println("📥 Installing...")
 		case PhaseDone:
 			elapsed := time.Since(p.started).Round(time.Millisecond)
 			if status.Error != nil {
@@ -222,6 +286,31 @@ func (p *SimpleProgress) Update(status DeployStatus) {
 			}
 		}
 	}
+	if status.Phase == PhaseInstall {
+		p.Step(status.Message)
+	}
+}
+
+// Step prints the step a phase has reached, such as the migration an
+// install is running, unless it is the step printed last.
+func (p *SimpleProgress) Step(message string) {
+	if message == "" || message == p.step {
+		return
+	}
+	p.step = message
+	fmt.Printf("   ▸ %s\n", message)
+}
+
+// Log prints a line of the server's log.
+func (p *SimpleProgress) Log(l DeployLogMsg) {
+	switch l.Level {
+	case "warning":
+		fmt.Printf("   ⚠️  %s\n", l.Text)
+	case "error":
+		fmt.Printf("   ❌ %s\n", l.Text)
+	default:
+		fmt.Printf("   │ %s\n", l.Text)
+	}
 }
 
 // IsInteractive returns true if the terminal supports interactive UI.
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -137,6 +138,45 @@ func TestDeployModel_RenderPhase_InProgress(t *testing.T) {
 	}
 }
 
+func TestDeployModel_RenderPhase_Reconnecting(t *testing.T) {
+	m := NewDeployModel()
+	m.status.Phase = PhaseUpload
+	m.status.FilesTotal = 10
+	m.status.Reconnecting = 2
+
+	view := m.View()
+
+	if !strings.Contains(view, "reconnecting (attempt 2)") {
+		t.Errorf("Phase should show the reconnect attempt, got: %s", view)
+	}
+}
+
+func TestDeployModel_InstallProgress(t *testing.T) {
+	m := NewDeployModel()
+
+	newModel, _ := m.Update(DeployUpdateMsg{Phase: PhaseInstall, Message: "migrate orders (1200/5000 records)"})
+	for i := range maxDeployLogs + 1 {
+		newModel, _ = newModel.Update(DeployLogMsg{Level: "info", Text: fmt.Sprintf("line %d", i)})
+	}
+	newModel, _ = newModel.Update(DeployLogMsg{Level: "warning", Text: "field orders.notes is deprecated"})
+	dm := newModel.(DeployModel)
+
+	view := dm.View()
+	for _, want := range []string{"Installing: migrate orders (1200/5000 records)", "field orders.notes is deprecated", "line 5"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View should contain %q, got: %s", want, view)
+		}
+	}
+	if strings.Contains(view, "line 1\n") {
+		t.Errorf("View should show only the latest %d log lines, got: %s", maxDeployLogs, view)
+	}
+
+	dm.quitting = true
+	if view := dm.View(); !strings.Contains(view, "(1 warnings)") {
+		t.Errorf("Final view should count warnings, got: %s", view)
+	}
+}
+
 func TestSimpleProgress(t *testing.T) {
 	p := NewSimpleProgress()
 
@@ -151,7 +191,11 @@ func TestSimpleProgress(t *testing.T) {
 	p.Update(DeployStatus{Phase: PhaseConnect})
 	p.Update(DeployStatus{Phase: PhaseManifest})
 	p.Update(DeployStatus{Phase: PhaseUpload, FilesTotal: 5, FilesCached: 2})
+	p.Update(DeployStatus{Phase: PhaseUpload, FilesTotal: 5, FilesCached: 2, Reconnecting: 1})
+	p.Update(DeployStatus{Phase: PhaseUpload, FilesTotal: 5, FilesCached: 2})
 	p.Update(DeployStatus{Phase: PhaseDeploy})
+	p.Update(DeployStatus{Phase: PhaseInstall, Message: "migrate orders"})
+	p.Log(DeployLogMsg{Level: "warning", Text: "field orders.notes is deprecated"})
 	p.Update(DeployStatus{Phase: PhaseDone})
 }
 